@@ -1,12 +1,28 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stefanpenner/cairn/pkg/api"
+	"github.com/stefanpenner/cairn/pkg/clipboard"
+	"github.com/stefanpenner/cairn/pkg/config"
+	"github.com/stefanpenner/cairn/pkg/enrich"
+	"github.com/stefanpenner/cairn/pkg/notify"
 	"github.com/stefanpenner/cairn/pkg/store"
 	gsync "github.com/stefanpenner/cairn/pkg/sync"
 	"github.com/stefanpenner/cairn/pkg/tui"
@@ -14,49 +30,190 @@ import (
 
 func main() {
 	if err := run(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// exitError ends the process with a specific exit code. The command has
+// already reported the outcome, so nothing more is printed.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// Exit codes for cairn check.
+const (
+	exitCheckFailed  = 1 // goals exist but aren't (all / any) complete
+	exitCheckMissing = 2 // at least one goal doesn't exist
+)
+
 func run() error {
+	args := os.Args[1:]
+	jsonOutput := hasFlag(args, "--json")
+	args = removeFlag(args, "--json")
+	readOnly := hasFlag(args, "--read-only")
+	args = removeFlag(args, "--read-only")
+	dequeue := hasFlag(args, "--dequeue-on-complete")
+	args = removeFlag(args, "--dequeue-on-complete")
+	noWatch := hasFlag(args, "--no-watch")
+	args = removeFlag(args, "--no-watch")
+	if on, err := strconv.ParseBool(os.Getenv("CAIRN_NO_WATCH")); err == nil && on {
+		noWatch = true
+	}
+
 	dataDir := getDataDir()
-	s, err := store.NewStore(dataDir)
+	cfg, err := config.Load(dataDir)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		cfg.ReadOnly = true
+	}
+	if noWatch {
+		cfg.Watch = "off"
+	}
+	if len(args) > 0 && args[0] == "add" {
+		// cairn add's flags override the defaults section
+		for _, flag := range []string{"horizon", "tags"} {
+			var value string
+			if value, args = takeFlag(args, "--"+flag); value != "" {
+				if err := cfg.Set("defaults."+flag, value); err != nil {
+					return fmt.Errorf("--%s: %w", flag, err)
+				}
+			}
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(args) > 0 && args[0] == "note" && hasFlag(args, "--time") {
+		// Prefix this note with the time unless the format already has it
+		args = removeFlag(args, "--time")
+		if !strings.Contains(cfg.NoteFormat, "{time}") {
+			format := cfg.NoteFormat
+			if format == "" {
+				format = "{text}"
+			}
+			cfg.NoteFormat = "{time} " + format
+		}
+	}
+
+	dirPerm, err := cfg.DirPerm()
+	if err != nil {
+		return err
+	}
+	s, err := store.NewStoreWithOptions(dataDir, store.Options{
+		ReadOnly:          cfg.ReadOnly,
+		DirPerm:           dirPerm,
+		Horizons:          configHorizons(cfg),
+		DefaultHorizon:    store.Horizon(cfg.NewGoalHorizon()),
+		DefaultTags:       cfg.Defaults.Tags,
+		BodyTemplate:      cfg.Defaults.Body,
+		DraftTags:         cfg.DraftTags,
+		EventLog:          cfg.EventLog,
+		Hooks:             cfg.Hooks,
+		Layout:            store.Layout(cfg.Layout),
+		FieldAliases:      cfg.FieldAliases,
+		AgeIdentity:       cfg.AgeIdentity,
+		NoteTemplate:      store.NoteTemplate{Format: cfg.NoteFormat, Section: cfg.NoteSection},
+		AutoQueue:         cfg.AutoQueue,
+		DequeueOnComplete: dequeue,
+	})
 	if err != nil {
 		return err
 	}
+	// Hooks run in the background; let them finish before exiting
+	s.OnHookError(func(err error) { fmt.Fprintf(os.Stderr, "Warning: %v\n", err) })
+	s.OnWarning(func(err error) { fmt.Fprintf(os.Stderr, "Warning: %v\n", err) })
+	defer s.WaitHooks()
 
-	args := os.Args[1:]
-	jsonOutput := hasFlag(args, "--json")
-	args = removeFlag(args, "--json")
+	if !cfg.ReadOnly && cfg.TrashRetention > 0 {
+		if _, err := s.PurgeTrash(cfg.TrashRetention, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: purging the trash: %v\n", err)
+		}
+	}
 
 	if len(args) == 0 {
-		return runTUI(s)
+		return runTUI(s, cfg, "")
 	}
+	return runCommand(s, cfg, dataDir, args, jsonOutput)
+}
 
+// runCommand runs the subcommand args[0] with the rest of args, after the
+// global flags have been taken out.
+func runCommand(s *store.Store, cfg *config.Config, dataDir string, args []string, jsonOutput bool) error {
 	switch args[0] {
+	case "tui":
+		goalPath := ""
+		if len(args) > 1 {
+			goalPath = args[1]
+		}
+		return runTUI(s, cfg, goalPath)
 	case "queue":
 		return cmdQueue(s, jsonOutput)
 	case "list":
-		return cmdList(s, jsonOutput)
+		ndjson := hasFlag(args, "--ndjson")
+		porcelain := hasFlag(args, "--porcelain")
+		args = removeFlag(removeFlag(args, "--ndjson"), "--porcelain")
+		columns, args := takeFlag(args, "--columns")
+		ref, _ := takeFlag(args, "--at")
+		var cols []string
+		if columns != "" {
+			if ndjson || porcelain {
+				return fmt.Errorf("--columns is for the table and --json output, not --ndjson or --porcelain")
+			}
+			var err error
+			if cols, err = parseColumns(columns); err != nil {
+				return err
+			}
+		}
+		return cmdList(s, ref, cols, jsonOutput, ndjson, porcelain)
+	case "diff":
+		since, args := takeFlag(args, "--since")
+		if since == "" && len(args) > 1 {
+			since = args[1]
+		}
+		return cmdDiff(s, since, jsonOutput)
 	case "status":
+		recursive := hasFlag(args, "--recursive")
+		args = removeFlag(args, "--recursive")
+		porcelain := hasFlag(args, "--porcelain")
+		args = removeFlag(args, "--porcelain")
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn status [--recursive] [--porcelain] <goal-path>")
+		}
+		return cmdStatus(s, args[1], recursive, jsonOutput, porcelain)
+	case "copy":
+		toStdout := hasFlag(args, "--stdout")
+		args = removeFlag(args, "--stdout")
 		if len(args) < 2 {
-			return fmt.Errorf("usage: cairn status <goal-path>")
+			return fmt.Errorf("usage: cairn copy [--stdout] <goal-path>")
 		}
-		return cmdStatus(s, args[1], jsonOutput)
+		return cmdCopy(s, args[1], toStdout)
 	case "complete":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: cairn complete <goal-path>")
+			return fmt.Errorf("usage: cairn complete [--dequeue-on-complete] <goal-path>")
 		}
-		return cmdSetStatus(s, args[1], store.StatusComplete, jsonOutput)
+		return cmdSetStatus(s, args[1], store.StatusComplete, cfg.PropagateStatus, jsonOutput)
 	case "incomplete":
 		if len(args) < 2 {
 			return fmt.Errorf("usage: cairn incomplete <goal-path>")
 		}
-		return cmdSetStatus(s, args[1], store.StatusIncomplete, jsonOutput)
+		return cmdSetStatus(s, args[1], store.StatusIncomplete, cfg.PropagateStatus, jsonOutput)
 	case "add":
+		if hasFlag(args, "-i") || hasFlag(args, "--interactive") {
+			return cmdAddInteractive(s, cfg, jsonOutput)
+		}
 		if len(args) < 2 {
-			return fmt.Errorf("usage: cairn add [parent] <slug>")
+			return fmt.Errorf("usage: cairn add [--horizon H] [--tags a,b] [parent] <slug>\n       cairn add -i")
 		}
 		parent := ""
 		slug := args[1]
@@ -66,16 +223,52 @@ func run() error {
 		}
 		return cmdAdd(s, parent, slug, jsonOutput)
 	case "note":
+		if hasFlag(args, "--edit") {
+			args = removeFlag(args, "--edit")
+			if len(args) != 2 {
+				return fmt.Errorf("usage: cairn note --edit <goal-path>")
+			}
+			return cmdNoteEdit(s, args[1], jsonOutput)
+		}
 		if len(args) < 3 {
-			return fmt.Errorf("usage: cairn note <goal-path> <text>")
+			return fmt.Errorf("usage: cairn note [--time] <goal-path> <text>\n       cairn note --edit <goal-path>")
 		}
 		text := strings.Join(args[2:], " ")
 		return cmdNote(s, args[1], text, jsonOutput)
 	case "delete":
+		yes := hasFlag(args, "--yes")
+		args = removeFlag(args, "--yes")
+		dryRun := hasFlag(args, "--dry-run")
+		args = removeFlag(args, "--dry-run")
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn delete [--yes] [--dry-run] <goal-path>")
+		}
+		return cmdDelete(s, args[1], yes, dryRun, jsonOutput)
+	case "trash":
+		if ref, _ := takeFlag(args, "--restore"); ref != "" {
+			return cmdTrashRestore(s, ref, jsonOutput)
+		}
+		if hasFlag(args, "--restore") {
+			return fmt.Errorf("usage: cairn trash --restore <id|goal-path>")
+		}
+		if hasFlag(args, "--empty") {
+			return cmdTrashEmpty(s, jsonOutput)
+		}
+		return cmdTrashList(s, jsonOutput)
+	case "backup":
+		out, _ := takeFlag(args, "--out")
+		return cmdBackup(dataDir, out, jsonOutput)
+	case "restore":
+		into, args := takeFlag(args, "--into")
+		force := hasFlag(args, "--force")
+		args = removeFlag(args, "--force")
 		if len(args) < 2 {
-			return fmt.Errorf("usage: cairn delete <goal-path>")
+			return fmt.Errorf("usage: cairn restore [--into dir] [--force] <archive>")
+		}
+		if cfg.ReadOnly {
+			return store.ErrReadOnly
 		}
-		return cmdDelete(s, args[1], jsonOutput)
+		return cmdRestore(s, args[1], into, force, jsonOutput)
 	case "init":
 		remote := ""
 		for i, a := range args {
@@ -83,21 +276,158 @@ func run() error {
 				remote = args[i+1]
 			}
 		}
-		return gsync.InitRepo(dataDir, remote)
+		branch := cfg.SyncBranch
+		if b, _ := takeFlag(args, "--branch"); b != "" {
+			branch = b
+		}
+		if err := s.Init(); err != nil {
+			return err
+		}
+		return gsync.InitRepo(dataDir, remote, branch)
 	case "sync":
-		return gsync.SyncRepo(dataDir)
+		return gsync.SyncRepo(dataDir, gsync.Options{
+			DraftTags:    cfg.DraftTags,
+			FieldAliases: cfg.FieldAliases,
+			Branch:       cfg.SyncBranch,
+			Pull:         cfg.SyncPull,
+		})
 	case "horizon":
 		if len(args) < 3 {
-			return fmt.Errorf("usage: cairn horizon <goal-path> <today|tomorrow|future>")
+			return fmt.Errorf("usage: cairn horizon <goal-path> <%s>", strings.Join(store.HorizonNames(s.Horizons()), "|"))
 		}
 		return cmdHorizon(s, args[1], args[2], jsonOutput)
-	case "search":
+	case "pin":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn pin <goal-path>")
+		}
+		return cmdPin(s, args[1], jsonOutput)
+	case "icon":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn icon <goal-path> [emoji]")
+		}
+		icon := ""
+		if len(args) > 2 {
+			icon = args[2]
+		}
+		return cmdSet(s, args[1], "icon", icon, false, jsonOutput)
+	case "summary":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn summary <goal-path> [text]")
+		}
+		return cmdSet(s, args[1], "summary", strings.Join(args[2:], " "), false, jsonOutput)
+	case "estimate":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn estimate <goal-path> [estimate, e.g. 2h, 3d, 1w, or 5 for points]")
+		}
+		estimate := ""
+		if len(args) > 2 {
+			estimate = strings.Join(args[2:], "")
+		}
+		return cmdEstimate(s, args[1], estimate, jsonOutput)
+	case "stats":
+		return cmdStats(s, jsonOutput)
+	case "doctor":
+		return cmdDoctor(s, jsonOutput)
+	case "normalize":
+		return cmdNormalize(s, hasFlag(args, "--dry-run"), jsonOutput)
+	case "encrypt-existing":
+		return cmdEncryptExisting(s, jsonOutput)
+	case "heatmap":
+		days := 90
+		for i, a := range args {
+			if a == "--days" && i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					return fmt.Errorf("invalid --days %q: expected a positive number", args[i+1])
+				}
+				days = n
+			}
+		}
+		return cmdHeatmap(s, days, jsonOutput)
+	case "today":
+		return cmdToday(s, cfg.StaleTodayDays, jsonOutput)
+	case "notify":
+		return cmdNotify(s, notify.System(), cfg.StaleTodayDays, jsonOutput)
+	case "statusline":
+		format, _ := takeFlag(args, "--format")
+		return cmdStatusline(s, format)
+	case "waiting":
+		return cmdWaiting(s, jsonOutput)
+	case "orphans":
+		days := defaultOrphanDays
+		if v, _ := takeFlag(args, "--days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --days %q: expected a positive number", v)
+			}
+			days = n
+		}
+		return cmdOrphans(s, days, jsonOutput)
+	case "events":
+		return cmdEvents(dataDir, hasFlag(args, "--tail"))
+	case "rollover":
+		return cmdRollover(s, jsonOutput)
+	case "check":
+		anyMode := hasFlag(args, "--any")
+		args = removeFlag(args, "--any")
+		if len(args) < 2 {
+			return fmt.Errorf("usage: cairn check [--any] <goal-path>...")
+		}
+		return cmdCheck(s, args[1:], anyMode, jsonOutput)
+	case "path":
+		dirOnly := hasFlag(args, "--dir")
+		args = removeFlag(args, "--dir")
 		if len(args) < 2 {
-			return fmt.Errorf("usage: cairn search <query>")
+			return fmt.Errorf("usage: cairn path [--dir] <goal-path>")
+		}
+		return cmdPath(s, args[1], dirOnly, jsonOutput)
+	case "get":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: cairn get <goal-path> <field>")
+		}
+		return cmdGet(s, args[1], args[2], jsonOutput)
+	case "set":
+		if len(args) < 4 {
+			return fmt.Errorf("usage: cairn set <goal-path> <field> <value>")
+		}
+		return cmdSet(s, args[1], args[2], strings.Join(args[3:], " "), cfg.PropagateStatus, jsonOutput)
+	case "search":
+		var opts store.SearchOptions
+		for _, flag := range []string{"--since", "--until"} {
+			var value string
+			value, args = takeFlag(args, flag)
+			if value == "" {
+				continue
+			}
+			day, err := store.ParseDate(value, time.Now())
+			if err != nil {
+				return fmt.Errorf("%s: %w", flag, err)
+			}
+			if flag == "--since" {
+				opts.Since = day
+			} else {
+				opts.Until = day
+			}
 		}
-		return cmdSearch(s, strings.Join(args[1:], " "), jsonOutput)
+		ndjson := hasFlag(args, "--ndjson")
+		args = removeFlag(args, "--ndjson")
+		execCmd, args := takeFlag(args, "--exec")
+		dryRun := hasFlag(args, "--dry-run")
+		args = removeFlag(args, "--dry-run")
+		if len(args) < 2 && !opts.Windowed() {
+			return fmt.Errorf("usage: cairn search [--since DATE] [--until DATE] [--ndjson] <query>\n       cairn search <query> --exec '<command> [args]' [--dry-run]")
+		}
+		query := strings.Join(args[1:], " ")
+		if execCmd != "" {
+			return cmdSearchExec(s, cfg, dataDir, query, opts, execCmd, dryRun, jsonOutput)
+		}
+		return cmdSearch(s, query, opts, jsonOutput, ndjson)
 	default:
-		return fmt.Errorf("unknown command: %s\nUsage: cairn [queue|list|status|complete|incomplete|add|note|delete|init|sync|horizon|search]", args[0])
+		// cairn <goal-path> opens the TUI on that goal
+		if _, err := s.LoadGoal(args[0]); err == nil {
+			return runTUI(s, cfg, args[0])
+		}
+		return fmt.Errorf("unknown command: %s\nUsage: cairn [tui|queue|list|diff|status|copy|complete|incomplete|add|note|delete|trash|backup|restore|init|sync|horizon|pin|icon|summary|estimate|stats|doctor|normalize|encrypt-existing|heatmap|today|notify|statusline|waiting|orphans|events|rollover|check|path|get|set|search]", args[0])
 	}
 }
 
@@ -116,6 +446,12 @@ func getDataDir() string {
 	return store.DefaultDataDir()
 }
 
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func hasFlag(args []string, flag string) bool {
 	for _, a := range args {
 		if a == flag {
@@ -125,6 +461,18 @@ func hasFlag(args []string, flag string) bool {
 	return false
 }
 
+// takeFlag returns the value following flag and args without the pair.
+// The value is "" if flag isn't present.
+func takeFlag(args []string, flag string) (string, []string) {
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			rest := append(append([]string(nil), args[:i]...), args[i+2:]...)
+			return args[i+1], rest
+		}
+	}
+	return "", args
+}
+
 func removeFlag(args []string, flag string) []string {
 	var result []string
 	for _, a := range args {
@@ -135,32 +483,60 @@ func removeFlag(args []string, flag string) []string {
 	return result
 }
 
-func runTUI(s *store.Store) error {
-	m := tui.NewModel(s)
+// runTUI starts the interactive UI, selecting startGoal if it's non-empty.
+func runTUI(s *store.Store, cfg *config.Config, startGoal string) error {
+	if err := tui.ApplyTheme(cfg.Theme); err != nil {
+		return err
+	}
+	m := tui.NewModel(s, cfg).WithStartGoal(startGoal).WithNotifier(notify.System())
+	if cfg.EnrichGitHub {
+		m = m.WithLinkStates(enrich.NewGitHub(os.Getenv("GITHUB_TOKEN")))
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
+	s.OnHookError(func(err error) { p.Send(tui.HookFailedMsg{Err: err}) })
+	// Warnings come from inside Update, which Send would block
+	s.OnWarning(func(err error) { go p.Send(tui.WarningMsg{Err: err}) })
+	s.OnEvent(func(e store.Event) { go p.Send(tui.EventMsg{Event: e}) })
 
 	// Start file watcher
-	cleanup, err := tui.StartWatcher(s.Root, p)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: file watcher failed: %v\n", err)
+	if cfg.Watch == "off" {
+		fmt.Fprintln(os.Stderr, "Live reload is off: press R to reload edits made outside cairn.")
 	} else {
-		defer cleanup()
+		opts := tui.WatchOptions{
+			Poll:         cfg.Watch == "poll",
+			Debounce:     cfg.WatchDebounce,
+			PollInterval: cfg.WatchPollInterval,
+		}
+		cleanup, err := tui.StartWatcher(s.Root, opts, p.Send)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: file watcher failed: %v\n", err)
+		} else {
+			defer cleanup()
+		}
 	}
 
-	_, err = p.Run()
+	final, err := p.Run()
+	if cfg.TerminalTitle {
+		fmt.Print(tui.ResetWindowTitle)
+	}
+	if m, ok := final.(tui.Model); ok && err == nil {
+		if summary := m.SessionSummary(); summary != "" {
+			fmt.Println(summary)
+		}
+	}
 	return err
 }
 
 // CLI Commands
 
-func cmdQueue(s *store.Store, jsonOut bool) error {
+func cmdQueue(s store.Backend, jsonOut bool) error {
 	q, err := s.LoadQueue()
 	if err != nil {
 		return err
 	}
 
 	if jsonOut {
-		return outputJSON(q)
+		return outputJSON(api.FromQueue(q))
 	}
 
 	if len(q.Items) == 0 {
@@ -184,59 +560,383 @@ func cmdQueue(s *store.Store, jsonOut bool) error {
 	return nil
 }
 
-func cmdList(s *store.Store, jsonOut bool) error {
-	goals, err := s.LoadGoalTree()
+// loadTree loads the current goal tree, or the one at a git ref if ref is set.
+func loadTree(s *store.Store, ref string) ([]*store.Goal, error) {
+	if ref == "" {
+		return s.LoadGoalTree()
+	}
+	return s.AtRef(ref)
+}
+
+// cmdList prints the goal tree, or with cols a table of the goals with
+// those columns (see listColumns).
+func cmdList(s *store.Store, ref string, cols []string, jsonOut, ndjson, porcelain bool) error {
+	goals, err := loadTree(s, ref)
 	if err != nil {
 		return err
 	}
 
+	if ndjson {
+		var flat []*store.Goal
+		store.WalkGoals(goals, func(g *store.Goal, _ int) error {
+			flat = append(flat, g)
+			return nil
+		})
+		return outputNDJSON(flat)
+	}
+
+	if cols != nil {
+		if jsonOut {
+			return outputJSON(columnRows(goals, cols, time.Now()))
+		}
+		return writeColumns(os.Stdout, goals, cols, time.Now())
+	}
 	if jsonOut {
-		return outputJSON(goalsToMap(goals))
+		return outputJSON(api.FromTree(goals))
+	}
+	if porcelain {
+		return writePorcelain(os.Stdout, goals, true)
 	}
 
-	printGoalTree(goals, 0)
+	printGoalTree(goals, "", true, store.FarHorizon(s.Horizons()))
 	return nil
 }
 
-func printGoalTree(goals []*store.Goal, depth int) {
-	for _, g := range goals {
-		indent := strings.Repeat("  ", depth)
+// cmdDiff shows, in goal terms, what changed since a ref or date (see
+// Store.SinceRef), by default since the last sync: goals added and
+// removed, and title, status, horizon and note changes.
+func cmdDiff(s *store.Store, since string, jsonOut bool) error {
+	ref, err := s.SinceRef(since)
+	if err != nil {
+		return err
+	}
+	var before []*store.Goal
+	if ref != "" {
+		if before, err = s.AtRef(ref); err != nil {
+			return err
+		}
+	}
+	after, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	changes := store.DiffTrees(before, after)
+
+	if jsonOut {
+		if changes == nil {
+			changes = []store.GoalChange{}
+		}
+		return outputJSON(changes)
+	}
+
+	if since == "" {
+		since = ref
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No changes since %s.\n", since)
+		return nil
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case store.ChangeAdded:
+			fmt.Printf("+ %s (%s)\n", c.Path, c.Title)
+		case store.ChangeRemoved:
+			fmt.Printf("- %s (%s)\n", c.Path, c.Title)
+		case store.ChangeTitle:
+			fmt.Printf("~ %s: renamed %q → %q\n", c.Path, c.From, c.To)
+		case store.ChangeStatus, store.ChangeHorizon:
+			fmt.Printf("~ %s: %s %s → %s\n", c.Path, c.Kind, orNone(c.From), orNone(c.To))
+		case store.ChangeNoteAdded:
+			fmt.Printf("  %s: + %s\n", c.Path, c.Note)
+		case store.ChangeNoteRemoved:
+			fmt.Printf("  %s: - %s\n", c.Path, c.Note)
+		}
+	}
+	return nil
+}
+
+// orNone shows an unset field in cairn diff.
+func orNone(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
+
+// printGoalTree prints goals one per line, each line starting with prefix,
+// and their sub-goals indented under them when recursive. Horizons other than
+// far, the last configured one, are shown after the title.
+func printGoalTree(goals []*store.Goal, prefix string, recursive bool, far store.Horizon) {
+	store.WalkGoals(goals, func(g *store.Goal, depth int) error {
+		indent := prefix + strings.Repeat("  ", depth)
 		status := "○"
 		if g.IsComplete() {
 			status = "✓"
 		}
 		horizon := ""
-		if g.Horizon == store.HorizonToday {
-			horizon = " [today]"
-		} else if g.Horizon == store.HorizonTomorrow {
-			horizon = " [tomorrow]"
+		if g.Horizon != "" && g.Horizon != far {
+			horizon = " [" + string(g.Horizon) + "]"
+		}
+		if g.Pinned {
+			horizon += " [pinned]"
+		}
+		if g.FrontmatterErr != nil {
+			horizon += " [frontmatter error]"
+		}
+		title := g.Title
+		if g.Icon != "" {
+			title = g.Icon + " " + title
+		}
+		fmt.Printf("%s%s %s%s%s\n", indent, status, title, summarySuffix(g), horizon)
+		if !recursive {
+			return store.SkipChildren
+		}
+		return nil
+	})
+}
+
+// summarySuffix is g's summary as plain list and search output show it
+// after the title, or "" if it has none.
+func summarySuffix(g *store.Goal) string {
+	if g.Summary == "" {
+		return ""
+	}
+	return " — " + g.Summary
+}
+
+// listColumns are the columns cairn list --columns can show after each
+// goal's title. age is how long ago the goal was created and updated how
+// long ago it last changed; in JSON they're whole days and a timestamp.
+var listColumns = []string{"path", "status", "horizon", "age", "created", "updated", "tags", "estimate", "summary"}
+
+// parseColumns splits a comma-separated --columns value, checking each
+// name is in listColumns and given once.
+func parseColumns(value string) ([]string, error) {
+	var cols []string
+	for _, c := range strings.Split(value, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(listColumns, c) {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", c, strings.Join(listColumns, ", "))
 		}
-		fmt.Printf("%s%s %s%s\n", indent, status, g.Title, horizon)
-		printGoalTree(g.Children, depth+1)
+		if slices.Contains(cols, c) {
+			return nil, fmt.Errorf("column %q is listed twice", c)
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// columnValue is column col of g for the table.
+func columnValue(g *store.Goal, col string, now time.Time) string {
+	switch col {
+	case "path":
+		return g.Path
+	case "status":
+		return string(g.Status)
+	case "horizon":
+		return string(g.Horizon)
+	case "age":
+		if g.Created.IsZero() {
+			return ""
+		}
+		return shortDuration(now.Sub(g.Created))
+	case "created":
+		if g.Created.IsZero() {
+			return ""
+		}
+		return g.Created.Local().Format("2006-01-02")
+	case "updated":
+		if g.Updated.IsZero() {
+			return ""
+		}
+		return shortDuration(now.Sub(g.Updated)) + " ago"
+	case "tags":
+		return strings.Join(g.Tags, ",")
+	case "estimate":
+		return g.Estimate
+	case "summary":
+		return g.Summary
+	}
+	return ""
+}
+
+// shortDuration rounds d down to its largest unit: "5m", "3h", "12d",
+// "3w", "4mo" or "2y".
+func shortDuration(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case days < 1:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case days < 14:
+		return fmt.Sprintf("%dd", days)
+	case days < 60:
+		return fmt.Sprintf("%dw", days/7)
+	case days < 365:
+		return fmt.Sprintf("%dmo", days/30)
 	}
+	return fmt.Sprintf("%dy", days/365)
+}
+
+// writeColumns writes goals in tree order as an aligned table: the title,
+// indented by depth, then cols.
+func writeColumns(w io.Writer, goals []*store.Goal, cols []string, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"TITLE"}
+	for _, c := range cols {
+		header = append(header, strings.ToUpper(c))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	store.WalkGoals(goals, func(g *store.Goal, depth int) error {
+		row := []string{strings.Repeat("  ", depth) + g.Title}
+		for _, c := range cols {
+			row = append(row, columnValue(g, c, now))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+		return nil
+	})
+	return tw.Flush()
+}
+
+// columnRows is writeColumns' table for --json: a flat list in tree order
+// of each goal's path, title and cols.
+func columnRows(goals []*store.Goal, cols []string, now time.Time) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	store.WalkGoals(goals, func(g *store.Goal, _ int) error {
+		row := map[string]interface{}{"path": g.Path, "title": g.Title}
+		for _, c := range cols {
+			switch c {
+			case "age":
+				if !g.Created.IsZero() {
+					row[c] = int(now.Sub(g.Created).Hours() / 24)
+				}
+			case "created", "updated":
+				t := g.Created
+				if c == "updated" {
+					t = g.Updated
+				}
+				if !t.IsZero() {
+					row[c] = api.FormatTime(t)
+				}
+			case "tags":
+				row[c] = g.Tags
+				if g.Tags == nil {
+					row[c] = []string{}
+				}
+			default:
+				row[c] = columnValue(g, c, now)
+			}
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows
+}
+
+// writePorcelain writes goals, and their descendants when recursive, in
+// tree order for --porcelain: one line per goal of tab-separated columns
+//
+//	path	status	horizon	title
+//
+// status is incomplete, in-progress or complete; horizon is today,
+// tomorrow, future or empty when unset. Tabs and newlines in titles
+// become spaces. There's no header and no decoration, and the format
+// won't change between versions: new fields go in the JSON output.
+func writePorcelain(w io.Writer, goals []*store.Goal, recursive bool) error {
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	return store.WalkGoals(goals, func(g *store.Goal, _ int) error {
+		status := g.Status
+		if status == "" {
+			status = store.StatusIncomplete
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", g.Path, status, g.Horizon, clean.Replace(g.Title)); err != nil {
+			return err
+		}
+		if !recursive {
+			return store.SkipChildren
+		}
+		return nil
+	})
 }
 
-func cmdStatus(s *store.Store, goalPath string, jsonOut bool) error {
+// cmdStatus shows a goal's details, its notes and its direct children, or
+// its whole subtree when recursive. porcelain lists the goal and those
+// children in writePorcelain's format instead.
+func cmdStatus(s store.Backend, goalPath string, recursive, jsonOut, porcelain bool) error {
 	g, err := s.LoadGoal(goalPath)
 	if err != nil {
 		return err
 	}
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	var children []*store.Goal
+	sub := store.FindGoal(goals, g.Path)
+	if sub != nil {
+		children = sub.Children
+	}
+	done := 0
+	for _, c := range children {
+		if c.IsComplete() {
+			done++
+		}
+	}
 
 	if jsonOut {
-		return outputJSON(goalToMap(g))
+		out := statusJSON{GoalJSON: api.FromGoal(g), ChildrenComplete: done, ChildrenTotal: len(children)}
+		if recursive {
+			tree := api.FromTree(children)
+			out.Children = &tree
+		}
+		return outputJSON(out)
+	}
+	if porcelain {
+		if err := writePorcelain(os.Stdout, []*store.Goal{g}, false); err != nil {
+			return err
+		}
+		return writePorcelain(os.Stdout, children, recursive)
 	}
 
 	status := "incomplete"
 	if g.IsComplete() {
 		status = "complete"
 	}
+	if g.StatusLabel != "" {
+		status += " (" + g.StatusLabel + ")"
+	}
 	fmt.Printf("%s: %s\n", g.Title, status)
+	if g.Summary != "" {
+		fmt.Println(g.Summary)
+	}
 	if g.Horizon != "" {
 		fmt.Printf("Horizon: %s\n", g.Horizon)
 	}
+	if g.Pinned {
+		fmt.Println("Pinned")
+	}
+	if g.Estimate != "" {
+		fmt.Printf("Estimate: %s\n", g.Estimate)
+	}
+	if len(children) > 0 {
+		if remaining := store.FormatRemaining(sub); remaining != "" {
+			fmt.Printf("Remaining: %s\n", remaining)
+		}
+	}
 	if len(g.Tags) > 0 {
 		fmt.Printf("Tags: %s\n", strings.Join(g.Tags, ", "))
 	}
+	if len(children) > 0 {
+		fmt.Printf("\nChildren (%d/%d complete):\n", done, len(children))
+		printGoalTree(children, "  ", recursive, store.FarHorizon(s.Horizons()))
+	}
+	if g.FrontmatterErr != nil {
+		fmt.Printf("Frontmatter error: %v\n", g.FrontmatterErr)
+	}
+	if g.BodyErr != nil {
+		fmt.Printf("\nNotes unavailable: %v\n", g.BodyErr)
+	}
 	if g.Body != "" {
 		fmt.Println()
 		fmt.Println(g.Body)
@@ -244,143 +944,1471 @@ func cmdStatus(s *store.Store, goalPath string, jsonOut bool) error {
 	return nil
 }
 
-func cmdSetStatus(s *store.Store, goalPath string, status store.GoalStatus, jsonOut bool) error {
+func cmdSetStatus(s store.Backend, goalPath string, status store.GoalStatus, propagate, jsonOut bool) error {
 	g, err := s.SetStatus(goalPath, status)
 	if err != nil {
 		return err
 	}
+	if propagate {
+		if err := propagateStatus(s, goalPath); err != nil {
+			return err
+		}
+	}
 
 	if jsonOut {
-		return outputJSON(goalToMap(g))
+		return outputJSON(api.FromGoal(g))
 	}
 
 	fmt.Printf("%s → %s\n", g.Title, status)
 	return nil
 }
 
-func cmdAdd(s *store.Store, parent, slug string, jsonOut bool) error {
+func cmdAdd(s store.Backend, parent, slug string, jsonOut bool) error {
 	g, err := s.CreateGoal(parent, slug)
 	if err != nil {
 		return err
 	}
 
 	if jsonOut {
-		return outputJSON(goalToMap(g))
+		return outputJSON(api.FromGoal(g))
 	}
 
 	fmt.Printf("Created: %s\n", g.Path)
 	return nil
 }
 
-func cmdNote(s *store.Store, goalPath, text string, jsonOut bool) error {
-	g, err := s.AddNote(goalPath, text)
-	if err != nil {
-		return err
-	}
-
-	if jsonOut {
-		return outputJSON(goalToMap(g))
-	}
+// errAborted is returned when Ctrl-C interrupts a prompt.
+var errAborted = errors.New("aborted")
 
-	fmt.Printf("Note added to %s\n", g.Title)
-	return nil
+// prompter asks questions on stderr and reads one answer per line from
+// stdin, so here-docs can answer them. Ctrl-C interrupts a pending
+// question with errAborted rather than killing the process.
+type prompter struct {
+	out       io.Writer
+	lines     chan string
+	interrupt chan os.Signal
 }
 
-func cmdDelete(s *store.Store, goalPath string, jsonOut bool) error {
-	if err := s.DeleteGoal(goalPath); err != nil {
-		return err
-	}
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	p := &prompter{out: out, lines: make(chan string), interrupt: make(chan os.Signal, 1)}
+	signal.Notify(p.interrupt, os.Interrupt)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			p.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(p.lines)
+	}()
+	return p
+}
 
-	if jsonOut {
-		return outputJSON(map[string]string{"deleted": goalPath})
+// ask prints question and returns the answer, io.EOF once input runs out.
+func (p *prompter) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			fmt.Fprintln(p.out)
+			return "", io.EOF
+		}
+		return line, nil
+	case <-p.interrupt:
+		fmt.Fprintln(p.out)
+		return "", errAborted
 	}
+}
 
-	fmt.Printf("Deleted: %s\n", goalPath)
-	return nil
+func (p *prompter) close() {
+	signal.Stop(p.interrupt)
 }
 
-func cmdHorizon(s *store.Store, goalPath, horizon string, jsonOut bool) error {
-	var h store.Horizon
-	switch horizon {
-	case "today":
-		h = store.HorizonToday
-	case "tomorrow":
-		h = store.HorizonTomorrow
-	case "future":
-		h = store.HorizonFuture
-	default:
-		return fmt.Errorf("invalid horizon: %s (use today, tomorrow, or future)", horizon)
-	}
+// addAnswers are the new goal's fields gathered by cairn add -i.
+type addAnswers struct {
+	title   string
+	parent  string
+	horizon store.Horizon
+	tags    string // comma-separated; empty keeps defaults.tags
+	note    string
+}
 
-	g, err := s.SetHorizon(goalPath, h)
+// cmdAddInteractive prompts for a new goal's title, parent, horizon, tags
+// and first note, then creates it. Blank answers take the defaults section;
+// nothing is created until every question is answered.
+func cmdAddInteractive(s store.Backend, cfg *config.Config, jsonOut bool) error {
+	goals, err := s.LoadGoalTree()
 	if err != nil {
 		return err
 	}
+	var paths []string
+	store.WalkGoals(goals, func(g *store.Goal, _ int) error {
+		paths = append(paths, g.Path)
+		return nil
+	})
 
-	if jsonOut {
-		return outputJSON(goalToMap(g))
+	p := newPrompter(os.Stdin, os.Stderr)
+	a, err := askAdd(p, paths, s.Horizons(), store.Horizon(cfg.NewGoalHorizon()), cfg.Defaults.Tags)
+	p.close()
+	if errors.Is(err, errAborted) {
+		fmt.Fprintln(os.Stderr, "Aborted.")
+		return &exitError{code: 1}
 	}
-
-	fmt.Printf("%s → %s\n", g.Title, horizon)
-	return nil
-}
-
-func cmdSearch(s *store.Store, query string, jsonOut bool) error {
-	matches, err := s.SearchNotes(query)
 	if err != nil {
 		return err
 	}
 
-	if jsonOut {
-		return outputJSON(goalsToMap(matches))
+	g, err := s.CreateGoal(a.parent, a.title)
+	if err != nil {
+		return err
 	}
-
-	if len(matches) == 0 {
-		fmt.Println("No matches found.")
-		return nil
+	saved := false
+	if g.Title != a.title || a.tags != "" {
+		g.Title = a.title
+		if a.tags != "" {
+			if err := g.SetField("tags", a.tags); err != nil {
+				return err
+			}
+		}
+		if err := s.SaveGoal(g); err != nil {
+			return err
+		}
+		saved = true
+	}
+	if a.horizon != g.Horizon {
+		// SetHorizon commits, picking up the title and tags too
+		if g, err = s.SetHorizon(g.Path, a.horizon); err != nil {
+			return err
+		}
+	} else if saved {
+		s.Commit("add goal: " + g.Path)
+	}
+	if a.note != "" {
+		if g, err = s.AddNote(g.Path, a.note); err != nil {
+			return err
+		}
 	}
 
-	for _, g := range matches {
-		fmt.Printf("%s (%s)\n", g.Title, g.Path)
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
 	}
+	fmt.Printf("Created: %s\n", g.Path)
 	return nil
 }
 
-// JSON helpers
-
-func outputJSON(v interface{}) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(v)
-}
-
-func goalToMap(g *store.Goal) map[string]interface{} {
-	m := map[string]interface{}{
-		"title":   g.Title,
-		"status":  string(g.Status),
-		"path":    g.Path,
-		"horizon": string(g.Horizon),
-		"tags":    g.Tags,
-		"links":   g.Links,
-		"body":    g.Body,
-	}
-	if !g.Created.IsZero() {
-		m["created"] = g.Created.Format("2006-01-02T15:04:05Z")
-	}
-	if !g.Updated.IsZero() {
-		m["updated"] = g.Updated.Format("2006-01-02T15:04:05Z")
+// askAdd asks cairn add -i's questions. A parent can be given by a prefix
+// of its path when only one goal has it; otherwise the candidates are
+// listed and the question asked again. horizons are the ones to choose from.
+func askAdd(p *prompter, paths []string, horizons []store.Horizon, horizon store.Horizon, tags []string) (addAnswers, error) {
+	a := addAnswers{horizon: horizon}
+	for a.title == "" {
+		title, err := p.ask("Title: ")
+		if err == io.EOF {
+			return a, errors.New("no title given")
+		}
+		if err != nil {
+			return a, err
+		}
+		if strings.Contains(title, "/") {
+			fmt.Fprintln(p.out, "A title can't contain /.")
+			continue
+		}
+		a.title = title
 	}
-	return m
-}
 
-func goalsToMap(goals []*store.Goal) []map[string]interface{} {
-	var result []map[string]interface{}
+	for {
+		parent, err := p.ask("Parent (blank for top level): ")
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return a, err
+		}
+		parent = strings.Trim(parent, "/")
+		if parent == "" {
+			break
+		}
+		matches := completePath(paths, parent)
+		if len(matches) == 1 {
+			a.parent = matches[0]
+			if a.parent != parent {
+				fmt.Fprintf(p.out, "  → %s\n", a.parent)
+			}
+			break
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(p.out, "No goal matches %s.\n", parent)
+		} else {
+			if len(matches) > 10 {
+				matches = append(matches[:10], "…")
+			}
+			fmt.Fprintf(p.out, "%s could be: %s\n", parent, strings.Join(matches, ", "))
+		}
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("Horizon (%s) [%s]: ", strings.Join(store.HorizonNames(horizons), ", "), horizon))
+		if err == io.EOF || err == nil && answer == "" {
+			break
+		}
+		if err != nil {
+			return a, err
+		}
+		h, err := store.ParseHorizon(strings.ToLower(answer), horizons)
+		if err == nil {
+			a.horizon = h
+			break
+		}
+		fmt.Fprintln(p.out, err)
+	}
+
+	question := "Tags, comma-separated: "
+	if len(tags) > 0 {
+		question = fmt.Sprintf("Tags, comma-separated [%s]: ", strings.Join(tags, ","))
+	}
+	var err error
+	if a.tags, err = p.ask(question); err != nil && err != io.EOF {
+		return a, err
+	}
+	if a.note, err = p.ask("First note (optional): "); err != nil && err != io.EOF {
+		return a, err
+	}
+	return a, nil
+}
+
+// completePath returns the path equal to prefix, or else the paths that
+// start with it.
+func completePath(paths []string, prefix string) []string {
+	var matches []string
+	for _, path := range paths {
+		if path == prefix {
+			return []string{path}
+		}
+		if strings.HasPrefix(path, prefix) {
+			matches = append(matches, path)
+		}
+	}
+	return matches
+}
+
+func cmdNote(s store.Backend, goalPath, text string, jsonOut bool) error {
+	g, err := s.AddNote(goalPath, text)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+
+	fmt.Printf("Note added to %s\n", g.Title)
+	return nil
+}
+
+// cmdNoteEdit opens today's notes of a goal, the lines under its date
+// header, in $EDITOR and splices what's saved back into the body. The
+// frontmatter and other days aren't touched; saving nothing removes the day.
+func cmdNoteEdit(s store.Backend, goalPath string, jsonOut bool) error {
+	g, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return err
+	}
+	if g.BodyErr != nil {
+		return fmt.Errorf("can't edit the notes of %s: %w", goalPath, g.BodyErr)
+	}
+	today := time.Now().Format("2006-01-02")
+	text, _ := store.NoteDay(g.Body, today)
+	if text != "" {
+		text += "\n"
+	}
+
+	f, err := os.CreateTemp("", "cairn-note-*.md")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	argv, _, err := tui.ResolveEditor(os.Getenv("EDITOR"))
+	if err != nil {
+		return err
+	}
+	editor := exec.Command(argv[0], append(argv[1:], f.Name())...)
+	editor.Stdin, editor.Stdout, editor.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editor.Run(); err != nil {
+		return fmt.Errorf("editor: %w", err)
+	}
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return err
+	}
+	if string(edited) == text {
+		if !jsonOut {
+			fmt.Println("No changes to today's notes")
+		}
+		return nil
+	}
+
+	g.Body = store.SetNoteDay(g.Body, today, string(edited))
+	if err := s.SaveGoal(g); err != nil {
+		return err
+	}
+	s.Commit("note: " + g.Path)
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+	fmt.Printf("Updated today's notes for %s\n", g.Title)
+	return nil
+}
+
+// cmdDelete removes a goal and its descendants after showing what will go.
+// Without --yes it asks for confirmation, which needs a terminal; --dry-run
+// only lists the paths.
+func cmdDelete(s store.Backend, goalPath string, yes, dryRun, jsonOut bool) error {
+	goalPath = filepath.Clean(goalPath)
+	summary, err := s.SubtreeSummary(goalPath)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		if jsonOut {
+			return outputJSON(summary)
+		}
+		for _, p := range summary.Paths {
+			fmt.Println(p)
+		}
+		return nil
+	}
+
+	if !yes {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to delete %s without confirmation: pass --yes", goalPath)
+		}
+		fmt.Fprintf(os.Stderr, "This will delete %d goal(s) and %d line(s) of notes under %s/\n", summary.Goals, summary.NoteLines, goalPath)
+		fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintln(os.Stderr, "Aborted.")
+			return &exitError{code: 1}
+		}
+	}
+
+	if err := s.DeleteGoal(goalPath); err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]string{"deleted": goalPath})
+	}
+
+	fmt.Printf("Deleted: %s (cairn trash --restore %s brings it back)\n", goalPath, goalPath)
+	return nil
+}
+
+// cmdTrashList lists deleted goals, newest first.
+func cmdTrashList(s *store.Store, jsonOut bool) error {
+	items, err := s.ListTrash()
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		if items == nil {
+			items = []store.TrashItem{}
+		}
+		return outputJSON(items)
+	}
+	if len(items) == 0 {
+		fmt.Println("Trash is empty.")
+		return nil
+	}
+	for _, item := range items {
+		fmt.Printf("%s  %s  %s\n", item.Deleted.Local().Format("2006-01-02 15:04"), item.Path, item.ID)
+	}
+	return nil
+}
+
+// cmdTrashRestore puts a deleted goal back where it was.
+func cmdTrashRestore(s *store.Store, ref string, jsonOut bool) error {
+	goalPath, err := s.RestoreTrash(ref)
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return outputJSON(map[string]string{"restored": goalPath})
+	}
+	fmt.Printf("Restored: %s\n", goalPath)
+	return nil
+}
+
+// cmdTrashEmpty permanently removes everything in the trash.
+func cmdTrashEmpty(s *store.Store, jsonOut bool) error {
+	n, err := s.EmptyTrash()
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return outputJSON(map[string]int{"purged": n})
+	}
+	fmt.Printf("Permanently removed %d goal(s).\n", n)
+	return nil
+}
+
+// cmdBackup writes the data directory's goals, queue and config to a
+// gzipped tar, cairn-<date>.tar.gz by default or stdout for "-".
+func cmdBackup(dataDir, out string, jsonOut bool) error {
+	if out == "" {
+		out = "cairn-" + time.Now().Format("2006-01-02") + ".tar.gz"
+	}
+	w := os.Stdout
+	if out != "-" {
+		f, err := os.OpenFile(out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	n, err := store.WriteBackup(dataDir, w)
+	if err != nil {
+		if out != "-" {
+			os.Remove(out)
+		}
+		return err
+	}
+	if out == "-" {
+		return nil
+	}
+	if err := w.Sync(); err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]interface{}{"archive": out, "files": n})
+	}
+	fmt.Printf("Backed up %d file(s) to %s\n", n, out)
+	return nil
+}
+
+// cmdRestore extracts a backup into the data directory, or into, after
+// verifying it. A data directory that already has anything besides cairn's
+// runtime files is only overwritten with force.
+func cmdRestore(s *store.Store, archive, into string, force, jsonOut bool) error {
+	dir := s.DataDir()
+	if into != "" {
+		dir = into
+	}
+	if !force {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		for _, e := range entries {
+			if e.Name() != store.RuntimeDir {
+				return fmt.Errorf("%s is not empty: pass --force to replace its goals, queue and config", dir)
+			}
+		}
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := store.RestoreBackup(f, dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	if dir == s.DataDir() {
+		s.Commit("restore backup: " + filepath.Base(archive))
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]interface{}{"restored": dir, "files": n})
+	}
+	fmt.Printf("Restored %d file(s) from %s into %s\n", n, archive, dir)
+	return nil
+}
+
+// configHorizons are cfg's horizons for store.Options.
+func configHorizons(cfg *config.Config) []store.Horizon {
+	hs := make([]store.Horizon, len(cfg.Horizons))
+	for i, h := range cfg.Horizons {
+		hs[i] = store.Horizon(h)
+	}
+	return hs
+}
+
+func cmdHorizon(s store.Backend, goalPath, horizon string, jsonOut bool) error {
+	h, err := store.ParseHorizon(horizon, s.Horizons())
+	if err != nil {
+		return err
+	}
+
+	g, err := s.SetHorizon(goalPath, h)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+
+	fmt.Printf("%s → %s\n", g.Title, horizon)
+	return nil
+}
+
+// cmdPin toggles whether a goal is pinned above the horizon sections.
+func cmdPin(s store.Backend, goalPath string, jsonOut bool) error {
+	g, err := s.TogglePin(goalPath)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+
+	if g.Pinned {
+		fmt.Printf("Pinned: %s\n", g.Path)
+	} else {
+		fmt.Printf("Unpinned: %s\n", g.Path)
+	}
+	return nil
+}
+
+// cmdToday lists TODAY goals, with those lingering past the stale threshold
+// in their own section.
+func cmdToday(s store.Backend, staleDays int, jsonOut bool) error {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var today, stale []*store.Goal
+	var walk func([]*store.Goal)
+	walk = func(goals []*store.Goal) {
+		for _, g := range goals {
+			if g.Horizon == store.HorizonToday {
+				if store.IsStaleToday(g, now, staleDays) {
+					stale = append(stale, g)
+				} else {
+					today = append(today, g)
+				}
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+
+	if jsonOut {
+		out := todayJSON{Today: []api.GoalJSON{}, Stale: []staleJSON{}}
+		for _, g := range today {
+			out.Today = append(out.Today, api.FromGoal(g))
+		}
+		for _, g := range stale {
+			out.Stale = append(out.Stale, staleJSON{GoalJSON: api.FromGoal(g), DaysInToday: store.DaysInToday(g, now)})
+		}
+		return outputJSON(out)
+	}
+
+	if len(today) == 0 && len(stale) == 0 {
+		fmt.Println("Nothing in TODAY.")
+		return nil
+	}
+	for _, g := range today {
+		fmt.Printf("%s %s (%s)\n", statusIcon(g), g.Title, g.Path)
+	}
+	if len(stale) > 0 {
+		if len(today) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Stale (in TODAY for more than %d days):\n", staleDays)
+		for _, g := range stale {
+			fmt.Printf("%s %s (%s) — %d days\n", statusIcon(g), g.Title, g.Path, store.DaysInToday(g, now))
+		}
+	}
+	return nil
+}
+
+// cmdEstimate sets (or with no duration, clears) a goal's effort estimate.
+func cmdEstimate(s store.Backend, goalPath, estimate string, jsonOut bool) error {
+	g, err := s.SetEstimate(goalPath, estimate)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+
+	if g.Estimate == "" {
+		fmt.Printf("%s: estimate cleared\n", g.Title)
+	} else {
+		fmt.Printf("%s: estimate → %s\n", g.Title, g.Estimate)
+	}
+	return nil
+}
+
+// cmdStats reports estimated and completed effort per horizon.
+func cmdStats(s store.Backend, jsonOut bool) error {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	horizons := s.Horizons()
+	effort := store.EffortByHorizon(goals, horizons)
+	streak := store.Streak(store.Activity(goals, time.Now(), 365))
+
+	if jsonOut {
+		result := map[string]interface{}{}
+		for _, h := range horizons {
+			e := effort[h]
+			result[string(h)] = map[string]interface{}{
+				"estimated_hours":  e.Total.Hours(),
+				"completed_hours":  e.Completed.Hours(),
+				"estimated_points": e.Points,
+				"completed_points": e.CompletedPoints,
+			}
+		}
+		result["streak"] = streak
+		return outputJSON(result)
+	}
+
+	var total store.Effort
+	for _, h := range horizons {
+		e := effort[h]
+		total.Total += e.Total
+		total.Completed += e.Completed
+		total.Points += e.Points
+		total.CompletedPoints += e.CompletedPoints
+		printEffort(string(h), e)
+	}
+	printEffort("total", total)
+	if streak > 0 {
+		fmt.Printf("\nStreak: %d day(s) with at least one completion\n", streak)
+	}
+	if problems := store.Diagnose(goals, horizons); len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d goal(s) have problems that affect these totals; run cairn doctor\n", len(problems))
+	}
+	return nil
+}
+
+// printEffort prints one row of cairn stats: completed over estimated time,
+// and points when any goal is estimated in them.
+func printEffort(label string, e store.Effort) {
+	line := fmt.Sprintf("%-9s %s / %s", label, store.FormatEstimate(e.Completed), store.FormatEstimate(e.Total))
+	if e.Points > 0 {
+		line += fmt.Sprintf(", %s / %s", strconv.FormatFloat(e.CompletedPoints, 'f', -1, 64), store.FormatPoints(e.Points))
+	}
+	fmt.Println(line)
+}
+
+// cmdHeatmap reports completions and notes per day for the last days days,
+// as a sparkline or (with --json) per-day counts.
+func cmdHeatmap(s store.Backend, days int, jsonOut bool) error {
+	activity, err := s.ActivityByDay(days)
+	if err != nil {
+		return err
+	}
+	streak := store.Streak(activity)
+
+	if jsonOut {
+		perDay := []map[string]interface{}{}
+		for _, d := range activity {
+			perDay = append(perDay, map[string]interface{}{
+				"date":        d.Date.Format("2006-01-02"),
+				"completions": d.Completions,
+				"notes":       d.Notes,
+			})
+		}
+		return outputJSON(map[string]interface{}{"days": perDay, "streak": streak})
+	}
+
+	completions, notes := 0, 0
+	counts := make([]int, len(activity))
+	for i, d := range activity {
+		completions += d.Completions
+		notes += d.Notes
+		counts[i] = d.Completions + d.Notes
+	}
+	fmt.Printf("%s → %s\n", activity[0].Date.Format("2006-01-02"), activity[len(activity)-1].Date.Format("2006-01-02"))
+	fmt.Println(sparkline(counts))
+	fmt.Printf("%d completion(s), %d note(s), %d-day streak\n", completions, notes, streak)
+	return nil
+}
+
+// sparkline draws counts as block characters scaled to the largest count;
+// days with nothing are a dot.
+func sparkline(counts []int) string {
+	const levels = "▁▂▃▄▅▆▇█"
+	blocks := []rune(levels)
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	var b strings.Builder
+	for _, c := range counts {
+		if c == 0 {
+			b.WriteRune('·')
+			continue
+		}
+		b.WriteRune(blocks[(c*len(blocks)-1)/max])
+	}
+	return b.String()
+}
+
+// cmdDoctor reports goal values cairn can't use. It exits 1 when it finds any.
+func cmdDoctor(s store.Backend, jsonOut bool) error {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	problems := store.Diagnose(goals, s.Horizons())
+
+	if jsonOut {
+		if problems == nil {
+			problems = []store.Problem{}
+		}
+		if err := outputJSON(problems); err != nil {
+			return err
+		}
+	} else if len(problems) == 0 {
+		fmt.Println("No problems found.")
+	} else {
+		for _, p := range problems {
+			fmt.Printf("%s: %s: %s\n", p.Path, p.Field, p.Message)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// cmdNormalize rewrites hand-edited goal files in canonical form and lists
+// the ones that changed.
+func cmdNormalize(s *store.Store, dryRun, jsonOut bool) error {
+	result, err := s.Normalize(dryRun)
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return outputJSON(result)
+	}
+
+	for _, p := range result.Problems {
+		fmt.Fprintf(os.Stderr, "skipped %s: %s\n", p.Path, p.Message)
+	}
+	verb := "Normalized"
+	if dryRun {
+		verb = "Would normalize"
+	}
+	for _, p := range result.Changed {
+		rel, _ := filepath.Rel(s.Root, s.GoalFile(p))
+		fmt.Println(rel)
+	}
+	if len(result.Changed) == 0 {
+		fmt.Println("Already normalized.")
+	} else {
+		fmt.Printf("%s %d file(s).\n", verb, len(result.Changed))
+	}
+	return nil
+}
+
+// cmdEncryptExisting encrypts the notes of goals written before the
+// recipients file was added and lists them.
+func cmdEncryptExisting(s *store.Store, jsonOut bool) error {
+	paths, err := s.EncryptExisting()
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		if paths == nil {
+			paths = []string{}
+		}
+		return outputJSON(map[string]interface{}{"encrypted": paths})
+	}
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+	if len(paths) == 0 {
+		fmt.Println("All notes are already encrypted.")
+	} else {
+		fmt.Printf("Encrypted %d goal(s).\n", len(paths))
+	}
+	return nil
+}
+
+// cmdNotify shows a desktop notification summarizing TODAY. With nothing
+// to report it prints nothing and succeeds, so it can run from cron.
+func cmdNotify(s store.Backend, n notify.Notifier, staleDays int, jsonOut bool) error {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	msg, err := notify.Today(n, goals, time.Now(), staleDays)
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return outputJSON(map[string]interface{}{"notified": msg != "", "message": msg})
+	}
+	return nil
+}
+
+// defaultStatuslineFormat is what `cairn statusline` prints without --format.
+const defaultStatuslineFormat = "cairn: {today_done}/{today_total} today {git}"
+
+// cmdCopy puts a markdown summary of goalPath on the clipboard, the same
+// one y copies in the TUI, or prints it.
+func cmdCopy(s store.Backend, goalPath string, toStdout bool) error {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	g := store.FindGoal(goals, filepath.Clean(goalPath))
+	if g == nil {
+		return fmt.Errorf("goal not found: %s", goalPath)
+	}
+	summary := tui.GoalSummary(g, time.Now())
+	if toStdout {
+		fmt.Print(summary)
+		return nil
+	}
+
+	var tty io.Writer
+	if isTerminal(os.Stdout) {
+		tty = os.Stdout
+	}
+	if err := clipboard.Copy(tty, summary); err != nil {
+		return fmt.Errorf("%w (use --stdout to print it)", err)
+	}
+	fmt.Printf("Copied summary of %s\n", g.Title)
+	return nil
+}
+
+// cmdStatusline prints one compact line for tmux and shell prompts. It reads
+// only frontmatter and makes a single time-limited git call so it stays fast
+// on big trees; a slow or failing git leaves {git} and {branch} empty.
+func cmdStatusline(s *store.Store, format string) error {
+	if format == "" {
+		format = defaultStatuslineFormat
+	}
+	goals, err := s.LoadFrontmatters()
+	if err != nil {
+		return err
+	}
+
+	done, total := store.TodayProgress(goals)
+	titles := map[string]string{}
+	for _, g := range goals {
+		titles[g.Path] = g.Title
+	}
+
+	head := ""
+	if q, err := s.LoadQueue(); err == nil && len(q.Items) > 0 {
+		head = q.Items[0]
+		if t := titles[head]; t != "" {
+			head = t
+		}
+	}
+
+	git, branch := "", ""
+	if st, err := gsync.Status(s.Root, 200*time.Millisecond); err == nil {
+		branch = st.Branch
+		var parts []string
+		if st.Dirty {
+			parts = append(parts, "●dirty")
+		}
+		if st.Ahead > 0 {
+			parts = append(parts, fmt.Sprintf("↑%d", st.Ahead))
+		}
+		if st.Behind > 0 {
+			parts = append(parts, fmt.Sprintf("↓%d", st.Behind))
+		}
+		git = strings.Join(parts, " ")
+	}
+
+	line := strings.NewReplacer(
+		"{today_done}", strconv.Itoa(done),
+		"{today_total}", strconv.Itoa(total),
+		"{queue_head}", head,
+		"{git}", git,
+		"{branch}", branch,
+	).Replace(format)
+	fmt.Println(strings.TrimSpace(line))
+	return nil
+}
+
+// cmdWaiting lists unfinished goals with a "waiting" link, longest-waiting first.
+func cmdWaiting(s store.Backend, jsonOut bool) error {
+	goals, err := s.Waiting()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if jsonOut {
+		result := []waitingJSON{}
+		for _, g := range goals {
+			result = append(result, waitingJSON{GoalJSON: api.FromGoal(g), Waiting: g.WaitingOn(), DaysWaiting: store.DaysWaiting(g, now)})
+		}
+		return outputJSON(result)
+	}
+
+	if len(goals) == 0 {
+		fmt.Println("Nothing is waiting.")
+		return nil
+	}
 	for _, g := range goals {
-		m := goalToMap(g)
-		if len(g.Children) > 0 {
-			m["children"] = goalsToMap(g.Children)
+		fmt.Printf("%s %s (%s) — waiting on %s, %d days\n", statusIcon(g), g.Title, g.Path, g.WaitingOn(), store.DaysWaiting(g, now))
+	}
+	return nil
+}
+
+// defaultOrphanDays is how long a FUTURE goal goes untouched before cairn
+// orphans calls it stale.
+const defaultOrphanDays = 30
+
+// cmdOrphans lists unfinished top-level goals missing from the queue and
+// FUTURE goals nobody has touched in days days.
+func cmdOrphans(s store.Backend, days int, jsonOut bool) error {
+	o, err := s.Orphans(days)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if jsonOut {
+		out := orphansJSON{Unqueued: []api.GoalJSON{}, Stale: []untouchedJSON{}}
+		for _, g := range o.Unqueued {
+			out.Unqueued = append(out.Unqueued, api.FromGoal(g))
 		}
-		result = append(result, m)
+		for _, g := range o.Stale {
+			out.Stale = append(out.Stale, untouchedJSON{GoalJSON: api.FromGoal(g), DaysUntouched: store.DaysUntouched(g, now)})
+		}
+		return outputJSON(out)
 	}
-	return result
+
+	if len(o.Unqueued) == 0 && len(o.Stale) == 0 {
+		fmt.Println("No orphaned goals.")
+		return nil
+	}
+	if len(o.Unqueued) > 0 {
+		fmt.Println("Not in the queue:")
+		for _, g := range o.Unqueued {
+			fmt.Printf("  %s %s (%s)\n", statusIcon(g), g.Title, g.Path)
+		}
+	}
+	if len(o.Stale) > 0 {
+		if len(o.Unqueued) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("FUTURE, untouched for over %d days:\n", days)
+		for _, g := range o.Stale {
+			fmt.Printf("  %s %s (%s) — %d days\n", statusIcon(g), g.Title, g.Path, store.DaysUntouched(g, now))
+		}
+	}
+	return nil
+}
+
+func statusIcon(g *store.Goal) string {
+	switch {
+	case g.IsComplete():
+		return "✓"
+	case g.IsInProgress():
+		return "◐"
+	default:
+		return "○"
+	}
+}
+
+func cmdRollover(s store.Backend, jsonOut bool) error {
+	n, err := s.RolloverHorizons(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]int{"rolled_over": n})
+	}
+
+	fmt.Printf("Rolled over %d goal(s) from tomorrow to today\n", n)
+	return nil
+}
+
+// cmdCheck prints each goal's status and exits 0 only if all of them (or,
+// with --any, at least one) are complete. Missing goals exit with
+// exitCheckMissing regardless of mode; a goal that can't be read for any
+// other reason is an error, not a missing goal.
+func cmdCheck(s store.Backend, goalPaths []string, anyMode, jsonOut bool) error {
+	type result struct {
+		Path   string `json:"path"`
+		Status string `json:"status"`
+		Found  bool   `json:"found"`
+	}
+
+	var results []result
+	complete, missing := 0, 0
+	for _, p := range goalPaths {
+		g, err := s.LoadGoal(p)
+		if errors.Is(err, os.ErrNotExist) {
+			missing++
+			results = append(results, result{Path: p, Status: "missing"})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if g.IsComplete() {
+			complete++
+		}
+		results = append(results, result{Path: p, Status: string(g.Status), Found: true})
+	}
+
+	if jsonOut {
+		if err := outputJSON(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			mark := "○"
+			switch {
+			case !r.Found:
+				mark = "?"
+			case r.Status == string(store.StatusComplete):
+				mark = "✓"
+			}
+			fmt.Printf("%s %s %s\n", mark, r.Path, r.Status)
+		}
+	}
+
+	switch {
+	case missing > 0:
+		return &exitError{code: exitCheckMissing}
+	case anyMode && complete == 0:
+		return &exitError{code: exitCheckFailed}
+	case !anyMode && complete < len(goalPaths):
+		return &exitError{code: exitCheckFailed}
+	}
+	return nil
+}
+
+// cmdPath prints the absolute path of a goal's file, or with dirOnly its
+// directory, on one line for $(...). Paths that lead out of the goals
+// directory, like ../.., are refused rather than resolved.
+func cmdPath(s store.Backend, goalPath string, dirOnly, jsonOut bool) error {
+	if !filepath.IsLocal(goalPath) || filepath.Clean(goalPath) == "." {
+		return fmt.Errorf("invalid goal path %s: it must be inside goals/", goalPath)
+	}
+	g, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return err
+	}
+
+	path := g.FilePath
+	if dirOnly {
+		path = filepath.Dir(path)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]string{"path": path})
+	}
+
+	fmt.Println(path)
+	return nil
+}
+
+func cmdGet(s store.Backend, goalPath, field string, jsonOut bool) error {
+	g, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return err
+	}
+
+	value, err := g.Field(field)
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return outputJSON(map[string]string{field: value})
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func cmdSet(s store.Backend, goalPath, field, value string, propagate, jsonOut bool) error {
+	g, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return err
+	}
+
+	if field == "horizon" {
+		if _, err := store.ParseHorizon(value, s.Horizons()); err != nil {
+			return err
+		}
+	}
+	if err := g.SetField(field, value); err != nil {
+		return err
+	}
+	if err := s.SaveGoal(g); err != nil {
+		return err
+	}
+	s.Commit("set " + goalPath + " " + field)
+	if propagate && field == "status" {
+		if err := propagateStatus(s, goalPath); err != nil {
+			return err
+		}
+	}
+
+	if jsonOut {
+		return outputJSON(api.FromGoal(g))
+	}
+
+	fmt.Printf("%s: %s → %s\n", g.Title, field, value)
+	return nil
+}
+
+// propagateStatus updates ancestors of goalPath and, if its parent now has
+// only complete children, prints a hint to stderr (keeping --json output clean).
+func propagateStatus(s store.Backend, goalPath string) error {
+	parent, err := s.PropagateStatus(goalPath)
+	if err != nil {
+		return err
+	}
+	if parent != nil {
+		fmt.Fprintf(os.Stderr, "All children of %s are complete — mark it complete with: cairn complete %s\n", parent.Title, parent.Path)
+	}
+	return nil
+}
+
+func cmdSearch(s store.Backend, query string, opts store.SearchOptions, jsonOut, ndjson bool) error {
+	matches, err := s.SearchNotes(query, opts)
+	if err != nil {
+		return err
+	}
+
+	result := func(g *store.Goal) api.SearchResultJSON {
+		r := api.SearchResultJSON{GoalJSON: api.FromGoal(g)}
+		if opts.Windowed() {
+			r.Entries = store.MatchingEntries(g, query, opts)
+		} else {
+			match := store.MatchLocation(g, query)
+			r.MatchedIn, r.Snippet = match.In, match.Snippet
+		}
+		return r
+	}
+	if ndjson {
+		enc := json.NewEncoder(os.Stdout)
+		for _, g := range matches {
+			r := result(g)
+			r.Depth = depth(g)
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if jsonOut {
+		results := []api.SearchResultJSON{}
+		for _, g := range matches {
+			results = append(results, result(g))
+		}
+		return outputJSON(results)
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No matches found.")
+		return nil
+	}
+
+	color := useColor(os.Stdout)
+	for _, g := range matches {
+		if opts.Windowed() {
+			fmt.Printf("%s (%s)%s\n", g.Title, g.Path, summarySuffix(g))
+			for _, e := range store.MatchingEntries(g, query, opts) {
+				fmt.Printf("  %s  %s\n", e.Date, highlight(e.Text, query, color))
+			}
+			continue
+		}
+		match := store.MatchLocation(g, query)
+		switch match.In {
+		case store.MatchTitle:
+			fmt.Printf("%s (%s)%s\n", highlight(g.Title, query, color), g.Path, summarySuffix(g))
+		case store.MatchPath:
+			fmt.Printf("%s (%s)%s\n", g.Title, highlight(g.Path, query, color), summarySuffix(g))
+		default:
+			fmt.Printf("%s (%s)%s\n", g.Title, g.Path, summarySuffix(g))
+			fmt.Printf("  %s: %s\n", match.In, highlight(match.Snippet, query, color))
+		}
+	}
+	return nil
+}
+
+// useColor reports whether output to f may use ANSI colors: f is a
+// terminal and NO_COLOR isn't set.
+func useColor(f *os.File) bool {
+	return isTerminal(f) && os.Getenv("NO_COLOR") == ""
+}
+
+// highlight shows the first case-insensitive match of query in text in
+// bold yellow when color is on.
+func highlight(text, query string, color bool) string {
+	lower := strings.ToLower(text)
+	i := strings.Index(lower, strings.ToLower(query))
+	if !color || i < 0 || query == "" || len(lower) != len(text) {
+		return text
+	}
+	end := i + len(query)
+	return text[:i] + "\x1b[1;33m" + text[i:end] + "\x1b[0m" + text[end:]
+}
+
+// execCommands are the subcommands search --exec can run: those taking a
+// goal path as their first argument.
+var execCommands = []string{"status", "copy", "complete", "incomplete", "note", "delete", "horizon", "pin", "icon", "summary", "estimate", "path", "get", "set"}
+
+// cmdSearchExec runs a subcommand on every goal matching query, in
+// process, as "cairn <command> <goal-path> [args]" would, reporting each
+// failure and a summary on stderr. command is split into words by
+// splitCommand. With dryRun it only prints the commands.
+func cmdSearchExec(s *store.Store, cfg *config.Config, dataDir, query string, opts store.SearchOptions, command string, dryRun, jsonOut bool) error {
+	words, err := splitCommand(command)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 || !slices.Contains(execCommands, words[0]) {
+		return fmt.Errorf("--exec runs one of %s on each match", strings.Join(execCommands, ", "))
+	}
+	matches, err := s.SearchNotes(query, opts)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(os.Stderr, "No matches found.")
+		return nil
+	}
+
+	failed := 0
+	for _, g := range matches {
+		args := append([]string{words[0], g.Path}, words[1:]...)
+		if dryRun {
+			quoted := make([]string, len(args))
+			for i, a := range args {
+				quoted[i] = shellQuote(a)
+			}
+			fmt.Println("cairn " + strings.Join(quoted, " "))
+			continue
+		}
+		if err := runCommand(s, cfg, dataDir, args, jsonOut); err != nil {
+			failed++
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", g.Path, err)
+			}
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Ran %s on %d goal(s)", words[0], len(matches))
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, ", %d failed\n", failed)
+		return &exitError{code: 1}
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+// splitCommand splits an --exec command into words at spaces, keeping
+// text in single or double quotes together as a shell would, so
+// "summary 'two  words'" passes the summary as one argument. There are no
+// backslash escapes: put a quote of one kind inside the other.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("--exec: unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// shellQuote single-quotes arg for a shell when it needs it.
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]#~;&|<>(){}") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// cmdEvents prints the event log (see config event_log), one JSON object per
+// line. With --tail it prints the last few events and then follows the log.
+func cmdEvents(dataDir string, tail bool) error {
+	path := store.EventsPath(dataDir)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && tail {
+		// Wait for the first event
+		for os.IsNotExist(err) {
+			time.Sleep(eventPollInterval)
+			f, err = os.Open(path)
+		}
+	}
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "No events yet. Set event_log: true in config.yaml to record them.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	if !tail {
+		_, err := io.Copy(os.Stdout, reader)
+		return err
+	}
+
+	const tailLines = 10
+	var recent []string
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" && err == nil {
+			recent = append(recent, line)
+			if len(recent) > tailLines {
+				recent = recent[1:]
+			}
+		}
+		if err != nil {
+			// Keep a trailing partial line for the follow loop
+			for _, l := range recent {
+				fmt.Print(l)
+			}
+			return followEvents(reader, line)
+		}
+	}
+}
+
+// eventPollInterval is how often cairn events --tail checks for new events.
+const eventPollInterval = 500 * time.Millisecond
+
+// followEvents prints lines appended to the log until interrupted. partial
+// is an incomplete line already read.
+func followEvents(reader *bufio.Reader, partial string) error {
+	for {
+		line, err := reader.ReadString('\n')
+		partial += line
+		if err == nil {
+			fmt.Print(partial)
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		time.Sleep(eventPollInterval)
+	}
+}
+
+// JSON helpers
+
+func outputJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// outputNDJSON writes goals as one compact JSON object per line, each with
+// its depth in the tree, for tools that stream records.
+func outputNDJSON(goals []*store.Goal) error {
+	enc := json.NewEncoder(os.Stdout)
+	for _, g := range goals {
+		j := api.FromGoal(g)
+		j.Depth = depth(g)
+		if err := enc.Encode(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// depth is g's nesting level for api.GoalJSON.Depth: 0 for a top-level goal.
+func depth(g *store.Goal) *int {
+	d := strings.Count(g.Path, "/")
+	return &d
+}
+
+// statusJSON is cairn status --json: the goal and how many of its
+// children are done. Children is set with --recursive, [] when it has none.
+type statusJSON struct {
+	api.GoalJSON
+	ChildrenComplete int             `json:"children_complete"`
+	ChildrenTotal    int             `json:"children_total"`
+	Children         *[]api.GoalJSON `json:"children,omitempty"`
+}
+
+// todayJSON is cairn today --json.
+type todayJSON struct {
+	Today []api.GoalJSON `json:"today"`
+	Stale []staleJSON    `json:"stale"`
+}
+
+type staleJSON struct {
+	api.GoalJSON
+	DaysInToday int `json:"days_in_today"`
+}
+
+// waitingJSON is an entry of cairn waiting --json.
+type waitingJSON struct {
+	api.GoalJSON
+	Waiting     string `json:"waiting"`
+	DaysWaiting int    `json:"days_waiting"`
+}
+
+// orphansJSON is cairn orphans --json.
+type orphansJSON struct {
+	Unqueued []api.GoalJSON  `json:"unqueued"`
+	Stale    []untouchedJSON `json:"stale"`
+}
+
+type untouchedJSON struct {
+	api.GoalJSON
+	DaysUntouched int `json:"days_untouched"`
 }
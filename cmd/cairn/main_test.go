@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/config"
+	"github.com/stefanpenner/cairn/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// golden compares got with testdata/name, or rewrites it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// captureStdout runs fn and returns what it printed to stdout.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// capture runs fn with *file redirected and returns what fn wrote to it.
+func capture(t *testing.T, file **os.File, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	saved := *file
+	*file = w
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	ferr := fn()
+	w.Close()
+	*file = saved
+	return <-out, ferr
+}
+
+// exitCode returns the code err would exit with: 0 for nil, an exitError's
+// code, and 1 for any other error.
+func exitCode(err error) int {
+	var e *exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &e):
+		return e.code
+	}
+	return 1
+}
+
+func TestPorcelainFormat(t *testing.T) {
+	goals := []*store.Goal{
+		{Path: "otr", Title: "OTR", Status: store.StatusInProgress, Horizon: store.HorizonToday, Icon: "🚀", Pinned: true, Children: []*store.Goal{
+			{Path: "otr/ios", Title: "iOS app", Status: store.StatusComplete, Horizon: store.HorizonFuture},
+			{Path: "otr/infra", Title: "Infra\twith\ntabs", Tags: []string{"ops"}},
+		}},
+		{Path: "hiring", Title: "Hiring", Status: store.StatusIncomplete, Horizon: store.HorizonTomorrow, StatusLabel: "on hold"},
+	}
+
+	var all bytes.Buffer
+	require.NoError(t, writePorcelain(&all, goals, true))
+	golden(t, "porcelain.golden", all.Bytes())
+
+	var top bytes.Buffer
+	require.NoError(t, writePorcelain(&top, goals, false))
+	assert.Equal(t, "otr\tin-progress\ttoday\tOTR\nhiring\tincomplete\ttomorrow\tHiring\n", top.String())
+}
+
+func TestNDJSONFormat(t *testing.T) {
+	goals := []*store.Goal{
+		{Path: "otr", Title: "OTR", Status: store.StatusInProgress},
+		{Path: "otr/ios", Title: "iOS\napp", Status: store.StatusComplete},
+		{Path: "otr/ios/testflight", Title: "TestFlight"},
+	}
+
+	out, err := captureStdout(t, func() error { return outputNDJSON(goals) })
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 3, "one object per line, newlines in titles escaped")
+	for i, want := range []struct {
+		path  string
+		depth int
+	}{{"otr", 0}, {"otr/ios", 1}, {"otr/ios/testflight", 2}} {
+		var got struct {
+			Path  string `json:"path"`
+			Depth *int   `json:"depth"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &got), lines[i])
+		assert.Equal(t, want.path, got.Path)
+		require.NotNil(t, got.Depth, "depth is set even at the top level")
+		assert.Equal(t, want.depth, *got.Depth)
+	}
+}
+
+func TestListColumns(t *testing.T) {
+	cols, err := parseColumns("status, Age,tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "age", "tags"}, cols)
+	_, err = parseColumns("status,owner")
+	assert.ErrorContains(t, err, `unknown column "owner" (available: path, status, horizon`)
+	_, err = parseColumns("age,age")
+	assert.ErrorContains(t, err, "listed twice")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	goals := []*store.Goal{
+		{Path: "otr", Title: "OTR", Status: store.StatusInProgress, Created: now.AddDate(0, 0, -40), Updated: now.Add(-3 * time.Hour), Tags: []string{"q1"}, Children: []*store.Goal{
+			{Path: "otr/ios", Title: "iOS", Status: store.StatusIncomplete, Created: now.AddDate(0, 0, -2), Updated: now.AddDate(0, 0, -2)},
+		}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeColumns(&buf, goals, []string{"status", "age", "updated", "tags"}, now))
+	assert.Equal(t, ""+
+		"TITLE  STATUS       AGE  UPDATED  TAGS\n"+
+		"OTR    in-progress  5w   3h ago   q1\n"+
+		"  iOS  incomplete   2d   2d ago   \n", buf.String())
+
+	rows := columnRows(goals, []string{"age", "tags"}, now)
+	require.Len(t, rows, 2)
+	assert.Equal(t, map[string]interface{}{"path": "otr", "title": "OTR", "age": 40, "tags": []string{"q1"}}, rows[0])
+	assert.Equal(t, []string{}, rows[1]["tags"])
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "fix the \x1b[1;33mAuth\x1b[0m bug", highlight("fix the Auth bug", "auth", true))
+	assert.Equal(t, "fix the Auth bug", highlight("fix the Auth bug", "auth", false), "NO_COLOR or not a terminal")
+	assert.Equal(t, "fix the Auth bug", highlight("fix the Auth bug", "login", true))
+}
+
+func TestNoteEdit(t *testing.T) {
+	s := store.NewMemStore()
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	today := time.Now().Format("2006-01-02")
+	g.Body = "## 2020-01-01\n- older\n\n## " + today + "\n- pinged legl"
+	require.NoError(t, s.SaveGoal(g))
+
+	// The editor sees only today's lines
+	editor := filepath.Join(t.TempDir(), "editor")
+	require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\ngrep -qx -- '- pinged legl' \"$1\" && sed 's/legl/legal/' \"$1\" > \"$1.new\" && mv \"$1.new\" \"$1\"\n"), 0755))
+	t.Setenv("EDITOR", editor)
+	require.NoError(t, cmdNoteEdit(s, "otr", true))
+
+	g, err = s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "## 2020-01-01\n- older\n\n## "+today+"\n- pinged legal\n", g.Body)
+	assert.Equal(t, "note: otr", s.Commits[len(s.Commits)-1])
+}
+
+// unreadableStore fails to load one goal the way a permission error would.
+type unreadableStore struct {
+	store.Backend
+	path string
+}
+
+func (s unreadableStore) LoadGoal(goalPath string) (*store.Goal, error) {
+	if goalPath == s.path {
+		return nil, &os.PathError{Op: "open", Path: goalPath, Err: os.ErrPermission}
+	}
+	return s.Backend.LoadGoal(goalPath)
+}
+
+// brokenTreeStore fails to load the goal tree.
+type brokenTreeStore struct {
+	store.Backend
+}
+
+func (brokenTreeStore) LoadGoalTree() ([]*store.Goal, error) {
+	return nil, &os.PathError{Op: "open", Path: "goals", Err: os.ErrPermission}
+}
+
+func TestStatus(t *testing.T) {
+	s := store.NewMemStore()
+	for _, p := range [][2]string{{"", "otr"}, {"otr", "ios"}, {"otr", "infra"}, {"otr/infra", "k8s"}} {
+		_, err := s.CreateGoal(p[0], p[1])
+		require.NoError(t, err)
+	}
+	_, err := s.SetStatus("otr/ios", store.StatusComplete)
+	require.NoError(t, err)
+	status := func(recursive, jsonOut bool) string {
+		out, err := captureStdout(t, func() error { return cmdStatus(s, "otr", recursive, jsonOut, false) })
+		require.NoError(t, err)
+		return out
+	}
+
+	out := status(false, false)
+	assert.Contains(t, out, "otr: incomplete\n")
+	assert.Contains(t, out, "\nChildren (1/2 complete):\n")
+	assert.Contains(t, out, "✓ ios")
+	assert.Contains(t, out, "○ infra")
+	assert.NotContains(t, out, "k8s", "only direct children without --recursive")
+
+	assert.Contains(t, status(true, false), "    ○ k8s", "grandchildren are indented under their parent")
+
+	var flat struct {
+		Path             string            `json:"path"`
+		ChildrenComplete int               `json:"children_complete"`
+		ChildrenTotal    int               `json:"children_total"`
+		Children         []json.RawMessage `json:"children"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(status(false, true)), &flat))
+	assert.Equal(t, "otr", flat.Path)
+	assert.Equal(t, 1, flat.ChildrenComplete)
+	assert.Equal(t, 2, flat.ChildrenTotal)
+	assert.Nil(t, flat.Children, "children are listed with --recursive")
+
+	var tree struct {
+		Children []struct {
+			Path     string `json:"path"`
+			Children []struct {
+				Path string `json:"path"`
+			} `json:"children"`
+		} `json:"children"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(status(true, true)), &tree))
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, "otr/infra", tree.Children[0].Path)
+	assert.Equal(t, "otr/ios", tree.Children[1].Path)
+	require.Len(t, tree.Children[0].Children, 1)
+	assert.Equal(t, "otr/infra/k8s", tree.Children[0].Children[0].Path)
+
+	out, err = captureStdout(t, func() error { return cmdStatus(brokenTreeStore{s}, "otr", false, false, false) })
+	assert.ErrorIs(t, err, os.ErrPermission, "an unreadable tree isn't a goal without children")
+	assert.Empty(t, out)
+}
+
+func TestSetHorizonUsesStoreHorizons(t *testing.T) {
+	s := store.NewMemStore()
+	s.SetHorizons([]store.Horizon{"today", "someday"})
+	_, err := s.CreateGoal("", "test")
+	require.NoError(t, err)
+
+	_, err = captureStdout(t, func() error { return cmdSet(s, "test", "horizon", "tomorrow", false, false) })
+	assert.EqualError(t, err, "invalid horizon: tomorrow (use today or someday)")
+
+	out, err := captureStdout(t, func() error { return cmdSet(s, "test", "horizon", "today", false, false) })
+	require.NoError(t, err)
+	assert.Equal(t, "test: horizon → today\n", out)
+}
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	goalDir := filepath.Join(dir, "goals", "otr", "ios")
+
+	out, err := captureStdout(t, func() error { return cmdPath(s, "otr/ios", false, false) })
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(goalDir, "goal.md")+"\n", out, "one line, for $(cairn path ...)")
+
+	out, err = captureStdout(t, func() error { return cmdPath(s, "otr/ios", true, false) })
+	require.NoError(t, err)
+	assert.Equal(t, goalDir+"\n", out)
+
+	_, err = captureStdout(t, func() error { return cmdPath(s, "otr/android", false, false) })
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, escape := range []string{"../..", "otr/../../..", "/etc", "otr/.."} {
+		out, err = captureStdout(t, func() error { return cmdPath(s, escape, true, false) })
+		assert.ErrorContains(t, err, "must be inside goals/", escape)
+		assert.Empty(t, out)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := store.NewMemStore()
+	for _, slug := range []string{"done", "open"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	_, err := s.SetStatus("done", store.StatusComplete)
+	require.NoError(t, err)
+
+	check := func(paths []string, anyMode, jsonOut bool) (string, int) {
+		out, err := captureStdout(t, func() error { return cmdCheck(s, paths, anyMode, jsonOut) })
+		return out, exitCode(err)
+	}
+
+	out, code := check([]string{"done"}, false, false)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "✓ done complete\n", out)
+
+	out, code = check([]string{"done", "open"}, false, false)
+	assert.Equal(t, exitCheckFailed, code, "all must be complete")
+	assert.Equal(t, "✓ done complete\n○ open incomplete\n", out)
+
+	_, code = check([]string{"done", "open"}, true, false)
+	assert.Equal(t, 0, code, "--any: one is enough")
+	_, code = check([]string{"open"}, true, false)
+	assert.Equal(t, exitCheckFailed, code)
+
+	out, code = check([]string{"done", "nope"}, true, false)
+	assert.Equal(t, exitCheckMissing, code, "missing goals win over --any")
+	assert.Contains(t, out, "? nope missing")
+
+	out, code = check([]string{"done", "nope"}, false, true)
+	assert.Equal(t, exitCheckMissing, code)
+	var results []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &results))
+	assert.Equal(t, []map[string]interface{}{
+		{"path": "done", "status": "complete", "found": true},
+		{"path": "nope", "status": "missing", "found": false},
+	}, results)
+
+	// A goal that can't be read isn't reported as missing
+	err = cmdCheck(unreadableStore{Backend: s, path: "open"}, []string{"open"}, false, false)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrPermission)
+	var exit *exitError
+	assert.False(t, errors.As(err, &exit))
+}
+
+func TestSplitCommand(t *testing.T) {
+	words, err := splitCommand(`set title "Q1  launch" 'it''s'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"set", "title", "Q1  launch", "its"}, words)
+	words, err = splitCommand(`note "she said 'ship it'"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"note", "she said 'ship it'"}, words)
+	_, err = splitCommand(`note "unterminated`)
+	assert.ErrorContains(t, err, "unterminated")
+}
+
+func TestSearchExec(t *testing.T) {
+	s, err := store.NewStore(t.TempDir())
+	require.NoError(t, err)
+	for _, slug := range []string{"q1-api", "q1-ui", "hiring"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	cfg := config.Default()
+	exec := func(command string, dryRun bool) (stdout, stderr string, err error) {
+		stderr, _ = capture(t, &os.Stderr, func() error {
+			stdout, err = captureStdout(t, func() error {
+				return cmdSearchExec(s, cfg, s.Root, "q1", store.SearchOptions{}, command, dryRun, false)
+			})
+			return nil
+		})
+		return stdout, stderr, err
+	}
+
+	out, _, err := exec(`set title "Q1  work"`, true)
+	require.NoError(t, err)
+	assert.Equal(t, "cairn set q1-api title 'Q1  work'\ncairn set q1-ui title 'Q1  work'\n", out)
+	g, err := s.LoadGoal("q1-api")
+	require.NoError(t, err)
+	assert.Equal(t, "q1-api", g.Title, "a dry run changes nothing")
+
+	_, errOut, err := exec(`set title "Q1  work"`, false)
+	require.NoError(t, err)
+	assert.Contains(t, errOut, "Ran set on 2 goal(s)")
+	g, err = s.LoadGoal("q1-ui")
+	require.NoError(t, err)
+	assert.Equal(t, "Q1  work", g.Title, "quoted arguments keep their spaces")
+
+	_, _, err = exec("list", false)
+	assert.ErrorContains(t, err, "--exec runs one of")
+
+	_, errOut, err = exec("horizon someday", false)
+	assert.Equal(t, 1, exitCode(err))
+	var exit *exitError
+	assert.True(t, errors.As(err, &exit))
+	assert.Contains(t, errOut, "q1-api: invalid horizon: someday")
+	assert.Contains(t, errOut, "Ran horizon on 2 goal(s), 2 failed")
+}
+
+func TestAskAdd(t *testing.T) {
+	paths := []string{"otr", "otr/ios", "otr/infra", "hiring"}
+	ask := func(input string) (addAnswers, string, error) {
+		var out bytes.Buffer
+		p := newPrompter(strings.NewReader(input), &out)
+		defer p.close()
+		a, err := askAdd(p, paths, store.DefaultHorizons, store.HorizonFuture, []string{"q1"})
+		return a, out.String(), err
+	}
+
+	a, out, err := ask("TestFlight build\notr/io\ntoday\nmobile,ios\nupload from CI\n")
+	require.NoError(t, err)
+	assert.Equal(t, addAnswers{title: "TestFlight build", parent: "otr/ios", horizon: store.HorizonToday, tags: "mobile,ios", note: "upload from CI"}, a)
+	assert.Contains(t, out, "  → otr/ios", "a unique prefix completes")
+	assert.Contains(t, out, "Tags, comma-separated [q1]: ")
+
+	a, out, err = ask("Runners\notr/i\notr/inf\n")
+	require.NoError(t, err)
+	assert.Equal(t, "otr/infra", a.parent)
+	assert.Contains(t, out, "otr/i could be: otr/ios, otr/infra", "an ambiguous prefix asks again")
+
+	a, out, err = ask("Runners\n\nsomeday\ntomorrow\n")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonTomorrow, a.horizon)
+	assert.Contains(t, out, "invalid horizon: someday", "an invalid horizon asks again")
+
+	a, _, err = ask("Runners\n")
+	require.NoError(t, err, "running out of input takes the defaults")
+	assert.Equal(t, addAnswers{title: "Runners", horizon: store.HorizonFuture}, a)
+
+	_, _, err = ask("")
+	assert.ErrorContains(t, err, "no title given")
+
+	a, out, err = ask("CI/CD\nCI and CD\n")
+	require.NoError(t, err)
+	assert.Equal(t, "CI and CD", a.title)
+	assert.Contains(t, out, "A title can't contain /.")
+}
+
+func TestCompletePath(t *testing.T) {
+	paths := []string{"otr", "otr/ios", "otrx"}
+	assert.Equal(t, []string{"otr"}, completePath(paths, "otr"), "an exact path wins over longer ones")
+	assert.Equal(t, []string{"otr/ios"}, completePath(paths, "otr/"))
+	assert.Nil(t, completePath(paths, "hiring"))
+}
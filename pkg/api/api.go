@@ -0,0 +1,123 @@
+// Package api defines the JSON cairn writes for --json, so integrations
+// have one set of field names and formats to rely on rather than whatever
+// each command happened to build. Changes to these types are changes to
+// the schema: add fields freely, but bump SchemaVersion before renaming or
+// removing one.
+package api
+
+import (
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// SchemaVersion is the version of the JSON described here. Every object
+// carries it as "schema_version".
+const SchemaVersion = 1
+
+// GoalJSON is a goal as --json output shows it.
+type GoalJSON struct {
+	SchemaVersion int               `json:"schema_version"`
+	Title         string            `json:"title"`
+	Summary       string            `json:"summary,omitempty"`
+	Status        string            `json:"status"`
+	StatusLabel   string            `json:"status_label,omitempty"`
+	Path          string            `json:"path"`
+	Horizon       string            `json:"horizon"`
+	Pinned        bool              `json:"pinned"`
+	Tags          []string          `json:"tags"`
+	Links         map[string]string `json:"links"`
+	Icon          string            `json:"icon,omitempty"`
+	Color         string            `json:"color,omitempty"`
+	Estimate      string            `json:"estimate,omitempty"`
+	Created       string            `json:"created,omitempty"` // see FormatTime
+	Updated       string            `json:"updated,omitempty"`
+	Completed     string            `json:"completed,omitempty"`   // when status last became complete
+	HorizonSet    string            `json:"horizon_set,omitempty"` // when the horizon was last changed
+	Body          string            `json:"body"`
+	// Depth is how deeply the goal is nested, set where a tree is
+	// flattened into lines (--ndjson).
+	Depth *int `json:"depth,omitempty"`
+	// Children are the sub-goals, for output of a tree.
+	Children []GoalJSON `json:"children,omitempty"`
+}
+
+// QueueJSON is the queue: goal paths in order.
+type QueueJSON struct {
+	SchemaVersion int      `json:"schema_version"`
+	Updated       string   `json:"updated,omitempty"` // see FormatTime
+	Items         []string `json:"items"`
+}
+
+// SearchResultJSON is a goal that matched a search and where it matched.
+// A search within dates lists the matching note entries instead.
+type SearchResultJSON struct {
+	GoalJSON
+	MatchedIn string            `json:"matched_in,omitempty"` // store.MatchTitle and the like
+	Snippet   string            `json:"snippet,omitempty"`
+	Entries   []store.NoteEntry `json:"entries,omitempty"`
+}
+
+// FormatTime writes t as UTC RFC 3339 to the second, e.g.
+// "2026-03-01T12:00:00Z", or "" for the zero time.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FromGoal converts g, without its sub-goals. Tags and links are [] and
+// {} rather than null when g has none.
+func FromGoal(g *store.Goal) GoalJSON {
+	tags, links := g.Tags, g.Links
+	if tags == nil {
+		tags = []string{}
+	}
+	if links == nil {
+		links = map[string]string{}
+	}
+	return GoalJSON{
+		SchemaVersion: SchemaVersion,
+		Title:         g.Title,
+		Summary:       g.Summary,
+		Status:        string(g.Status),
+		StatusLabel:   g.StatusLabel,
+		Path:          g.Path,
+		Horizon:       string(g.Horizon),
+		Pinned:        g.Pinned,
+		Tags:          tags,
+		Links:         links,
+		Icon:          g.Icon,
+		Color:         g.Color,
+		Estimate:      g.Estimate,
+		Created:       FormatTime(g.Created),
+		Updated:       FormatTime(g.Updated),
+		Completed:     FormatTime(g.Completed),
+		HorizonSet:    FormatTime(g.HorizonSet),
+		Body:          g.Body,
+	}
+}
+
+// FromTree converts goals and their sub-goals. It never returns nil, so
+// an empty tree is [] rather than null.
+func FromTree(goals []*store.Goal) []GoalJSON {
+	out := make([]GoalJSON, 0, len(goals))
+	for _, g := range goals {
+		j := FromGoal(g)
+		if len(g.Children) > 0 {
+			j.Children = FromTree(g.Children)
+		}
+		out = append(out, j)
+	}
+	return out
+}
+
+// FromQueue converts q.
+func FromQueue(q *store.Queue) QueueJSON {
+	items := q.Items
+	if items == nil {
+		items = []string{}
+	}
+	return QueueJSON{SchemaVersion: SchemaVersion, Updated: FormatTime(q.Updated), Items: items}
+}
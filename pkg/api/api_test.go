@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// TestGoalJSON pins the schema: a change here is a change integrations see.
+func TestGoalJSON(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	goal := &store.Goal{
+		Path:        "otr",
+		Title:       "OTR",
+		Summary:     "Off-the-record messaging",
+		Status:      store.StatusInProgress,
+		StatusLabel: "in review",
+		Horizon:     store.HorizonToday,
+		Pinned:      true,
+		Icon:        "🚀",
+		Color:       "#E05252",
+		Estimate:    "3d",
+		Created:     time.Date(2026, 3, 1, 7, 0, 0, 0, est),
+		Updated:     time.Date(2026, 3, 2, 9, 30, 15, 0, time.UTC),
+		HorizonSet:  time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+		Tags:        []string{"q1", "mobile"},
+		Links:       map[string]string{"pr": "https://example.com/pr/1"},
+		Body:        "## 2026-03-02\n- shipped beta\n",
+		Children: []*store.Goal{
+			{Path: "otr/ios", Title: "iOS app", Status: store.StatusComplete, Horizon: store.HorizonFuture, Completed: time.Date(2026, 3, 1, 18, 45, 0, 0, est)},
+		},
+	}
+
+	got, err := json.MarshalIndent(FromTree([]*store.Goal{goal}), "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "goal.json")
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestFromTreeEmpty(t *testing.T) {
+	got, err := json.Marshal(FromTree(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(got))
+
+	got, err = json.Marshal(FromQueue(&store.Queue{}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"schema_version":1,"items":[]}`, string(got))
+}
@@ -0,0 +1,68 @@
+// Package clipboard puts text on the system clipboard.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// OSC52 returns the escape sequence that asks the terminal to put text on
+// the clipboard. It reaches the local clipboard over SSH too.
+func OSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// Command returns the clipboard command for goos that lookPath finds:
+// pbcopy on macOS, clip on Windows and wl-copy, xclip or xsel elsewhere.
+// It's nil when none is installed.
+func Command(goos string, lookPath func(string) (string, error)) *exec.Cmd {
+	var candidates [][]string
+	switch goos {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+	for _, argv := range candidates {
+		if path, err := lookPath(argv[0]); err == nil {
+			return exec.Command(path, argv[1:]...)
+		}
+	}
+	return nil
+}
+
+// Copy puts text on the clipboard. It's sent to tty with OSC 52 when tty
+// is non-nil and, because terminals without OSC 52 support ignore it
+// silently, also piped to the platform's clipboard command when one is
+// installed. It fails only when neither was possible.
+func Copy(tty io.Writer, text string) error {
+	sent := false
+	if tty != nil {
+		_, err := io.WriteString(tty, OSC52(text))
+		sent = err == nil
+	}
+
+	cmd := Command(runtime.GOOS, exec.LookPath)
+	if cmd == nil {
+		if !sent {
+			return errors.New("no clipboard: not a terminal and no clipboard command (pbcopy, wl-copy, xclip, xsel) installed")
+		}
+		return nil
+	}
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil && !sent {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSC52(t *testing.T) {
+	assert.Equal(t, "\x1b]52;c;aGk=\a", OSC52("hi"))
+}
+
+func TestCommand(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	cmd := Command("darwin", installed("pbcopy"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, []string{"/usr/bin/pbcopy"}, cmd.Args)
+
+	cmd = Command("linux", installed("xsel", "xclip"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, []string{"/usr/bin/xclip", "-selection", "clipboard"}, cmd.Args, "xclip is preferred over xsel")
+
+	assert.Nil(t, Command("linux", installed()))
+}
@@ -0,0 +1,475 @@
+// Package config loads cairn's user settings.
+//
+// Settings are layered, lowest precedence first:
+//
+//  1. built-in defaults
+//  2. config.yaml in the data directory
+//  3. CAIRN_* environment variables
+//  4. command-line flags (applied by the caller with Set)
+//
+// Every key can be overridden from the environment: the variable name is
+// CAIRN_ followed by the upper-cased key, e.g. default_horizon is
+// CAIRN_DEFAULT_HORIZON and read_only is CAIRN_READ_ONLY.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the config file looked up in the data directory.
+const FileName = "config.yaml"
+
+// EnvPrefix prefixes every environment override.
+const EnvPrefix = "CAIRN_"
+
+// Config holds user settings. The yaml tag of each field is its key.
+type Config struct {
+	// DefaultHorizon is the horizon given to newly created goals.
+	DefaultHorizon string `yaml:"default_horizon"`
+	// Horizons are the horizon sections, nearest first: up to nine names
+	// of lowercase letters, digits and dashes, set with the 1–9 keys, e.g.
+	// [today, this-week, next-week, someday]. today and tomorrow keep
+	// their special meaning (stale TODAY goals, rollover) when listed.
+	// Goals with a horizon not listed are shown under OTHER.
+	Horizons []string `yaml:"horizons"`
+	// ReadOnly opens the data directory without ever writing to it.
+	ReadOnly bool `yaml:"read_only"`
+	// DirMode is the octal permission for directories cairn creates, e.g. "0700".
+	DirMode string `yaml:"dir_mode"`
+	// PropagateStatus marks ancestors in-progress when a child starts and
+	// offers to complete a parent once its last child is complete.
+	PropagateStatus bool `yaml:"propagate_status"`
+	// Layout is how goal files are named: "cairn" (goal.md) or "obsidian"
+	// (<slug>.md, a folder note per goal). Files in either layout are read,
+	// and cairn normalize converts them to this one.
+	Layout string `yaml:"layout"`
+	// FieldAliases renames frontmatter fields in goal files, e.g.
+	// status: cairn-status, so they don't clash with other tools' fields.
+	FieldAliases map[string]string `yaml:"field_aliases"`
+	// CollapseOnComplete collapses a parent in the TUI when it's marked
+	// complete: "on" just collapses it, "ask" also offers to complete its
+	// open sub-goals, "off" leaves it expanded.
+	CollapseOnComplete string `yaml:"collapse_on_complete"`
+	// DraftTags keep tagged goals out of commits and syncs until the tag is
+	// removed, so half-written notes aren't published.
+	DraftTags []string `yaml:"draft_tags"`
+	// StaleTodayDays flags goals that have been in TODAY for more than this
+	// many days. Zero disables the warning.
+	StaleTodayDays int `yaml:"stale_today_days"`
+	// ExpandAllConfirm asks before expand-all (C) in the TUI when it would
+	// show more than this many more goals. Zero never asks.
+	ExpandAllConfirm int `yaml:"expand_all_confirm"`
+	// ShowEstimates shows each goal's remaining estimate in the TUI tree.
+	ShowEstimates bool `yaml:"show_estimates"`
+	// HorizonRollup lists sub-goals due sooner than their top-level goal
+	// in TODAY or TOMORROW, under their parents' titles, instead of only
+	// grouping top-level goals by horizon.
+	HorizonRollup bool `yaml:"horizon_rollup"`
+	// PlainNotes shows note bodies as preformatted text instead of rendered
+	// markdown, for logs, tables and code that markdown reflows badly.
+	PlainNotes bool `yaml:"plain_notes"`
+	// NoteFormat shapes each note cairn note and the TUI add under the
+	// day's date header: {text} is the note and {time} the time it was
+	// added, e.g. "{time} {text}" gives "- 14:32 text". NoteSection files
+	// notes under a "### <section>" heading in the day, e.g. "Log". Goals
+	// override both with note_format and note_section in their frontmatter.
+	// cairn note --time puts {time} first for one note.
+	NoteFormat  string `yaml:"note_format"`
+	NoteSection string `yaml:"note_section"`
+	// EnrichGitHub looks up GitHub issue and pull request links in the TUI
+	// notes pane and shows whether they're open, merged or closed.
+	// GITHUB_TOKEN is used when set; lookups that fail show the plain link.
+	EnrichGitHub bool `yaml:"enrich_github"`
+	// AgeIdentity is the age identity file that decrypts goal notes when the
+	// data directory has a .age-recipients file and notes are encrypted.
+	AgeIdentity string `yaml:"age_identity"`
+	// SyncBranch is the git branch sync keeps in step with the same branch
+	// on origin, for data kept on a dedicated branch of a shared repo.
+	// cairn init --branch creates it. Empty syncs whatever is checked out
+	// with its upstream.
+	SyncBranch string `yaml:"sync_branch"`
+	// SyncPull is how sync brings in remote changes, one of SyncPullModes:
+	// "rebase" (falling back to a merge), "merge" or "ff-only".
+	SyncPull string `yaml:"sync_pull"`
+	// TrashRetention is how long deleted goals stay in the trash (see
+	// cairn trash) before they're purged, which happens on startup. Zero
+	// keeps them until the trash is emptied.
+	TrashRetention time.Duration `yaml:"trash_retention"`
+	// AutoQueue appends new top-level goals to queue.md, and has the TUI
+	// offer to remove goals from it when they're completed.
+	AutoQueue bool `yaml:"auto_queue"`
+	// EventLog records create/complete/move/delete/note events in
+	// .cairn/events.jsonl for other tools to consume.
+	EventLog bool `yaml:"event_log"`
+	// SessionLog appends each change made in the TUI, one timestamped
+	// line per action, to .cairn/sessions.log.
+	SessionLog bool `yaml:"session_log"`
+	// Hooks runs executables in .cairn/hooks/ (on-create, on-complete,
+	// on-note, on-move, on-delete) after the matching change. That directory
+	// is never committed or synced. Set false to never run them.
+	Hooks bool `yaml:"hooks"`
+	// PomodoroWork and PomodoroBreak are the lengths of a pomodoro (P in
+	// the TUI) and the break that follows it.
+	PomodoroWork  time.Duration `yaml:"pomodoro_work"`
+	PomodoroBreak time.Duration `yaml:"pomodoro_break"`
+	// HeaderCounters are the windows the TUI header counts completed and
+	// created goals over, from HeaderCounterWindows: "today", "week" (the
+	// last 7 days) and "month" (the last 30). Empty hides the counters.
+	HeaderCounters []string `yaml:"header_counters"`
+	// PanelLayout arranges the TUI's tree and notes panes: "side-by-side"
+	// or "stacked" (tree above notes, for tall narrow terminals). | toggles
+	// it, and the choice is remembered between sessions.
+	PanelLayout string `yaml:"panel_layout"`
+	// Theme is the TUI color preset, one of Themes.
+	Theme string `yaml:"theme"`
+	// TerminalTitle has the TUI set the terminal's window title to today's
+	// progress, e.g. "cairn · 3/12 today". Off by default since some
+	// terminals and multiplexers don't take title changes well.
+	TerminalTitle bool `yaml:"terminal_title"`
+	// Watch is how the TUI notices edits made outside it: "auto" uses
+	// filesystem events and falls back to polling where they're unavailable,
+	// "poll" always polls (for NFS and some containers), "off" disables it.
+	// --no-watch and CAIRN_NO_WATCH=1 turn it off for one run.
+	Watch string `yaml:"watch"`
+	// WatchDebounce is how long the TUI waits after the last filesystem
+	// event before reloading.
+	WatchDebounce time.Duration `yaml:"watch_debounce"`
+	// WatchPollInterval is how often the data directory is scanned when polling.
+	WatchPollInterval time.Duration `yaml:"watch_poll_interval"`
+	// Defaults fill in the frontmatter and body of new goals.
+	Defaults GoalDefaults `yaml:"defaults"`
+}
+
+// GoalDefaults are the defaults section: what new goals start with. Their
+// keys are prefixed with "defaults.", e.g. defaults.horizon, which
+// CAIRN_DEFAULTS_HORIZON overrides.
+type GoalDefaults struct {
+	// Horizon overrides default_horizon, e.g. "today" during a crunch.
+	Horizon string `yaml:"horizon"`
+	// Tags are given to every new goal.
+	Tags []string `yaml:"tags"`
+	// Body is a text/template for the goal's notes; {{title}} is its title
+	// and {{date}} today's date. A template that fails leaves the notes
+	// empty, with a warning.
+	Body string `yaml:"body"`
+}
+
+// Themes are the accepted values of theme. Besides color, colorblind and
+// high-contrast vary status glyphs and weight so status never depends on hue
+// alone.
+var Themes = []string{"default", "colorblind", "high-contrast"}
+
+// SyncPullModes are the accepted values of sync_pull.
+var SyncPullModes = []string{"rebase", "merge", "ff-only"}
+
+// horizonName is the form of a name in horizons.
+var horizonName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// reservedHorizons can't be horizons: the TUI's PINNED and OTHER sections
+// have those names.
+var reservedHorizons = []string{"pinned", "other"}
+
+// HeaderCounterWindows are the accepted values of header_counters.
+var HeaderCounterWindows = []string{"today", "week", "month"}
+
+// Default returns the built-in settings.
+func Default() *Config {
+	return &Config{
+		DefaultHorizon: "future",
+		Horizons:       []string{"today", "tomorrow", "future"},
+		DraftTags:      slices.Clone(store.DefaultDraftTags),
+		StaleTodayDays: 3,
+		Hooks:          true,
+		Theme:          "default",
+		Layout:         "cairn",
+		PanelLayout:    "side-by-side",
+		HeaderCounters: []string{"today", "week"},
+		TrashRetention: 30 * 24 * time.Hour,
+		SyncPull:       "rebase",
+
+		CollapseOnComplete: "off",
+		ExpandAllConfirm:   500,
+
+		PomodoroWork:  25 * time.Minute,
+		PomodoroBreak: 5 * time.Minute,
+
+		Watch:             "auto",
+		WatchDebounce:     200 * time.Millisecond,
+		WatchPollInterval: 2 * time.Second,
+	}
+}
+
+// Load reads config.yaml from dataDir (if present) over the defaults and
+// then applies environment overrides.
+func Load(dataDir string) (*Config, error) {
+	c := Default()
+	path := filepath.Join(dataDir, FileName)
+	if err := c.loadFile(path); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := c.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// applyEnv overrides every key that has a matching environment variable.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		name := EnvName(key)
+		if value, ok := lookup(name); ok {
+			if err := c.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Keys returns every config key in declaration order. Keys in a section
+// are the section's key, a dot and theirs, e.g. defaults.horizon.
+func Keys() []string {
+	return structKeys(reflect.TypeOf(Config{}), "")
+}
+
+func structKeys(t reflect.Type, prefix string) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key := prefix + yamlKey(t.Field(i))
+		if t.Field(i).Type.Kind() == reflect.Struct {
+			keys = append(keys, structKeys(t.Field(i).Type, key+".")...)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Set parses value into the field for key. Flags use it to apply the
+// highest-precedence layer.
+func (c *Config) Set(key, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	name := key
+	for {
+		section, rest, ok := strings.Cut(name, ".")
+		field, found := fieldByKey(v, section)
+		if !found || ok != (field.Kind() == reflect.Struct) {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		if !ok {
+			return setValue(field, value)
+		}
+		v, name = field, rest
+	}
+}
+
+// fieldByKey returns the field of struct v whose yaml key is key.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if yamlKey(v.Type().Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Slice:
+		items := []string{} // an empty value clears the list rather than unsetting it
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		pairs := map[string]string{} // key=value,key=value
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid mapping %q (use key=value, comma separated)", item)
+			}
+			pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		field.Set(reflect.ValueOf(pairs))
+	default:
+		return fmt.Errorf("unsupported config type %s", field.Type())
+	}
+	return nil
+}
+
+func yamlKey(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+// Validate checks values that have a fixed set of valid forms.
+func (c *Config) Validate() error {
+	if len(c.Horizons) == 0 || len(c.Horizons) > 9 {
+		return fmt.Errorf("invalid horizons: list one to nine")
+	}
+	for i, h := range c.Horizons {
+		if !horizonName.MatchString(h) {
+			return fmt.Errorf("invalid horizon %q: use lowercase letters, digits and dashes", h)
+		}
+		if slices.Contains(reservedHorizons, h) {
+			return fmt.Errorf("invalid horizon %q: it names a section of its own", h)
+		}
+		if slices.Contains(c.Horizons[:i], h) {
+			return fmt.Errorf("horizon %q is listed twice", h)
+		}
+	}
+	if !slices.Contains(c.Horizons, c.DefaultHorizon) {
+		return fmt.Errorf("invalid default_horizon %q (use %s)", c.DefaultHorizon, strings.Join(c.Horizons, ", "))
+	}
+	if c.Defaults.Horizon != "" && !slices.Contains(c.Horizons, c.Defaults.Horizon) {
+		return fmt.Errorf("invalid defaults.horizon %q (use %s)", c.Defaults.Horizon, strings.Join(c.Horizons, ", "))
+	}
+	if _, err := c.DirPerm(); err != nil {
+		return err
+	}
+	if c.StaleTodayDays < 0 {
+		return fmt.Errorf("invalid stale_today_days %d: must be zero or more", c.StaleTodayDays)
+	}
+	if c.ExpandAllConfirm < 0 {
+		return fmt.Errorf("invalid expand_all_confirm %d: must be zero or more", c.ExpandAllConfirm)
+	}
+	switch c.Layout {
+	case "cairn", "obsidian":
+	default:
+		return fmt.Errorf("invalid layout %q (use cairn or obsidian)", c.Layout)
+	}
+	switch c.CollapseOnComplete {
+	case "off", "on", "ask":
+	default:
+		return fmt.Errorf("invalid collapse_on_complete %q (use off, on, or ask)", c.CollapseOnComplete)
+	}
+	for _, w := range c.HeaderCounters {
+		if !slices.Contains(HeaderCounterWindows, w) {
+			return fmt.Errorf("invalid header_counters window %q (use %s)", w, strings.Join(HeaderCounterWindows, ", "))
+		}
+	}
+	if c.NoteFormat != "" && !strings.Contains(c.NoteFormat, "{text}") {
+		return fmt.Errorf("invalid note_format %q: it must include {text}", c.NoteFormat)
+	}
+	switch c.PanelLayout {
+	case "side-by-side", "stacked":
+	default:
+		return fmt.Errorf("invalid panel_layout %q (use side-by-side or stacked)", c.PanelLayout)
+	}
+	switch c.Watch {
+	case "auto", "poll", "off":
+	default:
+		return fmt.Errorf("invalid watch %q (use auto, poll, or off)", c.Watch)
+	}
+	if c.WatchDebounce < 0 {
+		return fmt.Errorf("invalid watch_debounce %s: must be zero or more", c.WatchDebounce)
+	}
+	if c.WatchPollInterval <= 0 {
+		return fmt.Errorf("invalid watch_poll_interval %s: must be positive", c.WatchPollInterval)
+	}
+	if !slices.Contains(SyncPullModes, c.SyncPull) {
+		return fmt.Errorf("invalid sync_pull %q (use %s)", c.SyncPull, strings.Join(SyncPullModes, ", "))
+	}
+	if strings.ContainsAny(c.SyncBranch, " ~^:?*[\\") || strings.HasPrefix(c.SyncBranch, "-") {
+		return fmt.Errorf("invalid sync_branch %q: not a git branch name", c.SyncBranch)
+	}
+	if c.TrashRetention < 0 {
+		return fmt.Errorf("invalid trash_retention %s: must be zero or more", c.TrashRetention)
+	}
+	if c.PomodoroWork < time.Minute {
+		return fmt.Errorf("invalid pomodoro_work %s: must be at least 1m", c.PomodoroWork)
+	}
+	if c.PomodoroBreak < 0 {
+		return fmt.Errorf("invalid pomodoro_break %s: must be zero or more", c.PomodoroBreak)
+	}
+	if !slices.Contains(Themes, c.Theme) {
+		return fmt.Errorf("invalid theme %q (use %s)", c.Theme, strings.Join(Themes, ", "))
+	}
+	return nil
+}
+
+// NewGoalHorizon is the horizon new goals get: defaults.horizon when set,
+// otherwise default_horizon.
+func (c *Config) NewGoalHorizon() string {
+	if c.Defaults.Horizon != "" {
+		return c.Defaults.Horizon
+	}
+	return c.DefaultHorizon
+}
+
+// DirPerm parses DirMode. Zero means the store's default.
+func (c *Config) DirPerm() (os.FileMode, error) {
+	if c.DirMode == "" {
+		return 0, nil
+	}
+	perm, err := strconv.ParseUint(c.DirMode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid dir_mode %q: expected an octal permission like 0700", c.DirMode)
+	}
+	return os.FileMode(perm), nil
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0644))
+}
+
+func TestLoadDefaultsWithoutFile(t *testing.T) {
+	c, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, Default(), c)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "default_horizon: tomorrow\ndir_mode: \"0750\"\n")
+
+	c, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "tomorrow", c.DefaultHorizon, "file overrides defaults")
+	assert.Equal(t, "0750", c.DirMode)
+
+	t.Setenv("CAIRN_DEFAULT_HORIZON", "today")
+	c, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "today", c.DefaultHorizon, "env overrides the file")
+	assert.Equal(t, "0750", c.DirMode, "keys without an env var keep the file value")
+
+	require.NoError(t, c.Set("default_horizon", "future"))
+	assert.Equal(t, "future", c.DefaultHorizon, "flags override env")
+}
+
+func TestEnvNames(t *testing.T) {
+	assert.Equal(t, "CAIRN_DEFAULT_HORIZON", EnvName("default_horizon"))
+	for _, key := range Keys() {
+		assert.NotEmpty(t, key)
+	}
+	assert.Contains(t, Keys(), "read_only")
+}
+
+func TestEnvBool(t *testing.T) {
+	t.Setenv("CAIRN_READ_ONLY", "true")
+	c, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, c.ReadOnly)
+
+	t.Setenv("CAIRN_READ_ONLY", "maybe")
+	_, err = Load(t.TempDir())
+	assert.ErrorContains(t, err, "CAIRN_READ_ONLY")
+}
+
+func TestFieldAliases(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "layout: obsidian\nfield_aliases:\n  status: cairn-status\n")
+	c, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "obsidian", c.Layout)
+	assert.Equal(t, map[string]string{"status": "cairn-status"}, c.FieldAliases)
+
+	t.Setenv("CAIRN_FIELD_ALIASES", "status=cairn-status, tags=cairn-tags")
+	c, err = Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "cairn-status", "tags": "cairn-tags"}, c.FieldAliases)
+
+	t.Setenv("CAIRN_FIELD_ALIASES", "status")
+	_, err = Load(t.TempDir())
+	assert.ErrorContains(t, err, "key=value")
+}
+
+func TestHorizons(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "horizons: [today, this-week, next-week, someday]\ndefault_horizon: someday\n")
+	c, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"today", "this-week", "next-week", "someday"}, c.Horizons)
+
+	writeConfig(t, dir, "horizons: [today, someday]\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, `invalid default_horizon "future" (use today, someday)`)
+
+	for _, bad := range []string{"[]", "[Today]", "[today, today]", "[today, other]", "[a, b, c, d, e, f, g, h, i, j]"} {
+		writeConfig(t, dir, "horizons: "+bad+"\ndefault_horizon: today\n")
+		_, err = Load(dir)
+		assert.ErrorContains(t, err, "horizon", bad)
+	}
+}
+
+func TestDefaultsSection(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "defaults:\n  horizon: today\n  tags: [crunch]\n  body: \"# {{title}}\"\n")
+	c, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, GoalDefaults{Horizon: "today", Tags: []string{"crunch"}, Body: "# {{title}}"}, c.Defaults)
+	assert.Equal(t, "today", c.NewGoalHorizon(), "overrides default_horizon")
+	assert.Contains(t, Keys(), "defaults.horizon")
+	assert.Equal(t, "CAIRN_DEFAULTS_HORIZON", EnvName("defaults.horizon"))
+
+	t.Setenv("CAIRN_DEFAULTS_TAGS", "a, b")
+	c, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, c.Defaults.Tags)
+
+	require.NoError(t, c.Set("defaults.horizon", "tomorrow"))
+	assert.Equal(t, "tomorrow", c.NewGoalHorizon())
+	assert.Error(t, c.Set("defaults", "today"), "sections aren't values")
+	assert.Error(t, c.Set("defaults.nope", "1"))
+	assert.Error(t, c.Set("theme.nope", "1"))
+
+	c = Default()
+	assert.Equal(t, "future", c.NewGoalHorizon())
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "default_horizon: someday\n")
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "default_horizon")
+
+	writeConfig(t, dir, "dir_mode: rwx\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "dir_mode")
+
+	writeConfig(t, dir, "theme: neon\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "theme")
+
+	writeConfig(t, dir, "watch: inotify\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "watch")
+
+	writeConfig(t, dir, "watch_poll_interval: 0s\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "watch_poll_interval")
+
+	writeConfig(t, dir, "defaults:\n  horizon: someday\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "defaults.horizon")
+
+	writeConfig(t, dir, "layout: notion\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "layout")
+
+	writeConfig(t, dir, "note_format: \"{time}\"\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "note_format")
+
+	writeConfig(t, dir, "header_counters: [today, year]\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "header_counters")
+
+	writeConfig(t, dir, "collapse_on_complete: yes\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "collapse_on_complete")
+
+	writeConfig(t, dir, "panel_layout: diagonal\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "panel_layout")
+
+	writeConfig(t, dir, "sync_pull: squash\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "sync_pull")
+
+	writeConfig(t, dir, "sync_branch: \"--force\"\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "sync_branch")
+
+	writeConfig(t, dir, "trash_retention: -1h\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "trash_retention")
+
+	writeConfig(t, dir, "pomodoro_work: 0s\n")
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "pomodoro_work")
+
+	writeConfig(t, dir, "colour: blue\n")
+	_, err = Load(dir)
+	assert.Error(t, err, "unknown keys are reported rather than ignored")
+}
+
+func TestSetUnknownKey(t *testing.T) {
+	assert.Error(t, Default().Set("nope", "1"))
+}
@@ -0,0 +1,205 @@
+// Package enrich looks up the state of things goals link to, such as GitHub
+// pull requests, so the TUI can show it next to the link.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State is the state of a linked issue or pull request.
+type State string
+
+const (
+	StateOpen   State = "open"
+	StateMerged State = "merged"
+	StateClosed State = "closed"
+)
+
+// Label is how s is shown after a link, e.g. "(merged ✓)".
+func (s State) Label() string {
+	switch s {
+	case StateOpen:
+		return "(open)"
+	case StateMerged:
+		return "(merged ✓)"
+	case StateClosed:
+		return "(closed)"
+	}
+	return ""
+}
+
+// Fetcher looks up the state of a link.
+type Fetcher interface {
+	// Supports reports whether the fetcher knows how to look up link.
+	Supports(link string) bool
+	State(ctx context.Context, link string) (State, error)
+}
+
+// Ref identifies a GitHub issue or pull request.
+type Ref struct {
+	Owner, Repo string
+	Pull        bool
+	Number      int
+}
+
+// ParseGitHubURL parses a github.com issue or pull request URL such as
+// https://github.com/owner/repo/pull/12. Trailing paths (/files, #anchors)
+// are ignored.
+func ParseGitHubURL(link string) (Ref, bool) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !strings.EqualFold(u.Host, "github.com") {
+		return Ref{}, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] == "" || parts[1] == "" {
+		return Ref{}, false
+	}
+	n, err := strconv.Atoi(parts[3])
+	if err != nil || n <= 0 {
+		return Ref{}, false
+	}
+	switch parts[2] {
+	case "pull":
+		return Ref{Owner: parts[0], Repo: parts[1], Pull: true, Number: n}, true
+	case "issues":
+		return Ref{Owner: parts[0], Repo: parts[1], Number: n}, true
+	}
+	return Ref{}, false
+}
+
+// GitHub fetches issue and pull request state from the GitHub REST API.
+type GitHub struct {
+	Token   string       // sent as a bearer token when set; public repos work without one
+	BaseURL string       // API root, https://api.github.com when empty
+	Client  *http.Client // http.DefaultClient when nil
+}
+
+// NewGitHub returns a GitHub fetcher authenticating with token.
+func NewGitHub(token string) *GitHub {
+	return &GitHub{Token: token}
+}
+
+// Supports implements Fetcher.
+func (g *GitHub) Supports(link string) bool {
+	_, ok := ParseGitHubURL(link)
+	return ok
+}
+
+// State implements Fetcher.
+func (g *GitHub) State(ctx context.Context, link string) (State, error) {
+	ref, ok := ParseGitHubURL(link)
+	if !ok {
+		return "", fmt.Errorf("not a GitHub issue or pull request: %s", link)
+	}
+	base := g.BaseURL
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	kind := "issues"
+	if ref.Pull {
+		kind = "pulls"
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/%s/%d", strings.TrimRight(base, "/"), ref.Owner, ref.Repo, kind, ref.Number)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", endpoint, resp.Status)
+	}
+
+	var body struct {
+		State  string `json:"state"`
+		Merged bool   `json:"merged"`
+		// Issues that are pull requests say so; their merge state isn't
+		// included, so they're reported as closed.
+		PullRequest *struct {
+			MergedAt *string `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding %s: %w", endpoint, err)
+	}
+	switch {
+	case body.State == "open":
+		return StateOpen, nil
+	case body.Merged, body.PullRequest != nil && body.PullRequest.MergedAt != nil:
+		return StateMerged, nil
+	case body.State == "closed":
+		return StateClosed, nil
+	}
+	return "", fmt.Errorf("unknown state %q for %s", body.State, link)
+}
+
+// Cache remembers a Fetcher's results, including failures, for TTL so a
+// link is looked up at most once per TTL however often it's shown.
+type Cache struct {
+	fetcher Fetcher
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	state   State // "" when the lookup failed
+	fetched time.Time
+}
+
+// NewCache wraps f with a cache whose entries expire after ttl.
+func NewCache(f Fetcher, ttl time.Duration) *Cache {
+	return &Cache{fetcher: f, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// Supports reports whether the underlying fetcher supports link.
+func (c *Cache) Supports(link string) bool {
+	return c.fetcher.Supports(link)
+}
+
+// Get returns link's cached state. ok is false when link hasn't been
+// fetched or its entry has expired; state is "" after a failed lookup.
+func (c *Cache) Get(link string) (state State, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.entries[link]
+	if !found || c.now().Sub(e.fetched) >= c.ttl {
+		return "", false
+	}
+	return e.state, true
+}
+
+// Fetch looks up link and caches the result. Errors are cached as an
+// empty state and returned.
+func (c *Cache) Fetch(ctx context.Context, link string) (State, error) {
+	state, err := c.fetcher.State(ctx, link)
+	if err != nil {
+		state = ""
+	}
+	c.mu.Lock()
+	c.entries[link] = cacheEntry{state: state, fetched: c.now()}
+	c.mu.Unlock()
+	return state, err
+}
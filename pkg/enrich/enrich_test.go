@@ -0,0 +1,117 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHubURL(t *testing.T) {
+	for link, want := range map[string]Ref{
+		"https://github.com/acme/app/pull/12":           {Owner: "acme", Repo: "app", Pull: true, Number: 12},
+		"https://github.com/acme/app/pull/12/files":     {Owner: "acme", Repo: "app", Pull: true, Number: 12},
+		"https://github.com/acme/app/issues/7#issuecom": {Owner: "acme", Repo: "app", Number: 7},
+	} {
+		got, ok := ParseGitHubURL(link)
+		assert.True(t, ok, link)
+		assert.Equal(t, want, got, link)
+	}
+	for _, link := range []string{
+		"https://github.com/acme/app",
+		"https://github.com/acme/app/pull/new",
+		"https://gitlab.com/acme/app/pull/12",
+		"not a url",
+	} {
+		_, ok := ParseGitHubURL(link)
+		assert.False(t, ok, link)
+	}
+}
+
+func TestGitHubState(t *testing.T) {
+	responses := map[string]string{
+		"/repos/acme/app/pulls/1":  `{"state": "open", "merged": false}`,
+		"/repos/acme/app/pulls/2":  `{"state": "closed", "merged": true}`,
+		"/repos/acme/app/pulls/3":  `{"state": "closed", "merged": false}`,
+		"/repos/acme/app/issues/4": `{"state": "closed"}`,
+		"/repos/acme/app/issues/5": `{"state": "closed", "pull_request": {"merged_at": "2026-01-02T00:00:00Z"}}`,
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	g := &GitHub{Token: "secret", BaseURL: srv.URL, Client: srv.Client()}
+	for link, want := range map[string]State{
+		"https://github.com/acme/app/pull/1":   StateOpen,
+		"https://github.com/acme/app/pull/2":   StateMerged,
+		"https://github.com/acme/app/pull/3":   StateClosed,
+		"https://github.com/acme/app/issues/4": StateClosed,
+		"https://github.com/acme/app/issues/5": StateMerged,
+	} {
+		got, err := g.State(context.Background(), link)
+		require.NoError(t, err, link)
+		assert.Equal(t, want, got, link)
+	}
+	assert.Equal(t, "Bearer secret", auth)
+
+	_, err := g.State(context.Background(), "https://github.com/acme/app/pull/404")
+	assert.ErrorContains(t, err, "404")
+}
+
+type stubFetcher struct {
+	calls int
+	state State
+	err   error
+}
+
+func (f *stubFetcher) Supports(string) bool { return true }
+
+func (f *stubFetcher) State(context.Context, string) (State, error) {
+	f.calls++
+	return f.state, f.err
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := &stubFetcher{state: StateOpen}
+	c := NewCache(f, time.Minute)
+	c.now = func() time.Time { return now }
+
+	link := "https://github.com/acme/app/pull/1"
+	_, ok := c.Get(link)
+	assert.False(t, ok)
+
+	state, err := c.Fetch(context.Background(), link)
+	require.NoError(t, err)
+	assert.Equal(t, StateOpen, state)
+	state, ok = c.Get(link)
+	assert.True(t, ok)
+	assert.Equal(t, StateOpen, state)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get(link)
+	assert.False(t, ok, "entries expire after the TTL")
+
+	// Failures are remembered too, as an empty state
+	f.err = errors.New("offline")
+	_, err = c.Fetch(context.Background(), link)
+	assert.Error(t, err)
+	state, ok = c.Get(link)
+	assert.True(t, ok)
+	assert.Empty(t, state)
+	assert.Empty(t, state.Label())
+	assert.Equal(t, 2, f.calls)
+}
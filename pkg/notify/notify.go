@@ -0,0 +1,113 @@
+// Package notify sends desktop notifications summarizing the day's goals.
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// Title is the heading of every notification.
+const Title = "cairn"
+
+// Notifier shows a desktop notification.
+type Notifier interface {
+	Notify(title, body string) error
+}
+
+// System returns the Notifier for the running platform.
+func System() Notifier {
+	return commandNotifier{goos: runtime.GOOS}
+}
+
+type commandNotifier struct {
+	goos string
+}
+
+func (n commandNotifier) Notify(title, body string) error {
+	cmd := Command(n.goos, title, body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Command builds the command that shows a notification on goos: osascript
+// on macOS, a PowerShell toast on Windows and notify-send elsewhere.
+func Command(goos, title, body string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return exec.Command("osascript", "-e", script)
+	case "windows":
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript(title, body))
+	default:
+		return exec.Command("notify-send", title, body)
+	}
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// toastScript shows a two-line toast through the WinRT notification API,
+// which needs no extra modules.
+func toastScript(title, body string) string {
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$x = $t.GetElementsByTagName('text')",
+		"$x.Item(0).AppendChild($t.CreateTextNode(" + quote(title) + ")) > $null",
+		"$x.Item(1).AppendChild($t.CreateTextNode(" + quote(body) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('cairn').Show([Windows.UI.Notifications.ToastNotification]::new($t))",
+	}, "; ")
+}
+
+// TodayMessage summarizes the unfinished TODAY goals, e.g. "3 goals for
+// today, 1 stale", counting as stale those there longer than staleDays (see
+// store.IsStaleToday). It returns "" when there's nothing to report.
+func TodayMessage(goals []*store.Goal, now time.Time, staleDays int) string {
+	today, stale := 0, 0
+	var walk func([]*store.Goal)
+	walk = func(goals []*store.Goal) {
+		for _, g := range goals {
+			if g.Horizon == store.HorizonToday && !g.IsComplete() {
+				today++
+				if store.IsStaleToday(g, now, staleDays) {
+					stale++
+				}
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+
+	if today == 0 {
+		return ""
+	}
+	noun := "goals"
+	if today == 1 {
+		noun = "goal"
+	}
+	msg := fmt.Sprintf("%d %s for today", today, noun)
+	if stale > 0 {
+		msg += fmt.Sprintf(", %d stale", stale)
+	}
+	return msg
+}
+
+// Today notifies n of the unfinished TODAY goals and returns the message
+// sent, or "" without notifying when there are none.
+func Today(n Notifier, goals []*store.Goal, now time.Time, staleDays int) (string, error) {
+	msg := TodayMessage(goals, now, staleDays)
+	if msg == "" {
+		return "", nil
+	}
+	return msg, n.Notify(Title, msg)
+}
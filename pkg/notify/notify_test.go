@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	titles, bodies []string
+	err            error
+}
+
+func (f *fakeNotifier) Notify(title, body string) error {
+	f.titles = append(f.titles, title)
+	f.bodies = append(f.bodies, body)
+	return f.err
+}
+
+func TestToday(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	goals := []*store.Goal{
+		{Path: "a", Horizon: store.HorizonToday, HorizonSet: now},
+		{Path: "b", Horizon: store.HorizonFuture, Children: []*store.Goal{
+			{Path: "b/c", Horizon: store.HorizonToday, HorizonSet: now.AddDate(0, 0, -5)},
+		}},
+		{Path: "d", Horizon: store.HorizonToday, Status: store.StatusComplete},
+	}
+
+	n := &fakeNotifier{}
+	msg, err := Today(n, goals, now, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "2 goals for today, 1 stale", msg)
+	assert.Equal(t, []string{"cairn"}, n.titles)
+	assert.Equal(t, []string{msg}, n.bodies)
+
+	assert.Equal(t, "1 goal for today", TodayMessage(goals[:1], now, 3))
+
+	// Nothing to report: no notification
+	n = &fakeNotifier{}
+	msg, err = Today(n, goals[3:], now, 3)
+	require.NoError(t, err)
+	assert.Empty(t, msg)
+	assert.Empty(t, n.bodies)
+
+	n = &fakeNotifier{err: errors.New("no display")}
+	_, err = Today(n, goals, now, 3)
+	assert.EqualError(t, err, "no display")
+}
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, []string{"notify-send", "cairn", "2 goals"}, Command("linux", "cairn", "2 goals").Args)
+	assert.Equal(t, []string{"osascript", "-e", `display notification "say \"hi\"" with title "cairn"`},
+		Command("darwin", "cairn", `say "hi"`).Args)
+
+	win := Command("windows", "cairn", "it's today").Args
+	assert.Equal(t, "powershell", win[0])
+	assert.Contains(t, win[len(win)-1], "CreateTextNode('it''s today')")
+}
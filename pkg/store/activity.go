@@ -0,0 +1,170 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// NoteDateHeader matches the "## 2006-01-02" headers AddNote writes; the
+// first submatch is the date.
+var NoteDateHeader = regexp.MustCompile(`^##\s+(\d{4}-\d{2}-\d{2})\b`)
+
+// DayActivity counts what happened on one local calendar day.
+type DayActivity struct {
+	Date        time.Time // midnight, local time
+	Completions int
+	Created     int
+	Notes       int
+}
+
+// noteCounts returns how many notes body has under each date header. A
+// header with no bullets under it still counts as one note. Headers and
+// bullets inside fenced code blocks don't count.
+func noteCounts(body string) map[string]int {
+	counts := make(map[string]int)
+	for _, sec := range parseNoteBody(body).sections {
+		if _, ok := counts[sec.Date]; !ok {
+			counts[sec.Date] = 0
+		}
+		var fence codeFence
+		for _, line := range sec.Lines {
+			if !fence.scan(line) && strings.HasPrefix(strings.TrimSpace(line), "- ") {
+				counts[sec.Date]++
+			}
+		}
+	}
+	for d, n := range counts {
+		if n == 0 {
+			counts[d] = 1
+		}
+	}
+	return counts
+}
+
+// indexNotes sets g.NoteCount and g.LastNote from its body. Bodies without
+// date headers have neither.
+func (g *Goal) indexNotes() {
+	g.NoteCount = 0
+	g.LastNote = time.Time{}
+	last := ""
+	for d, n := range noteCounts(g.Body) {
+		g.NoteCount += n
+		if d > last {
+			last = d
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", last, time.Local); err == nil {
+		g.LastNote = t
+	}
+}
+
+// completedOn returns the local day g was completed: its completed
+// timestamp, or for goals completed before those were recorded, the last
+// note date in its body. ok is false if neither is known.
+func completedOn(g *Goal, loc *time.Location) (day time.Time, ok bool) {
+	if !g.Completed.IsZero() {
+		return startOfDay(g.Completed.In(loc)), true
+	}
+	last := ""
+	for d := range noteCounts(g.Body) {
+		if d > last {
+			last = d
+		}
+	}
+	if last == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", last, loc)
+	return t, err == nil
+}
+
+// Activity buckets completions, creations and notes across goals (and their
+// descendants) into the last days local calendar days ending today,
+// oldest first.
+func Activity(goals []*Goal, now time.Time, days int) []DayActivity {
+	if days < 1 {
+		return nil
+	}
+	loc := now.Location()
+	today := startOfDay(now)
+	result := make([]DayActivity, days)
+	index := make(map[string]int, days)
+	for i := range result {
+		day := today.AddDate(0, 0, i-days+1)
+		result[i].Date = day
+		index[day.Format("2006-01-02")] = i
+	}
+
+	var walk func([]*Goal)
+	walk = func(goals []*Goal) {
+		for _, g := range goals {
+			if g.IsComplete() {
+				if day, ok := completedOn(g, loc); ok {
+					if i, ok := index[day.Format("2006-01-02")]; ok {
+						result[i].Completions++
+					}
+				}
+			}
+			if !g.Created.IsZero() {
+				if i, ok := index[startOfDay(g.Created.In(loc)).Format("2006-01-02")]; ok {
+					result[i].Created++
+				}
+			}
+			for d, n := range noteCounts(g.Body) {
+				if i, ok := index[d]; ok {
+					result[i].Notes += n
+				}
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+	return result
+}
+
+// Streak returns how many consecutive days, ending today, have at least one
+// completion. A day without completions yet today doesn't break a streak
+// that ran through yesterday.
+func Streak(activity []DayActivity) int {
+	i := len(activity) - 1
+	if i >= 0 && activity[i].Completions == 0 {
+		i--
+	}
+	streak := 0
+	for ; i >= 0 && activity[i].Completions > 0; i-- {
+		streak++
+	}
+	return streak
+}
+
+// LastDays totals the last days days of activity, ending today. days
+// beyond the activity's length count all of it.
+func LastDays(activity []DayActivity, days int) DayActivity {
+	var total DayActivity
+	for i := max(len(activity)-days, 0); i < len(activity); i++ {
+		total.Completions += activity[i].Completions
+		total.Created += activity[i].Created
+		total.Notes += activity[i].Notes
+	}
+	return total
+}
+
+// ActivityByDay returns per-day completion and note counts for the last
+// days days, oldest first, bucketed in local time.
+func (s *Store) ActivityByDay(days int) ([]DayActivity, error) {
+	return activityByDay(s, time.Now(), days)
+}
+
+// ActivityByDay returns per-day activity, like Store.ActivityByDay.
+func (s *MemStore) ActivityByDay(days int) ([]DayActivity, error) {
+	return activityByDay(s, time.Now(), days)
+}
+
+func activityByDay(b Backend, now time.Time, days int) ([]DayActivity, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	return Activity(goals, now, days), nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletedTimestamp(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+
+	g, err := s.SetStatus("task", StatusComplete)
+	require.NoError(t, err)
+	assert.False(t, g.Completed.IsZero())
+	stamped := g.Completed
+
+	// Later saves keep the original completion time
+	g, err = s.AddNote("task", "wrap-up")
+	require.NoError(t, err)
+	assert.True(t, stamped.Equal(g.Completed))
+
+	g, err = s.SetStatus("task", StatusIncomplete)
+	require.NoError(t, err)
+	assert.True(t, g.Completed.IsZero(), "reopening clears it")
+}
+
+func TestNoteIndex(t *testing.T) {
+	s := setupTestStore(t)
+	g, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+	g.Body = "Context without dates.\n\n## 2025-03-01\n- one\n- two\n\n## 2025-03-04\nA paragraph.\n"
+	require.NoError(t, s.SaveGoal(g))
+
+	g, err = s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, 3, g.NoteCount, "a dated section without bullets counts once")
+	assert.Equal(t, time.Date(2025, 3, 4, 0, 0, 0, 0, time.Local), g.LastNote)
+
+	g.Body = "Just prose, no date headers."
+	require.NoError(t, s.SaveGoal(g))
+	tree, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Zero(t, tree[0].NoteCount)
+	assert.True(t, tree[0].LastNote.IsZero())
+
+	g.Body = "## 2025-03-01\n- one\n```\n- not a note\n## 2025-03-09\n```\n"
+	require.NoError(t, s.SaveGoal(g))
+	g, err = s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.NoteCount, "code blocks hold neither notes nor dates")
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local), g.LastNote)
+}
+
+func TestActivity(t *testing.T) {
+	// Late evening local time: UTC has already rolled over to the next day
+	loc := time.FixedZone("UTC-5", -5*3600)
+	now := time.Date(2025, 3, 10, 22, 0, 0, 0, loc)
+	at := func(daysAgo int) time.Time { return now.AddDate(0, 0, -daysAgo).UTC() }
+
+	goals := []*Goal{
+		{Path: "a", Status: StatusComplete, Completed: at(0), Created: at(3)},
+		{Path: "b", Status: StatusComplete, Completed: at(1), Children: []*Goal{
+			{Path: "b/c", Status: StatusComplete, Completed: at(1)},
+		}},
+		{Path: "d", Status: StatusComplete, Completed: at(2)},
+		{Path: "old", Status: StatusComplete, Body: "## 2025-03-06\n- done at last\n"},
+		{Path: "e", Status: StatusIncomplete, Created: at(0), Body: "## 2025-03-09\n- one\n- two\n\n## 2025-03-10\n"},
+	}
+
+	activity := Activity(goals, now, 7)
+	require.Len(t, activity, 7)
+	assert.Equal(t, time.Date(2025, 3, 4, 0, 0, 0, 0, loc), activity[0].Date)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, loc), activity[6].Date)
+
+	completions := make([]int, 7)
+	notes := make([]int, 7)
+	for i, day := range activity {
+		completions[i] = day.Completions
+		notes[i] = day.Notes
+	}
+	assert.Equal(t, []int{0, 0, 1, 0, 1, 2, 1}, completions, "buckets use local days; old goals fall back to their last note date")
+	assert.Equal(t, []int{0, 0, 1, 0, 0, 2, 1}, notes)
+
+	created := make([]int, 7)
+	for i, day := range activity {
+		created[i] = day.Created
+	}
+	assert.Equal(t, []int{0, 0, 0, 1, 0, 0, 1}, created)
+
+	assert.Equal(t, DayActivity{Completions: 1, Created: 1, Notes: 1}, LastDays(activity, 1))
+	assert.Equal(t, DayActivity{Completions: 5, Created: 2, Notes: 4}, LastDays(activity, 7))
+	assert.Equal(t, LastDays(activity, 7), LastDays(activity, 30), "a window longer than the activity counts all of it")
+
+	assert.Equal(t, 3, Streak(activity))
+	assert.Equal(t, 2, Streak(activity[:6]), "no completion yet today doesn't break the streak")
+	assert.Equal(t, 1, Streak(activity[:4]))
+	assert.Equal(t, 0, Streak(activity[:2]))
+}
@@ -0,0 +1,83 @@
+package store
+
+import "slices"
+
+// enqueue appends goalPath to the queue unless it's already there, without
+// committing. queue.md is only written when that changes it.
+func (s *Store) enqueue(goalPath string) error {
+	q, err := s.LoadQueue()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(q.Items, goalPath) {
+		return nil
+	}
+	q.Items = append(q.Items, goalPath)
+	return s.writeQueue(q)
+}
+
+// unqueue removes goalPath from the queue without committing, reporting
+// whether it was there. queue.md is only written when it was.
+func (s *Store) unqueue(goalPath string) (bool, error) {
+	q, err := s.LoadQueue()
+	if err != nil {
+		return false, err
+	}
+	items, removed := withoutItem(q.Items, goalPath)
+	if !removed {
+		return false, nil
+	}
+	q.Items = items
+	return true, s.writeQueue(q)
+}
+
+// Dequeue removes goalPath from the queue, reporting whether it was there.
+// Nothing is written or committed when it wasn't.
+func (s *Store) Dequeue(goalPath string) (bool, error) {
+	if err := s.writable(); err != nil {
+		return false, err
+	}
+	removed, err := s.unqueue(goalPath)
+	if removed && err == nil {
+		s.Commit("dequeue " + goalPath)
+	}
+	return removed, err
+}
+
+// Dequeue removes goalPath from the queue, like Store.Dequeue.
+func (s *MemStore) Dequeue(goalPath string) (bool, error) {
+	items, removed := withoutItem(s.queue.Items, goalPath)
+	if !removed {
+		return false, nil
+	}
+	if err := s.SaveQueue(&Queue{Items: items}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// afterCreate queues new top-level goals when AutoQueue is set.
+func (s *Store) afterCreate(g *Goal) error {
+	if !s.opts.AutoQueue || parentOf(g.Path) != "" {
+		return nil
+	}
+	return s.enqueue(g.Path)
+}
+
+// afterStatus takes completed goals off the queue when DequeueOnComplete
+// is set.
+func (s *Store) afterStatus(g *Goal) error {
+	if !s.opts.DequeueOnComplete || !g.IsComplete() {
+		return nil
+	}
+	_, err := s.unqueue(g.Path)
+	return err
+}
+
+func withoutItem(items []string, item string) ([]string, bool) {
+	i := slices.Index(items, item)
+	if i < 0 {
+		return items, false
+	}
+	return slices.Delete(slices.Clone(items), i, i+1), true
+}
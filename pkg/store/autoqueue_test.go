@@ -0,0 +1,88 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoQueue(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{AutoQueue: true})
+	require.NoError(t, err)
+
+	_, err = s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "infra")
+	require.NoError(t, err)
+
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr", "infra"}, q.Items, "only top-level goals, in creation order")
+}
+
+func TestDequeueOnComplete(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{DequeueOnComplete: true})
+	require.NoError(t, err)
+	for _, slug := range []string{"otr", "infra", "later"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.SaveQueue(&Queue{Items: []string{"otr", "infra"}}))
+
+	_, err = s.SetStatus("otr", StatusComplete)
+	require.NoError(t, err)
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra"}, q.Items)
+
+	// Completing an unqueued goal leaves queue.md alone, byte for byte
+	hand := "1. infra <!-- kept as written -->\n"
+	require.NoError(t, os.WriteFile(s.QueuePath(), []byte(hand), 0644))
+	_, err = s.SetStatus("later", StatusComplete)
+	require.NoError(t, err)
+	data, err := os.ReadFile(s.QueuePath())
+	require.NoError(t, err)
+	assert.Equal(t, hand, string(data))
+
+	// ToggleStatus only dequeues once the goal is complete
+	_, err = s.ToggleStatus("infra")
+	require.NoError(t, err)
+	q, err = s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra"}, q.Items)
+	_, err = s.ToggleStatus("infra")
+	require.NoError(t, err)
+	q, err = s.LoadQueue()
+	require.NoError(t, err)
+	assert.Empty(t, q.Items)
+}
+
+func TestDequeue(t *testing.T) {
+	s := setupTestStore(t)
+	require.NoError(t, s.SaveQueue(&Queue{Items: []string{"otr", filepath.Join("otr", "ios")}}))
+
+	removed, err := s.Dequeue("otr")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = s.Dequeue("otr")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("otr", "ios")}, q.Items)
+
+	mem := NewMemStore()
+	require.NoError(t, mem.SaveQueue(&Queue{Items: []string{"otr", "infra"}}))
+	removed, err = mem.Dequeue("infra")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	q, err = mem.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr"}, q.Items)
+}
@@ -0,0 +1,61 @@
+package store
+
+import "time"
+
+// Backend is the set of storage operations the TUI and CLI depend on.
+// *Store is the filesystem-backed implementation; MemStore keeps everything
+// in memory for tests and as a template for alternate backends.
+type Backend interface {
+	// DataDir returns the root of the data directory.
+	DataDir() string
+	// GoalsDir returns the directory goal files live under.
+	GoalsDir() string
+	// Commit records all pending changes with the given message.
+	Commit(message string)
+	// Horizons returns the horizons goals can be given, nearest first.
+	Horizons() []Horizon
+
+	LoadQueue() (*Queue, error)
+	SaveQueue(q *Queue) error
+	// Dequeue removes goalPath from the queue, reporting whether it was
+	// there.
+	Dequeue(goalPath string) (bool, error)
+
+	LoadGoal(goalPath string) (*Goal, error)
+	LoadGoalTree() ([]*Goal, error)
+	SaveGoal(g *Goal) error
+	// ForceSaveGoal saves g even when it's unchanged, refreshing its
+	// updated time.
+	ForceSaveGoal(g *Goal) error
+	CreateGoal(parentPath, slug string) (*Goal, error)
+	DeleteGoal(goalPath string) error
+	SubtreeSummary(goalPath string) (*SubtreeSummary, error)
+
+	ToggleStatus(goalPath string) (*Goal, error)
+	SetStatus(goalPath string, status GoalStatus) (*Goal, error)
+	SetHorizon(goalPath string, horizon Horizon) (*Goal, error)
+	TogglePin(goalPath string) (*Goal, error)
+	SetEstimate(goalPath, estimate string) (*Goal, error)
+	RolloverHorizons(now time.Time) (int, error)
+	StaleToday(thresholdDays int) ([]*Goal, error)
+	Waiting() ([]*Goal, error)
+	Orphans(staleDays int) (Orphans, error)
+	ActivityByDay(days int) ([]DayActivity, error)
+	AddNote(goalPath, text string) (*Goal, error)
+	PropagateStatus(goalPath string) (*Goal, error)
+	CompleteDescendants(goalPath string) ([]string, error)
+
+	MoveGoal(goalPath, newParentPath string) error
+	ReorderGoal(goalPath string, delta int) error
+	// MoveToIndex moves a goal to position index among its siblings,
+	// clamped to the first or last slot.
+	MoveToIndex(goalPath string, index int) error
+	SetChildrenOrder(parentPath string, order []string) error
+
+	SearchNotes(query string, opts SearchOptions) ([]*Goal, error)
+}
+
+var (
+	_ Backend = (*Store)(nil)
+	_ Backend = (*MemStore)(nil)
+)
@@ -0,0 +1,286 @@
+package store
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BackupManifest is the last entry of a backup: the SHA-256 of every other
+// file in it, one "<hex>  <name>" line each like sha256sum writes.
+const BackupManifest = "MANIFEST.sha256"
+
+// backupFiles are the top-level files a backup keeps besides goals/: the
+// queue, a snapshot of config.yaml, and the recipients encrypted notes
+// need. .git and RuntimeDir (trash, events, UI state) are left out.
+var backupFiles = []string{"queue.md", "config.yaml", RecipientsFile}
+
+// WriteBackup streams dataDir's goals, queue and config to w as a gzipped
+// tar ending in BackupManifest. It returns the number of files written.
+func WriteBackup(dataDir string, w io.Writer) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	var manifest strings.Builder
+
+	add := func(name, file string, info fs.FileInfo) error {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if info.IsDir() {
+			hdr.Name += "/"
+			return tw.WriteHeader(hdr)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(tw, h), f); err != nil {
+			return fmt.Errorf("backing up %s: %w", name, err)
+		}
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(h.Sum(nil)), name)
+		return nil
+	}
+
+	files := 0
+	goalsDir := filepath.Join(dataDir, "goals")
+	err := filepath.WalkDir(goalsDir, func(file string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && file == goalsDir {
+			return fs.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // symlinks and the like aren't goals
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dataDir, file)
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files++
+		}
+		return add(filepath.ToSlash(rel), file, info)
+	})
+	if err != nil {
+		return files, err
+	}
+	for _, name := range backupFiles {
+		file := filepath.Join(dataDir, name)
+		info, err := os.Stat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return files, err
+		}
+		if err := add(name, file, info); err != nil {
+			return files, err
+		}
+		files++
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: BackupManifest, Mode: 0644, Size: int64(manifest.Len()), ModTime: time.Now()}); err != nil {
+		return files, err
+	}
+	if _, err := io.WriteString(tw, manifest.String()); err != nil {
+		return files, err
+	}
+	if err := tw.Close(); err != nil {
+		return files, err
+	}
+	return files, gz.Close()
+}
+
+// VerifyBackup reads a backup through, checking its structure and every
+// file against the manifest. It returns the number of files checked.
+func VerifyBackup(r io.Reader) (int, error) {
+	return readBackup(r, func(string, *tar.Header, io.Reader) error { return nil })
+}
+
+// RestoreBackup extracts a backup into dataDir, which is created if needed.
+// The archive is unpacked and verified beside the data first, so a bad
+// archive changes nothing; then goals/ is replaced and the other files
+// overwritten. .git and RuntimeDir are kept. It returns the number of
+// files restored.
+func RestoreBackup(r io.Reader, dataDir string) (int, error) {
+	runtime := filepath.Join(dataDir, RuntimeDir)
+	if err := os.MkdirAll(runtime, DefaultDirPerm); err != nil {
+		return 0, err
+	}
+	staging, err := os.MkdirTemp(runtime, "restore-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(staging)
+
+	files, err := readBackup(r, func(name string, hdr *tar.Header, body io.Reader) error {
+		target := filepath.Join(staging, filepath.FromSlash(name))
+		if hdr.Typeflag == tar.TypeDir {
+			return os.MkdirAll(target, DefaultDirPerm)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), DefaultDirPerm); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, hdr.FileInfo().Mode().Perm()|0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	goals := filepath.Join(dataDir, "goals")
+	if err := os.RemoveAll(goals); err != nil {
+		return 0, err
+	}
+	restored := filepath.Join(staging, "goals")
+	if _, err := os.Stat(restored); err == nil {
+		if err := os.Rename(restored, goals); err != nil {
+			return 0, err
+		}
+	}
+	for _, name := range backupFiles {
+		src := filepath.Join(staging, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := os.Rename(src, filepath.Join(dataDir, name)); err != nil {
+			return 0, err
+		}
+	}
+	return files, nil
+}
+
+// readBackup streams a backup's entries, handing each file and directory
+// under its cleaned name to fn, and checks the manifest once it's read.
+// Entries outside goals/ and backupFiles, and files missing from or not
+// matching the manifest, are errors.
+func readBackup(r io.Reader, fn func(name string, hdr *tar.Header, body io.Reader) error) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("not a cairn backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	sums := make(map[string]string) // name → SHA-256 of what was read
+	var manifest map[string]string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading backup: %w", err)
+		}
+		if manifest != nil {
+			return 0, fmt.Errorf("invalid backup: %s after %s", hdr.Name, BackupManifest)
+		}
+		name := path.Clean(strings.TrimSuffix(hdr.Name, "/"))
+		if name == BackupManifest && hdr.Typeflag == tar.TypeReg {
+			if manifest, err = parseManifest(tr); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if !backupEntry(name) {
+			return 0, fmt.Errorf("invalid backup: unexpected entry %q", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := fn(name, hdr, nil); err != nil {
+				return 0, err
+			}
+		case tar.TypeReg:
+			h := sha256.New()
+			if err := fn(name, hdr, io.TeeReader(tr, h)); err != nil {
+				return 0, err
+			}
+			// Hash whatever fn didn't read
+			if _, err := io.Copy(h, tr); err != nil {
+				return 0, fmt.Errorf("reading backup: %w", err)
+			}
+			sums[name] = hex.EncodeToString(h.Sum(nil))
+		default:
+			return 0, fmt.Errorf("invalid backup: %s is not a file or directory", hdr.Name)
+		}
+	}
+
+	if manifest == nil {
+		return 0, fmt.Errorf("invalid backup: no %s", BackupManifest)
+	}
+	for name, sum := range sums {
+		want, ok := manifest[name]
+		if !ok {
+			return 0, fmt.Errorf("invalid backup: %s is not in the manifest", name)
+		}
+		if sum != want {
+			return 0, fmt.Errorf("invalid backup: checksum mismatch for %s", name)
+		}
+	}
+	for name := range manifest {
+		if _, ok := sums[name]; !ok {
+			return 0, fmt.Errorf("invalid backup: %s is missing", name)
+		}
+	}
+	return len(sums), nil
+}
+
+// backupEntry reports whether name belongs in a backup.
+func backupEntry(name string) bool {
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return false
+	}
+	if name == "goals" || strings.HasPrefix(name, "goals/") {
+		return true
+	}
+	for _, f := range backupFiles {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// parseManifest reads BackupManifest's lines into name → checksum.
+func parseManifest(r io.Reader) (map[string]string, error) {
+	manifest := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid backup: bad %s line %q", BackupManifest, scanner.Text())
+		}
+		manifest[name] = sum
+	}
+	return manifest, scanner.Err()
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.AddNote("otr/ios", "ship it")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Root, "config.yaml"), []byte("theme: colorblind\n"), 0644))
+	require.NoError(t, s.DeleteGoal("otr/ios")) // trashed goals stay out
+
+	var archive bytes.Buffer
+	n, err := WriteBackup(s.Root, &archive)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = VerifyBackup(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	dir := filepath.Join(t.TempDir(), "restored")
+	_, err = RestoreBackup(bytes.NewReader(archive.Bytes()), dir)
+	require.NoError(t, err)
+	restored, err := NewStore(dir)
+	require.NoError(t, err)
+	goals, err := restored.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+	assert.Equal(t, "otr", goals[0].Path)
+	assert.Empty(t, goals[0].Children)
+	config, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "theme: colorblind\n", string(config))
+	items, err := restored.ListTrash()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	// Restoring replaces the goals there
+	_, err = restored.CreateGoal("", "scratch")
+	require.NoError(t, err)
+	_, err = RestoreBackup(bytes.NewReader(archive.Bytes()), dir)
+	require.NoError(t, err)
+	_, err = restored.LoadGoal("scratch")
+	assert.Error(t, err)
+}
+
+// writeArchive builds a gzipped tar of name → content entries, in order.
+func writeArchive(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: e[0], Mode: 0644, Size: int64(len(e[1]))}))
+		_, err := tw.Write([]byte(e[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestRestoreBackupRejectsBadArchives(t *testing.T) {
+	const sum = "0000000000000000000000000000000000000000000000000000000000000000"
+	for name, archive := range map[string][]byte{
+		"not gzip":          []byte("hello"),
+		"no manifest":       writeArchive(t, [2]string{"goals/a/goal.md", "x"}),
+		"checksum mismatch": writeArchive(t, [2]string{"goals/a/goal.md", "x"}, [2]string{BackupManifest, sum + "  goals/a/goal.md\n"}),
+		"missing file":      writeArchive(t, [2]string{BackupManifest, sum + "  queue.md\n"}),
+		"outside the data":  writeArchive(t, [2]string{"../evil", "x"}, [2]string{BackupManifest, ""}),
+		"unexpected file":   writeArchive(t, [2]string{".git/config", "x"}, [2]string{BackupManifest, ""}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "goals", "keep"), 0755))
+			_, err := RestoreBackup(bytes.NewReader(archive), dir)
+			assert.Error(t, err)
+			assert.DirExists(t, filepath.Join(dir, "goals", "keep"), "nothing is replaced")
+		})
+	}
+}
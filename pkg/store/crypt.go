@@ -0,0 +1,181 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RecipientsFile lists the age recipients (public keys, one per line) goal
+// bodies are encrypted to. While it exists in the data directory, SaveGoal
+// encrypts bodies; frontmatter stays readable so the tree, queue and sync
+// keep working without a key.
+const RecipientsFile = ".age-recipients"
+
+// ageArmorHeader starts an ASCII-armored age file.
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// ErrNoIdentity is returned when an encrypted body needs decrypting and no
+// age identity is configured.
+var ErrNoIdentity = errors.New("goal notes are encrypted: set age_identity in config.yaml or CAIRN_AGE_IDENTITY to your age identity file")
+
+// ErrLocked is returned when saving a goal whose encrypted body couldn't be
+// read with a changed body, which would lose the original.
+var ErrLocked = errors.New("can't change the notes of a goal whose encrypted notes couldn't be read")
+
+// bodyCipher encrypts goal bodies to armored text and back.
+type bodyCipher interface {
+	encrypt(plain string) (string, error)
+	decrypt(armored string) (string, error)
+}
+
+// ageCipher runs the age command with the data directory's recipients file
+// and the configured identity file.
+type ageCipher struct {
+	recipients string
+	identity   string
+}
+
+func (c ageCipher) encrypt(plain string) (string, error) {
+	return runAge(plain, "--encrypt", "--armor", "-R", c.recipients)
+}
+
+func (c ageCipher) decrypt(armored string) (string, error) {
+	if c.identity == "" {
+		return "", ErrNoIdentity
+	}
+	return runAge(armored, "--decrypt", "-i", c.identity)
+}
+
+func runAge(input string, args ...string) (string, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return "", errors.New("encrypted notes need the age command; install it from https://age-encryption.org")
+	}
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("age: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// plainCacheSize caps how many decrypted bodies a Store keeps, so a
+// long-running TUI doesn't hold every version of every goal's notes.
+const plainCacheSize = 256
+
+// isEncrypted reports whether body is an armored age file.
+func isEncrypted(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), ageArmorHeader)
+}
+
+// encrypting reports whether bodies are encrypted on save.
+func (s *Store) encrypting() bool {
+	return fileExists(filepath.Join(s.Root, RecipientsFile))
+}
+
+// unseal decrypts g's body when it's encrypted, remembering the armored
+// text so an unchanged body is written back as it was. A body that can't
+// be decrypted is left empty with the reason in BodyErr.
+func (s *Store) unseal(g *Goal) {
+	if !isEncrypted(g.Body) {
+		return
+	}
+	g.Encrypted = true
+	g.sealed = strings.TrimSpace(g.Body)
+	g.Body = ""
+
+	s.plainMu.Lock()
+	plain, ok := s.plain[g.sealed]
+	s.plainMu.Unlock()
+	if !ok {
+		var err error
+		if plain, err = s.cipher.decrypt(g.sealed); err != nil {
+			g.BodyErr = err
+			return
+		}
+		plain = strings.TrimSpace(plain)
+		s.plainMu.Lock()
+		if s.plain == nil {
+			s.plain = make(map[string]string)
+		}
+		for sealed := range s.plain {
+			if len(s.plain) < plainCacheSize {
+				break
+			}
+			delete(s.plain, sealed) // any entry; a stale version is as likely as not
+		}
+		s.plain[g.sealed] = plain
+		s.plainMu.Unlock()
+	}
+	g.Body = plain
+	g.sealedPlain = plain
+}
+
+// sealedBody returns the body to write for g: its encrypted text as read
+// when the body hasn't changed, freshly encrypted when encrypting, and
+// plaintext otherwise.
+func (s *Store) sealedBody(g *Goal) (string, error) {
+	switch {
+	case g.sealed != "" && g.BodyErr != nil:
+		if g.Body != "" {
+			return "", fmt.Errorf("%w: %v", ErrLocked, g.BodyErr)
+		}
+		return g.sealed, nil
+	case g.sealed != "" && g.Body == g.sealedPlain:
+		return g.sealed, nil
+	case g.Body != "" && s.encrypting():
+		armored, err := s.cipher.encrypt(g.Body)
+		if err != nil {
+			return "", fmt.Errorf("encrypting notes: %w", err)
+		}
+		// Saving g again unchanged writes the same text
+		g.Encrypted = true
+		g.sealed = strings.TrimSpace(armored)
+		g.sealedPlain = g.Body
+		return g.sealed, nil
+	}
+	return g.Body, nil
+}
+
+// EncryptExisting encrypts the notes of every goal still stored in
+// plaintext, after RecipientsFile has been created, and commits them. It
+// returns the paths of the goals it encrypted.
+func (s *Store) EncryptExisting() ([]string, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if !s.encrypting() {
+		return nil, fmt.Errorf("no recipients file: add age public keys to %s first", filepath.Join(s.Root, RecipientsFile))
+	}
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	var encrypted []string
+	var walk func([]*Goal) error
+	walk = func(goals []*Goal) error {
+		for _, g := range goals {
+			if !g.Encrypted && strings.TrimSpace(g.Body) != "" {
+				if err := s.SaveGoal(g); err != nil {
+					return fmt.Errorf("encrypting %s: %w", g.Path, err)
+				}
+				encrypted = append(encrypted, g.Path)
+			}
+			if err := walk(g.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = walk(goals)
+	if len(encrypted) > 0 {
+		s.Commit(fmt.Sprintf("encrypt: %d goals", len(encrypted)))
+	}
+	return encrypted, err
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCipher armors bodies as base64 so tests don't need the age command.
+type fakeCipher struct {
+	encrypts int
+}
+
+func (c *fakeCipher) encrypt(plain string) (string, error) {
+	c.encrypts++
+	return ageArmorHeader + "\n" + base64.StdEncoding.EncodeToString([]byte(plain)) + "\n-----END AGE ENCRYPTED FILE-----\n", nil
+}
+
+func (c *fakeCipher) decrypt(armored string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(armored), "\n")
+	plain, err := base64.StdEncoding.DecodeString(lines[1])
+	return string(plain), err
+}
+
+func setupEncryptedStore(t *testing.T) (*Store, *fakeCipher) {
+	t.Helper()
+	s := setupTestStore(t)
+	c := &fakeCipher{}
+	s.cipher = c
+	require.NoError(t, os.MkdirAll(s.Root, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(s.Root, RecipientsFile), []byte("age1example\n"), 0644))
+	return s, c
+}
+
+func TestEncryptedNotesRoundTrip(t *testing.T) {
+	s, c := setupEncryptedStore(t)
+	_, err := s.CreateGoal("", "secret")
+	require.NoError(t, err)
+	_, err = s.AddNote("secret", "launch codes")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(s.GoalFile("secret"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ageArmorHeader)
+	assert.NotContains(t, string(data), "launch codes")
+	assert.Contains(t, string(data), "title: secret", "frontmatter stays readable")
+
+	g, err := s.LoadGoal("secret")
+	require.NoError(t, err)
+	assert.True(t, g.Encrypted)
+	assert.Contains(t, g.Body, "launch codes")
+	assert.Equal(t, 1, g.NoteCount)
+
+	// An unchanged body keeps its encrypted text
+	encrypts := c.encrypts
+	_, err = s.SetStatus("secret", StatusComplete)
+	require.NoError(t, err)
+	assert.Equal(t, encrypts, c.encrypts)
+	after, err := os.ReadFile(s.GoalFile("secret"))
+	require.NoError(t, err)
+	assert.Contains(t, string(after), strings.SplitN(string(data), ageArmorHeader, 2)[1])
+
+	results, err := s.SearchNotes("launch", SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "secret", results[0].Path)
+}
+
+func TestDecryptedBodyCacheIsBounded(t *testing.T) {
+	s, c := setupEncryptedStore(t)
+	for i := range plainCacheSize + 10 {
+		armored, err := c.encrypt(fmt.Sprintf("note %d", i))
+		require.NoError(t, err)
+		g := &Goal{Body: armored}
+		s.unseal(g)
+		assert.Equal(t, fmt.Sprintf("note %d", i), g.Body)
+	}
+	assert.LessOrEqual(t, len(s.plain), plainCacheSize)
+}
+
+func TestEncryptedNotesWithoutIdentity(t *testing.T) {
+	s, _ := setupEncryptedStore(t)
+	_, err := s.CreateGoal("", "secret")
+	require.NoError(t, err)
+	_, err = s.AddNote("secret", "launch codes")
+	require.NoError(t, err)
+
+	locked, err := NewStore(s.Root)
+	require.NoError(t, err)
+	g, err := locked.LoadGoal("secret")
+	require.NoError(t, err)
+	assert.True(t, g.Encrypted)
+	assert.ErrorIs(t, g.BodyErr, ErrNoIdentity)
+	assert.Empty(t, g.Body)
+
+	// Frontmatter changes keep the notes; note changes would lose them
+	_, err = locked.SetStatus("secret", StatusComplete)
+	require.NoError(t, err)
+	_, err = locked.AddNote("secret", "more")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	g, err = s.LoadGoal("secret")
+	require.NoError(t, err)
+	assert.True(t, g.IsComplete())
+	assert.Contains(t, g.Body, "launch codes")
+}
+
+func TestEncryptExisting(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "infra")
+	require.NoError(t, err)
+	_, err = s.AddNote("otr/infra", "plaintext")
+	require.NoError(t, err)
+
+	_, err = s.EncryptExisting()
+	assert.Error(t, err, "needs a recipients file")
+
+	s.cipher = &fakeCipher{}
+	require.NoError(t, os.WriteFile(filepath.Join(s.Root, RecipientsFile), []byte("age1example\n"), 0644))
+	paths, err := s.EncryptExisting()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr/infra"}, paths)
+
+	data, err := os.ReadFile(s.GoalFile("otr/infra"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "plaintext")
+
+	paths, err = s.EncryptExisting()
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var relativeDatePattern = regexp.MustCompile(`^(\d+)([dwm])$`)
+
+// ParseDate parses a calendar day relative to now: "2006-01-02", "today",
+// "yesterday", "tomorrow", or an amount of time ago such as "3d", "2w" or
+// "1m" (days, weeks, months). The result is local midnight of that day.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := startOfDay(now)
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if m := relativeDatePattern.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", s)
+		}
+		switch m[2] {
+		case "d":
+			return today.AddDate(0, 0, -n), nil
+		case "w":
+			return today.AddDate(0, 0, -7*n), nil
+		default:
+			return today.AddDate(0, -n, 0), nil
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD, today, yesterday or e.g. 3d, 2w, 1m", s)
+	}
+	return t, nil
+}
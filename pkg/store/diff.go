@@ -0,0 +1,115 @@
+package store
+
+import "strings"
+
+// Kinds of GoalChange.
+const (
+	ChangeAdded       = "added"
+	ChangeRemoved     = "removed"
+	ChangeStatus      = "status"
+	ChangeHorizon     = "horizon"
+	ChangeTitle       = "title"
+	ChangeNoteAdded   = "note_added"
+	ChangeNoteRemoved = "note_removed"
+)
+
+// GoalChange is one difference between two goal trees.
+type GoalChange struct {
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	From  string `json:"from,omitempty"` // status, horizon and title changes
+	To    string `json:"to,omitempty"`
+	Note  string `json:"note,omitempty"` // note changes: the bullet's text
+}
+
+// DiffTrees compares goal trees by path: goals only in after are added,
+// goals only in before are removed, and goals in both report changes to
+// their title, status and horizon and the note bullets added to or removed
+// from their body. Changes are in tree order, removals last.
+func DiffTrees(before, after []*Goal) []GoalChange {
+	old := make(map[string]*Goal)
+	WalkGoals(before, func(g *Goal, _ int) error {
+		old[g.Path] = g
+		return nil
+	})
+
+	var changes []GoalChange
+	seen := make(map[string]bool)
+	WalkGoals(after, func(g *Goal, _ int) error {
+		seen[g.Path] = true
+		if prev, ok := old[g.Path]; ok {
+			changes = append(changes, DiffGoal(prev, g)...)
+		} else {
+			changes = append(changes, GoalChange{Kind: ChangeAdded, Path: g.Path, Title: g.Title})
+		}
+		return nil
+	})
+	WalkGoals(before, func(g *Goal, _ int) error {
+		if !seen[g.Path] {
+			changes = append(changes, GoalChange{Kind: ChangeRemoved, Path: g.Path, Title: g.Title})
+		}
+		return nil
+	})
+	return changes
+}
+
+// DiffGoal lists what changed between two versions of the same goal: its
+// title, status, horizon, then note bullets added and removed.
+func DiffGoal(before, after *Goal) []GoalChange {
+	var changes []GoalChange
+	change := func(kind, from, to string) {
+		if from != to {
+			changes = append(changes, GoalChange{Kind: kind, Path: after.Path, Title: after.Title, From: from, To: to})
+		}
+	}
+	change(ChangeTitle, before.Title, after.Title)
+	change(ChangeStatus, string(before.Status), string(after.Status))
+	change(ChangeHorizon, string(before.Horizon), string(after.Horizon))
+
+	added, removed := diffBullets(noteBullets(before.Body), noteBullets(after.Body))
+	for _, n := range added {
+		changes = append(changes, GoalChange{Kind: ChangeNoteAdded, Path: after.Path, Title: after.Title, Note: n})
+	}
+	for _, n := range removed {
+		changes = append(changes, GoalChange{Kind: ChangeNoteRemoved, Path: after.Path, Title: after.Title, Note: n})
+	}
+	return changes
+}
+
+// noteBullets returns the text of body's "- " and "* " list items.
+func noteBullets(body string) []string {
+	var bullets []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if text, ok := strings.CutPrefix(line, "- "); ok {
+			bullets = append(bullets, text)
+		} else if text, ok := strings.CutPrefix(line, "* "); ok {
+			bullets = append(bullets, text)
+		}
+	}
+	return bullets
+}
+
+// diffBullets returns the bullets only in after and only in before, in
+// their order there. Repeated bullets are matched one for one.
+func diffBullets(before, after []string) (added, removed []string) {
+	count := make(map[string]int)
+	for _, b := range before {
+		count[b]++
+	}
+	for _, b := range after {
+		if count[b] > 0 {
+			count[b]--
+		} else {
+			added = append(added, b)
+		}
+	}
+	for _, b := range before {
+		if count[b] > 0 {
+			count[b]--
+			removed = append(removed, b)
+		}
+	}
+	return added, removed
+}
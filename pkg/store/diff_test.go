@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffGoal(t *testing.T) {
+	parse := func(path, content string) *Goal {
+		t.Helper()
+		g, err := ParseFrontmatter(content)
+		require.NoError(t, err)
+		g.Path = path
+		return g
+	}
+
+	before := parse("otr", `---
+title: OTR
+status: incomplete
+horizon: today
+---
+## 2025-03-09
+- shipped beta
+- fixed crash
+- fixed crash
+`)
+	after := parse("otr", `---
+title: iOS OTR
+status: complete
+---
+## 2025-03-10
+- released
+
+## 2025-03-09
+- shipped beta
+- fixed crash
+`)
+
+	assert.Equal(t, []GoalChange{
+		{Kind: ChangeTitle, Path: "otr", Title: "iOS OTR", From: "OTR", To: "iOS OTR"},
+		{Kind: ChangeStatus, Path: "otr", Title: "iOS OTR", From: "incomplete", To: "complete"},
+		{Kind: ChangeHorizon, Path: "otr", Title: "iOS OTR", From: "today"},
+		{Kind: ChangeNoteAdded, Path: "otr", Title: "iOS OTR", Note: "released"},
+		{Kind: ChangeNoteRemoved, Path: "otr", Title: "iOS OTR", Note: "fixed crash"},
+	}, DiffGoal(before, after))
+
+	assert.Empty(t, DiffGoal(after, after))
+}
+
+func TestDiffTrees(t *testing.T) {
+	before := []*Goal{
+		{Path: "otr", Title: "otr", Status: StatusIncomplete, Children: []*Goal{
+			{Path: "otr/ios", Title: "ios", Status: StatusIncomplete},
+		}},
+		{Path: "old", Title: "old"},
+	}
+	after := []*Goal{
+		{Path: "new", Title: "new"},
+		{Path: "otr", Title: "otr", Status: StatusIncomplete, Children: []*Goal{
+			{Path: "otr/ios", Title: "ios", Status: StatusIncomplete, Body: "- note\n"},
+		}},
+	}
+	assert.Equal(t, []GoalChange{
+		{Kind: ChangeAdded, Path: "new", Title: "new"},
+		{Kind: ChangeNoteAdded, Path: "otr/ios", Title: "ios", Note: "note"},
+		{Kind: ChangeRemoved, Path: "old", Title: "old"},
+	}, DiffTrees(before, after))
+}
@@ -0,0 +1,35 @@
+package store
+
+// Problem is an issue cairn doctor found with a goal.
+type Problem struct {
+	Path    string `json:"path"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Diagnose checks goals and their descendants for values the rest of cairn
+// would otherwise skip over silently, such as unparsable estimates and
+// horizons that aren't configured, and goal files whose frontmatter doesn't
+// parse. horizons are the store's configured horizons.
+func Diagnose(goals []*Goal, horizons []Horizon) []Problem {
+	var problems []Problem
+	var walk func([]*Goal)
+	walk = func(goals []*Goal) {
+		for _, g := range goals {
+			if g.FrontmatterErr != nil {
+				problems = append(problems, Problem{Path: g.Path, Field: "frontmatter", Message: g.FrontmatterErr.Error()})
+			}
+			if _, err := g.EstimateDuration(); err != nil {
+				problems = append(problems, Problem{Path: g.Path, Field: "estimate", Message: err.Error()})
+			}
+			if g.Horizon != "" {
+				if _, err := ParseHorizon(string(g.Horizon), horizons); err != nil {
+					problems = append(problems, Problem{Path: g.Path, Field: "horizon", Message: err.Error()})
+				}
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+	return problems
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Work-time units for estimates: a day is a working day and a week is a
+// working week, so "1w" means five days of effort rather than seven.
+const (
+	WorkDay  = 8 * time.Hour
+	WorkWeek = 5 * WorkDay
+)
+
+var estimateUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': WorkDay,
+	'w': WorkWeek,
+}
+
+// ParseEstimate parses an effort estimate such as "90m", "2h", "1.5d" or
+// "1w2d". Units are m, h, d (8h) and w (5d).
+func ParseEstimate(s string) (time.Duration, error) {
+	rest := strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if rest == "" {
+		return 0, fmt.Errorf("invalid estimate: empty")
+	}
+
+	var total time.Duration
+	for rest != "" {
+		i := 0
+		for i < len(rest) && (rest[i] == '.' || (rest[i] >= '0' && rest[i] <= '9')) {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, fmt.Errorf("invalid estimate: %s (use a number and unit, e.g. 2h, 3d, 1w)", s)
+		}
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		unit, ok := estimateUnits[rest[i]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("invalid estimate: %s (units are m, h, d, w)", s)
+		}
+		total += time.Duration(n * float64(unit))
+		rest = rest[i+1:]
+	}
+	return total, nil
+}
+
+// FormatEstimate renders d in the largest work-time units, e.g. "1w 2d" or
+// "3h 30m". Zero is "0h".
+func FormatEstimate(d time.Duration) string {
+	if d <= 0 {
+		return "0h"
+	}
+	var parts []string
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{WorkWeek, "w"}, {WorkDay, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if n := d / u.unit; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.name))
+			d -= n * u.unit
+		}
+	}
+	if len(parts) == 0 {
+		return "0h"
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParsePoints parses an estimate given as a plain number, e.g. "3" or
+// "0.5", as story points. ok is false for anything else.
+func ParsePoints(s string) (points float64, ok bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// FormatPoints renders a points total, e.g. "1 pt" or "2.5 pts".
+func FormatPoints(p float64) string {
+	if p == 1 {
+		return "1 pt"
+	}
+	return strconv.FormatFloat(p, 'f', -1, 64) + " pts"
+}
+
+// EstimateDuration parses g's estimate. A goal without one, or whose
+// estimate is in points, is zero.
+func (g *Goal) EstimateDuration() (time.Duration, error) {
+	if g.Estimate == "" {
+		return 0, nil
+	}
+	if _, ok := ParsePoints(g.Estimate); ok {
+		return 0, nil
+	}
+	return ParseEstimate(g.Estimate)
+}
+
+// EstimatePoints returns g's estimate in points, zero unless it's a plain
+// number.
+func (g *Goal) EstimatePoints() float64 {
+	p, _ := ParsePoints(g.Estimate)
+	return p
+}
+
+// RemainingEstimate sums the estimates of g and its descendants that aren't
+// complete. Unparsable estimates count as zero; cairn doctor reports them.
+func RemainingEstimate(g *Goal) time.Duration {
+	var total time.Duration
+	if !g.IsComplete() {
+		if d, err := g.EstimateDuration(); err == nil {
+			total += d
+		}
+	}
+	for _, c := range g.Children {
+		total += RemainingEstimate(c)
+	}
+	return total
+}
+
+// RemainingPoints is RemainingEstimate for estimates given in points.
+func RemainingPoints(g *Goal) float64 {
+	var total float64
+	if !g.IsComplete() {
+		total += g.EstimatePoints()
+	}
+	for _, c := range g.Children {
+		total += RemainingPoints(c)
+	}
+	return total
+}
+
+// FormatRemaining renders what's left of g's subtree, time and points side
+// by side since they don't add up, e.g. "2d 4h" or "1d · 5 pts". It's ""
+// when nothing estimated is left.
+func FormatRemaining(g *Goal) string {
+	var parts []string
+	if d := RemainingEstimate(g); d > 0 {
+		parts = append(parts, FormatEstimate(d))
+	}
+	if p := RemainingPoints(g); p > 0 {
+		parts = append(parts, FormatPoints(p))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Effort totals estimated work: Total over every estimated goal and
+// Completed over the ones marked complete, with points estimates kept apart.
+type Effort struct {
+	Total           time.Duration
+	Completed       time.Duration
+	Points          float64
+	CompletedPoints float64
+}
+
+func (e *Effort) add(g *Goal) {
+	if d, err := g.EstimateDuration(); err == nil {
+		e.Total += d
+		if g.IsComplete() {
+			e.Completed += d
+		}
+	}
+	if p := g.EstimatePoints(); p > 0 {
+		e.Points += p
+		if g.IsComplete() {
+			e.CompletedPoints += p
+		}
+	}
+	for _, c := range g.Children {
+		e.add(c)
+	}
+}
+
+// EffortByHorizon totals the estimates under each horizon. Sub-goals count
+// toward their top-level goal's horizon, as in the TUI's sections; goals
+// without a horizon count in the last of horizons.
+func EffortByHorizon(goals []*Goal, horizons []Horizon) map[Horizon]Effort {
+	result := make(map[Horizon]Effort)
+	far := FarHorizon(horizons)
+	for _, g := range goals {
+		h := g.Horizon
+		if h == "" {
+			h = far
+		}
+		e := result[h]
+		e.add(g)
+		result[h] = e
+	}
+	return result
+}
+
+// SetEstimate sets a goal's effort estimate. An empty estimate clears it.
+func (s *Store) SetEstimate(goalPath, estimate string) (*Goal, error) {
+	return setEstimate(s, goalPath, estimate)
+}
+
+// SetEstimate sets a goal's effort estimate, like Store.SetEstimate.
+func (s *MemStore) SetEstimate(goalPath, estimate string) (*Goal, error) {
+	return setEstimate(s, goalPath, estimate)
+}
+
+func setEstimate(b Backend, goalPath, estimate string) (*Goal, error) {
+	goal, err := b.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := goal.SetField("estimate", estimate); err != nil {
+		return nil, err
+	}
+	if err := b.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	b.Commit("set " + goalPath + " estimate: " + goal.Estimate)
+	return goal, nil
+}
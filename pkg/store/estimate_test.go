@@ -0,0 +1,112 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEstimate(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"90m":   90 * time.Minute,
+		"2h":    2 * time.Hour,
+		"1.5d":  12 * time.Hour,
+		"3d":    24 * time.Hour,
+		"1w":    40 * time.Hour,
+		"1w2d":  56 * time.Hour,
+		"1d 4h": 12 * time.Hour,
+	} {
+		got, err := ParseEstimate(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "3", "h", "2y", "1..5h", "soon"} {
+		_, err := ParseEstimate(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestFormatEstimate(t *testing.T) {
+	assert.Equal(t, "0h", FormatEstimate(0))
+	assert.Equal(t, "3h 30m", FormatEstimate(210*time.Minute))
+	assert.Equal(t, "1w 2d", FormatEstimate(56*time.Hour))
+}
+
+func TestEstimateRollup(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "ship")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("ship", "api")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("ship", "docs")
+	require.NoError(t, err)
+	_, err = s.SetHorizon("ship", HorizonToday)
+	require.NoError(t, err)
+
+	_, err = s.SetEstimate("ship/api", "2d")
+	require.NoError(t, err)
+	_, err = s.SetEstimate("ship/docs", "4h")
+	require.NoError(t, err)
+	_, err = s.SetStatus("ship/docs", StatusComplete)
+	require.NoError(t, err)
+
+	_, err = s.SetEstimate("ship", "soon")
+	assert.ErrorContains(t, err, "invalid estimate")
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+	assert.Equal(t, 2*WorkDay, RemainingEstimate(goals[0]), "complete descendants don't count")
+
+	effort := EffortByHorizon(goals, DefaultHorizons)
+	assert.Equal(t, Effort{Total: 2*WorkDay + 4*time.Hour, Completed: 4 * time.Hour}, effort[HorizonToday])
+	assert.Empty(t, Diagnose(goals, DefaultHorizons))
+
+	// Hand-edited estimates that don't parse are reported, not zeroed silently
+	goals[0].Children[0].Estimate = "a while"
+	problems := Diagnose(goals, DefaultHorizons)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "ship/api", problems[0].Path)
+	assert.Equal(t, "estimate", problems[0].Field)
+}
+
+func TestEstimatePointsRollup(t *testing.T) {
+	s := setupTestStore(t)
+
+	for _, p := range [][2]string{{"", "ship"}, {"ship", "api"}, {"ship/api", "auth"}, {"ship", "docs"}} {
+		_, err := s.CreateGoal(p[0], p[1])
+		require.NoError(t, err)
+	}
+	for path, est := range map[string]string{"ship": "1", "ship/api": "3", "ship/api/auth": "2.5", "ship/docs": "4h"} {
+		_, err := s.SetEstimate(path, est)
+		require.NoError(t, err, path)
+	}
+	_, err := s.SetStatus("ship/api/auth", StatusComplete)
+	require.NoError(t, err)
+
+	p, ok := ParsePoints("2.5")
+	assert.True(t, ok)
+	assert.Equal(t, 2.5, p)
+	_, ok = ParsePoints("2h")
+	assert.False(t, ok)
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+	ship := goals[0]
+	assert.Equal(t, 4.0, RemainingPoints(ship), "own points plus incomplete descendants")
+	assert.Equal(t, 4*time.Hour, RemainingEstimate(ship), "points don't count as time")
+	assert.Equal(t, "4h · 4 pts", FormatRemaining(ship))
+	assert.Equal(t, "3 pts", FormatRemaining(FindGoal(goals, "ship/api")))
+	assert.Equal(t, "1 pt", FormatPoints(1))
+	assert.Empty(t, Diagnose(goals, DefaultHorizons))
+
+	effort := EffortByHorizon(goals, DefaultHorizons)[HorizonFuture]
+	assert.Equal(t, 6.5, effort.Points)
+	assert.Equal(t, 2.5, effort.CompletedPoints)
+	assert.Equal(t, 4*time.Hour, effort.Total)
+}
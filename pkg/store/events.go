@@ -0,0 +1,72 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RuntimeDir holds local state in the data directory that isn't goal data
+// (e.g. the event log). It is never staged or synced.
+const RuntimeDir = ".cairn"
+
+// Event types written to the event log.
+const (
+	EventCreate   = "create"
+	EventComplete = "complete"
+	EventMove     = "move"
+	EventDelete   = "delete"
+	EventNote     = "note"
+)
+
+// Event is one line of the event log.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Path string    `json:"path"`
+	To   string    `json:"to,omitempty"`   // new path, for moves
+	Text string    `json:"text,omitempty"` // note text
+}
+
+// EventsPath returns the event log in dataDir.
+func EventsPath(dataDir string) string {
+	return filepath.Join(dataDir, RuntimeDir, "events.jsonl")
+}
+
+// logEvent appends e to the event log when Options.EventLog is set. It's
+// best-effort: failures are ignored so logging never blocks the operation
+// being logged.
+func (s *Store) logEvent(e Event) {
+	if !s.opts.EventLog {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	path := EventsPath(s.Root)
+	if err := os.MkdirAll(filepath.Dir(path), s.opts.DirPerm); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// completedOnDisk reports whether the saved copy of the goal at goalPath is
+// complete, so SaveGoal can log the transition rather than every save.
+func (s *Store) completedOnDisk(goalPath string) bool {
+	data, err := os.ReadFile(s.GoalFile(goalPath))
+	if err != nil {
+		return false
+	}
+	g, err := s.parse(string(data))
+	return err == nil && g.IsComplete()
+}
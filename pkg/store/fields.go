@@ -0,0 +1,161 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoalFields lists the field names accepted by Goal.Field. "links" lists the
+// link keys; individual links are addressed as "links.<key>".
+var GoalFields = []string{"title", "summary", "status", "status_label", "horizon", "pinned", "icon", "color", "estimate", "created", "updated", "completed", "tags", "links", "links.<key>", "note_format", "note_section", "body"}
+
+// Field returns a single field as a string, for scripting.
+// Tags are comma-joined and times are RFC 3339.
+func (g *Goal) Field(name string) (string, error) {
+	if key, ok := strings.CutPrefix(name, "links."); ok {
+		url, found := g.Links[key]
+		if !found {
+			return "", fmt.Errorf("goal %s has no link %q", g.Path, key)
+		}
+		return url, nil
+	}
+
+	switch name {
+	case "title":
+		return g.Title, nil
+	case "summary":
+		return g.Summary, nil
+	case "status":
+		return string(g.Status), nil
+	case "status_label":
+		return g.StatusLabel, nil
+	case "horizon":
+		return string(g.Horizon), nil
+	case "pinned":
+		return strconv.FormatBool(g.Pinned), nil
+	case "icon":
+		return g.Icon, nil
+	case "color":
+		return g.Color, nil
+	case "estimate":
+		return g.Estimate, nil
+	case "created":
+		return formatFieldTime(g.Created), nil
+	case "updated":
+		return formatFieldTime(g.Updated), nil
+	case "completed":
+		return formatFieldTime(g.Completed), nil
+	case "tags":
+		return strings.Join(g.Tags, ","), nil
+	case "links":
+		keys := make([]string, 0, len(g.Links))
+		for k := range g.Links {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ","), nil
+	case "note_format":
+		return g.NoteFormat, nil
+	case "note_section":
+		return g.NoteSection, nil
+	case "body":
+		return g.Body, nil
+	}
+	return "", unknownField(name)
+}
+
+// SetField sets a writable field from its string form, validating enum
+// values. Horizons depend on the store's configuration, so callers check
+// them with ParseHorizon first. Tags are comma-separated; an empty link value removes the link.
+// created, updated and completed are managed by the store and can't be set.
+func (g *Goal) SetField(name, value string) error {
+	if key, ok := strings.CutPrefix(name, "links."); ok && key != "" {
+		if value == "" {
+			delete(g.Links, key)
+			return nil
+		}
+		if g.Links == nil {
+			g.Links = make(map[string]string)
+		}
+		g.Links[key] = value
+		return nil
+	}
+
+	switch name {
+	case "title":
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("title cannot be empty")
+		}
+		g.Title = value
+	case "summary":
+		// One line: newlines and runs of spaces collapse
+		g.Summary = strings.Join(strings.Fields(value), " ")
+	case "status":
+		st, err := ParseStatus(value)
+		if err != nil {
+			return err
+		}
+		g.Status = st
+	case "status_label":
+		g.StatusLabel = strings.TrimSpace(value)
+	case "horizon":
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("horizon cannot be empty")
+		}
+		g.Horizon = Horizon(value)
+	case "pinned":
+		pinned, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid pinned value: %s (use true or false)", value)
+		}
+		g.Pinned = pinned
+	case "icon":
+		g.Icon = strings.TrimSpace(value)
+	case "color":
+		g.Color = strings.TrimSpace(value)
+	case "estimate":
+		value = strings.TrimSpace(value)
+		if _, ok := ParsePoints(value); value != "" && !ok {
+			if _, err := ParseEstimate(value); err != nil {
+				return err
+			}
+		}
+		g.Estimate = value
+	case "tags":
+		g.Tags = nil
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				g.Tags = append(g.Tags, tag)
+			}
+		}
+	case "note_format":
+		value = strings.TrimSpace(value)
+		if value != "" && !strings.Contains(value, "{text}") {
+			return fmt.Errorf("invalid note_format %q: it must include {text}", value)
+		}
+		g.NoteFormat = value
+	case "note_section":
+		g.NoteSection = strings.TrimSpace(value)
+	case "body":
+		g.Body = value
+	case "created", "updated", "completed", "links":
+		return fmt.Errorf("field %s is read-only", name)
+	default:
+		return unknownField(name)
+	}
+	return nil
+}
+
+func unknownField(name string) error {
+	return fmt.Errorf("unknown field: %s (use %s)", name, strings.Join(GoalFields, ", "))
+}
+
+func formatFieldTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoalField(t *testing.T) {
+	g := &Goal{
+		Title:       "Ship it",
+		Status:      StatusInProgress,
+		StatusLabel: "in review",
+		Horizon:     HorizonToday,
+		Created:     time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		Tags:        []string{"work", "q1"},
+		Links:       map[string]string{"pr": "https://example.com/pr/1", "doc": "https://example.com/doc"},
+		Body:        "notes",
+	}
+
+	for field, want := range map[string]string{
+		"title":        "Ship it",
+		"status":       "in-progress",
+		"status_label": "in review",
+		"horizon":      "today",
+		"pinned":       "false",
+		"created":      "2025-03-01T09:00:00Z",
+		"updated":      "",
+		"tags":         "work,q1",
+		"links":        "doc,pr",
+		"links.pr":     "https://example.com/pr/1",
+		"body":         "notes",
+	} {
+		got, err := g.Field(field)
+		require.NoError(t, err, field)
+		assert.Equal(t, want, got, field)
+	}
+
+	_, err := g.Field("links.missing")
+	assert.Error(t, err)
+	_, err = g.Field("colour")
+	assert.ErrorContains(t, err, "unknown field")
+}
+
+func TestGoalSetField(t *testing.T) {
+	g := &Goal{Title: "t", Status: StatusIncomplete}
+
+	require.NoError(t, g.SetField("status", "complete"))
+	assert.Equal(t, StatusComplete, g.Status)
+	require.NoError(t, g.SetField("summary", "  ship the\nbeta  "))
+	assert.Equal(t, "ship the beta", g.Summary)
+	require.NoError(t, g.SetField("status_label", " deployed "))
+	assert.Equal(t, "deployed", g.StatusLabel)
+	require.NoError(t, g.SetField("horizon", "tomorrow"))
+	assert.Equal(t, HorizonTomorrow, g.Horizon)
+	require.NoError(t, g.SetField("pinned", "true"))
+	assert.True(t, g.Pinned)
+	require.NoError(t, g.SetField("tags", "a, b,,c"))
+	assert.Equal(t, []string{"a", "b", "c"}, g.Tags)
+	require.NoError(t, g.SetField("links.pr", "https://example.com"))
+	assert.Equal(t, "https://example.com", g.Links["pr"])
+	require.NoError(t, g.SetField("links.pr", ""))
+	assert.NotContains(t, g.Links, "pr")
+
+	assert.ErrorContains(t, g.SetField("status", "done"), "invalid status")
+	assert.ErrorContains(t, g.SetField("horizon", " "), "horizon cannot be empty")
+	assert.ErrorContains(t, g.SetField("pinned", "maybe"), "invalid pinned")
+	assert.ErrorContains(t, g.SetField("created", "2025-01-01"), "read-only")
+	assert.Error(t, g.SetField("title", "  "))
+	assert.Error(t, g.SetField("nope", "x"))
+	assert.Equal(t, StatusComplete, g.Status, "failed sets leave the goal untouched")
+}
@@ -1,7 +1,10 @@
 package store
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -9,9 +12,19 @@ import (
 
 const frontmatterDelimiter = "---"
 
+// ErrBadFrontmatter is returned when saving a goal loaded from a file whose
+// frontmatter doesn't parse, which would overwrite what's there.
+var ErrBadFrontmatter = errors.New("can't change a goal whose frontmatter doesn't parse; fix its file first")
+
 // ParseFrontmatter splits a markdown file into YAML frontmatter and body.
 // Returns the parsed Goal and any error.
 func ParseFrontmatter(content string) (*Goal, error) {
+	return parseGoal(content, nil)
+}
+
+// parseGoal is ParseFrontmatter for files whose frontmatter keys may be
+// renamed by aliases (see Options.FieldAliases).
+func parseGoal(content string, aliases map[string]string) (*Goal, error) {
 	content = strings.TrimSpace(content)
 
 	if !strings.HasPrefix(content, frontmatterDelimiter) {
@@ -30,18 +43,119 @@ func ParseFrontmatter(content string) (*Goal, error) {
 	body := rest[idx+len("\n"+frontmatterDelimiter):]
 	body = strings.TrimLeft(body, "\n")
 
+	goal, err := decodeGoal([]byte(yamlContent), aliases)
+	if err != nil {
+		return nil, err
+	}
+	goal.Body = body
+	return goal, nil
+}
+
+// parseGoalLenient is parseGoal for files that must stay reachable when
+// their frontmatter is broken: instead of failing it returns a goal with
+// the error in FrontmatterErr and, as Body, what follows the frontmatter,
+// or the whole file when the frontmatter never closes.
+func parseGoalLenient(content string, aliases map[string]string) *Goal {
+	g, err := parseGoal(content, aliases)
+	if err == nil {
+		return g
+	}
+	body := strings.TrimSpace(content)
+	if rest, ok := strings.CutPrefix(body, frontmatterDelimiter); ok {
+		if idx := strings.Index(rest, "\n"+frontmatterDelimiter); idx >= 0 {
+			body = strings.TrimLeft(rest[idx+len("\n"+frontmatterDelimiter):], "\n")
+		}
+	}
+	return &Goal{Status: StatusIncomplete, Body: body, FrontmatterErr: err}
+}
+
+// decodeGoal unmarshals frontmatter YAML, reading aliased keys under their
+// canonical names. Canonical names are accepted too, so files written
+// before an alias was configured still load.
+func decodeGoal(yamlContent []byte, aliases map[string]string) (*Goal, error) {
 	var goal Goal
-	if err := yaml.Unmarshal([]byte(yamlContent), &goal); err != nil {
-		return nil, fmt.Errorf("parsing frontmatter YAML: %w", err)
+	if len(aliases) == 0 {
+		if err := yaml.Unmarshal(yamlContent, &goal); err != nil {
+			return nil, fmt.Errorf("parsing frontmatter YAML: %w", err)
+		}
+		return &goal, nil
 	}
 
-	goal.Body = body
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlContent, &doc); err != nil {
+		return nil, fmt.Errorf("parsing frontmatter YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return &goal, nil
+	}
+	canonical := make(map[string]string, len(aliases))
+	for field, alias := range aliases {
+		canonical[alias] = field
+	}
+	renameKeys(doc.Content[0], canonical)
+	if err := doc.Content[0].Decode(&goal); err != nil {
+		return nil, fmt.Errorf("parsing frontmatter YAML: %w", err)
+	}
 	return &goal, nil
 }
 
+// renameKeys renames the top-level keys of a YAML mapping found in names.
+func renameKeys(mapping *yaml.Node, names map[string]string) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if name, ok := names[mapping.Content[i].Value]; ok {
+			mapping.Content[i].Value = name
+		}
+	}
+}
+
+// ReadFrontmatter parses only the frontmatter at the start of r, stopping at
+// the closing delimiter without reading the body. The Goal's Body is empty.
+func ReadFrontmatter(r io.Reader) (*Goal, error) {
+	return readGoalFrontmatter(r, nil)
+}
+
+func readGoalFrontmatter(r io.Reader, aliases map[string]string) (*Goal, error) {
+	scanner := bufio.NewScanner(r)
+	var yamlContent strings.Builder
+	opened := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !opened {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if strings.TrimRight(line, " \t") != frontmatterDelimiter {
+				return &Goal{}, nil // no frontmatter
+			}
+			opened = true
+			continue
+		}
+		if strings.HasPrefix(line, frontmatterDelimiter) {
+			return decodeGoal([]byte(yamlContent.String()), aliases)
+		}
+		yamlContent.WriteString(line)
+		yamlContent.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !opened {
+		return &Goal{}, nil
+	}
+	return nil, fmt.Errorf("unclosed frontmatter delimiter")
+}
+
 // SerializeFrontmatter renders a Goal back to markdown with YAML frontmatter.
 func SerializeFrontmatter(g *Goal) (string, error) {
-	yamlBytes, err := yaml.Marshal(g)
+	return serializeGoal(g, nil)
+}
+
+// serializeGoal is SerializeFrontmatter writing fields under their aliases.
+func serializeGoal(g *Goal, aliases map[string]string) (string, error) {
+	yamlBytes, err := encodeGoal(g, aliases)
 	if err != nil {
 		return "", fmt.Errorf("serializing frontmatter YAML: %w", err)
 	}
@@ -64,6 +178,18 @@ func SerializeFrontmatter(g *Goal) (string, error) {
 	return b.String(), nil
 }
 
+func encodeGoal(g *Goal, aliases map[string]string) ([]byte, error) {
+	if len(aliases) == 0 {
+		return yaml.Marshal(g)
+	}
+	var mapping yaml.Node
+	if err := mapping.Encode(g); err != nil {
+		return nil, err
+	}
+	renameKeys(&mapping, aliases)
+	return yaml.Marshal(&mapping)
+}
+
 // ParseQueue parses a queue.md file into a Queue struct.
 func ParseQueue(content string) (*Queue, error) {
 	content = strings.TrimSpace(content)
@@ -90,6 +216,10 @@ func ParseQueue(content string) (*Queue, error) {
 
 	// Parse numbered list
 	for _, line := range strings.Split(content, "\n") {
+		// Drop the title comment SerializeQueue appends
+		if i := strings.Index(line, "<!--"); i >= 0 {
+			line = line[:i]
+		}
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -118,8 +248,11 @@ func ParseQueue(content string) (*Queue, error) {
 	return &q, nil
 }
 
-// SerializeQueue renders a Queue back to markdown.
-func SerializeQueue(q *Queue) string {
+// SerializeQueue renders a Queue back to markdown. When title is non-nil,
+// each item whose title it resolves to something other than the item
+// itself is followed by the title in an HTML comment, for people editing
+// queue.md by hand; ParseQueue ignores it.
+func SerializeQueue(q *Queue, title func(item string) string) string {
 	var b strings.Builder
 	b.WriteString(frontmatterDelimiter)
 	b.WriteString("\n")
@@ -134,7 +267,13 @@ func SerializeQueue(q *Queue) string {
 	b.WriteString("\n\n")
 
 	for i, item := range q.Items {
-		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, item))
+		b.WriteString(fmt.Sprintf("%d. %s", i+1, item))
+		if title != nil {
+			if t := title(item); t != "" && t != item && !strings.Contains(t, "-->") {
+				b.WriteString("  <!-- " + t + " -->")
+			}
+		}
+		b.WriteString("\n")
 	}
 
 	return b.String()
@@ -131,12 +131,26 @@ func TestSerializeQueue(t *testing.T) {
 		Items:   []string{"otr", "infra-migration"},
 	}
 
-	content := SerializeQueue(q)
-	assert.Contains(t, content, "1. otr")
-	assert.Contains(t, content, "2. infra-migration")
+	content := SerializeQueue(q, nil)
+	assert.Contains(t, content, "1. otr\n")
+	assert.Contains(t, content, "2. infra-migration\n")
 
 	// Round-trip
 	parsed, err := ParseQueue(content)
 	require.NoError(t, err)
 	assert.Equal(t, q.Items, parsed.Items)
 }
+
+func TestSerializeQueueTitles(t *testing.T) {
+	q := &Queue{Items: []string{"otr", "infra", "gone"}}
+	titles := map[string]string{"otr": "iOS OTR", "infra": "infra"}
+
+	content := SerializeQueue(q, func(item string) string { return titles[item] })
+	assert.Contains(t, content, "1. otr  <!-- iOS OTR -->\n")
+	assert.Contains(t, content, "2. infra\n", "no comment when the title is the slug")
+	assert.Contains(t, content, "3. gone\n")
+
+	parsed, err := ParseQueue(content + "<!-- a note on its own line -->\n")
+	require.NoError(t, err)
+	assert.Equal(t, q.Items, parsed.Items)
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OpenAtRef loads the goal tree as it was at a git ref (commit, branch, tag,
+// or an expression like HEAD~3 or main@{1.week.ago}) of the data directory's
+// repo. The working tree is left alone: the goals are read from `git
+// archive` into a temporary directory that is removed before returning.
+// FilePath is cleared on the returned goals since the files don't exist.
+func OpenAtRef(dir, ref string) ([]*Goal, error) {
+	return openAtRef(dir, ref, Options{})
+}
+
+// AtRef loads the goal tree as it was at ref, like OpenAtRef, reading goal
+// files with the store's field aliases.
+func (s *Store) AtRef(ref string) ([]*Goal, error) {
+	return openAtRef(s.Root, ref, Options{FieldAliases: s.opts.FieldAliases, Layout: s.opts.Layout, AgeIdentity: s.opts.AgeIdentity})
+}
+
+// SinceRef resolves what `cairn diff --since` compares against. A date
+// (2006-01-02) is the last commit before that day began, in local time;
+// anything else is a ref. Without since it's the upstream branch, i.e. the
+// last sync. The ref is "" when the date predates every commit, meaning
+// everything is new.
+func (s *Store) SinceRef(since string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", errors.New("diffing goals needs git")
+	}
+	if since == "" {
+		out, err := exec.Command("git", "-C", s.Root, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Output()
+		if err != nil {
+			return "", errors.New("no upstream branch to compare with; pass --since <ref|date>")
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", since, time.Local)
+	if err != nil {
+		return since, nil
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", s.Root, "rev-list", "-1", "--before="+day.Format(time.RFC3339), "HEAD")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("finding the last commit before %s: %s", since, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func openAtRef(dir, ref string, opts Options) ([]*Goal, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("reading goals at a ref needs git")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "archive", "--format=tar", ref, "--", "goals")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("reading goals at %s: %s", ref, msg)
+	}
+
+	tmp, err := os.MkdirTemp("", "cairn-ref-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractTar(bytes.NewReader(out), tmp); err != nil {
+		return nil, fmt.Errorf("reading goals at %s: %w", ref, err)
+	}
+
+	opts.ReadOnly = true
+	s, err := NewStoreWithOptions(tmp, opts)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	var clear func([]*Goal)
+	clear = func(goals []*Goal) {
+		for _, g := range goals {
+			g.FilePath = ""
+			clear(g.Children)
+		}
+	}
+	clear(goals)
+	return goals, nil
+}
+
+// extractTar writes the directories and regular files of a tar stream
+// under dir.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("unexpected path %q in archive", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, DefaultDirPerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), DefaultDirPerm); err != nil {
+				return err
+			}
+			f, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
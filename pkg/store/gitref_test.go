@@ -0,0 +1,73 @@
+package store
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAtRefAndDiff(t *testing.T) {
+	s := setupGitStore(t)
+
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "old")
+	require.NoError(t, err)
+	out, err := exec.Command("git", "-C", s.Root, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	ref := strings.TrimSpace(string(out))
+
+	_, err = s.SetStatus(filepath.Join("otr", "ios"), StatusComplete)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteGoal("old"))
+	_, err = s.CreateGoal("", "new")
+	require.NoError(t, err)
+
+	before, err := OpenAtRef(s.Root, ref)
+	require.NoError(t, err)
+	require.Len(t, before, 2)
+	assert.Equal(t, "old", before[0].Slug)
+	assert.Equal(t, "otr", before[1].Slug)
+	require.Len(t, before[1].Children, 1)
+	assert.Equal(t, StatusIncomplete, before[1].Children[0].Status)
+	assert.Empty(t, before[1].Children[0].FilePath)
+
+	after, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Equal(t, []GoalChange{
+		{Kind: ChangeAdded, Path: "new", Title: "new"},
+		{Kind: ChangeStatus, Path: filepath.Join("otr", "ios"), Title: "ios", From: string(StatusIncomplete), To: string(StatusComplete)},
+		{Kind: ChangeRemoved, Path: "old", Title: "old"},
+	}, DiffTrees(before, after))
+
+	_, err = OpenAtRef(s.Root, "no-such-ref")
+	assert.Error(t, err)
+}
+
+func TestSinceRef(t *testing.T) {
+	s := setupGitStore(t)
+
+	_, err := s.SinceRef("")
+	assert.ErrorContains(t, err, "no upstream")
+
+	ref, err := s.SinceRef("HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, "HEAD~1", ref)
+
+	ref, err = s.SinceRef("2000-01-01")
+	require.NoError(t, err)
+	assert.Empty(t, ref, "nothing was committed before then")
+
+	head, err := exec.Command("git", "-C", s.Root, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+	ref, err = s.SinceRef(time.Now().AddDate(0, 0, 2).Format("2006-01-02"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(head)), ref)
+}
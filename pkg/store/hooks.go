@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// HooksDir is where hook scripts live in the data directory. A hook is an
+// executable named after the event it handles, e.g. .cairn/hooks/on-complete.
+// Like git's own hooks it's under RuntimeDir, which is never committed, so
+// a sync can't bring in scripts that then run here.
+const HooksDir = RuntimeDir + "/hooks"
+
+// HooksLogPath returns the file hook output is appended to in dataDir.
+func HooksLogPath(dataDir string) string {
+	return filepath.Join(dataDir, RuntimeDir, "hooks.log")
+}
+
+// HookError reports a hook that couldn't be run or exited non-zero. The
+// mutation that triggered it has already succeeded.
+type HookError struct {
+	Hook string // e.g. "on-complete"
+	Path string // goal the event was about
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("hook %s for %s: %v", e.Hook, e.Path, e.Err)
+}
+
+func (e *HookError) Unwrap() error { return e.Err }
+
+// OnHookError sets fn to be called, from the hook's goroutine, when a hook
+// fails. Set it before the first mutation.
+func (s *Store) OnHookError(fn func(error)) {
+	s.hookErr = fn
+}
+
+// WaitHooks blocks until every hook started so far has finished. Short-lived
+// callers like the CLI use it so hooks aren't killed when the process exits.
+func (s *Store) WaitHooks() {
+	s.hooks.Wait()
+}
+
+// OnEvent sets fn to be called with each event as it happens, whether or
+// not the event log is on. It's called from the mutating call.
+func (s *Store) OnEvent(fn func(Event)) {
+	s.onEvent = fn
+}
+
+// recordEvent logs e, runs its hook and hands it to OnEvent's callback.
+func (s *Store) recordEvent(e Event) {
+	e.Time = time.Now().UTC()
+	s.logEvent(e)
+	s.runHook(e)
+	if s.onEvent != nil {
+		s.onEvent(e)
+	}
+}
+
+// runHook starts HooksDir/on-<type> for e when Options.Hooks is set and the
+// script exists, without waiting for it. The goal is described to the
+// script through CAIRN_* environment variables; its output goes to
+// HooksLogPath.
+func (s *Store) runHook(e Event) {
+	if !s.opts.Hooks {
+		return
+	}
+	name := "on-" + e.Type
+	script := filepath.Join(s.Root, HooksDir, name)
+	info, err := os.Stat(script)
+	if err != nil || info.IsDir() {
+		return
+	}
+	if info.Mode()&0111 == 0 {
+		s.hookFailed(&HookError{Hook: name, Path: e.Path, Err: fmt.Errorf("%s is not executable", script)})
+		return
+	}
+
+	cmd := exec.Command(script)
+	cmd.Dir = s.Root
+	cmd.Env = append(os.Environ(), s.hookEnv(e)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		s.hookFailed(&HookError{Hook: name, Path: e.Path, Err: err})
+		return
+	}
+
+	s.hooks.Add(1)
+	go func() {
+		defer s.hooks.Done()
+		err := cmd.Wait()
+		s.logHookOutput(name, e.Path, err, out.Bytes())
+		if err != nil {
+			s.hookFailed(&HookError{Hook: name, Path: e.Path, Err: err})
+		}
+	}()
+}
+
+// hookEnv describes e's goal to a hook. Deleted goals only have a path.
+func (s *Store) hookEnv(e Event) []string {
+	path := e.Path
+	if e.To != "" {
+		path = e.To
+	}
+	env := []string{
+		"CAIRN_EVENT=" + e.Type,
+		"CAIRN_DIR=" + s.Root,
+		"CAIRN_GOAL_PATH=" + path,
+	}
+	if e.To != "" {
+		env = append(env, "CAIRN_GOAL_OLD_PATH="+e.Path)
+	}
+	if e.Text != "" {
+		env = append(env, "CAIRN_NOTE="+e.Text)
+	}
+	if data, err := os.ReadFile(s.GoalFile(path)); err == nil {
+		if g, err := s.parse(string(data)); err == nil {
+			env = append(env, "CAIRN_GOAL_TITLE="+g.Title, "CAIRN_STATUS="+string(g.Status))
+		}
+	}
+	return env
+}
+
+// logHookOutput appends a hook's result and output to HooksLogPath. Like
+// the event log it's best-effort.
+func (s *Store) logHookOutput(name, goalPath string, runErr error, output []byte) {
+	s.hookLogMu.Lock()
+	defer s.hookLogMu.Unlock()
+
+	path := HooksLogPath(s.Root)
+	if err := os.MkdirAll(filepath.Dir(path), s.opts.DirPerm); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	result := "ok"
+	if runErr != nil {
+		result = runErr.Error()
+	}
+	fmt.Fprintf(f, "%s %s %s: %s\n", time.Now().UTC().Format(time.RFC3339), name, goalPath, result)
+	if len(output) > 0 {
+		f.Write(output)
+		if output[len(output)-1] != '\n' {
+			f.Write([]byte{'\n'})
+		}
+	}
+}
+
+func (s *Store) hookFailed(err error) {
+	if s.hookErr != nil {
+		s.hookErr(err)
+	}
+}
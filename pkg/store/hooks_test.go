@@ -0,0 +1,113 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, HooksDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, HooksDir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestHooks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("needs sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "calls")
+	writeHook(t, dir, "on-complete", `echo "$CAIRN_EVENT $CAIRN_GOAL_PATH $CAIRN_GOAL_TITLE $CAIRN_STATUS" >> `+out+"\necho done\n")
+	writeHook(t, dir, "on-note", `echo "$CAIRN_EVENT $CAIRN_GOAL_PATH $CAIRN_NOTE" >> `+out+"\nexit 3\n")
+
+	s, err := NewStoreWithOptions(dir, Options{Hooks: true})
+	require.NoError(t, err)
+	var mu sync.Mutex
+	var failures []error
+	s.OnHookError(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err)
+	})
+
+	_, err = s.CreateGoal("", "otr") // no on-create hook: nothing runs
+	require.NoError(t, err)
+	_, err = s.SetStatus("otr", StatusComplete)
+	require.NoError(t, err)
+	s.WaitHooks()
+	_, err = s.AddNote("otr", "shipped")
+	require.NoError(t, err, "a failing hook doesn't fail the change")
+	s.WaitHooks()
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"complete otr otr complete", "note otr shipped"}, strings.Split(strings.TrimSpace(string(data)), "\n"))
+
+	require.Len(t, failures, 1)
+	var hookErr *HookError
+	require.True(t, errors.As(failures[0], &hookErr))
+	assert.Equal(t, "on-note", hookErr.Hook)
+	assert.Equal(t, "otr", hookErr.Path)
+
+	log, err := os.ReadFile(HooksLogPath(dir))
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "on-complete otr: ok\ndone\n")
+	assert.Contains(t, string(log), "on-note otr: exit status 3")
+}
+
+func TestHooksDisabled(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "calls")
+	writeHook(t, dir, "on-create", "touch "+out+"\n")
+
+	s, err := NewStore(dir)
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	s.WaitHooks()
+	assert.NoFileExists(t, out)
+}
+
+func TestSyncedHooksDontRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("needs sh")
+	}
+	remote := setupGitStore(t)
+	out := filepath.Join(t.TempDir(), "calls")
+	script := []byte("#!/bin/sh\necho \"$CAIRN_GOAL_PATH\" >> " + out + "\n")
+	// The other machine's hook, and a script someone committed where hooks
+	// used to live
+	writeHook(t, remote.Root, "on-create", "true\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(remote.Root, "hooks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(remote.Root, "hooks", "on-create"), script, 0755))
+	remote.Commit("add hooks")
+	files := committedFiles(t, remote.Root)
+	require.Contains(t, files, "hooks/on-create")
+	assert.NotContains(t, files, HooksDir+"/on-create", "local hooks are never committed")
+
+	dir := filepath.Join(t.TempDir(), "clone")
+	require.NoError(t, exec.Command("git", "clone", "-q", remote.Root, dir).Run())
+	s, err := NewStoreWithOptions(dir, Options{Hooks: true})
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "pulled")
+	require.NoError(t, err)
+	s.WaitHooks()
+	assert.NoFileExists(t, out, "a hook that came with a sync doesn't run")
+
+	// Copying it into HooksDir enables it on this machine
+	writeHook(t, dir, "on-create", string(script[len("#!/bin/sh\n"):]))
+	_, err = s.CreateGoal("", "enabled")
+	require.NoError(t, err)
+	s.WaitHooks()
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "enabled\n", string(data))
+}
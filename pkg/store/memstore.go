@@ -0,0 +1,506 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemStore is an in-memory Backend. It mirrors the filesystem Store's
+// semantics (ordering, move validation, status cycling) without touching disk,
+// which keeps TUI tests fast and deterministic. It is not safe for concurrent use.
+type MemStore struct {
+	goals    map[string]*Goal // keyed by goal path; stored without tree links
+	topOrder []string         // equivalent of goals/goal.md children_order
+	queue    *Queue
+	horizons []Horizon
+
+	// Commits records every commit message, oldest first.
+	Commits []string
+}
+
+// NewMemStore returns an empty in-memory store.
+func NewMemStore() *MemStore {
+	return &MemStore{
+		goals:    make(map[string]*Goal),
+		queue:    &Queue{},
+		horizons: DefaultHorizons,
+	}
+}
+
+// SetHorizons sets the horizons goals can be given, nearest first, as
+// Options.Horizons does for a Store; empty restores DefaultHorizons.
+func (s *MemStore) SetHorizons(hs []Horizon) {
+	if len(hs) == 0 {
+		hs = DefaultHorizons
+	}
+	s.horizons = slices.Clone(hs)
+}
+
+// Horizons returns the configured horizons, nearest first.
+func (s *MemStore) Horizons() []Horizon {
+	return slices.Clone(s.horizons)
+}
+
+// DataDir returns an empty path; a MemStore has no data directory.
+func (s *MemStore) DataDir() string {
+	return ""
+}
+
+// GoalsDir returns the virtual goals directory used to build FilePath values.
+func (s *MemStore) GoalsDir() string {
+	return "goals"
+}
+
+// Commit records the message in Commits.
+func (s *MemStore) Commit(message string) {
+	s.Commits = append(s.Commits, message)
+}
+
+// LoadQueue returns a copy of the queue.
+func (s *MemStore) LoadQueue() (*Queue, error) {
+	return &Queue{
+		Updated: s.queue.Updated,
+		Items:   append([]string(nil), s.queue.Items...),
+	}, nil
+}
+
+// SaveQueue replaces the queue.
+func (s *MemStore) SaveQueue(q *Queue) error {
+	q.Updated = time.Now()
+	s.queue = &Queue{
+		Updated: q.Updated,
+		Items:   append([]string(nil), q.Items...),
+	}
+	s.Commit("update queue")
+	return nil
+}
+
+// LoadGoal returns a copy of the goal at goalPath.
+func (s *MemStore) LoadGoal(goalPath string) (*Goal, error) {
+	g, ok := s.goals[goalPath]
+	if !ok {
+		return nil, fmt.Errorf("reading goal %s: %w", goalPath, os.ErrNotExist)
+	}
+	goal := cloneGoal(g)
+	goal.Slug = filepath.Base(goalPath)
+	goal.Path = goalPath
+	goal.FilePath = filepath.Join(s.GoalsDir(), goalPath, "goal.md")
+	goal.indexNotes()
+	return goal, nil
+}
+
+// LoadGoalTree builds the goal hierarchy from the stored goals.
+func (s *MemStore) LoadGoalTree() ([]*Goal, error) {
+	return s.buildTree("", nil), nil
+}
+
+func (s *MemStore) buildTree(parentPath string, parent *Goal) []*Goal {
+	order := s.topOrder
+	if parent != nil {
+		order = parent.ChildrenOrder
+	}
+
+	var goals []*Goal
+	for _, name := range mergeOrder(order, s.childNames(parentPath)) {
+		goal := s.loadOrStub(joinGoalPath(parentPath, name))
+		goal.Parent = parent
+		goal.Children = s.buildTree(goal.Path, goal)
+		goals = append(goals, goal)
+	}
+	return goals
+}
+
+// loadOrStub loads a goal, synthesizing a minimal one for intermediate paths
+// that have descendants but no goal of their own (like a directory without goal.md).
+func (s *MemStore) loadOrStub(goalPath string) *Goal {
+	if goal, err := s.LoadGoal(goalPath); err == nil {
+		return goal
+	}
+	return &Goal{
+		Title:  filepath.Base(goalPath),
+		Status: StatusIncomplete,
+		Slug:   filepath.Base(goalPath),
+		Path:   goalPath,
+	}
+}
+
+// childNames returns the sorted names of the direct children of parentPath.
+func (s *MemStore) childNames(parentPath string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for p := range s.goals {
+		rel := p
+		if parentPath != "" {
+			if !strings.HasPrefix(p, parentPath+string(filepath.Separator)) {
+				continue
+			}
+			rel = p[len(parentPath)+1:]
+		}
+		name := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exists reports whether goalPath is a stored goal or has stored descendants.
+func (s *MemStore) exists(goalPath string) bool {
+	if _, ok := s.goals[goalPath]; ok {
+		return true
+	}
+	prefix := goalPath + string(filepath.Separator)
+	for p := range s.goals {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveGoal stores a copy of the goal. Like Store.SaveGoal, a goal that
+// would be stored exactly as it already is isn't, and its updated time is
+// kept.
+func (s *MemStore) SaveGoal(g *Goal) error {
+	return s.saveGoal(g, false)
+}
+
+// ForceSaveGoal stores a copy of the goal like SaveGoal, but always,
+// refreshing its updated time even when nothing else changed.
+func (s *MemStore) ForceSaveGoal(g *Goal) error {
+	return s.saveGoal(g, true)
+}
+
+func (s *MemStore) saveGoal(g *Goal, force bool) error {
+	now := time.Now()
+	g.stampCompleted(now)
+	g.FilePath = filepath.Join(s.GoalsDir(), g.Path, "goal.md")
+	if old, ok := s.goals[g.Path]; ok && !force && sameContent(old, g) {
+		return nil
+	}
+	g.Updated = now
+	s.goals[g.Path] = cloneGoal(g)
+	return nil
+}
+
+// sameContent reports whether a and b hold the same goal, updated time
+// aside.
+func sameContent(a, b *Goal) bool {
+	ac, bc := cloneGoal(a), cloneGoal(b)
+	bc.Updated = ac.Updated
+	return reflect.DeepEqual(ac, bc)
+}
+
+// CreateGoal creates a new goal under the given parent path.
+func (s *MemStore) CreateGoal(parentPath, slug string) (*Goal, error) {
+	slug = normalizeSlug(slug)
+	goalPath := joinGoalPath(parentPath, slug)
+
+	if s.exists(goalPath) {
+		return nil, fmt.Errorf("goal %s already exists", goalPath)
+	}
+
+	now := time.Now()
+	goal := &Goal{
+		Title:   slug,
+		Status:  StatusIncomplete,
+		Horizon: FarHorizon(s.horizons),
+		Created: now,
+		Updated: now,
+		Slug:    slug,
+		Path:    goalPath,
+	}
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit("add goal: " + slug)
+	return goal, nil
+}
+
+// DeleteGoal removes a goal and all its descendants.
+func (s *MemStore) DeleteGoal(goalPath string) error {
+	if !s.exists(goalPath) {
+		return fmt.Errorf("goal %s not found", goalPath)
+	}
+	prefix := goalPath + string(filepath.Separator)
+	for p := range s.goals {
+		if p == goalPath || strings.HasPrefix(p, prefix) {
+			delete(s.goals, p)
+		}
+	}
+	s.Commit("remove goal: " + goalPath)
+	return nil
+}
+
+// ToggleStatus cycles a goal through incomplete → in-progress → complete → incomplete.
+func (s *MemStore) ToggleStatus(goalPath string) (*Goal, error) {
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	goal.Status = nextStatus(goal.Status)
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit("mark " + goalPath + " " + string(goal.Status))
+	return goal, nil
+}
+
+// SetStatus sets a goal's status directly.
+func (s *MemStore) SetStatus(goalPath string, status GoalStatus) (*Goal, error) {
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	goal.Status = status
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit("mark " + goalPath + " " + string(status))
+	return goal, nil
+}
+
+// SetHorizon sets the temporal horizon of a goal.
+func (s *MemStore) SetHorizon(goalPath string, horizon Horizon) (*Goal, error) {
+	if _, err := ParseHorizon(string(horizon), s.horizons); err != nil {
+		return nil, err
+	}
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	if goal.Horizon != horizon {
+		goal.Horizon = horizon
+		goal.HorizonSet = time.Now()
+	}
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit("set " + goalPath + " horizon: " + string(horizon))
+	return goal, nil
+}
+
+// TogglePin pins or unpins a goal.
+func (s *MemStore) TogglePin(goalPath string) (*Goal, error) {
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	goal.Pinned = !goal.Pinned
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit(pinMessage(goal))
+	return goal, nil
+}
+
+// AddNote appends a note entry to a goal's body.
+func (s *MemStore) AddNote(goalPath, text string) (*Goal, error) {
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+	if goal.Body, err = appendNote(goal.Body, text, time.Now(), NoteTemplateFor(goal, NoteTemplate{})); err != nil {
+		return nil, err
+	}
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit("note: " + goalPath)
+	return goal, nil
+}
+
+// MoveGoal moves a goal and its descendants to a new parent, rewriting queue
+// entries and clearing the horizon of goals that become nested, like Store.MoveGoal.
+func (s *MemStore) MoveGoal(goalPath, newParentPath string) error {
+	slug := filepath.Base(goalPath)
+	oldParentPath := parentOf(goalPath)
+
+	if newParentPath == goalPath || strings.HasPrefix(newParentPath, goalPath+string(filepath.Separator)) {
+		return fmt.Errorf("cannot move a goal into itself or a descendant")
+	}
+
+	newGoalPath := joinGoalPath(newParentPath, slug)
+	if s.exists(newGoalPath) {
+		return fmt.Errorf("goal %s already exists at destination", newGoalPath)
+	}
+	if newParentPath != "" && !s.exists(newParentPath) {
+		return fmt.Errorf("destination parent %s does not exist", newParentPath)
+	}
+
+	prefix := goalPath + string(filepath.Separator)
+	for p, g := range s.goals {
+		if p == goalPath || strings.HasPrefix(p, prefix) {
+			delete(s.goals, p)
+			np := newGoalPath + p[len(goalPath):]
+			g.Path = np
+			s.goals[np] = g
+		}
+	}
+
+	var oldOrder []string
+	for _, name := range s.siblingOrder(oldParentPath) {
+		if name != slug {
+			oldOrder = append(oldOrder, name)
+		}
+	}
+	s.setOrder(oldParentPath, oldOrder)
+
+	newOrder := s.siblingOrder(newParentPath)
+	found := false
+	for _, name := range newOrder {
+		if name == slug {
+			found = true
+		}
+	}
+	if !found {
+		newOrder = append(newOrder, slug)
+	}
+	s.setOrder(newParentPath, newOrder)
+
+	if oldParentPath == "" && newParentPath != "" {
+		if g := s.goals[newGoalPath]; g != nil {
+			g.Horizon = ""
+		}
+	}
+
+	if items, changed := rewriteQueuePaths(s.queue.Items, goalPath, newGoalPath); changed {
+		s.queue.Items = items
+	}
+
+	newGoalDisplay := newParentPath
+	if newParentPath == "" {
+		newGoalDisplay = "(root)"
+	}
+	s.Commit("move " + goalPath + " → " + newGoalDisplay)
+	return nil
+}
+
+// ReorderGoal swaps a goal with a sibling in the given direction.
+func (s *MemStore) ReorderGoal(goalPath string, delta int) error {
+	slug := filepath.Base(goalPath)
+	parentPath := parentOf(goalPath)
+
+	siblings := s.siblingOrder(parentPath)
+	idx := -1
+	for i, name := range siblings {
+		if name == slug {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return fmt.Errorf("goal %s not found among siblings", slug)
+	}
+
+	newIdx := idx + delta
+	if newIdx < 0 || newIdx >= len(siblings) {
+		return nil
+	}
+	siblings[idx], siblings[newIdx] = siblings[newIdx], siblings[idx]
+	s.setOrder(parentPath, siblings)
+	s.Commit("reorder: " + goalPath)
+	return nil
+}
+
+// MoveToIndex moves a goal to position index among its siblings, like
+// Store.MoveToIndex.
+func (s *MemStore) MoveToIndex(goalPath string, index int) error {
+	parentPath := parentOf(goalPath)
+	order, moved, err := moveInOrder(s.siblingOrder(parentPath), filepath.Base(goalPath), index)
+	if err != nil || !moved {
+		return err
+	}
+	s.setOrder(parentPath, order)
+	s.Commit("reorder: " + goalPath)
+	return nil
+}
+
+// SearchNotes searches across all goals for matching text, like
+// Store.SearchNotes.
+func (s *MemStore) SearchNotes(query string, opts SearchOptions) ([]*Goal, error) {
+	goals, err := s.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	return searchGoals(goals, query, opts), nil
+}
+
+// SetChildrenOrder replaces the child order of parentPath, like
+// Store.SetChildrenOrder.
+func (s *MemStore) SetChildrenOrder(parentPath string, order []string) error {
+	if parentPath != "" {
+		if _, ok := s.goals[parentPath]; !ok {
+			return fmt.Errorf("goal %s not found", parentPath)
+		}
+	}
+	s.setOrder(parentPath, order)
+	return nil
+}
+
+// siblingOrder returns the effective child order of parentPath.
+func (s *MemStore) siblingOrder(parentPath string) []string {
+	order := s.topOrder
+	if parentPath != "" {
+		if g, ok := s.goals[parentPath]; ok {
+			order = g.ChildrenOrder
+		} else {
+			order = nil
+		}
+	}
+	return append([]string(nil), mergeOrder(order, s.childNames(parentPath))...)
+}
+
+// setOrder persists the child order of parentPath, dropping entries for
+// children that don't exist.
+func (s *MemStore) setOrder(parentPath string, order []string) {
+	order = filterOrder(order, s.childNames(parentPath))
+	if parentPath == "" {
+		s.topOrder = order
+		return
+	}
+	goal := s.loadOrStub(parentPath)
+	goal.ChildrenOrder = order
+	s.SaveGoal(goal)
+}
+
+// cloneGoal returns a copy of g without tree links.
+func cloneGoal(g *Goal) *Goal {
+	c := *g
+	c.Tags = append([]string(nil), g.Tags...)
+	c.ChildrenOrder = append([]string(nil), g.ChildrenOrder...)
+	if g.Links != nil {
+		c.Links = make(map[string]string, len(g.Links))
+		for k, v := range g.Links {
+			c.Links[k] = v
+		}
+	}
+	c.Children = nil
+	c.Parent = nil
+	return &c
+}
+
+// parentOf returns the parent path of goalPath, or "" for top-level goals.
+func parentOf(goalPath string) string {
+	parent := filepath.Dir(goalPath)
+	if parent == "." {
+		return ""
+	}
+	return parent
+}
+
+// joinGoalPath joins a parent path and slug, treating "" as the root.
+func joinGoalPath(parentPath, slug string) string {
+	if parentPath == "" {
+		return slug
+	}
+	return filepath.Join(parentPath, slug)
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStoreTreeOrder(t *testing.T) {
+	s := NewMemStore()
+
+	for _, slug := range []string{"gamma", "alpha", "beta"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	_, err := s.CreateGoal("alpha", "child")
+	require.NoError(t, err)
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 3)
+	assert.Equal(t, "alpha", goals[0].Slug)
+	assert.Equal(t, "beta", goals[1].Slug)
+	assert.Equal(t, "gamma", goals[2].Slug)
+	require.Len(t, goals[0].Children, 1)
+	assert.Same(t, goals[0], goals[0].Children[0].Parent)
+
+	require.NoError(t, s.ReorderGoal("gamma", -1))
+	goals, err = s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Equal(t, "gamma", goals[1].Slug)
+
+	require.NoError(t, s.MoveToIndex("alpha", 2))
+	goals, err = s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Equal(t, "gamma", goals[0].Slug)
+	assert.Equal(t, "alpha", goals[2].Slug)
+}
+
+func TestMemStoreMoveGoal(t *testing.T) {
+	s := NewMemStore()
+
+	_, err := s.CreateGoal("", "alpha")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "beta")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("beta", "leaf")
+	require.NoError(t, err)
+
+	require.NoError(t, s.MoveGoal("beta", "alpha"))
+
+	_, err = s.LoadGoal(filepath.Join("alpha", "beta", "leaf"))
+	assert.NoError(t, err, "descendants move with the goal")
+	_, err = s.LoadGoal("beta")
+	assert.Error(t, err)
+
+	assert.Error(t, s.MoveGoal("alpha", filepath.Join("alpha", "beta")))
+	assert.Error(t, s.MoveGoal(filepath.Join("alpha", "beta"), "missing"))
+}
+
+func TestMemStoreMoveGoalRewritesQueueAndHorizon(t *testing.T) {
+	s := NewMemStore()
+
+	_, err := s.CreateGoal("", "alpha")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "beta")
+	require.NoError(t, err)
+	_, err = s.SetHorizon("beta", HorizonToday)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveQueue(&Queue{Items: []string{"beta"}}))
+
+	require.NoError(t, s.MoveGoal("beta", "alpha"))
+
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("alpha", "beta")}, q.Items)
+	moved, err := s.LoadGoal(filepath.Join("alpha", "beta"))
+	require.NoError(t, err)
+	assert.Empty(t, moved.Horizon)
+}
+
+func TestMemStoreLoadGoalReturnsCopy(t *testing.T) {
+	s := NewMemStore()
+
+	g, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+	g.Title = "mutated"
+
+	loaded, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, "task", loaded.Title, "callers can't mutate stored state without SaveGoal")
+	assert.Equal(t, []string{"add goal: task"}, s.Commits)
+}
+
+func TestMemStoreSaveGoalSkipsNoOp(t *testing.T) {
+	s := NewMemStore()
+	_, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+
+	g, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	g.Updated = time.Now().Add(-time.Hour)
+	s.goals["task"].Updated = g.Updated
+	before := g.Updated
+
+	require.NoError(t, s.SaveGoal(g))
+	assert.True(t, g.Updated.Equal(before), "an unchanged goal keeps its updated time, as in Store")
+
+	require.NoError(t, s.ForceSaveGoal(g))
+	assert.True(t, g.Updated.After(before), "a forced save refreshes it")
+	loaded, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.True(t, loaded.Updated.Equal(g.Updated))
+
+	g.Title = "renamed"
+	require.NoError(t, s.SaveGoal(g))
+	assert.True(t, g.Updated.After(before))
+	loaded, err = s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", loaded.Title)
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NormalizeResult reports what Normalize changed or would change.
+type NormalizeResult struct {
+	Changed  []string  `json:"changed"`  // goal paths whose goal file was rewritten
+	Problems []Problem `json:"problems"` // goals left alone because they don't parse
+}
+
+// Normalize rewrites every goal file in its canonical form: named for the
+// store's layout, frontmatter re-serialized with SerializeFrontmatter (and
+// the field aliases) and children_order cleaned of missing and duplicate
+// entries. Files that are already canonical are left
+// untouched, as are their updated timestamps. With dryRun nothing is written.
+func (s *Store) Normalize(dryRun bool) (*NormalizeResult, error) {
+	if !dryRun {
+		if err := s.writable(); err != nil {
+			return nil, err
+		}
+	}
+	result := &NormalizeResult{Changed: []string{}, Problems: []Problem{}}
+	if err := s.normalizeDir("", dryRun, result); err != nil {
+		return nil, err
+	}
+	if len(result.Changed) > 0 && !dryRun {
+		s.Commit(fmt.Sprintf("normalize %d goal(s)", len(result.Changed)))
+	}
+	return result, nil
+}
+
+// normalizeDir normalizes the goals under goalPath ("" for the top level).
+func (s *Store) normalizeDir(goalPath string, dryRun bool, result *NormalizeResult) error {
+	entries, err := os.ReadDir(filepath.Join(s.GoalsDir(), goalPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		child := joinGoalPath(goalPath, e.Name())
+		if err := s.normalizeGoal(child, dryRun, result); err != nil {
+			return err
+		}
+		if err := s.normalizeDir(child, dryRun, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) normalizeGoal(goalPath string, dryRun bool, result *NormalizeResult) error {
+	filePath := s.GoalFile(goalPath)
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil // a directory without a goal file has nothing to normalize
+	}
+	if err != nil {
+		return err
+	}
+	g, err := s.parse(string(data))
+	if err != nil {
+		result.Problems = append(result.Problems, Problem{Path: goalPath, Field: "frontmatter", Message: err.Error()})
+		return nil
+	}
+
+	names, err := s.childDirNames(goalPath)
+	if err != nil {
+		return err
+	}
+	g.ChildrenOrder = filterOrder(g.ChildrenOrder, names)
+	if len(g.ChildrenOrder) == 0 {
+		g.ChildrenOrder = nil
+	}
+
+	content, err := s.serialize(g)
+	if err != nil {
+		return fmt.Errorf("serializing goal %s: %w", goalPath, err)
+	}
+	canonicalName := goalFileName(s.opts.Layout, filepath.Base(goalPath))
+	if content == string(data) && filepath.Base(filePath) == canonicalName {
+		return nil
+	}
+	result.Changed = append(result.Changed, goalPath)
+	if dryRun {
+		return nil
+	}
+	_, err = s.writeGoalFile(goalPath, content, filePath)
+	return err
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "clean")
+	require.NoError(t, err)
+
+	// Hand edits: fields out of order, a stale and a duplicate children_order entry
+	otrFile := filepath.Join(s.GoalsDir(), "otr", "goal.md")
+	require.NoError(t, os.WriteFile(otrFile, []byte(`---
+status: incomplete
+title: OTR
+children_order: [gone, ios, ios]
+created: 2025-01-02T03:04:05Z
+updated: 2025-01-02T03:04:05Z
+---
+
+Notes.
+`), 0644))
+	brokenDir := filepath.Join(s.GoalsDir(), "broken")
+	require.NoError(t, os.MkdirAll(brokenDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "goal.md"), []byte("---\ntitle: [\n"), 0644))
+	cleanBefore, err := os.ReadFile(filepath.Join(s.GoalsDir(), "clean", "goal.md"))
+	require.NoError(t, err)
+
+	result, err := s.Normalize(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr"}, result.Changed)
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, "broken", result.Problems[0].Path)
+	data, err := os.ReadFile(otrFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "gone", "a dry run writes nothing")
+
+	result, err = s.Normalize(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr"}, result.Changed)
+
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "OTR", g.Title)
+	assert.Equal(t, []string{"ios"}, g.ChildrenOrder)
+	assert.Equal(t, "Notes.", g.Body)
+	assert.Equal(t, 2025, g.Updated.Year(), "normalizing doesn't count as an update")
+
+	cleanAfter, err := os.ReadFile(filepath.Join(s.GoalsDir(), "clean", "goal.md"))
+	require.NoError(t, err)
+	assert.Equal(t, string(cleanBefore), string(cleanAfter))
+
+	result, err = s.Normalize(false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changed, "normalizing is idempotent")
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NoteTemplate shapes the entries AddNote writes under a day's date header.
+type NoteTemplate struct {
+	// Format is the bullet's text, with {text} replaced by the note and
+	// {time} by when it was added (15:04). Empty means "{text}".
+	Format string
+	// Section files notes under a "### Section" heading inside the day,
+	// e.g. "Log" or "Decisions". Empty puts them right under the date.
+	Section string
+}
+
+// NoteTemplateFor is base with the goal's own note_format and
+// note_section, where set, taking precedence.
+func NoteTemplateFor(g *Goal, base NoteTemplate) NoteTemplate {
+	if g.NoteFormat != "" {
+		base.Format = g.NoteFormat
+	}
+	if g.NoteSection != "" {
+		base.Section = g.NoteSection
+	}
+	return base
+}
+
+// entry is the bullet line t makes of text added at now.
+func (t NoteTemplate) entry(text string, now time.Time) string {
+	format := t.Format
+	if format == "" {
+		format = "{text}"
+	}
+	return "- " + strings.NewReplacer("{time}", now.Format("15:04"), "{text}", text).Replace(format)
+}
+
+// Snippet is an empty entry for now, under t's section heading if it has
+// one, to type a note into by hand.
+func (t NoteTemplate) Snippet(now time.Time) string {
+	entry := t.entry("", now)
+	if t.Section == "" {
+		return entry
+	}
+	return sectionHeading(t.Section) + "\n" + entry
+}
+
+// DateHeader is the header a day's notes go under, e.g. "## 2006-01-02".
+func DateHeader(date string) string {
+	return "## " + date
+}
+
+// sectionHeading is the heading a note section goes under inside a day.
+func sectionHeading(section string) string {
+	return "### " + section
+}
+
+// noteBody is a goal body split at its date headers. Joining the preamble
+// and each section's header and lines gives back the body exactly.
+type noteBody struct {
+	preamble []string
+	sections []NoteSection
+}
+
+// NoteSection is one dated section of a goal's notes: a "## 2006-01-02"
+// header and the lines up to the next.
+type NoteSection struct {
+	Date   string
+	Header string
+	Lines  []string
+}
+
+// SplitNoteSections splits body into the text before the first date header
+// and the dated sections that follow, the way AddNote reads it: headers
+// inside fenced code blocks are text, not sections.
+func SplitNoteSections(body string) (preamble []string, sections []NoteSection) {
+	b := parseNoteBody(body)
+	return b.preamble, b.sections
+}
+
+// parseNoteBody splits body at its date headers. Headers inside fenced
+// code blocks are text, not sections.
+func parseNoteBody(body string) noteBody {
+	var b noteBody
+	var fence codeFence
+	for _, line := range strings.Split(body, "\n") {
+		if match := NoteDateHeader.FindStringSubmatch(line); match != nil && fence == "" {
+			b.sections = append(b.sections, NoteSection{Date: match[1], Header: line})
+			continue
+		}
+		fence.scan(line)
+		if len(b.sections) == 0 {
+			b.preamble = append(b.preamble, line)
+		} else {
+			last := &b.sections[len(b.sections)-1]
+			last.Lines = append(last.Lines, line)
+		}
+	}
+	return b
+}
+
+// codeFence is the ``` or ~~~ that opened the fenced code block the lines
+// read so far are in, or "" outside one.
+type codeFence string
+
+// scan moves f past line and reports whether line is part of a fenced
+// code block, including the fence lines themselves.
+func (f *codeFence) scan(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case *f == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+		*f = codeFence(trimmed[:3])
+		return true
+	case *f != "" && strings.HasPrefix(trimmed, string(*f)):
+		*f = ""
+		return true
+	}
+	return *f != ""
+}
+
+// String joins b back into a body.
+func (b noteBody) String() string {
+	lines := append([]string(nil), b.preamble...)
+	for _, sec := range b.sections {
+		lines = append(lines, sec.Header)
+		lines = append(lines, sec.Lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// add puts entry first in the day's section for date, under the ### section
+// heading when one is given, creating the day at the end of the body and
+// the heading at the end of the day as needed.
+func (b *noteBody) add(date, entry, section string) {
+	i := -1
+	for j, sec := range b.sections {
+		if sec.Date == date {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		// A blank line before the new day, none piling up after the last
+		b.trimTrailingBlanks()
+		if len(b.sections) > 0 || len(b.preamble) > 0 {
+			b.appendLine("")
+		}
+		b.sections = append(b.sections, NoteSection{Date: date, Header: DateHeader(date)})
+		i = len(b.sections) - 1
+	}
+	sec := &b.sections[i]
+
+	at := 0
+	if section != "" {
+		heading := sectionHeading(section)
+		at = -1
+		for j, line := range sec.Lines {
+			if strings.TrimSpace(line) == heading {
+				at = j + 1
+				break
+			}
+		}
+		if at < 0 {
+			end := len(sec.Lines)
+			for end > 0 && strings.TrimSpace(sec.Lines[end-1]) == "" {
+				end--
+			}
+			sec.Lines = sec.Lines[:end:end]
+			if end > 0 {
+				sec.Lines = append(sec.Lines, "")
+			}
+			sec.Lines = append(sec.Lines, heading, entry, "")
+			return
+		}
+	}
+	sec.Lines = append(sec.Lines[:at:at], append([]string{entry}, sec.Lines[at:]...)...)
+	if i == len(b.sections)-1 && (len(sec.Lines) == 0 || sec.Lines[len(sec.Lines)-1] != "") {
+		sec.Lines = append(sec.Lines, "") // end the body with a newline
+	}
+}
+
+// trimTrailingBlanks drops empty lines at the end of the body.
+func (b *noteBody) trimTrailingBlanks() {
+	if n := len(b.sections); n > 0 {
+		sec := &b.sections[n-1]
+		for len(sec.Lines) > 0 && strings.TrimSpace(sec.Lines[len(sec.Lines)-1]) == "" {
+			sec.Lines = sec.Lines[:len(sec.Lines)-1]
+		}
+		return
+	}
+	for len(b.preamble) > 0 && strings.TrimSpace(b.preamble[len(b.preamble)-1]) == "" {
+		b.preamble = b.preamble[:len(b.preamble)-1]
+	}
+}
+
+// appendLine adds line at the end of the body.
+func (b *noteBody) appendLine(line string) {
+	if n := len(b.sections); n > 0 {
+		b.sections[n-1].Lines = append(b.sections[n-1].Lines, line)
+	} else {
+		b.preamble = append(b.preamble, line)
+	}
+}
+
+// NoteDay returns the text under body's "## date" header, without the
+// header or the blank lines ending it, and whether body has that day.
+func NoteDay(body, date string) (string, bool) {
+	b := parseNoteBody(body)
+	for _, sec := range b.sections {
+		if sec.Date == date {
+			return strings.Join(trimBlankTail(sec.Lines), "\n"), true
+		}
+	}
+	return "", false
+}
+
+// SetNoteDay replaces the text under date's header with text, adding the
+// day at the end of body when it's missing and dropping it when text is
+// blank. The rest of body is kept as it was, except that it ends with a
+// newline when the day is last.
+func SetNoteDay(body, date, text string) string {
+	lines := trimBlankTail(strings.Split(text, "\n"))
+	b := parseNoteBody(body)
+	for i := range b.sections {
+		if b.sections[i].Date != date {
+			continue
+		}
+		if len(lines) == 0 {
+			b.sections = append(b.sections[:i], b.sections[i+1:]...)
+		} else {
+			b.sections[i].Lines = append(lines, "")
+		}
+		return b.String()
+	}
+	if len(lines) == 0 {
+		return body
+	}
+	b.trimTrailingBlanks()
+	if len(b.sections) > 0 || len(b.preamble) > 0 {
+		b.appendLine("")
+	}
+	b.sections = append(b.sections, NoteSection{Date: date, Header: DateHeader(date), Lines: append(lines, "")})
+	return b.String()
+}
+
+// trimBlankTail returns lines without the blank lines at its end.
+func trimBlankTail(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end:end]
+}
+
+// appendNote adds text to body as t's entry for now under the day's date
+// header, which is created at the end of body if it doesn't exist yet.
+func appendNote(body, text string, now time.Time, t NoteTemplate) (string, error) {
+	if t.Format != "" && !strings.Contains(t.Format, "{text}") {
+		return "", fmt.Errorf("invalid note format %q: it must include {text}", t.Format)
+	}
+	b := parseNoteBody(body)
+	b.add(now.Format("2006-01-02"), t.entry(text, now), t.Section)
+	return b.String(), nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendNote(t *testing.T) {
+	now := time.Date(2025, 3, 10, 14, 32, 0, 0, time.Local)
+	add := func(body string, tmpl NoteTemplate) string {
+		t.Helper()
+		out, err := appendNote(body, "shipped", now, tmpl)
+		require.NoError(t, err)
+		return out
+	}
+
+	for name, tc := range map[string]struct{ body, want string }{
+		"empty body": {
+			"",
+			"## 2025-03-10\n- shipped\n",
+		},
+		"preamble": {
+			"# Plan\n\n",
+			"# Plan\n\n## 2025-03-10\n- shipped\n",
+		},
+		"new day after the last": {
+			"## 2025-03-09\n- planned\n",
+			"## 2025-03-09\n- planned\n\n## 2025-03-10\n- shipped\n",
+		},
+		"existing day, newest first": {
+			"## 2025-03-10\n- planned\n\n## 2025-03-09\n- older\n",
+			"## 2025-03-10\n- shipped\n- planned\n\n## 2025-03-09\n- older\n",
+		},
+		"header as the last line": {
+			"intro\n## 2025-03-10",
+			"intro\n## 2025-03-10\n- shipped\n",
+		},
+		"header inside a code block": {
+			"```\n## 2025-03-10\n```\n",
+			"```\n## 2025-03-10\n```\n\n## 2025-03-10\n- shipped\n",
+		},
+	} {
+		assert.Equal(t, tc.want, add(tc.body, NoteTemplate{}), name)
+	}
+
+	assert.Equal(t, "## 2025-03-10\n- 14:32 shipped\n", add("", NoteTemplate{Format: "{time} {text}"}))
+
+	log := NoteTemplate{Section: "Log"}
+	assert.Equal(t, "## 2025-03-10\n### Log\n- shipped\n", add("", log))
+	assert.Equal(t, "## 2025-03-10\n- planned\n\n### Log\n- shipped\n\n## 2025-03-09\n",
+		add("## 2025-03-10\n- planned\n\n## 2025-03-09\n", log), "the heading goes at the end of the day")
+	assert.Equal(t, "## 2025-03-10\n### Decisions\n- a\n### Log\n- shipped\n- b\n",
+		add("## 2025-03-10\n### Decisions\n- a\n### Log\n- b\n", log))
+
+	_, err := appendNote("", "lost", now, NoteTemplate{Format: "{time}"})
+	assert.ErrorContains(t, err, "{text}")
+}
+
+func TestAddNoteUsesGoalTemplate(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{NoteTemplate: NoteTemplate{Section: "Log"}})
+	require.NoError(t, err)
+	g, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	g.NoteFormat = "{time} {text}"
+	require.NoError(t, s.SaveGoal(g))
+
+	g, err = s.AddNote("otr", "shipped")
+	require.NoError(t, err)
+	assert.Regexp(t, `### Log\n- \d\d:\d\d shipped\n`, g.Body)
+
+	g, err = s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.NoteCount, "notes under a heading still count for the day")
+}
+
+func TestSetNoteDay(t *testing.T) {
+	day := "2025-03-10"
+	text, ok := NoteDay("## 2025-03-10\n- planned\n\n## 2025-03-09\n- older\n", day)
+	assert.True(t, ok)
+	assert.Equal(t, "- planned", text)
+	_, ok = NoteDay("## 2025-03-09\n- older\n", day)
+	assert.False(t, ok)
+
+	for name, tc := range map[string]struct{ body, text, want string }{
+		"replace a day before another": {
+			"## 2025-03-10\n- planed\n\n## 2025-03-09\n- older\n",
+			"- planned\n",
+			"## 2025-03-10\n- planned\n\n## 2025-03-09\n- older\n",
+		},
+		"replace the last day without a trailing newline": {
+			"## 2025-03-09\n- older\n\n## 2025-03-10\n- planed",
+			"- planned",
+			"## 2025-03-09\n- older\n\n## 2025-03-10\n- planned\n",
+		},
+		"missing day is added at the end": {
+			"# Plan\n\n## 2025-03-09\n- older",
+			"- planned\n\n",
+			"# Plan\n\n## 2025-03-09\n- older\n\n## 2025-03-10\n- planned\n",
+		},
+		"missing day in an empty body": {
+			"",
+			"- planned\n",
+			"## 2025-03-10\n- planned\n",
+		},
+		"blank text drops the day": {
+			"## 2025-03-09\n- older\n\n## 2025-03-10\n- planned\n",
+			"\n",
+			"## 2025-03-09\n- older\n",
+		},
+		"blank text for a missing day changes nothing": {
+			"## 2025-03-09\n- older",
+			"",
+			"## 2025-03-09\n- older",
+		},
+		"a header in a code block isn't the day": {
+			"```\n## 2025-03-10\n```\n",
+			"- planned",
+			"```\n## 2025-03-10\n```\n\n## 2025-03-10\n- planned\n",
+		},
+	} {
+		assert.Equal(t, tc.want, SetNoteDay(tc.body, day, tc.text), name)
+	}
+}
+
+func TestSplitNoteSections(t *testing.T) {
+	body := "Intro\n\n## 2025-03-01\n- first\n```\n## 2025-03-02\n```\n\n## 2025-03-03\n- third\n"
+	preamble, sections := SplitNoteSections(body)
+	assert.Equal(t, []string{"Intro", ""}, preamble)
+	require.Len(t, sections, 2, "a header in a code block isn't a section")
+	assert.Equal(t, NoteSection{
+		Date:   "2025-03-01",
+		Header: "## 2025-03-01",
+		Lines:  []string{"- first", "```", "## 2025-03-02", "```", ""},
+	}, sections[0])
+	assert.Equal(t, "2025-03-03", sections[1].Date)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"slices"
+	"time"
+)
+
+// Orphans are goals that are easy to lose track of.
+type Orphans struct {
+	// Unqueued are unfinished top-level goals that aren't in the queue.
+	Unqueued []*Goal
+	// Stale are unfinished goals in the far horizon (FUTURE by default) that
+	// haven't been updated for more than the threshold, in tree order.
+	Stale []*Goal
+}
+
+// FindOrphans returns the unfinished top-level goals missing from q and the
+// goals at any depth in the last of horizons untouched for more than
+// staleDays as of now.
+func FindOrphans(goals []*Goal, q *Queue, horizons []Horizon, now time.Time, staleDays int) Orphans {
+	var o Orphans
+	far := FarHorizon(horizons)
+	for _, g := range goals {
+		if !g.IsComplete() && !slices.Contains(q.Items, g.Path) {
+			o.Unqueued = append(o.Unqueued, g)
+		}
+	}
+	WalkGoals(goals, func(g *Goal, _ int) error {
+		if g.Horizon == far && !g.IsComplete() && DaysUntouched(g, now) > staleDays {
+			o.Stale = append(o.Stale, g)
+		}
+		return nil
+	})
+	return o
+}
+
+// Orphans returns the goals cairn orphans reports, see FindOrphans.
+func (s *Store) Orphans(staleDays int) (Orphans, error) {
+	return orphans(s, time.Now(), staleDays)
+}
+
+// Orphans returns the goals cairn orphans reports, like Store.Orphans.
+func (s *MemStore) Orphans(staleDays int) (Orphans, error) {
+	return orphans(s, time.Now(), staleDays)
+}
+
+func orphans(b Backend, now time.Time, staleDays int) (Orphans, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return Orphans{}, err
+	}
+	q, err := b.LoadQueue()
+	if err != nil {
+		return Orphans{}, err
+	}
+	return FindOrphans(goals, q, b.Horizons(), now, staleDays), nil
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOrphans(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	ago := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	goals := []*Goal{
+		{Path: "otr", Horizon: HorizonFuture, Updated: ago(90), Children: []*Goal{
+			{Path: "otr/ios", Horizon: HorizonFuture, Updated: ago(40)},
+			{Path: "otr/web", Updated: ago(40)},
+		}},
+		{Path: "infra", Horizon: HorizonToday, Updated: ago(90)},
+		{Path: "learn", Horizon: HorizonFuture, Updated: ago(10)},
+		{Path: "done", Status: StatusComplete, Horizon: HorizonFuture, Updated: ago(90)},
+	}
+	q := &Queue{Items: []string{"otr"}}
+
+	o := FindOrphans(goals, q, DefaultHorizons, now, 30)
+	paths := func(goals []*Goal) (p []string) {
+		for _, g := range goals {
+			p = append(p, g.Path)
+		}
+		return p
+	}
+	assert.Equal(t, []string{"infra", "learn"}, paths(o.Unqueued), "queued and finished goals aren't orphans")
+	assert.Equal(t, []string{"otr", "otr/ios"}, paths(o.Stale), "only FUTURE goals go stale")
+
+	assert.Empty(t, FindOrphans(goals, q, DefaultHorizons, now, 100).Stale)
+}
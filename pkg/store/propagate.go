@@ -0,0 +1,133 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PropagateStatus applies a goal's current status to its ancestors.
+//
+// An in-progress goal marks every incomplete ancestor in-progress; completed
+// ancestors are never touched. When a goal is complete and it was the last
+// incomplete child of its parent, the parent is returned so the caller can
+// suggest completing it. Otherwise the returned goal is nil.
+func (s *Store) PropagateStatus(goalPath string) (*Goal, error) {
+	return propagateStatus(s, goalPath)
+}
+
+// PropagateStatus applies a goal's current status to its ancestors, like
+// Store.PropagateStatus.
+func (s *MemStore) PropagateStatus(goalPath string) (*Goal, error) {
+	return propagateStatus(s, goalPath)
+}
+
+func propagateStatus(b Backend, goalPath string) (*Goal, error) {
+	goal, err := b.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch goal.Status {
+	case StatusInProgress:
+		var started []string
+		for p := parentOf(goalPath); p != ""; p = parentOf(p) {
+			ancestor, err := b.LoadGoal(p)
+			if err != nil || ancestor.Status != StatusIncomplete {
+				continue
+			}
+			ancestor.Status = StatusInProgress
+			if err := b.SaveGoal(ancestor); err != nil {
+				return nil, err
+			}
+			started = append(started, p)
+		}
+		if len(started) > 0 {
+			b.Commit("mark " + strings.Join(started, ", ") + " " + string(StatusInProgress))
+		}
+
+	case StatusComplete:
+		parentPath := parentOf(goalPath)
+		if parentPath == "" {
+			return nil, nil
+		}
+		goals, err := b.LoadGoalTree()
+		if err != nil {
+			return nil, err
+		}
+		parent := FindGoal(goals, parentPath)
+		if parent == nil || parent.IsComplete() {
+			return nil, nil
+		}
+		for _, child := range parent.Children {
+			if !child.IsComplete() {
+				return nil, nil
+			}
+		}
+		return parent, nil
+	}
+	return nil, nil
+}
+
+// FindGoal returns the goal at goalPath within a loaded tree, or nil.
+func FindGoal(goals []*Goal, goalPath string) *Goal {
+	for _, g := range goals {
+		if g.Path == goalPath {
+			return g
+		}
+		if found := FindGoal(g.Children, goalPath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// OpenDescendants returns the paths of g's descendants that aren't
+// complete, in tree order.
+func OpenDescendants(g *Goal) []string {
+	var open []string
+	for _, c := range g.Children {
+		if !c.IsComplete() {
+			open = append(open, c.Path)
+		}
+		open = append(open, OpenDescendants(c)...)
+	}
+	return open
+}
+
+// CompleteDescendants marks every descendant of goalPath that isn't complete
+// as complete, in a single commit, and returns their paths.
+func (s *Store) CompleteDescendants(goalPath string) ([]string, error) {
+	return completeDescendants(s, goalPath)
+}
+
+// CompleteDescendants marks every open descendant of goalPath complete,
+// like Store.CompleteDescendants.
+func (s *MemStore) CompleteDescendants(goalPath string) ([]string, error) {
+	return completeDescendants(s, goalPath)
+}
+
+func completeDescendants(b Backend, goalPath string) ([]string, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	root := FindGoal(goals, goalPath)
+	if root == nil {
+		return nil, fmt.Errorf("goal %s not found", goalPath)
+	}
+	open := OpenDescendants(root)
+	for _, p := range open {
+		g, err := b.LoadGoal(p)
+		if err != nil {
+			return nil, err
+		}
+		g.Status = StatusComplete
+		if err := b.SaveGoal(g); err != nil {
+			return nil, err
+		}
+	}
+	if len(open) > 0 {
+		b.Commit(fmt.Sprintf("complete %d sub-goal(s) of %s", len(open), goalPath))
+	}
+	return open, nil
+}
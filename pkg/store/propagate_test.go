@@ -0,0 +1,93 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagateStatusStartsAncestors(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "project")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("project", "epic")
+	require.NoError(t, err)
+	_, err = s.CreateGoal(filepath.Join("project", "epic"), "task")
+	require.NoError(t, err)
+	_, err = s.SetStatus("project", StatusComplete)
+	require.NoError(t, err)
+
+	task := filepath.Join("project", "epic", "task")
+	_, err = s.SetStatus(task, StatusInProgress)
+	require.NoError(t, err)
+	parent, err := s.PropagateStatus(task)
+	require.NoError(t, err)
+	assert.Nil(t, parent)
+
+	epic, err := s.LoadGoal(filepath.Join("project", "epic"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusInProgress, epic.Status)
+	project, err := s.LoadGoal("project")
+	require.NoError(t, err)
+	assert.Equal(t, StatusComplete, project.Status, "completed ancestors are never downgraded")
+}
+
+func TestPropagateStatusSuggestsCompletingParent(t *testing.T) {
+	s := NewMemStore()
+
+	_, err := s.CreateGoal("", "project")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("project", "a")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("project", "b")
+	require.NoError(t, err)
+
+	_, err = s.SetStatus(filepath.Join("project", "a"), StatusComplete)
+	require.NoError(t, err)
+	parent, err := s.PropagateStatus(filepath.Join("project", "a"))
+	require.NoError(t, err)
+	assert.Nil(t, parent, "b is still incomplete")
+
+	_, err = s.SetStatus(filepath.Join("project", "b"), StatusComplete)
+	require.NoError(t, err)
+	parent, err = s.PropagateStatus(filepath.Join("project", "b"))
+	require.NoError(t, err)
+	require.NotNil(t, parent)
+	assert.Equal(t, "project", parent.Path)
+
+	_, err = s.SetStatus("project", StatusComplete)
+	require.NoError(t, err)
+	parent, err = s.PropagateStatus(filepath.Join("project", "b"))
+	require.NoError(t, err)
+	assert.Nil(t, parent, "no suggestion once the parent is already complete")
+}
+
+func TestCompleteDescendants(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "project")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("project", "a")
+	require.NoError(t, err)
+	_, err = s.CreateGoal(filepath.Join("project", "a"), "deep")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("project", "b")
+	require.NoError(t, err)
+	_, err = s.SetStatus(filepath.Join("project", "b"), StatusComplete)
+	require.NoError(t, err)
+
+	done, err := s.CompleteDescendants("project")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("project", "a"), filepath.Join("project", "a", "deep")}, done)
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Empty(t, OpenDescendants(goals[0]))
+	assert.False(t, goals[0].IsComplete(), "the goal itself is left to the caller")
+
+	_, err = s.CompleteDescendants("missing")
+	assert.Error(t, err)
+}
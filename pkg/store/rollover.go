@@ -0,0 +1,71 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// RolloverHorizons promotes tomorrow goals to today once the day their
+// horizon was set has passed. Completed goals are left alone. It returns the
+// number of goals promoted.
+func (s *Store) RolloverHorizons(now time.Time) (int, error) {
+	return rolloverHorizons(s, now)
+}
+
+// RolloverHorizons promotes stale tomorrow goals to today, like
+// Store.RolloverHorizons.
+func (s *MemStore) RolloverHorizons(now time.Time) (int, error) {
+	return rolloverHorizons(s, now)
+}
+
+func rolloverHorizons(b Backend, now time.Time) (int, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return 0, err
+	}
+
+	today := startOfDay(now)
+	moved := 0
+	var walk func([]*Goal) error
+	walk = func(goals []*Goal) error {
+		for _, g := range goals {
+			if g.Horizon == HorizonTomorrow && !g.IsComplete() && startOfDay(horizonSetAt(g).In(now.Location())).Before(today) {
+				goal, err := b.LoadGoal(g.Path)
+				if err != nil {
+					return err
+				}
+				goal.Horizon = HorizonToday
+				goal.HorizonSet = now
+				if err := b.SaveGoal(goal); err != nil {
+					return err
+				}
+				moved++
+			}
+			if err := walk(g.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = walk(goals)
+	if moved > 0 {
+		b.Commit(fmt.Sprintf("rollover: %d tomorrow → today", moved))
+	}
+	return moved, err
+}
+
+// horizonSetAt returns when the goal's horizon was last set. Goals written
+// before horizon_set existed fall back to their last update, which is never
+// earlier than the real time.
+func horizonSetAt(g *Goal) time.Time {
+	if !g.HorizonSet.IsZero() {
+		return g.HorizonSet
+	}
+	return g.Updated
+}
+
+// startOfDay truncates t to midnight in its own location.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
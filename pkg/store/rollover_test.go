@@ -0,0 +1,63 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolloverHorizons(t *testing.T) {
+	s := setupTestStore(t)
+
+	for _, slug := range []string{"stale", "fresh", "done", "later"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for _, slug := range []string{"stale", "done"} {
+		g, err := s.LoadGoal(slug)
+		require.NoError(t, err)
+		g.Horizon = HorizonTomorrow
+		g.HorizonSet = yesterday
+		require.NoError(t, s.SaveGoal(g))
+	}
+	_, err := s.SetStatus("done", StatusComplete)
+	require.NoError(t, err)
+	_, err = s.SetHorizon("fresh", HorizonTomorrow)
+	require.NoError(t, err)
+
+	n, err := s.RolloverHorizons(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	for slug, want := range map[string]Horizon{
+		"stale": HorizonToday,
+		"fresh": HorizonTomorrow, // set today, so it's still tomorrow's work
+		"done":  HorizonTomorrow, // completed goals are never touched
+		"later": HorizonFuture,
+	} {
+		g, err := s.LoadGoal(slug)
+		require.NoError(t, err)
+		assert.Equal(t, want, g.Horizon, slug)
+	}
+
+	n, err = s.RolloverHorizons(time.Now().AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "fresh rolls over the next day")
+}
+
+func TestSetHorizonRecordsTime(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+	before := time.Now()
+	_, err = s.SetHorizon("task", HorizonTomorrow)
+	require.NoError(t, err)
+
+	g, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.False(t, g.HorizonSet.Before(before.Truncate(time.Second)), "horizon_set round-trips through goal.md")
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// SearchOptions narrows SearchNotes. Zero values leave that side of the
+// window open.
+type SearchOptions struct {
+	Since time.Time // first day included
+	Until time.Time // last day included
+}
+
+// Windowed reports whether opts restricts matches to a date range.
+func (o SearchOptions) Windowed() bool {
+	return !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+// includes reports whether the "2006-01-02" day falls inside the window.
+func (o SearchOptions) includes(day string) bool {
+	if !o.Since.IsZero() && day < o.Since.Format("2006-01-02") {
+		return false
+	}
+	if !o.Until.IsZero() && day > o.Until.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// NoteEntry is one line of a goal's notes under a date header.
+type NoteEntry struct {
+	Date string `json:"date"` // 2006-01-02
+	Text string `json:"text"` // without the leading "- "
+}
+
+// noteEntries returns the non-blank lines of body that sit under a date
+// header, in body order.
+func noteEntries(body string) []NoteEntry {
+	var entries []NoteEntry
+	date := ""
+	for _, line := range strings.Split(body, "\n") {
+		if match := NoteDateHeader.FindStringSubmatch(line); match != nil {
+			date = match[1]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			date = "" // an undated section ends the dated one
+			continue
+		}
+		text := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		if date != "" && text != "" {
+			entries = append(entries, NoteEntry{Date: date, Text: text})
+		}
+	}
+	return entries
+}
+
+// MatchingEntries returns g's dated note entries inside the window of opts
+// that contain query (case-insensitive). When the title matches, every
+// entry in the window counts.
+func MatchingEntries(g *Goal, query string, opts SearchOptions) []NoteEntry {
+	query = strings.ToLower(query)
+	titleMatch := strings.Contains(strings.ToLower(g.Title), query)
+	var matches []NoteEntry
+	for _, e := range noteEntries(g.Body) {
+		if opts.includes(e.Date) && (titleMatch || strings.Contains(strings.ToLower(e.Text), query)) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// Where a search matched a goal, for SearchMatch.In.
+const (
+	MatchTitle = "title"
+	MatchPath  = "path"
+	MatchTag   = "tag"
+	MatchBody  = "body"
+)
+
+// snippetWidth is about how many characters of a body line a snippet keeps.
+const snippetWidth = 80
+
+// SearchMatch is where query first matched a goal, checking its title,
+// path, tags and body in that order, and the text it matched in: the
+// title, path or tag, or the body line shortened around the match.
+type SearchMatch struct {
+	In      string `json:"matched_in"`
+	Snippet string `json:"snippet"`
+}
+
+// MatchLocation returns where query (case-insensitive) matches g. In is
+// empty when it doesn't.
+func MatchLocation(g *Goal, query string) SearchMatch {
+	q := strings.ToLower(query)
+	switch {
+	case strings.Contains(strings.ToLower(g.Title), q):
+		return SearchMatch{In: MatchTitle, Snippet: g.Title}
+	case strings.Contains(strings.ToLower(g.Path), q):
+		return SearchMatch{In: MatchPath, Snippet: g.Path}
+	}
+	for _, tag := range g.Tags {
+		if strings.Contains(strings.ToLower(tag), q) {
+			return SearchMatch{In: MatchTag, Snippet: tag}
+		}
+	}
+	for _, line := range strings.Split(g.Body, "\n") {
+		lower := strings.ToLower(line)
+		if i := strings.Index(lower, q); i >= 0 {
+			n := len(q)
+			if len(lower) != len(line) {
+				i, n = 0, 0 // offsets in lower don't carry over to line
+			}
+			return SearchMatch{In: MatchBody, Snippet: snippet(line, i, n)}
+		}
+	}
+	return SearchMatch{}
+}
+
+// snippet trims line to about snippetWidth characters around the match at
+// byte offset i of length n, marking cut ends with "…".
+func snippet(line string, i, n int) string {
+	trimmed := strings.TrimLeft(line, " \t")
+	i -= len(line) - len(trimmed)
+	line = strings.TrimRight(trimmed, " \t")
+	if len([]rune(line)) <= snippetWidth {
+		return line
+	}
+	runes := []rune(line)
+	at := len([]rune(line[:i]))
+	start := at - (snippetWidth-len([]rune(line[i:i+n])))/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWidth
+	if end > len(runes) {
+		end = len(runes)
+		start = max(end-snippetWidth, 0)
+	}
+	out := string(runes[start:end])
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(runes) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 4, 0, 0, time.Local)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	for input, want := range map[string]time.Time{
+		"2025-01-31": day(2025, 1, 31),
+		"today":      day(2025, 3, 12),
+		"Yesterday":  day(2025, 3, 11),
+		"tomorrow":   day(2025, 3, 13),
+		"3d":         day(2025, 3, 9),
+		"2w":         day(2025, 2, 26),
+		"1m":         day(2025, 2, 12),
+	} {
+		got, err := ParseDate(input, now)
+		require.NoError(t, err, input)
+		assert.True(t, want.Equal(got), "%s: got %s", input, got)
+	}
+
+	for _, input := range []string{"", "soon", "3y", "2025-13-01"} {
+		_, err := ParseDate(input, now)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSearchNotesWindow(t *testing.T) {
+	s := NewMemStore()
+	for slug, body := range map[string]string{
+		"auth": "## 2025-03-01\n- started the auth rewrite\n\n## 2025-03-05\n- auth tokens expire\n- wrote docs\n",
+		"docs": "Some context about docs\n\n## 2025-02-20\n- outlined the docs\n\n## Ideas\n- docs site redesign\n",
+		"misc": "## 2025-03-06\n- nothing relevant\n",
+	} {
+		g, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+		g.Body = body
+		require.NoError(t, s.SaveGoal(g))
+	}
+	slugs := func(goals []*Goal) []string {
+		var result []string
+		for _, g := range goals {
+			result = append(result, g.Slug)
+		}
+		return result
+	}
+	day := func(s string) time.Time {
+		d, err := ParseDate(s, time.Now())
+		require.NoError(t, err)
+		return d
+	}
+
+	matches, err := s.SearchNotes("docs", SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth", "docs"}, slugs(matches), "no window searches whole bodies")
+
+	march := SearchOptions{Since: day("2025-03-01"), Until: day("2025-03-05")}
+	matches, err = s.SearchNotes("docs", march)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth"}, slugs(matches), "undated text and out-of-window entries don't count")
+	assert.Equal(t, []NoteEntry{{Date: "2025-03-05", Text: "wrote docs"}}, MatchingEntries(matches[0], "docs", march))
+
+	matches, err = s.SearchNotes("", SearchOptions{Since: day("2025-03-05")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth", "misc"}, slugs(matches), "an empty query lists all activity in the window")
+
+	matches, err = s.SearchNotes("docs", SearchOptions{Until: day("2025-02-28")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs"}, slugs(matches))
+	assert.Equal(t, []NoteEntry{{Date: "2025-02-20", Text: "outlined the docs"}}, MatchingEntries(matches[0], "docs", SearchOptions{Until: day("2025-02-28")}),
+		"the docs title matches, but entries under undated headers are outside any window")
+}
+
+func TestMatchLocation(t *testing.T) {
+	g := &Goal{
+		Title: "Launch",
+		Path:  "otr/launch",
+		Tags:  []string{"q1-release"},
+		Body:  "## 2026-01-02\n- call the Vendor about pricing\n",
+	}
+	for query, want := range map[string]SearchMatch{
+		"laun":    {In: MatchTitle, Snippet: "Launch"},
+		"otr/":    {In: MatchPath, Snippet: "otr/launch"},
+		"RELEASE": {In: MatchTag, Snippet: "q1-release"},
+		"vendor":  {In: MatchBody, Snippet: "- call the Vendor about pricing"},
+		"nothing": {},
+	} {
+		assert.Equal(t, want, MatchLocation(g, query), query)
+	}
+
+	long := &Goal{Body: "- " + strings.Repeat("a", 100) + " needle " + strings.Repeat("b", 100)}
+	snip := MatchLocation(long, "needle").Snippet
+	assert.Contains(t, snip, "needle")
+	assert.True(t, strings.HasPrefix(snip, "…") && strings.HasSuffix(snip, "…"), snip)
+	assert.Len(t, []rune(snip), snippetWidth+2)
+}
+
+func TestSearchNotesMatchesTags(t *testing.T) {
+	s := setupTestStore(t)
+	g, err := s.CreateGoal("", "launch")
+	require.NoError(t, err)
+	g.Tags = []string{"q1"}
+	require.NoError(t, s.SaveGoal(g))
+	_, err = s.CreateGoal("", "docs")
+	require.NoError(t, err)
+
+	matches, err := s.SearchNotes("Q1", SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "launch", matches[0].Path)
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDraftTags are the tags that keep a goal's changes out of commits.
+var DefaultDraftTags = []string{"wip"}
+
+// StagePaths stages every changed, added, or deleted file in the git repo at
+// dir, except goal files tagged with one of draftTags and anything under
+// RuntimeDir. Draft goals are committed once the tag is removed; a draft
+// that was moved keeps its old file in history until then. It stages paths
+// explicitly instead of running `git add -A` so drafts are never swept into
+// a commit. aliases are the goal files' field aliases (see
+// Options.FieldAliases), so renamed tags are still found.
+func StagePaths(dir string, draftTags []string, aliases map[string]string) error {
+	out, err := exec.Command("git", "-C", dir, "ls-files", "-z",
+		"--modified", "--deleted", "--others", "--exclude-standard").Output()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var changed []string
+	for _, p := range bytes.Split(out, []byte{0}) {
+		path := string(p)
+		if path == "" || seen[path] || strings.HasPrefix(path, RuntimeDir+"/") {
+			continue
+		}
+		seen[path] = true
+		changed = append(changed, path)
+	}
+
+	drafts := draftGoalFiles(dir, changed, draftTags, aliases)
+	var paths bytes.Buffer
+	for _, path := range changed {
+		if !drafts[path] {
+			paths.WriteString(path)
+			paths.WriteByte(0)
+		}
+	}
+	if paths.Len() == 0 {
+		return nil
+	}
+
+	// Paths go through stdin: a large tree's first commit can have more of
+	// them than fit on a command line
+	cmd := exec.Command("git", "-C", dir, "--literal-pathspecs", "add", "-A",
+		"--pathspec-from-file=-", "--pathspec-file-nul")
+	cmd.Stdin = &paths
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// draftGoalFiles returns the changed paths (slash-separated, relative to
+// dir) to hold back: goal files whose tags include any of draftTags, and
+// deleted goal files of goals that were moved and are now drafts. Only
+// changed files are read, so unchanged drafts cost nothing.
+func draftGoalFiles(dir string, changed, draftTags []string, aliases map[string]string) map[string]bool {
+	drafts := make(map[string]bool)
+	if len(draftTags) == 0 {
+		return drafts
+	}
+
+	var deleted []string
+	moved := make(map[int64]bool) // created times of changed drafts
+	for _, path := range changed {
+		if !strings.HasPrefix(path, "goals/") || !isGoalFile(path) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if errors.Is(err, fs.ErrNotExist) {
+			deleted = append(deleted, path)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		goal, err := parseGoal(string(data), aliases)
+		if err != nil || !hasAnyTag(goal, draftTags) {
+			continue
+		}
+		drafts[path] = true
+		if !goal.Created.IsZero() {
+			moved[goal.Created.UnixNano()] = true
+		}
+	}
+
+	// A deleted file whose goal is now a draft elsewhere is that draft's
+	// old location
+	for _, path := range deleted {
+		if len(moved) == 0 {
+			break
+		}
+		data, err := exec.Command("git", "-C", dir, "show", "HEAD:"+path).Output()
+		if err != nil {
+			continue
+		}
+		if goal, err := parseGoal(string(data), aliases); err == nil && moved[goal.Created.UnixNano()] {
+			drafts[path] = true
+		}
+	}
+	return drafts
+}
+
+func hasAnyTag(g *Goal, tags []string) bool {
+	for _, t := range g.Tags {
+		for _, want := range tags {
+			if strings.EqualFold(t, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
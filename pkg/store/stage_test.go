@@ -0,0 +1,135 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupGitStore returns a store whose data directory is a git repo with a
+// usable commit identity. Tests using it are skipped without git.
+func setupGitStore(t *testing.T) *Store {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+		"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_CONFIG_GLOBAL": "/dev/null", "GIT_CONFIG_NOSYSTEM": "1",
+	} {
+		t.Setenv(k, v)
+	}
+	s := setupTestStore(t)
+	require.NoError(t, s.Init())
+	require.True(t, s.GitEnabled)
+	return s
+}
+
+// committedFiles lists the files tracked at HEAD.
+func committedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "ls-tree", "-r", "--name-only", "HEAD").Output()
+	require.NoError(t, err)
+	return strings.Fields(string(out))
+}
+
+func TestCommitSkipsDraftGoals(t *testing.T) {
+	s := setupGitStore(t)
+
+	_, err := s.CreateGoal("", "public")
+	require.NoError(t, err)
+	draft, err := s.CreateGoal("", "secret")
+	require.NoError(t, err)
+
+	files := committedFiles(t, s.Root)
+	require.Contains(t, files, "goals/secret/goal.md", "not a draft yet")
+
+	draft.Tags = []string{"wip"}
+	draft.Body = "half-written thought"
+	require.NoError(t, s.SaveGoal(draft))
+	_, err = s.AddNote("public", "shipped")
+	require.NoError(t, err)
+
+	out, err := exec.Command("git", "-C", s.Root, "show", "HEAD:goals/secret/goal.md").Output()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "half-written", "draft edits stay uncommitted")
+	out, err = exec.Command("git", "-C", s.Root, "show", "HEAD:goals/public/goal.md").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "shipped", "other changes are still committed")
+
+	draft.Tags = nil
+	require.NoError(t, s.SaveGoal(draft))
+	s.Commit("publish")
+	out, err = exec.Command("git", "-C", s.Root, "show", "HEAD:goals/secret/goal.md").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "half-written", "removing the tag publishes the goal")
+}
+
+func TestStagePathsNewDraftGoal(t *testing.T) {
+	s := setupGitStore(t)
+
+	_, err := s.CreateGoal("", "visible")
+	require.NoError(t, err)
+	g := &Goal{Title: "idea", Status: StatusIncomplete, Tags: []string{"WIP"}, Path: "idea"}
+	require.NoError(t, s.SaveGoal(g))
+	_, err = s.CreateGoal("idea", "child")
+	require.NoError(t, err)
+
+	files := committedFiles(t, s.Root)
+	assert.NotContains(t, files, "goals/idea/goal.md", "tags match case-insensitively")
+	assert.Contains(t, files, filepath.ToSlash(filepath.Join("goals", "idea", "child", "goal.md")), "only the draft's own file is held back")
+}
+
+func TestCommitKeepsMovedDraftInHistory(t *testing.T) {
+	s := setupGitStore(t)
+
+	_, err := s.CreateGoal("", "area")
+	require.NoError(t, err)
+	idea, err := s.CreateGoal("", "idea")
+	require.NoError(t, err)
+	idea.Tags = []string{"wip"}
+	require.NoError(t, s.SaveGoal(idea))
+	require.NoError(t, s.MoveGoal("idea", "area"))
+
+	files := committedFiles(t, s.Root)
+	assert.Contains(t, files, "goals/idea/goal.md", "the draft's old file stays until it's published")
+	assert.NotContains(t, files, "goals/area/idea/goal.md")
+
+	idea, err = s.LoadGoal("area/idea")
+	require.NoError(t, err)
+	idea.Tags = nil
+	require.NoError(t, s.SaveGoal(idea))
+	s.Commit("publish")
+	files = committedFiles(t, s.Root)
+	assert.NotContains(t, files, "goals/idea/goal.md")
+	assert.Contains(t, files, "goals/area/idea/goal.md", "publishing commits the move")
+}
+
+func TestStagePathsManyPaths(t *testing.T) {
+	s := setupGitStore(t)
+
+	// More path bytes than fit on a command line (ARG_MAX is 2MB on Linux)
+	dir := s.Root
+	for range 8 {
+		dir = filepath.Join(dir, strings.Repeat("d", 200))
+	}
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	const n = 1900
+	for i := range n {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%05d.txt", i)), nil, 0644))
+	}
+	// Pathspec magic in a name is taken literally
+	require.NoError(t, os.WriteFile(filepath.Join(s.Root, "*.md"), nil, 0644))
+
+	require.NoError(t, StagePaths(s.Root, DefaultDraftTags, nil))
+	out, err := exec.Command("git", "-C", s.Root, "diff", "--cached", "--name-only", "-z").Output()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"), n+1)
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"math"
+	"time"
+)
+
+// DaysInToday returns how many calendar days g has been in TODAY as of now,
+// based on horizon_set (or the last update for older goals).
+func DaysInToday(g *Goal, now time.Time) int {
+	since := startOfDay(horizonSetAt(g).In(now.Location()))
+	return int(math.Round(startOfDay(now).Sub(since).Hours() / 24))
+}
+
+// DaysUntouched returns how many calendar days g has gone without an
+// update as of now.
+func DaysUntouched(g *Goal, now time.Time) int {
+	since := startOfDay(g.Updated.In(now.Location()))
+	return int(math.Round(startOfDay(now).Sub(since).Hours() / 24))
+}
+
+// IsStaleToday reports whether g is an unfinished TODAY goal that has been
+// there for more than thresholdDays. A threshold of zero disables the check.
+func IsStaleToday(g *Goal, now time.Time, thresholdDays int) bool {
+	if thresholdDays <= 0 || g.Horizon != HorizonToday || g.IsComplete() {
+		return false
+	}
+	return DaysInToday(g, now) > thresholdDays
+}
+
+// TodayProgress counts the goals in TODAY and how many of them are
+// complete. goals is walked as a tree, so a flat list like
+// LoadFrontmatters's works too.
+func TodayProgress(goals []*Goal) (done, total int) {
+	WalkGoals(goals, func(g *Goal, _ int) error {
+		if g.Horizon == HorizonToday {
+			total++
+			if g.IsComplete() {
+				done++
+			}
+		}
+		return nil
+	})
+	return done, total
+}
+
+// StaleToday returns the goals that have been in TODAY for more than
+// thresholdDays without being completed, in tree order.
+func (s *Store) StaleToday(thresholdDays int) ([]*Goal, error) {
+	return staleToday(s, time.Now(), thresholdDays)
+}
+
+// StaleToday returns the goals that have lingered in TODAY, like
+// Store.StaleToday.
+func (s *MemStore) StaleToday(thresholdDays int) ([]*Goal, error) {
+	return staleToday(s, time.Now(), thresholdDays)
+}
+
+func staleToday(b Backend, now time.Time, thresholdDays int) ([]*Goal, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []*Goal
+	var walk func([]*Goal)
+	walk = func(goals []*Goal) {
+		for _, g := range goals {
+			if IsStaleToday(g, now, thresholdDays) {
+				stale = append(stale, g)
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+	return stale, nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleToday(t *testing.T) {
+	s := setupTestStore(t)
+
+	now := time.Now()
+	for slug, daysAgo := range map[string]int{"old": 6, "recent": 2, "done": 10, "edge": 3} {
+		g, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+		g.Horizon = HorizonToday
+		g.HorizonSet = now.AddDate(0, 0, -daysAgo)
+		if slug == "done" {
+			g.Status = StatusComplete
+		}
+		require.NoError(t, s.SaveGoal(g))
+	}
+
+	stale, err := s.StaleToday(3)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, "old", stale[0].Path)
+	assert.Equal(t, 6, DaysInToday(stale[0], now))
+
+	stale, err = s.StaleToday(0)
+	require.NoError(t, err)
+	assert.Empty(t, stale, "a zero threshold disables the check")
+}
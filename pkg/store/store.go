@@ -1,32 +1,187 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 )
 
+// ErrReadOnly is returned by mutating operations on a read-only Store.
+var ErrReadOnly = errors.New("data directory is opened read-only")
+
+// DefaultDirPerm is the permission used for directories the Store creates.
+const DefaultDirPerm os.FileMode = 0755
+
+// Options configures how a Store treats its data directory.
+type Options struct {
+	// ReadOnly skips creating the data directory and makes every write
+	// return ErrReadOnly. Useful for CI checks and shared read-only mounts.
+	ReadOnly bool
+	// DirPerm is the permission for directories created by the Store.
+	// Zero means DefaultDirPerm.
+	DirPerm os.FileMode
+	// Horizons are the horizons goals can be given, nearest first, as the
+	// config's horizons lists them. Empty means DefaultHorizons. today and
+	// tomorrow keep their meaning (stale TODAY goals, rollover) when listed.
+	Horizons []Horizon
+	// DefaultHorizon is the horizon given to new goals.
+	// Empty means the last of Horizons.
+	DefaultHorizon Horizon
+	// DefaultTags are given to new goals.
+	DefaultTags []string
+	// BodyTemplate is a text/template for new goals' notes, where
+	// {{title}} is the goal's title and {{date}} today's date. If it
+	// fails, the goal is created with empty notes and a warning.
+	BodyTemplate string
+	// DraftTags keep tagged goals out of commits until the tag is removed.
+	// Nil means DefaultDraftTags; use an empty slice to commit everything.
+	DraftTags []string
+	// EventLog appends create/complete/move/delete/note events to
+	// EventsPath as JSON lines.
+	EventLog bool
+	// Hooks runs the script in HooksDir named after each of those events,
+	// e.g. .cairn/hooks/on-complete, in the background.
+	Hooks bool
+	// Layout is how goal files are named. Empty means LayoutCairn; files in
+	// either layout are read regardless.
+	Layout Layout
+	// FieldAliases renames frontmatter fields in goal files, e.g.
+	// {"status": "cairn-status"}. Files using the canonical names still load.
+	FieldAliases map[string]string
+	// NoteTemplate shapes the notes AddNote writes; goals can override it
+	// with note_format and note_section.
+	NoteTemplate NoteTemplate
+	// AgeIdentity is the age identity file that decrypts encrypted goal
+	// notes (see RecipientsFile). Without it they load with BodyErr set.
+	AgeIdentity string
+	// AutoQueue appends new top-level goals to the queue.
+	AutoQueue bool
+	// DequeueOnComplete removes goals from the queue when ToggleStatus or
+	// SetStatus completes them.
+	DequeueOnComplete bool
+}
+
 // Store manages the filesystem-backed goal data.
 type Store struct {
 	Root       string // e.g., ~/Library/Application Support/cairn
 	GitEnabled bool
+
+	opts        Options
+	initialized bool
+
+	hooks     sync.WaitGroup // running hook scripts
+	hookLogMu sync.Mutex
+	hookErr   func(error)
+	warn      func(error)
+	onEvent   func(Event)
+
+	cipher  bodyCipher
+	plainMu sync.Mutex
+	plain   map[string]string // decrypted bodies by their encrypted text
 }
 
-// NewStore creates a Store rooted at the given directory.
-// It creates the directory structure if it doesn't exist.
+// NewStore creates a Store rooted at the given directory with default options.
+// The directory structure is created lazily on the first write.
 func NewStore(root string) (*Store, error) {
-	goalsDir := filepath.Join(root, "goals")
-	if err := os.MkdirAll(goalsDir, 0755); err != nil {
-		return nil, fmt.Errorf("creating goals directory: %w", err)
+	return NewStoreWithOptions(root, Options{})
+}
+
+// NewStoreWithOptions creates a Store rooted at the given directory.
+// Nothing is written until the first mutating operation (or Init).
+func NewStoreWithOptions(root string, opts Options) (*Store, error) {
+	if opts.DirPerm == 0 {
+		opts.DirPerm = DefaultDirPerm
+	}
+	if len(opts.Horizons) == 0 {
+		opts.Horizons = DefaultHorizons
+	}
+	opts.Horizons = slices.Clone(opts.Horizons)
+	if opts.DefaultHorizon == "" {
+		opts.DefaultHorizon = FarHorizon(opts.Horizons)
+	}
+	if opts.DraftTags == nil {
+		opts.DraftTags = DefaultDraftTags
+	}
+	if opts.Layout == "" {
+		opts.Layout = LayoutCairn
+	}
+	if _, err := ParseLayout(string(opts.Layout)); err != nil {
+		return nil, err
+	}
+	if err := checkFieldAliases(opts.FieldAliases); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("data directory %s is not a directory", root)
+	}
+
+	s := &Store{Root: root, opts: opts}
+	s.cipher = ageCipher{recipients: filepath.Join(root, RecipientsFile), identity: opts.AgeIdentity}
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		if _, err := exec.LookPath("git"); err == nil {
+			s.GitEnabled = true
+		}
 	}
-	s := &Store{Root: root}
-	s.initGit()
 	return s, nil
 }
 
+// OnWarning sets fn to be called with problems that don't stop an
+// operation, like a body template that fails to render.
+func (s *Store) OnWarning(fn func(error)) {
+	s.warn = fn
+}
+
+func (s *Store) warning(err error) {
+	if s.warn != nil {
+		s.warn(err)
+	}
+}
+
+// ReadOnly reports whether the store rejects writes.
+func (s *Store) ReadOnly() bool {
+	return s.opts.ReadOnly
+}
+
+// Init creates the data directory structure and git repo if they don't exist.
+// Mutating operations call it automatically; it only needs to be called
+// directly to set up an empty data directory.
+func (s *Store) Init() error {
+	if s.opts.ReadOnly {
+		return ErrReadOnly
+	}
+	if s.initialized {
+		return nil
+	}
+	if err := os.MkdirAll(s.GoalsDir(), s.opts.DirPerm); err != nil {
+		return permissionHint(fmt.Errorf("creating goals directory: %w", err), s.Root)
+	}
+	s.initGit()
+	s.initialized = true
+	return nil
+}
+
+// writable returns ErrReadOnly for read-only stores and otherwise makes sure
+// the data directory exists.
+func (s *Store) writable() error {
+	return s.Init()
+}
+
+// permissionHint adds an actionable suggestion to permission errors.
+func permissionHint(err error, path string) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return fmt.Errorf("%w\n  cairn cannot write to %s; fix its permissions, point --dir/CAIRN_DIR elsewhere, or pass --read-only", err, path)
+}
+
 // initGit initializes the data directory as a git repo if git is available.
 func (s *Store) initGit() {
 	if _, err := exec.LookPath("git"); err != nil {
@@ -46,7 +201,7 @@ func (s *Store) initGit() {
 	// Create .gitignore
 	gitignore := filepath.Join(s.Root, ".gitignore")
 	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
-		os.WriteFile(gitignore, []byte("*.swp\n*.swo\n*~\n.DS_Store\n"), 0644)
+		os.WriteFile(gitignore, []byte("*.swp\n*.swo\n*~\n.DS_Store\n"+RuntimeDir+"/\n"), 0644)
 	}
 
 	// Initial commit
@@ -56,18 +211,23 @@ func (s *Store) initGit() {
 	s.GitEnabled = true
 }
 
-// Commit stages all changes and commits with the given message.
-// Fire-and-forget: git failures never break the user's workflow.
+// Commit stages all changes (except draft goals) and commits with the given
+// message. Fire-and-forget: git failures never break the user's workflow.
 func (s *Store) Commit(message string) {
-	if !s.GitEnabled {
+	if !s.GitEnabled || s.opts.ReadOnly {
 		return
 	}
-	exec.Command("git", "-C", s.Root, "add", "-A").Run()
+	StagePaths(s.Root, s.opts.DraftTags, s.opts.FieldAliases)
 	if err := exec.Command("git", "-C", s.Root, "diff", "--cached", "--quiet").Run(); err != nil {
 		exec.Command("git", "-C", s.Root, "commit", "-m", message).Run()
 	}
 }
 
+// DataDir returns the root of the data directory.
+func (s *Store) DataDir() string {
+	return s.Root
+}
+
 // GoalsDir returns the path to the goals directory.
 func (s *Store) GoalsDir() string {
 	return filepath.Join(s.Root, "goals")
@@ -92,31 +252,60 @@ func (s *Store) LoadQueue() (*Queue, error) {
 
 // SaveQueue writes queue.md to disk.
 func (s *Store) SaveQueue(q *Queue) error {
-	q.Updated = time.Now()
-	content := SerializeQueue(q)
-	if err := os.WriteFile(s.QueuePath(), []byte(content), 0644); err != nil {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if err := s.writeQueue(q); err != nil {
 		return err
 	}
 	s.Commit("update queue")
 	return nil
 }
 
+// writeQueue writes queue.md without committing.
+func (s *Store) writeQueue(q *Queue) error {
+	q.Updated = time.Now()
+	content := SerializeQueue(q, s.queueTitle)
+	if err := os.WriteFile(s.QueuePath(), []byte(content), 0644); err != nil {
+		return permissionHint(err, s.QueuePath())
+	}
+	return nil
+}
+
+// queueTitle is the title of the queued goal item, read from its
+// frontmatter alone, or "" when it can't be read.
+func (s *Store) queueTitle(item string) string {
+	f, err := os.Open(s.GoalFile(item))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	g, err := readGoalFrontmatter(f, s.opts.FieldAliases)
+	if err != nil {
+		return ""
+	}
+	return g.Title
+}
+
 // LoadGoal reads a single goal from its directory path (relative to goals/).
+// A goal whose frontmatter doesn't parse still loads, with FrontmatterErr
+// set.
 func (s *Store) LoadGoal(goalPath string) (*Goal, error) {
-	filePath := filepath.Join(s.GoalsDir(), goalPath, "goal.md")
+	filePath := s.GoalFile(goalPath)
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("reading goal %s: %w", goalPath, err)
 	}
 
-	goal, err := ParseFrontmatter(string(data))
-	if err != nil {
-		return nil, fmt.Errorf("parsing goal %s: %w", goalPath, err)
-	}
+	goal := s.parseLenient(string(data))
 
 	goal.Slug = filepath.Base(goalPath)
+	if goal.FrontmatterErr != nil {
+		goal.Title = goal.Slug
+	}
 	goal.Path = goalPath
 	goal.FilePath = filePath
+	goal.indexNotes()
 	return goal, nil
 }
 
@@ -150,39 +339,62 @@ func (s *Store) LoadGoalTree() ([]*Goal, error) {
 	var topOrder []string
 	topGoalPath := filepath.Join(goalsDir, "goal.md")
 	if data, err := os.ReadFile(topGoalPath); err == nil {
-		if topGoal, err := ParseFrontmatter(string(data)); err == nil {
+		if topGoal, err := s.parse(string(data)); err == nil {
 			topOrder = topGoal.ChildrenOrder
 		}
 	}
 
 	var goals []*Goal
-	if len(topOrder) > 0 {
-		seen := make(map[string]bool)
-		for _, name := range topOrder {
-			if g, ok := goalMap[name]; ok {
-				goals = append(goals, g)
-				seen[name] = true
+	for _, name := range mergeOrder(topOrder, defaultOrder) {
+		goals = append(goals, goalMap[name])
+	}
+
+	return goals, nil
+}
+
+// LoadFrontmatters reads the frontmatter of every goal without parsing
+// bodies or building the tree, for callers that only need field values and
+// must be fast. Goals are returned flat, parents before their children;
+// unreadable ones are skipped.
+func (s *Store) LoadFrontmatters() ([]*Goal, error) {
+	var goals []*Goal
+	root := s.GoalsDir()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll
 			}
+			return nil
 		}
-		// Append any not in the ordering
-		for _, name := range defaultOrder {
-			if !seen[name] {
-				goals = append(goals, goalMap[name])
-			}
+		if !d.IsDir() || path == root {
+			return nil
 		}
-	} else {
-		for _, name := range defaultOrder {
-			goals = append(goals, goalMap[name])
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
 		}
-	}
-
-	return goals, nil
+		rel, _ := filepath.Rel(root, path)
+		f, err := os.Open(s.GoalFile(rel))
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		goal, err := readGoalFrontmatter(f, s.opts.FieldAliases)
+		if err != nil {
+			return nil
+		}
+		goal.Path = filepath.ToSlash(rel)
+		goal.Slug = d.Name()
+		goal.FilePath = f.Name()
+		goals = append(goals, goal)
+		return nil
+	})
+	return goals, err
 }
 
 func (s *Store) loadGoalRecursive(goalPath string, parent *Goal) (*Goal, error) {
 	goal, err := s.LoadGoal(goalPath)
 	if err != nil {
-		// If no goal.md exists, create a minimal goal from the directory name
+		// If no goal file exists, create a minimal goal from the directory name
 		goal = &Goal{
 			Title:  filepath.Base(goalPath),
 			Status: StatusIncomplete,
@@ -216,52 +428,80 @@ func (s *Store) loadGoalRecursive(goalPath string, parent *Goal) (*Goal, error)
 	}
 
 	// Use children_order if present, falling back to alphabetical (os.ReadDir order)
-	if len(goal.ChildrenOrder) > 0 {
-		seen := make(map[string]bool)
-		for _, name := range goal.ChildrenOrder {
-			if child, ok := childMap[name]; ok {
-				goal.Children = append(goal.Children, child)
-				seen[name] = true
-			}
-		}
-		// Append any children not listed in children_order
-		for _, name := range defaultOrder {
-			if !seen[name] {
-				goal.Children = append(goal.Children, childMap[name])
-			}
-		}
-	} else {
-		for _, name := range defaultOrder {
-			goal.Children = append(goal.Children, childMap[name])
-		}
+	for _, name := range mergeOrder(goal.ChildrenOrder, defaultOrder) {
+		goal.Children = append(goal.Children, childMap[name])
 	}
 
 	return goal, nil
 }
 
-// SaveGoal writes a goal to disk.
+// SaveGoal writes a goal to disk. A goal that would be written exactly as
+// its file already holds it isn't written, and its updated time is kept.
 func (s *Store) SaveGoal(g *Goal) error {
-	g.Updated = time.Now()
+	return s.saveGoal(g, false)
+}
+
+// ForceSaveGoal writes a goal to disk like SaveGoal, but always, refreshing
+// its updated time even when nothing else changed.
+func (s *Store) ForceSaveGoal(g *Goal) error {
+	return s.saveGoal(g, true)
+}
+
+func (s *Store) saveGoal(g *Goal, force bool) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if g.FrontmatterErr != nil {
+		return fmt.Errorf("%s: %w (%v)", g.Path, ErrBadFrontmatter, g.FrontmatterErr)
+	}
+	now := time.Now()
+	g.stampCompleted(now)
+	if !force && s.unchangedOnDisk(g) {
+		return nil
+	}
+	g.Updated = now
+	completing := (s.opts.EventLog || s.opts.Hooks || s.onEvent != nil) && g.IsComplete() && !s.completedOnDisk(g.Path)
 
 	dir := filepath.Join(s.GoalsDir(), g.Path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("creating goal directory: %w", err)
+	if err := os.MkdirAll(dir, s.opts.DirPerm); err != nil {
+		return permissionHint(fmt.Errorf("creating goal directory: %w", err), dir)
 	}
 
-	content, err := SerializeFrontmatter(g)
+	content, err := s.serialize(g)
 	if err != nil {
 		return fmt.Errorf("serializing goal: %w", err)
 	}
 
-	filePath := filepath.Join(dir, "goal.md")
+	filePath, err := s.writeGoalFile(g.Path, content, g.FilePath)
+	if err != nil {
+		return err
+	}
 	g.FilePath = filePath
-	return os.WriteFile(filePath, []byte(content), 0644)
+	if completing {
+		s.recordEvent(Event{Type: EventComplete, Path: g.Path})
+	}
+	return nil
+}
+
+// unchangedOnDisk reports whether g's file already holds exactly what
+// saving it would write, updated time aside.
+func (s *Store) unchangedOnDisk(g *Goal) bool {
+	want := filepath.Join(s.GoalsDir(), g.Path, goalFileName(s.opts.Layout, filepath.Base(g.Path)))
+	if g.FilePath != want {
+		return false // new, or moving to the layout's file name
+	}
+	data, err := os.ReadFile(want)
+	if err != nil {
+		return false
+	}
+	content, err := s.serialize(g)
+	return err == nil && content == string(data)
 }
 
 // CreateGoal creates a new goal under the given parent path.
 // If parentPath is empty, creates a top-level goal.
 func (s *Store) CreateGoal(parentPath, slug string) (*Goal, error) {
-	slug = strings.ToLower(strings.ReplaceAll(slug, " ", "-"))
+	slug = normalizeSlug(slug)
 
 	var goalPath string
 	if parentPath == "" {
@@ -279,30 +519,74 @@ func (s *Store) CreateGoal(parentPath, slug string) (*Goal, error) {
 	goal := &Goal{
 		Title:   slug,
 		Status:  StatusIncomplete,
-		Horizon: HorizonFuture,
+		Horizon: s.opts.DefaultHorizon,
 		Created: now,
 		Updated: now,
 		Slug:    slug,
 		Path:    goalPath,
+		Tags:    slices.Clone(s.opts.DefaultTags),
+	}
+	if s.opts.BodyTemplate != "" {
+		body, err := renderBody(s.opts.BodyTemplate, goal, now)
+		if err != nil {
+			s.warning(fmt.Errorf("new goal %s has empty notes: %w", goalPath, err))
+		}
+		goal.Body = body
 	}
 
 	if err := s.SaveGoal(goal); err != nil {
 		return nil, err
 	}
+	if err := s.afterCreate(goal); err != nil {
+		return nil, err
+	}
 
+	s.recordEvent(Event{Type: EventCreate, Path: goalPath})
 	s.Commit("add goal: " + slug)
+	if parentPath != "" {
+		if parent, err := s.LoadGoal(parentPath); err == nil && parent.IsComplete() {
+			s.warning(fmt.Errorf("%s is complete: %s was added under it anyway", parentPath, goalPath))
+		}
+	}
 	return goal, nil
 }
 
-// DeleteGoal removes a goal directory and all its children.
+// normalizeSlug lowercases a goal name and replaces spaces with dashes.
+func normalizeSlug(slug string) string {
+	return strings.ToLower(strings.ReplaceAll(slug, " ", "-"))
+}
+
+// renderBody executes the BodyTemplate text for g, created at now. It
+// returns "" when the template fails.
+func renderBody(text string, g *Goal, now time.Time) (string, error) {
+	tmpl, err := template.New("body").Funcs(template.FuncMap{
+		"title": func() string { return g.Title },
+		"date":  func() string { return now.Format("2006-01-02") },
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("body template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("body template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// DeleteGoal moves a goal directory and all its children to the trash,
+// from which RestoreTrash can bring them back until they're purged.
 func (s *Store) DeleteGoal(goalPath string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	dir := filepath.Join(s.GoalsDir(), goalPath)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		return fmt.Errorf("goal %s not found", goalPath)
 	}
-	if err := os.RemoveAll(dir); err != nil {
+	if err := s.trashGoal(goalPath); err != nil {
 		return err
 	}
+	s.recordEvent(Event{Type: EventDelete, Path: goalPath})
 	s.Commit("remove goal: " + goalPath)
 	return nil
 }
@@ -314,22 +598,30 @@ func (s *Store) ToggleStatus(goalPath string) (*Goal, error) {
 		return nil, err
 	}
 
-	switch goal.Status {
-	case StatusIncomplete:
-		goal.Status = StatusInProgress
-	case StatusInProgress:
-		goal.Status = StatusComplete
-	default:
-		goal.Status = StatusIncomplete
-	}
+	goal.Status = nextStatus(goal.Status)
 
 	if err := s.SaveGoal(goal); err != nil {
 		return nil, err
 	}
+	if err := s.afterStatus(goal); err != nil {
+		return nil, err
+	}
 	s.Commit("mark " + goalPath + " " + string(goal.Status))
 	return goal, nil
 }
 
+// nextStatus returns the status that follows s in the toggle cycle.
+func nextStatus(s GoalStatus) GoalStatus {
+	switch s {
+	case StatusIncomplete:
+		return StatusInProgress
+	case StatusInProgress:
+		return StatusComplete
+	default:
+		return StatusIncomplete
+	}
+}
+
 // SetStatus sets a goal's status directly.
 func (s *Store) SetStatus(goalPath string, status GoalStatus) (*Goal, error) {
 	goal, err := s.LoadGoal(goalPath)
@@ -340,18 +632,32 @@ func (s *Store) SetStatus(goalPath string, status GoalStatus) (*Goal, error) {
 	if err := s.SaveGoal(goal); err != nil {
 		return nil, err
 	}
+	if err := s.afterStatus(goal); err != nil {
+		return nil, err
+	}
 	s.Commit("mark " + goalPath + " " + string(status))
 	return goal, nil
 }
 
+// Horizons returns the configured horizons, nearest first.
+func (s *Store) Horizons() []Horizon {
+	return slices.Clone(s.opts.Horizons)
+}
+
 // SetHorizon sets the temporal horizon of a goal.
 func (s *Store) SetHorizon(goalPath string, horizon Horizon) (*Goal, error) {
+	if _, err := ParseHorizon(string(horizon), s.opts.Horizons); err != nil {
+		return nil, err
+	}
 	goal, err := s.LoadGoal(goalPath)
 	if err != nil {
 		return nil, err
 	}
 
-	goal.Horizon = horizon
+	if goal.Horizon != horizon {
+		goal.Horizon = horizon
+		goal.HorizonSet = time.Now()
+	}
 	if err := s.SaveGoal(goal); err != nil {
 		return nil, err
 	}
@@ -359,6 +665,30 @@ func (s *Store) SetHorizon(goalPath string, horizon Horizon) (*Goal, error) {
 	return goal, nil
 }
 
+// TogglePin pins or unpins a goal. Pinned goals are listed above the
+// horizon sections in the TUI.
+func (s *Store) TogglePin(goalPath string) (*Goal, error) {
+	goal, err := s.LoadGoal(goalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	goal.Pinned = !goal.Pinned
+	if err := s.SaveGoal(goal); err != nil {
+		return nil, err
+	}
+	s.Commit(pinMessage(goal))
+	return goal, nil
+}
+
+// pinMessage is the commit message for a pin toggle.
+func pinMessage(g *Goal) string {
+	if g.Pinned {
+		return "pin " + g.Path
+	}
+	return "unpin " + g.Path
+}
+
 // AddNote appends a note entry to a goal's body.
 func (s *Store) AddNote(goalPath, text string) (*Goal, error) {
 	goal, err := s.LoadGoal(goalPath)
@@ -366,62 +696,54 @@ func (s *Store) AddNote(goalPath, text string) (*Goal, error) {
 		return nil, err
 	}
 
-	today := time.Now().Format("2006-01-02")
-	dateHeader := fmt.Sprintf("## %s", today)
-
-	if strings.Contains(goal.Body, dateHeader) {
-		// Append under existing date header
-		idx := strings.Index(goal.Body, dateHeader)
-		afterHeader := idx + len(dateHeader)
-		// Find end of line
-		nlIdx := strings.Index(goal.Body[afterHeader:], "\n")
-		if nlIdx == -1 {
-			goal.Body += "\n- " + text + "\n"
-		} else {
-			insertAt := afterHeader + nlIdx + 1
-			goal.Body = goal.Body[:insertAt] + "- " + text + "\n" + goal.Body[insertAt:]
-		}
-	} else {
-		// Add new date header
-		if goal.Body != "" && !strings.HasSuffix(goal.Body, "\n") {
-			goal.Body += "\n"
-		}
-		if goal.Body != "" {
-			goal.Body += "\n"
-		}
-		goal.Body += dateHeader + "\n- " + text + "\n"
+	if goal.Body, err = appendNote(goal.Body, text, time.Now(), NoteTemplateFor(goal, s.opts.NoteTemplate)); err != nil {
+		return nil, fmt.Errorf("goal %s: %w", goalPath, err)
 	}
 
 	if err := s.SaveGoal(goal); err != nil {
 		return nil, err
 	}
+	s.recordEvent(Event{Type: EventNote, Path: goalPath, Text: text})
 	s.Commit("note: " + goalPath)
 	return goal, nil
 }
 
-// SearchNotes searches across all goals for matching text.
-func (s *Store) SearchNotes(query string) ([]*Goal, error) {
+// SearchNotes searches across all goals for matching text. With a date
+// window in opts, only goals with matching notes dated inside it are returned.
+func (s *Store) SearchNotes(query string, opts SearchOptions) ([]*Goal, error) {
 	allGoals, err := s.LoadGoalTree()
 	if err != nil {
 		return nil, err
 	}
 
+	return searchGoals(allGoals, query, opts), nil
+}
+
+// MatchesName reports whether query appears, ignoring case, in g's title
+// or path, slug included: whatever a goal is remembered by.
+func MatchesName(g *Goal, query string) bool {
 	query = strings.ToLower(query)
-	var matches []*Goal
+	return strings.Contains(strings.ToLower(g.Title), query) ||
+		strings.Contains(strings.ToLower(g.Path), query)
+}
 
-	var search func(goals []*Goal)
-	search = func(goals []*Goal) {
-		for _, g := range goals {
-			if strings.Contains(strings.ToLower(g.Title), query) ||
-				strings.Contains(strings.ToLower(g.Body), query) {
+// searchGoals returns every goal in the tree whose title, path, tags or body
+// contain query (case-insensitive), in tree order. A windowed search instead
+// matches on MatchingEntries.
+func searchGoals(goals []*Goal, query string, opts SearchOptions) []*Goal {
+	query = strings.ToLower(query)
+	var matches []*Goal
+	WalkGoals(goals, func(g *Goal, _ int) error {
+		if opts.Windowed() {
+			if len(MatchingEntries(g, query, opts)) > 0 {
 				matches = append(matches, g)
 			}
-			search(g.Children)
+		} else if MatchLocation(g, query).In != "" {
+			matches = append(matches, g)
 		}
-	}
-	search(allGoals)
-
-	return matches, nil
+		return nil
+	})
+	return matches
 }
 
 // ReorderGoal swaps a goal with a sibling in the given direction (delta: -1 for up, +1 for down).
@@ -468,9 +790,70 @@ func (s *Store) ReorderGoal(goalPath string, delta int) error {
 	return nil
 }
 
+// MoveToIndex moves a goal to position index among its siblings, shifting
+// the ones in between. Indices out of range are clamped to the first or
+// last slot. Nothing is written when the goal is already there.
+func (s *Store) MoveToIndex(goalPath string, index int) error {
+	parentPath := parentOf(goalPath)
+	siblings, err := s.getSiblingOrder(parentPath)
+	if err != nil {
+		return err
+	}
+	order, moved, err := moveInOrder(siblings, filepath.Base(goalPath), index)
+	if err != nil || !moved {
+		return err
+	}
+	if err := s.saveChildrenOrder(parentPath, order); err != nil {
+		return err
+	}
+	s.Commit("reorder: " + goalPath)
+	return nil
+}
+
+// moveInOrder returns siblings with slug moved to index, clamped to the
+// list, and whether that changed anything.
+func moveInOrder(siblings []string, slug string, index int) ([]string, bool, error) {
+	idx := slices.Index(siblings, slug)
+	if idx == -1 {
+		return nil, false, fmt.Errorf("goal %s not found among siblings", slug)
+	}
+	index = max(0, min(index, len(siblings)-1))
+	if index == idx {
+		return siblings, false, nil
+	}
+	order := slices.Delete(slices.Clone(siblings), idx, idx+1)
+	return slices.Insert(order, index, slug), true, nil
+}
+
+// SetChildrenOrder replaces the child order of parentPath ("" for top-level
+// goals). Unknown slugs are dropped; children missing from order keep their
+// place after the listed ones.
+func (s *Store) SetChildrenOrder(parentPath string, order []string) error {
+	if err := s.saveChildrenOrder(parentPath, order); err != nil {
+		return err
+	}
+	s.Commit("reorder children: " + childrenOrderTarget(parentPath))
+	return nil
+}
+
+// childrenOrderTarget names parentPath in commit messages.
+func childrenOrderTarget(parentPath string) string {
+	if parentPath == "" {
+		return "top-level"
+	}
+	return parentPath
+}
+
 // MoveGoal moves a goal directory to a new parent.
 // If newParentPath is empty, it becomes a top-level goal.
+//
+// Queue entries for the goal and its descendants are rewritten to the new
+// paths. Horizons only group top-level goals, so a top-level goal that moves
+// under a parent has its horizon cleared; it is then shown under the parent's section.
 func (s *Store) MoveGoal(goalPath, newParentPath string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	slug := filepath.Base(goalPath)
 	oldParentPath := filepath.Dir(goalPath)
 	if oldParentPath == "." {
@@ -519,36 +902,74 @@ func (s *Store) MoveGoal(goalPath, newParentPath string) error {
 	// Add the goal to new parent's children_order
 	s.addToChildrenOrder(newParentPath, slug)
 
+	// The directory has moved, so the rest is done and committed even if
+	// part of it fails, and the failures reported after
+	var errs []error
+
+	// Nested goals don't carry their own horizon
+	if oldParentPath == "" && newParentPath != "" {
+		goal, err := s.LoadGoal(newGoalPath)
+		if err == nil && goal.Horizon != "" {
+			goal.Horizon = ""
+			err = s.SaveGoal(goal)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("clearing its horizon: %w", err))
+		}
+	}
+
+	// Keep queue.md pointing at the goal's new location
+	q, err := s.LoadQueue()
+	if err == nil {
+		if items, changed := rewriteQueuePaths(q.Items, goalPath, newGoalPath); changed {
+			q.Items = items
+			err = s.writeQueue(q)
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("updating queue.md: %w", err))
+	}
+
 	var newGoalDisplay string
 	if newParentPath == "" {
 		newGoalDisplay = "(root)"
 	} else {
 		newGoalDisplay = newParentPath
 	}
+	s.recordEvent(Event{Type: EventMove, Path: goalPath, To: newGoalPath})
 	s.Commit("move " + goalPath + " → " + newGoalDisplay)
+	if len(errs) > 0 {
+		return fmt.Errorf("moved %s to %s, but %w", goalPath, newGoalPath, errors.Join(errs...))
+	}
 	return nil
 }
 
+// rewriteQueuePaths replaces oldPath (and any path beneath it) with newPath
+// in queue items. It reports whether anything changed.
+func rewriteQueuePaths(items []string, oldPath, newPath string) ([]string, bool) {
+	changed := false
+	result := make([]string, len(items))
+	for i, item := range items {
+		switch {
+		case item == oldPath:
+			result[i] = newPath
+			changed = true
+		case strings.HasPrefix(item, oldPath+string(filepath.Separator)):
+			result[i] = newPath + item[len(oldPath):]
+			changed = true
+		default:
+			result[i] = item
+		}
+	}
+	return result, changed
+}
+
 // getSiblingOrder returns the ordered list of child directory names for a parent path.
 // If children_order is set, it uses that; otherwise falls back to directory listing order.
 func (s *Store) getSiblingOrder(parentPath string) ([]string, error) {
-	var dir string
-	if parentPath == "" {
-		dir = s.GoalsDir()
-	} else {
-		dir = filepath.Join(s.GoalsDir(), parentPath)
-	}
-
-	entries, err := os.ReadDir(dir)
+	dirNames, err := s.childDirNames(parentPath)
 	if err != nil {
-		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
-	}
-
-	var dirNames []string
-	for _, e := range entries {
-		if e.IsDir() {
-			dirNames = append(dirNames, e.Name())
-		}
+		return nil, err
 	}
 
 	// Check for existing children_order
@@ -557,7 +978,7 @@ func (s *Store) getSiblingOrder(parentPath string) ([]string, error) {
 		// Top-level: check goals/goal.md
 		topGoalPath := filepath.Join(s.GoalsDir(), "goal.md")
 		if data, err := os.ReadFile(topGoalPath); err == nil {
-			if topGoal, err := ParseFrontmatter(string(data)); err == nil {
+			if topGoal, err := s.parse(string(data)); err == nil {
 				order = topGoal.ChildrenOrder
 			}
 		}
@@ -568,45 +989,87 @@ func (s *Store) getSiblingOrder(parentPath string) ([]string, error) {
 		}
 	}
 
-	if len(order) > 0 {
-		// Merge: use order first, then append any not in order
-		seen := make(map[string]bool)
-		dirSet := make(map[string]bool)
-		for _, d := range dirNames {
-			dirSet[d] = true
-		}
-		var result []string
-		for _, name := range order {
-			if dirSet[name] {
-				result = append(result, name)
-				seen[name] = true
-			}
+	return mergeOrder(order, dirNames), nil
+}
+
+// childDirNames lists the child goal directories of parentPath in directory order.
+func (s *Store) childDirNames(parentPath string) ([]string, error) {
+	dir := filepath.Join(s.GoalsDir(), parentPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
 		}
-		for _, name := range dirNames {
-			if !seen[name] {
-				result = append(result, name)
-			}
+	}
+	return names, nil
+}
+
+// mergeOrder returns names arranged by order: entries listed in order come
+// first (skipping any not present in names), followed by the remaining names
+// in their original sequence.
+func mergeOrder(order, names []string) []string {
+	if len(order) == 0 {
+		return names
+	}
+	result := filterOrder(order, names)
+	seen := make(map[string]bool, len(result))
+	for _, name := range result {
+		seen[name] = true
+	}
+	for _, name := range names {
+		if !seen[name] {
+			result = append(result, name)
 		}
-		return result, nil
 	}
+	return result
+}
 
-	return dirNames, nil
+// filterOrder drops entries of order that aren't in names, and duplicates.
+func filterOrder(order, names []string) []string {
+	present := make(map[string]bool, len(names))
+	for _, n := range names {
+		present[n] = true
+	}
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range order {
+		if present[name] && !seen[name] {
+			result = append(result, name)
+			seen[name] = true
+		}
+	}
+	return result
 }
 
 // saveChildrenOrder persists the children_order to the appropriate goal.md.
+// Entries without a matching directory (left behind by a crash or an
+// out-of-band delete) are dropped so they can't be written back.
 func (s *Store) saveChildrenOrder(parentPath string, order []string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	names, err := s.childDirNames(parentPath)
+	if err != nil {
+		return err
+	}
+	order = filterOrder(order, names)
 	if parentPath == "" {
 		// Top-level: save to goals/goal.md
 		topGoalPath := filepath.Join(s.GoalsDir(), "goal.md")
 		var goal *Goal
 		if data, err := os.ReadFile(topGoalPath); err == nil {
-			goal, _ = ParseFrontmatter(string(data))
+			goal, _ = s.parse(string(data))
 		}
 		if goal == nil {
 			goal = &Goal{}
 		}
 		goal.ChildrenOrder = order
-		content, err := SerializeFrontmatter(goal)
+		content, err := s.serialize(goal)
 		if err != nil {
 			return err
 		}
@@ -664,28 +1127,25 @@ func (s *Store) updateGoalPaths(goalPath string) {
 	// They are derived from the filesystem at load time. This is a no-op.
 }
 
-// GoalsByHorizon returns goals grouped by their temporal horizon.
-func (s *Store) GoalsByHorizon() (today, tomorrow, future []*Goal, err error) {
-	allGoals, err := s.LoadGoalTree()
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	var categorize func(goals []*Goal)
-	categorize = func(goals []*Goal) {
-		for _, g := range goals {
-			switch g.Horizon {
-			case HorizonToday:
-				today = append(today, g)
-			case HorizonTomorrow:
-				tomorrow = append(tomorrow, g)
-			default:
-				future = append(future, g)
-			}
-			categorize(g.Children)
+// GoalsByHorizon groups top-level goals by their temporal horizon, matching
+// the TUI's sections: one per configured horizon (see Horizons), with goals
+// without a horizon in the last. Goals whose horizon isn't configured keep
+// it as their key, like the TUI's OTHER section. Sub-goals travel with their
+// top-level ancestor (reach them through Children) so nothing is counted
+// twice.
+func (s *Store) GoalsByHorizon() (map[Horizon][]*Goal, error) {
+	far := FarHorizon(s.opts.Horizons)
+	groups := make(map[Horizon][]*Goal)
+	err := s.Walk(func(g *Goal, _ int) error {
+		h := g.Horizon
+		if h == "" {
+			h = far
 		}
+		groups[h] = append(groups[h], g)
+		return SkipChildren
+	})
+	if err != nil {
+		return nil, err
 	}
-	categorize(allGoals)
-
-	return today, tomorrow, future, nil
+	return groups, nil
 }
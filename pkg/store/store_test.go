@@ -1,9 +1,12 @@
 package store
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -82,6 +85,30 @@ func TestLoadGoalTree(t *testing.T) {
 	assert.Len(t, otr.Children, 2)
 }
 
+func TestLoadFrontmatters(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.SetHorizon("otr", HorizonToday)
+	require.NoError(t, err)
+	_, err = s.AddNote("otr/ios", "not read")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(s.GoalsDir(), ".trash", "old"), 0755))
+
+	goals, err := s.LoadFrontmatters()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "otr", goals[0].Path)
+	assert.Equal(t, HorizonToday, goals[0].Horizon)
+	assert.Equal(t, "otr/ios", goals[1].Path)
+	assert.Equal(t, "ios", goals[1].Slug)
+	assert.Empty(t, goals[1].Body)
+	assert.Nil(t, goals[0].Children)
+}
+
 func TestToggleStatus(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -120,6 +147,79 @@ func TestSetHorizon(t *testing.T) {
 	assert.Equal(t, HorizonToday, goal.Horizon)
 }
 
+func TestConfiguredHorizons(t *testing.T) {
+	horizons := []Horizon{"today", "this-week", "someday"}
+	s, err := NewStoreWithOptions(t.TempDir(), Options{Horizons: horizons})
+	require.NoError(t, err)
+	assert.Equal(t, horizons, s.Horizons())
+
+	goal, err := s.CreateGoal("", "test")
+	require.NoError(t, err)
+	assert.Equal(t, Horizon("someday"), goal.Horizon, "new goals default to the last horizon")
+
+	goal, err = s.SetHorizon("test", "this-week")
+	require.NoError(t, err)
+	assert.Equal(t, Horizon("this-week"), goal.Horizon)
+
+	_, err = s.SetHorizon("test", HorizonFuture)
+	assert.EqualError(t, err, "invalid horizon: future (use today, this-week, or someday)")
+
+	problems := Diagnose([]*Goal{{Path: "old", Horizon: HorizonTomorrow}, {Path: "new", Horizon: "someday"}}, s.Horizons())
+	require.Len(t, problems, 1)
+	assert.Equal(t, Problem{Path: "old", Field: "horizon", Message: "invalid horizon: tomorrow (use today, this-week, or someday)"}, problems[0])
+
+	assert.Equal(t, []string{"today", "this-week", "someday"}, HorizonNames(horizons))
+	assert.Equal(t, DefaultHorizons, setupTestStore(t).Horizons())
+	assert.Equal(t, HorizonFuture, FarHorizon(DefaultHorizons))
+}
+
+func TestSaveGoalSkipsNoOp(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "test")
+	require.NoError(t, err)
+	before, err := s.SetHorizon("test", HorizonToday)
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(s.GoalFile("test"), past, past))
+
+	goal, err := s.SetHorizon("test", HorizonToday)
+	require.NoError(t, err)
+	info, err := os.Stat(s.GoalFile("test"))
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(past), "the file isn't written")
+	assert.True(t, goal.Updated.Equal(before.Updated), "updated isn't bumped")
+
+	// A forced save always writes
+	require.NoError(t, s.ForceSaveGoal(goal))
+	info, err = os.Stat(s.GoalFile("test"))
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().After(past))
+	assert.True(t, goal.Updated.After(before.Updated))
+}
+
+func TestTogglePinSurvivesMove(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "parent")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "test")
+	require.NoError(t, err)
+
+	goal, err := s.TogglePin("test")
+	require.NoError(t, err)
+	assert.True(t, goal.Pinned)
+
+	require.NoError(t, s.MoveGoal("test", "parent"))
+	goal, err = s.LoadGoal("parent/test")
+	require.NoError(t, err)
+	assert.True(t, goal.Pinned)
+
+	goal, err = s.TogglePin("parent/test")
+	require.NoError(t, err)
+	assert.False(t, goal.Pinned)
+}
+
 func TestAddNote(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -167,6 +267,15 @@ func TestQueue(t *testing.T) {
 	q2, err := s.LoadQueue()
 	require.NoError(t, err)
 	assert.Equal(t, []string{"otr", "infra"}, q2.Items)
+
+	g, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	g.Title = "iOS OTR"
+	require.NoError(t, s.SaveGoal(g))
+	require.NoError(t, s.SaveQueue(q2))
+	data, err := os.ReadFile(s.QueuePath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "1. otr  <!-- iOS OTR -->\n2. infra\n")
 }
 
 func TestSearchNotes(t *testing.T) {
@@ -182,12 +291,65 @@ func TestSearchNotes(t *testing.T) {
 	_, err = s.AddNote("project-b", "Write documentation")
 	require.NoError(t, err)
 
-	matches, err := s.SearchNotes("authentication")
+	matches, err := s.SearchNotes("authentication", SearchOptions{})
 	require.NoError(t, err)
 	assert.Len(t, matches, 1)
 	assert.Equal(t, "project-a", matches[0].Slug)
 }
 
+func TestSearchNotesMatchesPath(t *testing.T) {
+	s := setupTestStore(t)
+
+	g, err := s.CreateGoal("", "infra-migration")
+	require.NoError(t, err)
+	g.Title = "Infrastructure Migration 2026"
+	require.NoError(t, s.SaveGoal(g))
+	_, err = s.CreateGoal("", "docs")
+	require.NoError(t, err)
+
+	matches, err := s.SearchNotes("infra-mig", SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "infra-migration", matches[0].Path)
+}
+
+func TestLoadGoalWithBrokenFrontmatter(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "broken")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "unclosed")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(s.GoalFile("broken"), []byte("---\ntitle: [oops\n---\n## 2026-01-02\n- keep me\n"), 0644))
+	require.NoError(t, os.WriteFile(s.GoalFile("unclosed"), []byte("---\ntitle: Unclosed\n- keep me too\n"), 0644))
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	broken := FindGoal(goals, "broken")
+	require.NotNil(t, broken, "still in the tree")
+	assert.Error(t, broken.FrontmatterErr)
+	assert.Equal(t, "## 2026-01-02\n- keep me", broken.Body)
+	assert.Equal(t, 1, broken.NoteCount)
+	unclosed := FindGoal(goals, "unclosed")
+	require.NotNil(t, unclosed)
+	assert.Error(t, unclosed.FrontmatterErr)
+	assert.Contains(t, unclosed.Body, "keep me too", "the whole file when the frontmatter doesn't end")
+
+	// Saving would overwrite the file
+	_, err = s.AddNote("broken", "more")
+	assert.ErrorIs(t, err, ErrBadFrontmatter)
+	data, err := os.ReadFile(s.GoalFile("broken"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "title: [oops")
+
+	problems := Diagnose(goals, DefaultHorizons)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "frontmatter", problems[0].Field)
+
+	_, err = ParseFrontmatter("---\ntitle: [oops\n---\n")
+	assert.Error(t, err, "strict parsing is still available")
+}
+
 func TestReorderGoal(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -236,6 +398,42 @@ func TestReorderGoal(t *testing.T) {
 	assert.Equal(t, "alpha", goals[0].Slug)
 }
 
+func TestMoveToIndex(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "parent")
+	require.NoError(t, err)
+	for _, slug := range []string{"aaa", "bbb", "ccc", "ddd"} {
+		_, err := s.CreateGoal("parent", slug)
+		require.NoError(t, err)
+	}
+	children := func() []string {
+		t.Helper()
+		goals, err := s.LoadGoalTree()
+		require.NoError(t, err)
+		var slugs []string
+		for _, c := range goals[0].Children {
+			slugs = append(slugs, c.Slug)
+		}
+		return slugs
+	}
+
+	// First to last
+	require.NoError(t, s.MoveToIndex(filepath.Join("parent", "aaa"), 3))
+	assert.Equal(t, []string{"bbb", "ccc", "ddd", "aaa"}, children())
+
+	// Last to first
+	require.NoError(t, s.MoveToIndex(filepath.Join("parent", "aaa"), 0))
+	assert.Equal(t, []string{"aaa", "bbb", "ccc", "ddd"}, children())
+
+	// Out-of-range indices clamp
+	require.NoError(t, s.MoveToIndex(filepath.Join("parent", "bbb"), 99))
+	assert.Equal(t, []string{"aaa", "ccc", "ddd", "bbb"}, children())
+	require.NoError(t, s.MoveToIndex(filepath.Join("parent", "ddd"), -5))
+	assert.Equal(t, []string{"ddd", "aaa", "ccc", "bbb"}, children())
+
+	assert.Error(t, s.MoveToIndex(filepath.Join("parent", "zzz"), 0))
+}
+
 func TestReorderSubGoal(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -299,6 +497,52 @@ func TestMoveGoalUnparent(t *testing.T) {
 	assert.True(t, os.IsNotExist(err))
 }
 
+func TestSetChildrenOrder(t *testing.T) {
+	s := setupTestStore(t)
+	for _, slug := range []string{"alpha", "beta", "gamma"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.SetChildrenOrder("", []string{"gamma", "missing", "alpha"}))
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	var slugs []string
+	for _, g := range goals {
+		slugs = append(slugs, g.Slug)
+	}
+	assert.Equal(t, []string{"gamma", "alpha", "beta"}, slugs, "unknown slugs dropped, unlisted ones kept")
+}
+
+func TestReorderDropsStaleChildrenOrder(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "parent")
+	require.NoError(t, err)
+	for _, slug := range []string{"a", "b", "c"} {
+		_, err := s.CreateGoal("parent", slug)
+		require.NoError(t, err)
+	}
+
+	// Simulate a crash that left a deleted slug (and a repeat) in the order
+	parent, err := s.LoadGoal("parent")
+	require.NoError(t, err)
+	parent.ChildrenOrder = []string{"a", "ghost", "b", "c", "a"}
+	require.NoError(t, s.SaveGoal(parent))
+
+	require.NoError(t, s.ReorderGoal("parent/c", -1))
+
+	parent, err = s.LoadGoal("parent")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "b"}, parent.ChildrenOrder)
+
+	// Writers that don't go through the merged sibling order are filtered too
+	require.NoError(t, s.saveChildrenOrder("parent", []string{"ghost", "b", "a", "c"}))
+	parent, err = s.LoadGoal("parent")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "c"}, parent.ChildrenOrder)
+}
+
 func TestMoveGoalReparent(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -347,6 +591,71 @@ func TestMoveGoalIntoSelfFails(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestMoveGoalRewritesQueue(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "alpha")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "beta")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("beta", "leaf")
+	require.NoError(t, err)
+	require.NoError(t, s.SaveQueue(&Queue{Items: []string{"beta", filepath.Join("beta", "leaf"), "alpha", "betamax"}}))
+
+	require.NoError(t, s.MoveGoal("beta", "alpha"))
+
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("alpha", "beta"),
+		filepath.Join("alpha", "beta", "leaf"),
+		"alpha",
+		"betamax", // shares a prefix but isn't a descendant
+	}, q.Items)
+}
+
+func TestMoveGoalReportsQueueFailure(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "alpha")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "beta")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(s.QueuePath(), 0755)) // unreadable as a file
+
+	err = s.MoveGoal("beta", "alpha")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue.md")
+	_, err = s.LoadGoal(filepath.Join("alpha", "beta"))
+	assert.NoError(t, err, "the goal itself still moved")
+}
+
+func TestMoveGoalClearsHorizonWhenNested(t *testing.T) {
+	s := setupTestStore(t)
+
+	_, err := s.CreateGoal("", "alpha")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "beta")
+	require.NoError(t, err)
+	_, err = s.SetHorizon("beta", HorizonToday)
+	require.NoError(t, err)
+
+	require.NoError(t, s.MoveGoal("beta", "alpha"))
+	moved, err := s.LoadGoal(filepath.Join("alpha", "beta"))
+	require.NoError(t, err)
+	assert.Empty(t, moved.Horizon)
+
+	// Nested-to-nested moves leave the horizon alone
+	_, err = s.CreateGoal("", "gamma")
+	require.NoError(t, err)
+	_, err = s.SetHorizon(filepath.Join("alpha", "beta"), HorizonTomorrow)
+	require.NoError(t, err)
+	require.NoError(t, s.MoveGoal(filepath.Join("alpha", "beta"), "gamma"))
+	moved, err = s.LoadGoal(filepath.Join("gamma", "beta"))
+	require.NoError(t, err)
+	assert.Equal(t, HorizonTomorrow, moved.Horizon)
+}
+
 func TestChildrenOrderRoundTrip(t *testing.T) {
 	s := setupTestStore(t)
 
@@ -393,9 +702,283 @@ func TestGoalsByHorizon(t *testing.T) {
 	require.NoError(t, err)
 	// default horizon is future
 
-	today, tomorrow, future, err := s.GoalsByHorizon()
+	_, err = s.CreateGoal("urgent", "step")
+	require.NoError(t, err)
+
+	groups, err := s.GoalsByHorizon()
+	require.NoError(t, err)
+	assert.Len(t, groups[HorizonToday], 1)
+	assert.Len(t, groups[HorizonTomorrow], 1)
+	assert.Len(t, groups[HorizonFuture], 1, "sub-goals are grouped with their top-level goal")
+	assert.Len(t, groups[HorizonToday][0].Children, 1)
+}
+
+func TestGoalsByConfiguredHorizon(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{Horizons: []Horizon{"now", "next", "someday"}})
+	require.NoError(t, err)
+	for _, slug := range []string{"a", "b", "c", "d"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+	}
+	_, err = s.SetHorizon("a", "now")
+	require.NoError(t, err)
+	_, err = s.SetHorizon("b", "next")
+	require.NoError(t, err)
+	d, err := s.LoadGoal("d")
+	require.NoError(t, err)
+	d.Horizon = "" // from before it had one
+	require.NoError(t, s.SaveGoal(d))
+	g, err := s.CreateGoal("", "old")
+	require.NoError(t, err)
+	g.Horizon = HorizonTomorrow // no longer configured
+	require.NoError(t, s.SaveGoal(g))
+
+	groups, err := s.GoalsByHorizon()
+	require.NoError(t, err)
+	paths := func(h Horizon) (out []string) {
+		for _, g := range groups[h] {
+			out = append(out, g.Path)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"a"}, paths("now"))
+	assert.Equal(t, []string{"b"}, paths("next"))
+	assert.ElementsMatch(t, []string{"c", "d"}, paths("someday"), "no horizon counts in the last one")
+	assert.Equal(t, []string{"old"}, paths(HorizonTomorrow))
+	assert.Empty(t, groups[HorizonFuture])
+}
+
+func TestNewStoreIsLazy(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := NewStore(dir)
+	require.NoError(t, err)
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err), "opening a store must not create the data directory")
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Empty(t, goals)
+
+	_, err = s.CreateGoal("", "first")
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(s.GoalsDir(), "first", "goal.md"))
+	assert.NoError(t, err)
+}
+
+func TestReadOnlyStore(t *testing.T) {
+	dir := t.TempDir()
+	rw, err := NewStore(dir)
+	require.NoError(t, err)
+	_, err = rw.CreateGoal("", "existing")
+	require.NoError(t, err)
+
+	s, err := NewStoreWithOptions(dir, Options{ReadOnly: true})
+	require.NoError(t, err)
+	assert.True(t, s.ReadOnly())
+
+	g, err := s.LoadGoal("existing")
+	require.NoError(t, err)
+	assert.Equal(t, "existing", g.Title)
+
+	_, err = s.CreateGoal("", "new")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = s.SetHorizon("existing", HorizonToday)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, s.DeleteGoal("existing"), ErrReadOnly)
+	assert.ErrorIs(t, s.SaveQueue(&Queue{}), ErrReadOnly)
+	assert.ErrorIs(t, s.Init(), ErrReadOnly)
+
+	_, err = os.Stat(filepath.Join(s.GoalsDir(), "new"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReadOnlyStoreDoesNotCreateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	s, err := NewStoreWithOptions(dir, Options{ReadOnly: true})
+	require.NoError(t, err)
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Empty(t, goals)
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStoreDirPerm(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	s, err := NewStoreWithOptions(dir, Options{DirPerm: 0700})
+	require.NoError(t, err)
+
+	_, err = s.CreateGoal("", "private")
+	require.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(s.GoalsDir(), "private"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestStorePermissionErrorIsActionable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks don't apply to root")
+	}
+	parent := t.TempDir()
+	require.NoError(t, os.Chmod(parent, 0500))
+	t.Cleanup(func() { os.Chmod(parent, 0755) })
+
+	s, err := NewStore(filepath.Join(parent, "data"))
+	require.NoError(t, err)
+
+	_, err = s.CreateGoal("", "nope")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.Contains(t, err.Error(), "--read-only")
+}
+
+func TestStoreDefaultHorizonOption(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{DefaultHorizon: HorizonToday})
+	require.NoError(t, err)
+
+	goal, err := s.CreateGoal("", "task")
+	require.NoError(t, err)
+	assert.Equal(t, HorizonToday, goal.Horizon)
+}
+
+func TestSubtreeSummary(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "infra")
+	require.NoError(t, err)
+	_, err = s.AddNote("otr", "kickoff")
+	require.NoError(t, err)
+	_, err = s.AddNote(filepath.Join("otr", "ios"), "shipped")
+	require.NoError(t, err)
+
+	summary, err := s.SubtreeSummary("otr")
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Goals)
+	assert.Equal(t, 4, summary.NoteLines, "a date header and a bullet per note")
+	assert.Equal(t, []string{"otr", filepath.Join("otr", "ios")}, summary.Paths)
+
+	_, err = s.SubtreeSummary("missing")
+	assert.Error(t, err)
+}
+
+func TestEventLog(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStoreWithOptions(dir, Options{EventLog: true})
+	require.NoError(t, err)
+
+	_, err = s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("", "infra")
+	require.NoError(t, err)
+	_, err = s.AddNote("otr", "kickoff")
+	require.NoError(t, err)
+	_, err = s.SetStatus("otr", StatusComplete)
+	require.NoError(t, err)
+	_, err = s.AddNote("otr", "follow-up") // already complete: no second event
+	require.NoError(t, err)
+	require.NoError(t, s.MoveGoal("otr", "infra"))
+	require.NoError(t, s.DeleteGoal("infra"))
+
+	data, err := os.ReadFile(EventsPath(dir))
+	require.NoError(t, err)
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		assert.False(t, e.Time.IsZero())
+		got = append(got, e.Type+" "+e.Path+" "+e.To+e.Text)
+	}
+	assert.Equal(t, []string{
+		"create otr ",
+		"create infra ",
+		"note otr kickoff",
+		"complete otr ",
+		"note otr follow-up",
+		"move otr " + filepath.Join("infra", "otr"),
+		"delete infra ",
+	}, got)
+}
+
+func TestOnEvent(t *testing.T) {
+	s := setupTestStore(t)
+	var got []string
+	s.OnEvent(func(e Event) {
+		assert.False(t, e.Time.IsZero())
+		got = append(got, e.Type+" "+e.Path)
+	})
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.SetStatus("otr", StatusComplete)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create otr", "complete otr"}, got)
+
+	_, err = os.Stat(EventsPath(s.Root))
+	assert.True(t, os.IsNotExist(err), "the event log stays off")
+}
+
+func TestEventLogIsOptIn(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = os.Stat(EventsPath(s.Root))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCreateGoalDefaults(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{
+		DefaultHorizon: HorizonToday,
+		DefaultTags:    []string{"crunch", "q3"},
+		BodyTemplate:   "# {{title}}\n\nStarted {{date}}\n",
+	})
+	require.NoError(t, err)
+
+	g, err := s.CreateGoal("", "launch")
+	require.NoError(t, err)
+	assert.Equal(t, HorizonToday, g.Horizon)
+
+	loaded, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"crunch", "q3"}, loaded.Tags)
+	assert.Equal(t, "# launch\n\nStarted "+time.Now().Format("2006-01-02"), strings.TrimSpace(loaded.Body))
+}
+
+func TestCreateGoalUnderCompleteParent(t *testing.T) {
+	s := setupTestStore(t)
+	var warnings []error
+	s.OnWarning(func(err error) { warnings = append(warnings, err) })
+	_, err := s.CreateGoal("", "launch")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("launch", "design")
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	_, err = s.SetStatus("launch", StatusComplete)
+	require.NoError(t, err)
+	g, err := s.CreateGoal("launch", "followup")
+	require.NoError(t, err, "a complete parent doesn't stop creation")
+	assert.Equal(t, "launch/followup", g.Path)
+	require.Len(t, warnings, 1)
+	assert.ErrorContains(t, warnings[0], "launch is complete")
+}
+
+func TestCreateGoalBodyTemplateFails(t *testing.T) {
+	s, err := NewStoreWithOptions(t.TempDir(), Options{BodyTemplate: "{{title"})
+	require.NoError(t, err)
+	var warnings []error
+	s.OnWarning(func(err error) { warnings = append(warnings, err) })
+
+	_, err = s.CreateGoal("", "launch")
+	require.NoError(t, err, "a broken template doesn't stop creation")
+	require.Len(t, warnings, 1)
+	assert.ErrorContains(t, warnings[0], "body template")
+
+	g, err := s.LoadGoal("launch")
 	require.NoError(t, err)
-	assert.Len(t, today, 1)
-	assert.Len(t, tomorrow, 1)
-	assert.Len(t, future, 1)
+	assert.Empty(t, strings.TrimSpace(g.Body))
 }
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SubtreeSummary describes a goal and everything under it, e.g. to show
+// what a delete would remove.
+type SubtreeSummary struct {
+	Goals     int      `json:"goals"`      // the goal itself and its descendants
+	NoteLines int      `json:"note_lines"` // non-blank body lines across them
+	Paths     []string `json:"paths"`      // in tree order, the goal first
+}
+
+// SubtreeSummary counts the goals and note lines at and below goalPath.
+func (s *Store) SubtreeSummary(goalPath string) (*SubtreeSummary, error) {
+	return subtreeSummary(s, goalPath)
+}
+
+// SubtreeSummary counts the goals and note lines at and below goalPath,
+// like Store.SubtreeSummary.
+func (s *MemStore) SubtreeSummary(goalPath string) (*SubtreeSummary, error) {
+	return subtreeSummary(s, goalPath)
+}
+
+func subtreeSummary(b Backend, goalPath string) (*SubtreeSummary, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+	root := findInTree(goals, goalPath)
+	if root == nil {
+		return nil, fmt.Errorf("goal %s not found", goalPath)
+	}
+
+	summary := &SubtreeSummary{}
+	var walk func(*Goal)
+	walk = func(g *Goal) {
+		summary.Goals++
+		summary.NoteLines += countNoteLines(g.Body)
+		summary.Paths = append(summary.Paths, g.Path)
+		for _, c := range g.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	return summary, nil
+}
+
+// findInTree returns the goal at goalPath in goals, or nil.
+func findInTree(goals []*Goal, goalPath string) *Goal {
+	for _, g := range goals {
+		if g.Path == goalPath {
+			return g
+		}
+		if found := findInTree(g.Children, goalPath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// countNoteLines counts the non-blank lines of a goal body.
+func countNoteLines(body string) int {
+	n := 0
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// trashMeta is the file in each trash entry recording where it came from.
+const trashMeta = "trash.json"
+
+// TrashItem is a deleted goal kept in TrashDir until it's restored or
+// purged. Its directory is kept whole, sub-goals included.
+type TrashItem struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"` // where the goal was
+	Deleted time.Time `json:"deleted"`
+}
+
+// TrashDir returns where DeleteGoal moves goals in dataDir. Being under
+// RuntimeDir it's never loaded, searched, watched or committed.
+func TrashDir(dataDir string) string {
+	return filepath.Join(dataDir, RuntimeDir, "trash")
+}
+
+// trashGoal moves goalPath's directory into the trash.
+func (s *Store) trashGoal(goalPath string) error {
+	now := time.Now().UTC()
+	item := TrashItem{
+		ID:      now.Format("20060102T150405.000000000") + "-" + filepath.Base(goalPath),
+		Path:    goalPath,
+		Deleted: now,
+	}
+	entry := filepath.Join(TrashDir(s.Root), item.ID)
+	if err := os.MkdirAll(entry, s.opts.DirPerm); err != nil {
+		return fmt.Errorf("creating trash entry: %w", err)
+	}
+	meta, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(entry, trashMeta), meta, 0644); err != nil {
+		return fmt.Errorf("creating trash entry: %w", err)
+	}
+	if err := os.Rename(filepath.Join(s.GoalsDir(), goalPath), filepath.Join(entry, "goal")); err != nil {
+		os.RemoveAll(entry)
+		return fmt.Errorf("moving %s to the trash: %w", goalPath, err)
+	}
+	return nil
+}
+
+// ListTrash returns the goals in the trash, most recently deleted first.
+func (s *Store) ListTrash() ([]TrashItem, error) {
+	entries, err := os.ReadDir(TrashDir(s.Root))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []TrashItem
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(TrashDir(s.Root), e.Name(), trashMeta))
+		if err != nil {
+			continue // not an entry
+		}
+		var item TrashItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			continue
+		}
+		item.ID = e.Name()
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Deleted.After(items[j].Deleted) })
+	return items, nil
+}
+
+// RestoreTrash moves a goal out of the trash back to where it was and
+// commits it. ref is a TrashItem's ID or the goal's old path, which picks
+// its most recent deletion. It returns the restored goal's path.
+func (s *Store) RestoreTrash(ref string) (string, error) {
+	if err := s.writable(); err != nil {
+		return "", err
+	}
+	items, err := s.ListTrash()
+	if err != nil {
+		return "", err
+	}
+	ref = filepath.Clean(ref)
+	var item *TrashItem
+	for i := range items {
+		if items[i].ID == ref || items[i].Path == ref {
+			item = &items[i]
+			break
+		}
+	}
+	if item == nil {
+		return "", fmt.Errorf("%s is not in the trash", ref)
+	}
+
+	dst := filepath.Join(s.GoalsDir(), item.Path)
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("goal %s already exists", item.Path)
+	}
+	if parent := parentOf(item.Path); parent != "" {
+		if _, err := os.Stat(filepath.Join(s.GoalsDir(), parent)); err != nil {
+			return "", fmt.Errorf("can't restore %s: its parent %s no longer exists", item.Path, parent)
+		}
+	}
+	entry := filepath.Join(TrashDir(s.Root), item.ID)
+	if err := os.Rename(filepath.Join(entry, "goal"), dst); err != nil {
+		return "", fmt.Errorf("restoring %s: %w", item.Path, err)
+	}
+	os.RemoveAll(entry)
+	s.Commit("restore goal: " + item.Path)
+	return item.Path, nil
+}
+
+// EmptyTrash permanently removes everything in the trash and returns how
+// many goals it removed.
+func (s *Store) EmptyTrash() (int, error) {
+	return s.purgeTrash(func(TrashItem) bool { return true })
+}
+
+// PurgeTrash permanently removes goals deleted more than retention before
+// now and returns how many it removed.
+func (s *Store) PurgeTrash(retention time.Duration, now time.Time) (int, error) {
+	return s.purgeTrash(func(item TrashItem) bool { return now.Sub(item.Deleted) > retention })
+}
+
+func (s *Store) purgeTrash(expired func(TrashItem) bool) (int, error) {
+	items, err := s.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, item := range items {
+		if !expired(item) {
+			continue
+		}
+		if s.opts.ReadOnly {
+			return purged, ErrReadOnly
+		}
+		if err := os.RemoveAll(filepath.Join(TrashDir(s.Root), item.ID)); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteGoalRestore(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.AddNote(filepath.Join("otr", "ios"), "ship it")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteGoal("otr"))
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Empty(t, goals, "trashed goals don't load")
+	results, err := s.SearchNotes("ship", SearchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results, "or show up in search")
+
+	items, err := s.ListTrash()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "otr", items[0].Path)
+
+	restored, err := s.RestoreTrash("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "otr", restored)
+	g, err := s.LoadGoal(filepath.Join("otr", "ios"))
+	require.NoError(t, err)
+	assert.Contains(t, g.Body, "ship it", "sub-goals come back with their notes")
+
+	items, err = s.ListTrash()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	_, err = s.RestoreTrash("otr")
+	assert.ErrorContains(t, err, "not in the trash")
+}
+
+func TestRestoreTrashConflicts(t *testing.T) {
+	s := setupTestStore(t)
+	_, err := s.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteGoal(filepath.Join("otr", "ios")))
+	_, err = s.CreateGoal("otr", "ios")
+	require.NoError(t, err)
+	_, err = s.RestoreTrash(filepath.Join("otr", "ios"))
+	assert.ErrorContains(t, err, "already exists")
+
+	require.NoError(t, s.DeleteGoal("otr"))
+	items, err := s.ListTrash()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "otr", items[0].Path, "newest first")
+	_, err = s.RestoreTrash(items[1].ID)
+	assert.ErrorContains(t, err, "parent otr no longer exists")
+}
+
+func TestPurgeTrash(t *testing.T) {
+	s := setupTestStore(t)
+	for _, slug := range []string{"old", "new"} {
+		_, err := s.CreateGoal("", slug)
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteGoal(slug))
+	}
+	now := time.Now()
+	backdateTrash(t, s, "old", now.Add(-40*24*time.Hour))
+
+	n, err := s.PurgeTrash(30*24*time.Hour, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	items, err := s.ListTrash()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "new", items[0].Path)
+
+	n, err = s.EmptyTrash()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	items, err = s.ListTrash()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+// backdateTrash rewrites when the trashed goalPath was deleted.
+func backdateTrash(t *testing.T, s *Store, goalPath string, deleted time.Time) {
+	t.Helper()
+	items, err := s.ListTrash()
+	require.NoError(t, err)
+	for _, item := range items {
+		if item.Path == goalPath {
+			item.Deleted = deleted
+			data, err := json.Marshal(item)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(TrashDir(s.Root), item.ID, trashMeta), data, 0644))
+			return
+		}
+	}
+	t.Fatalf("%s is not in the trash", goalPath)
+}
@@ -1,6 +1,11 @@
 package store
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // GoalStatus represents the completion state of a goal.
 type GoalStatus string
@@ -11,6 +16,15 @@ const (
 	StatusComplete   GoalStatus = "complete"
 )
 
+// ParseStatus validates a status name.
+func ParseStatus(s string) (GoalStatus, error) {
+	switch st := GoalStatus(s); st {
+	case StatusIncomplete, StatusInProgress, StatusComplete:
+		return st, nil
+	}
+	return "", fmt.Errorf("invalid status: %s (use incomplete, in-progress, or complete)", s)
+}
+
 // Horizon represents the temporal priority of a goal.
 type Horizon string
 
@@ -20,21 +34,88 @@ const (
 	HorizonFuture   Horizon = "future"
 )
 
+// DefaultHorizons are the horizons when the config doesn't list its own.
+var DefaultHorizons = []Horizon{HorizonToday, HorizonTomorrow, HorizonFuture}
+
+// FarHorizon is the last of horizons, future by default. Goals without a
+// horizon are counted in it.
+func FarHorizon(horizons []Horizon) Horizon {
+	if len(horizons) == 0 {
+		return HorizonFuture
+	}
+	return horizons[len(horizons)-1]
+}
+
+// HorizonNames returns the names of horizons, nearest first.
+func HorizonNames(horizons []Horizon) []string {
+	names := make([]string, len(horizons))
+	for i, h := range horizons {
+		names[i] = string(h)
+	}
+	return names
+}
+
+// ParseHorizon validates a horizon name against horizons, a store's
+// configured horizons (see Options.Horizons).
+func ParseHorizon(s string, horizons []Horizon) (Horizon, error) {
+	if h := Horizon(s); slices.Contains(horizons, h) {
+		return h, nil
+	}
+	return "", fmt.Errorf("invalid horizon: %s (use %s)", s, orList(HorizonNames(horizons)))
+}
+
+// orList joins names as "a, b, or c".
+func orList(names []string) string {
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
+
 // Goal represents a goal or sub-goal loaded from a goal.md file.
 type Goal struct {
 	// Frontmatter fields
 	Title         string            `yaml:"title"`
+	Summary       string            `yaml:"summary,omitempty"` // one line shown after the title
 	Status        GoalStatus        `yaml:"status"`
+	StatusLabel   string            `yaml:"status_label,omitempty"` // free-form, e.g. "in review"; Status still decides completion
 	Horizon       Horizon           `yaml:"horizon,omitempty"`
+	HorizonSet    time.Time         `yaml:"horizon_set,omitempty"`
+	Pinned        bool              `yaml:"pinned,omitempty"`
+	Icon          string            `yaml:"icon,omitempty"`     // emoji shown before the title
+	Color         string            `yaml:"color,omitempty"`    // title accent, e.g. "#E05252"
+	Estimate      string            `yaml:"estimate,omitempty"` // effort, e.g. "2h", "3d" or points like "5"
 	Created       time.Time         `yaml:"created"`
 	Updated       time.Time         `yaml:"updated"`
+	Completed     time.Time         `yaml:"completed,omitempty"` // when status last became complete
 	Tags          []string          `yaml:"tags,omitempty"`
 	Links         map[string]string `yaml:"links,omitempty"`
 	ChildrenOrder []string          `yaml:"children_order,omitempty"`
+	NoteFormat    string            `yaml:"note_format,omitempty"`  // overrides the configured NoteTemplate.Format
+	NoteSection   string            `yaml:"note_section,omitempty"` // overrides the configured NoteTemplate.Section
 
 	// Parsed from markdown body
 	Body string `yaml:"-"`
 
+	// Encrypted is set when Body is stored encrypted (see RecipientsFile).
+	// BodyErr says why an encrypted Body couldn't be decrypted; Body is
+	// empty then.
+	Encrypted bool  `yaml:"-"`
+	BodyErr   error `yaml:"-"`
+	// FrontmatterErr says why the goal file's frontmatter couldn't be
+	// parsed. The goal still loads, with the file's body, so its notes stay
+	// reachable, but it can't be saved until the file is fixed.
+	FrontmatterErr error `yaml:"-"`
+	// The encrypted text as read, and what it decrypted to
+	sealed, sealedPlain string
+
+	// Derived from Body on load (see indexNotes)
+	NoteCount int       `yaml:"-"` // notes under date headers
+	LastNote  time.Time `yaml:"-"` // latest date header, local midnight; zero if none
+
 	// Filesystem metadata (not serialized to YAML)
 	Slug     string  `yaml:"-"` // directory name
 	Path     string  `yaml:"-"` // relative path from goals/ (e.g., "otr/ios")
@@ -53,6 +134,17 @@ func (g *Goal) IsInProgress() bool {
 	return g.Status == StatusInProgress
 }
 
+// stampCompleted records when g became complete, and forgets it once g is
+// reopened. Stores call it on every save.
+func (g *Goal) stampCompleted(now time.Time) {
+	switch {
+	case !g.IsComplete():
+		g.Completed = time.Time{}
+	case g.Completed.IsZero():
+		g.Completed = now
+	}
+}
+
 // FullPath returns the slash-separated path suitable for CLI commands.
 func (g *Goal) FullPath() string {
 	return g.Path
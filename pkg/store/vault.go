@@ -0,0 +1,143 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Layout is how goal files are named inside goal directories.
+type Layout string
+
+const (
+	// LayoutCairn names every goal file goal.md: goals/otr/ios/goal.md.
+	LayoutCairn Layout = "cairn"
+	// LayoutObsidian names goal files after their directory, as folder
+	// notes: goals/otr/ios/ios.md. Obsidian shows them with readable names.
+	LayoutObsidian Layout = "obsidian"
+)
+
+// ParseLayout validates a layout name.
+func ParseLayout(s string) (Layout, error) {
+	switch l := Layout(s); l {
+	case LayoutCairn, LayoutObsidian:
+		return l, nil
+	}
+	return "", fmt.Errorf("invalid layout: %s (use cairn or obsidian)", s)
+}
+
+// goalFileName returns the name of the goal file in the directory named
+// dirName under layout.
+func goalFileName(layout Layout, dirName string) string {
+	if layout == LayoutObsidian {
+		return dirName + ".md"
+	}
+	return "goal.md"
+}
+
+// isGoalFile reports whether path names a goal file in either layout.
+func isGoalFile(path string) bool {
+	name := filepath.Base(path)
+	return name == "goal.md" || name == filepath.Base(filepath.Dir(path))+".md"
+}
+
+// GoalFile returns the path of the goal file for goalPath. Both layouts are
+// accepted so a data directory can be converted gradually: the store's own
+// layout wins when both files exist, and its name is returned when neither
+// does.
+func (s *Store) GoalFile(goalPath string) string {
+	dir := filepath.Join(s.GoalsDir(), goalPath)
+	preferred := filepath.Join(dir, goalFileName(s.opts.Layout, filepath.Base(goalPath)))
+	if fileExists(preferred) {
+		return preferred
+	}
+	other := LayoutObsidian
+	if s.opts.Layout == LayoutObsidian {
+		other = LayoutCairn
+	}
+	if alt := filepath.Join(dir, goalFileName(other, filepath.Base(goalPath))); fileExists(alt) {
+		return alt
+	}
+	return preferred
+}
+
+// writeGoalFile writes content as goalPath's goal file under the store's
+// layout and returns its path. oldFile, the file the goal was read from, is
+// removed when it was in the other layout, converting the goal.
+func (s *Store) writeGoalFile(goalPath, content, oldFile string) (string, error) {
+	dir := filepath.Join(s.GoalsDir(), goalPath)
+	filePath := filepath.Join(dir, goalFileName(s.opts.Layout, filepath.Base(goalPath)))
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return "", permissionHint(err, filePath)
+	}
+	if oldFile != "" && oldFile != filePath && filepath.Dir(oldFile) == dir && isGoalFile(oldFile) {
+		if err := os.Remove(oldFile); err != nil && !os.IsNotExist(err) {
+			return "", permissionHint(err, oldFile)
+		}
+	}
+	return filePath, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// parse reads a goal file's content with the store's field aliases,
+// decrypting an encrypted body.
+func (s *Store) parse(content string) (*Goal, error) {
+	g, err := parseGoal(content, s.opts.FieldAliases)
+	if err != nil {
+		return nil, err
+	}
+	s.unseal(g)
+	return g, nil
+}
+
+// parseLenient is parse reporting broken frontmatter in the goal's
+// FrontmatterErr rather than failing (see parseGoalLenient).
+func (s *Store) parseLenient(content string) *Goal {
+	g := parseGoalLenient(content, s.opts.FieldAliases)
+	s.unseal(g)
+	return g
+}
+
+// serialize renders g with the store's field aliases, encrypting its body
+// when the data directory has a RecipientsFile.
+func (s *Store) serialize(g *Goal) (string, error) {
+	body, err := s.sealedBody(g)
+	if err != nil {
+		return "", err
+	}
+	out := *g
+	out.Body = body
+	return serializeGoal(&out, s.opts.FieldAliases)
+}
+
+// checkFieldAliases rejects aliases for fields goal files don't have, and
+// aliases that collide with each other or with another field's name.
+func checkFieldAliases(aliases map[string]string) error {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(Goal{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "-" {
+			fields[name] = true
+		}
+	}
+	seen := map[string]string{}
+	for field, alias := range aliases {
+		if !fields[field] {
+			return fmt.Errorf("invalid field alias: %s is not a goal field", field)
+		}
+		if alias == "" || (fields[alias] && aliases[alias] == "") {
+			return fmt.Errorf("invalid field alias for %s: %q", field, alias)
+		}
+		if other, ok := seen[alias]; ok {
+			return fmt.Errorf("invalid field alias: %s and %s both use %q", other, field, alias)
+		}
+		seen[alias] = field
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		layout  Layout
+		aliases map[string]string
+		file    string // goal file of otr/ios, relative to goals/
+	}{
+		{LayoutCairn, nil, "otr/ios/goal.md"},
+		{LayoutCairn, map[string]string{"status": "cairn-status"}, "otr/ios/goal.md"},
+		{LayoutObsidian, nil, "otr/ios/ios.md"},
+		{LayoutObsidian, map[string]string{"status": "cairn-status", "tags": "cairn-tags"}, "otr/ios/ios.md"},
+	} {
+		t.Run(string(tc.layout), func(t *testing.T) {
+			s, err := NewStoreWithOptions(t.TempDir(), Options{Layout: tc.layout, FieldAliases: tc.aliases})
+			require.NoError(t, err)
+
+			_, err = s.CreateGoal("", "otr")
+			require.NoError(t, err)
+			_, err = s.CreateGoal("otr", "ios")
+			require.NoError(t, err)
+			g, err := s.LoadGoal("otr/ios")
+			require.NoError(t, err)
+			g.Tags = []string{"mobile"}
+			require.NoError(t, s.SaveGoal(g))
+			_, err = s.SetStatus("otr/ios", StatusComplete)
+			require.NoError(t, err)
+			_, err = s.AddNote("otr/ios", "shipped")
+			require.NoError(t, err)
+
+			file := filepath.Join(s.GoalsDir(), filepath.FromSlash(tc.file))
+			assert.Equal(t, file, s.GoalFile("otr/ios"))
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			for field, alias := range tc.aliases {
+				assert.Contains(t, string(data), "\n"+alias+":")
+				assert.NotContains(t, string(data), "\n"+field+":")
+			}
+			entries, err := os.ReadDir(filepath.Dir(file))
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "one goal file per directory")
+
+			goals, err := s.LoadGoalTree()
+			require.NoError(t, err)
+			ios := FindGoal(goals, "otr/ios")
+			require.NotNil(t, ios)
+			assert.Equal(t, StatusComplete, ios.Status)
+			assert.Equal(t, []string{"mobile"}, ios.Tags)
+			assert.Contains(t, ios.Body, "shipped")
+			assert.Equal(t, file, ios.FilePath)
+
+			flat, err := s.LoadFrontmatters()
+			require.NoError(t, err)
+			require.Len(t, flat, 2)
+			assert.Equal(t, StatusComplete, flat[1].Status)
+
+			result, err := s.Normalize(true)
+			require.NoError(t, err)
+			assert.Empty(t, result.Changed, "what the store writes is already canonical")
+		})
+	}
+}
+
+func TestLayoutTransition(t *testing.T) {
+	dir := t.TempDir()
+	cairn, err := NewStore(dir)
+	require.NoError(t, err)
+	_, err = cairn.CreateGoal("", "otr")
+	require.NoError(t, err)
+	_, err = cairn.CreateGoal("", "infra")
+	require.NoError(t, err)
+	_, err = cairn.SetStatus("infra", StatusComplete)
+	require.NoError(t, err)
+
+	// Existing goal.md files keep loading after switching layouts
+	s, err := NewStoreWithOptions(dir, Options{Layout: LayoutObsidian, FieldAliases: map[string]string{"status": "cairn-status"}})
+	require.NoError(t, err)
+	g, err := s.LoadGoal("infra")
+	require.NoError(t, err)
+	assert.Equal(t, StatusComplete, g.Status, "canonical names load under aliases")
+	assert.Equal(t, filepath.Join(dir, "goals", "infra", "goal.md"), g.FilePath)
+
+	// Saving converts the goal
+	g.Title = "Infrastructure"
+	require.NoError(t, s.SaveGoal(g))
+	assert.NoFileExists(t, filepath.Join(dir, "goals", "infra", "goal.md"))
+	assert.FileExists(t, filepath.Join(dir, "goals", "infra", "infra.md"))
+
+	// Normalize converts the rest
+	result, err := s.Normalize(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"otr"}, result.Changed)
+	assert.NoFileExists(t, filepath.Join(dir, "goals", "otr", "goal.md"))
+	data, err := os.ReadFile(filepath.Join(dir, "goals", "otr", "otr.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "cairn-status: incomplete")
+
+	// And the old layout still reads the converted files
+	goals, err := cairn.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	infra := FindGoal(goals, "infra")
+	require.NotNil(t, infra)
+	assert.Equal(t, "Infrastructure", infra.Title)
+}
+
+func TestStoreOptionsRejectBadLayoutAndAliases(t *testing.T) {
+	_, err := NewStoreWithOptions(t.TempDir(), Options{Layout: "notion"})
+	assert.ErrorContains(t, err, "invalid layout")
+
+	for _, aliases := range []map[string]string{
+		{"priority": "cairn-priority"},
+		{"status": ""},
+		{"status": "title"},
+		{"status": "state", "horizon": "state"},
+	} {
+		_, err := NewStoreWithOptions(t.TempDir(), Options{FieldAliases: aliases})
+		assert.ErrorContains(t, err, "invalid field alias", aliases)
+	}
+
+	// Swapping two fields' names is allowed
+	_, err = NewStoreWithOptions(t.TempDir(), Options{FieldAliases: map[string]string{"status": "title", "title": "name"}})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// LinkWaiting is the link key for a soft "waiting on" annotation, e.g.
+// "waiting: Alice's review". Unlike a dependency it doesn't block anything;
+// it's a reminder to follow up.
+const LinkWaiting = "waiting"
+
+// WaitingOn returns who or what g is waiting on, or "" if nothing.
+func (g *Goal) WaitingOn() string {
+	return g.Links[LinkWaiting]
+}
+
+// DaysWaiting returns how many calendar days g has gone without an update
+// as of now.
+func DaysWaiting(g *Goal, now time.Time) int {
+	return DaysUntouched(g, now)
+}
+
+// Waiting returns the unfinished goals that are waiting on something,
+// longest-waiting first.
+func (s *Store) Waiting() ([]*Goal, error) {
+	return waiting(s)
+}
+
+// Waiting returns the goals waiting on something, like Store.Waiting.
+func (s *MemStore) Waiting() ([]*Goal, error) {
+	return waiting(s)
+}
+
+func waiting(b Backend) ([]*Goal, error) {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Goal
+	var walk func([]*Goal)
+	walk = func(goals []*Goal) {
+		for _, g := range goals {
+			if g.WaitingOn() != "" && !g.IsComplete() {
+				result = append(result, g)
+			}
+			walk(g.Children)
+		}
+	}
+	walk(goals)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Updated.Before(result[j].Updated)
+	})
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaiting(t *testing.T) {
+	s := setupTestStore(t)
+
+	now := time.Now()
+	for _, tc := range []struct {
+		slug    string
+		waiting string
+		daysAgo int
+		status  GoalStatus
+	}{
+		{"recent", "Bob", 1, StatusIncomplete},
+		{"idle", "", 9, StatusIncomplete},
+		{"oldest", "Alice's review", 5, StatusInProgress},
+		{"done", "Carol", 8, StatusComplete},
+	} {
+		g, err := s.CreateGoal("", tc.slug)
+		require.NoError(t, err)
+		g.Status = tc.status
+		g.Updated = now.AddDate(0, 0, -tc.daysAgo)
+		if tc.waiting != "" {
+			g.Links = map[string]string{LinkWaiting: tc.waiting}
+		}
+		// SaveGoal stamps Updated, so write the file directly to backdate it
+		content, err := SerializeFrontmatter(g)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(g.FilePath, []byte(content), 0644))
+	}
+
+	goals, err := s.Waiting()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "oldest", goals[0].Path, "longest-waiting first")
+	assert.Equal(t, "Alice's review", goals[0].WaitingOn())
+	assert.Equal(t, 5, DaysWaiting(goals[0], now))
+	assert.Equal(t, "recent", goals[1].Path)
+}
@@ -0,0 +1,59 @@
+package store
+
+import "errors"
+
+// SkipChildren can be returned by a WalkFunc to skip the goal's sub-goals.
+var SkipChildren = errors.New("skip children")
+
+// SkipAll can be returned by a WalkFunc to stop the walk early.
+var SkipAll = errors.New("skip all")
+
+// WalkFunc is called for each goal in a walk, with depth 0 for the goals
+// the walk starts from.
+type WalkFunc func(g *Goal, depth int) error
+
+// WalkGoals calls fn for every goal in goals and their sub-goals in
+// pre-order: each goal before its children, siblings in order. A
+// SkipChildren result skips the goal's sub-goals and SkipAll ends the
+// walk, with WalkGoals returning nil; any other error ends it and is
+// returned.
+func WalkGoals(goals []*Goal, fn WalkFunc) error {
+	if err := walkGoals(goals, 0, fn); !errors.Is(err, SkipAll) {
+		return err
+	}
+	return nil
+}
+
+func walkGoals(goals []*Goal, depth int, fn WalkFunc) error {
+	for _, g := range goals {
+		err := fn(g, depth)
+		if errors.Is(err, SkipChildren) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := walkGoals(g.Children, depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk loads the goal tree and walks it with WalkGoals.
+func (s *Store) Walk(fn WalkFunc) error {
+	return walk(s, fn)
+}
+
+// Walk loads the goal tree and walks it with WalkGoals, like Store.Walk.
+func (s *MemStore) Walk(fn WalkFunc) error {
+	return walk(s, fn)
+}
+
+func walk(b Backend, fn WalkFunc) error {
+	goals, err := b.LoadGoalTree()
+	if err != nil {
+		return err
+	}
+	return WalkGoals(goals, fn)
+}
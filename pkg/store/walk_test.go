@@ -0,0 +1,75 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walkTestTree is otr (ios (push), web) and roadmap.
+func walkTestTree(t *testing.T) *MemStore {
+	t.Helper()
+	s := NewMemStore()
+	for _, g := range [][2]string{
+		{"", "otr"}, {"otr", "ios"}, {filepath.Join("otr", "ios"), "push"}, {"otr", "web"}, {"", "roadmap"},
+	} {
+		_, err := s.CreateGoal(g[0], g[1])
+		require.NoError(t, err)
+	}
+	return s
+}
+
+func TestWalkOrder(t *testing.T) {
+	s := walkTestTree(t)
+	var visited []string
+	require.NoError(t, s.Walk(func(g *Goal, depth int) error {
+		visited = append(visited, fmt.Sprintf("%d %s", depth, g.Path))
+		return nil
+	}))
+	assert.Equal(t, []string{
+		"0 otr",
+		"1 " + filepath.Join("otr", "ios"),
+		"2 " + filepath.Join("otr", "ios", "push"),
+		"1 " + filepath.Join("otr", "web"),
+		"0 roadmap",
+	}, visited)
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	s := walkTestTree(t)
+	var visited []string
+	require.NoError(t, s.Walk(func(g *Goal, depth int) error {
+		visited = append(visited, g.Path)
+		if g.Slug == "ios" {
+			return SkipChildren
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"otr", filepath.Join("otr", "ios"), filepath.Join("otr", "web"), "roadmap"}, visited)
+}
+
+func TestWalkStop(t *testing.T) {
+	s := walkTestTree(t)
+	var visited []string
+	require.NoError(t, s.Walk(func(g *Goal, depth int) error {
+		visited = append(visited, g.Path)
+		if g.Slug == "push" {
+			return SkipAll
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"otr", filepath.Join("otr", "ios"), filepath.Join("otr", "ios", "push")}, visited)
+
+	boom := errors.New("boom")
+	visited = nil
+	err := s.Walk(func(g *Goal, depth int) error {
+		visited = append(visited, g.Path)
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, []string{"otr"}, visited)
+}
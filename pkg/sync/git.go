@@ -1,91 +1,258 @@
 package sync
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
 )
 
-// InitRepo sets the remote for the data directory's git repo.
+// InitRepo sets the remote for the data directory's git repo and, when
+// branch is given, switches to that branch (creating it if needed) and
+// makes it track the same branch on the remote.
 // Git init is handled by store.initGit(); this only configures the remote.
-func InitRepo(dir string, remote string) error {
+func InitRepo(dir, remote, branch string) error {
 	// Ensure it's a git repo
 	gitDir := filepath.Join(dir, ".git")
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 		return fmt.Errorf("not a git repository — open cairn once first to initialize")
 	}
 
+	if branch != "" {
+		if err := switchBranch(dir, branch); err != nil {
+			return err
+		}
+	}
+
 	if remote == "" {
 		fmt.Println("No remote specified. Use --remote <url> to set one.")
 		return nil
 	}
 
+	if err := SetRemote(dir, remote); err != nil {
+		return err
+	}
+	fmt.Printf("Remote set to: %s\n", remote)
+
+	if branch != "" {
+		// Track origin/<branch> even before it exists there, so the first
+		// sync pushes to it
+		exec.Command("git", "-C", dir, "config", "branch."+branch+".remote", "origin").Run()
+		exec.Command("git", "-C", dir, "config", "branch."+branch+".merge", "refs/heads/"+branch).Run()
+		fmt.Printf("Branch %s tracks origin/%s\n", branch, branch)
+	}
+	return nil
+}
+
+// ErrNoRemote is returned by SyncRepo when there's no remote to pull from
+// and push to. Local changes are still committed.
+var ErrNoRemote = errors.New("no remote configured, so changes were only committed locally: run 'cairn init --remote <url>' to sync them")
+
+// SetRemote points dir's origin at url, replacing any existing origin.
+func SetRemote(dir, url string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return fmt.Errorf("not a git repository — open cairn once first to initialize")
+	}
 	// Remove existing origin first (ignore error if doesn't exist)
 	exec.Command("git", "-C", dir, "remote", "remove", "origin").Run()
 
-	cmd := exec.Command("git", "-C", dir, "remote", "add", "origin", remote)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("setting remote: %w", err)
+	if out, err := exec.Command("git", "-C", dir, "remote", "add", "origin", url).CombinedOutput(); err != nil {
+		return fmt.Errorf("setting remote: %s", strings.TrimSpace(string(out)))
 	}
-	fmt.Printf("Remote set to: %s\n", remote)
 	return nil
 }
 
+// HasRemote reports whether dir's repo has an origin to sync with.
+func HasRemote(dir string) bool {
+	return exec.Command("git", "-C", dir, "remote", "get-url", "origin").Run() == nil
+}
+
+// hasUpstream reports whether dir's current branch tracks a remote branch.
+func hasUpstream(dir string) bool {
+	return exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Run() == nil
+}
+
+// switchBranch checks out branch in dir, creating it from HEAD if it
+// doesn't exist yet.
+func switchBranch(dir, branch string) error {
+	args := []string{"-C", dir, "checkout", branch}
+	if !branchExists(dir, branch) {
+		args = []string{"-C", dir, "checkout", "-b", branch}
+	}
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("switching to branch %s: %s", branch, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// branchExists reports whether dir's repo has a local branch named branch.
+func branchExists(dir, branch string) bool {
+	return exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+// remoteHasBranch reports whether origin has branch. Failing to reach
+// origin counts as having it, so the pull that follows reports why.
+func remoteHasBranch(dir, branch string) bool {
+	err := exec.Command("git", "-C", dir, "ls-remote", "--exit-code", "--heads", "origin", branch).Run()
+	var exit *exec.ExitError
+	return !(errors.As(err, &exit) && exit.ExitCode() == 2)
+}
+
+// CurrentBranch returns the branch checked out in dir, or "" when HEAD is
+// detached.
+func CurrentBranch(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "symbolic-ref", "--quiet", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Step is a stage of SyncRepo, reported to Options.Progress as it starts.
+type Step string
+
+// Steps of SyncRepo, in order. StepMerging only happens when rebasing
+// onto the remote fails.
+const (
+	StepStaging    Step = "Staging changes"
+	StepCommitting Step = "Committing"
+	StepPulling    Step = "Pulling"
+	StepMerging    Step = "Rebase failed, trying merge"
+	StepPushing    Step = "Pushing"
+)
+
+// Pull modes for Options.Pull.
+const (
+	PullRebase = "rebase"  // rebase local commits, merging if that fails
+	PullMerge  = "merge"   // always merge
+	PullFFOnly = "ff-only" // only fast-forward; fail when histories diverged
+)
+
+// Options configures SyncRepo.
+type Options struct {
+	// DraftTags keep tagged goals out of the sync commit (see store.StagePaths).
+	DraftTags []string
+	// FieldAliases are the goal files' renamed frontmatter fields, so
+	// renamed tags still mark drafts.
+	FieldAliases map[string]string
+	// Branch is the branch to sync with the same branch on origin. It must
+	// exist and be checked out. Empty syncs the current branch with its
+	// upstream.
+	Branch string
+	// Pull is how remote changes are brought in, one of the Pull modes.
+	// Empty means PullRebase.
+	Pull string
+	// Progress is called as each step starts. Nil prints the steps.
+	Progress func(Step)
+	// Output receives git's output. Nil means stdout and stderr.
+	Output io.Writer
+}
+
 // SyncRepo synchronizes the data directory with the remote.
-// Strategy: commit local changes, rebase, fallback to merge, push.
-func SyncRepo(dir string) error {
+// Strategy: commit local changes, pull (by default rebasing, falling back
+// to merge), push.
+func SyncRepo(dir string, opts Options) error {
 	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
 		return fmt.Errorf("not a git repository. Run 'cairn init' first")
 	}
+	if opts.Branch != "" {
+		if !branchExists(dir, opts.Branch) {
+			return fmt.Errorf("sync branch %s doesn't exist; run 'cairn init --branch %s' to create it", opts.Branch, opts.Branch)
+		}
+		if current := CurrentBranch(dir); current != opts.Branch {
+			return fmt.Errorf("the data directory is on branch %q, not sync branch %s; check it out first", current, opts.Branch)
+		}
+	}
 
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(step Step) { fmt.Println(string(step) + "...") }
+	}
 	git := func(args ...string) *exec.Cmd {
 		return exec.Command("git", append([]string{"-C", dir}, args...)...)
 	}
-
+	// run runs a git command with its output shown
+	run := func(args ...string) error {
+		cmd := git(args...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if opts.Output != nil {
+			cmd.Stdout, cmd.Stderr = opts.Output, opts.Output
+		}
+		return cmd.Run()
+	}
 	// 1. Stage and commit any uncommitted local changes
-	fmt.Println("Staging changes...")
-	git("add", "-A").Run()
+	progress(StepStaging)
+	if err := store.StagePaths(dir, opts.DraftTags, opts.FieldAliases); err != nil {
+		return fmt.Errorf("staging changes: %w", err)
+	}
 	if err := git("diff", "--cached", "--quiet").Run(); err != nil {
-		msg := "sync " + time.Now().Format("2006-01-02 15:04:05")
-		cmd := git("commit", "-m", msg)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		cmd.Run()
-	}
-
-	// 2. Try pull --rebase
-	fmt.Println("Pulling...")
-	rebaseCmd := git("pull", "--rebase")
-	rebaseCmd.Stdout = os.Stdout
-	rebaseCmd.Stderr = os.Stderr
-	if err := rebaseCmd.Run(); err != nil {
-		// 3. Rebase failed — abort and try merge
-		fmt.Println("Rebase failed, trying merge...")
-		git("rebase", "--abort").Run()
-
-		mergeCmd := git("pull", "--no-rebase")
-		mergeCmd.Stdout = os.Stdout
-		mergeCmd.Stderr = os.Stderr
-		if err := mergeCmd.Run(); err != nil {
-			// 4. Merge also failed — abort and report
+		progress(StepCommitting)
+		run("commit", "-m", "sync "+time.Now().Format("2006-01-02 15:04:05"))
+	}
+
+	if !HasRemote(dir) {
+		return ErrNoRemote
+	}
+	// A named branch, or one that doesn't track a remote branch yet, is
+	// pulled from and pushed to the same branch on origin
+	branch := opts.Branch
+	if branch == "" && !hasUpstream(dir) {
+		branch = CurrentBranch(dir)
+	}
+	var remote []string
+	if branch != "" {
+		remote = []string{"origin", branch}
+	}
+
+	// 2. Pull, unless this is the branch's first push
+	progress(StepPulling)
+	switch {
+	case branch != "" && !remoteHasBranch(dir, branch):
+	case opts.Pull == PullMerge:
+		if err := run(append([]string{"pull", "--no-rebase"}, remote...)...); err != nil {
 			git("merge", "--abort").Run()
-			return fmt.Errorf("sync failed: could not rebase or merge. Resolve conflicts manually")
+			return fmt.Errorf("sync failed: could not merge. Resolve conflicts manually")
+		}
+	case opts.Pull == PullFFOnly:
+		if err := run(append([]string{"pull", "--ff-only"}, remote...)...); err != nil {
+			return fmt.Errorf("sync failed: local and remote changes diverged and sync_pull is ff-only. Pull manually")
+		}
+	default:
+		if err := run(append([]string{"pull", "--rebase"}, remote...)...); err != nil {
+			// 3. Rebase failed — abort and try merge
+			progress(StepMerging)
+			git("rebase", "--abort").Run()
+
+			if err := run(append([]string{"pull", "--no-rebase"}, remote...)...); err != nil {
+				// 4. Merge also failed — abort and report
+				git("merge", "--abort").Run()
+				return fmt.Errorf("sync failed: could not rebase or merge. Resolve conflicts manually")
+			}
 		}
 	}
 
 	// 5. Push
-	fmt.Println("Pushing...")
-	pushCmd := git("push")
-	pushCmd.Stdout = os.Stdout
-	pushCmd.Stderr = os.Stderr
-	if err := pushCmd.Run(); err != nil {
+	progress(StepPushing)
+	push := []string{"push"}
+	if branch != "" {
+		push = append(push, "-u") // later syncs find the upstream
+	}
+	if err := run(append(push, remote...)...); err != nil {
 		return fmt.Errorf("push failed: %w", err)
 	}
 
-	fmt.Println("Sync complete.")
+	if opts.Progress == nil {
+		if branch := CurrentBranch(dir); branch != "" {
+			fmt.Printf("Sync complete on %s.\n", branch)
+		} else {
+			fmt.Println("Sync complete.")
+		}
+	}
 	return nil
 }
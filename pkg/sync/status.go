@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// RepoStatus summarizes the working tree of the data directory.
+type RepoStatus struct {
+	Branch string // checked-out branch, "" when HEAD is detached
+	Dirty  bool   // uncommitted changes, untracked files included
+	Ahead  int    // commits not yet pushed to the upstream
+	Behind int    // upstream commits not yet pulled
+}
+
+// Status runs a single `git status --porcelain --branch` in dir, giving up
+// after timeout so callers like status bars never hang on a slow disk.
+func Status(dir string, timeout time.Duration) (RepoStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain", "--branch").Output()
+	if ctx.Err() != nil {
+		return RepoStatus{}, fmt.Errorf("git status: timed out after %s", timeout)
+	}
+	if err != nil {
+		return RepoStatus{}, fmt.Errorf("git status: %w", err)
+	}
+	return parseStatus(out), nil
+}
+
+// parseStatus reads `git status --porcelain --branch` output: a "## branch"
+// line, with "...upstream" and "[ahead N, behind M]" when it tracks one,
+// then one line per changed path.
+func parseStatus(out []byte) RepoStatus {
+	var st RepoStatus
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "## ") {
+			if line != "" {
+				st.Dirty = true
+			}
+			continue
+		}
+		st.Branch = statusBranch(strings.TrimPrefix(line, "## "))
+		if i := strings.LastIndex(line, "["); i >= 0 {
+			for _, part := range strings.Split(strings.Trim(line[i:], "[]"), ", ") {
+				fmt.Sscanf(part, "ahead %d", &st.Ahead)
+				fmt.Sscanf(part, "behind %d", &st.Behind)
+			}
+		}
+	}
+	return st
+}
+
+// statusBranch returns the branch named by a "## " status header, e.g.
+// "main...origin/main [ahead 1]" or "No commits yet on main".
+func statusBranch(header string) string {
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	header = strings.TrimPrefix(header, "Initial commit on ")
+	if strings.HasPrefix(header, "HEAD (no branch)") {
+		return ""
+	}
+	if i := strings.Index(header, "..."); i >= 0 {
+		return header[:i]
+	}
+	if fields := strings.Fields(header); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
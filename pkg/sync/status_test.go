@@ -0,0 +1,16 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatus(t *testing.T) {
+	st := parseStatus([]byte("## cairn...origin/cairn [ahead 2, behind 1]\n M goals/otr/goal.md\n"))
+	assert.Equal(t, RepoStatus{Branch: "cairn", Dirty: true, Ahead: 2, Behind: 1}, st)
+
+	assert.Equal(t, "main", parseStatus([]byte("## main\n")).Branch)
+	assert.Equal(t, "main", parseStatus([]byte("## No commits yet on main\n")).Branch)
+	assert.Equal(t, "", parseStatus([]byte("## HEAD (no branch)\n")).Branch)
+}
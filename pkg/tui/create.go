@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// Fields of the add-goal prompt, cycled with tab.
+const (
+	createFieldName = iota
+	createFieldHorizon
+	createFieldTags
+	createFieldCount
+)
+
+// createHorizons are the horizons the add prompt cycles through with ←/→:
+// "", which keeps the configured default, then the configured horizons.
+func (m *Model) createHorizons() []store.Horizon {
+	return append([]store.Horizon{""}, m.store.Horizons()...)
+}
+
+// startCreate opens the add-goal prompt with the name field focused and
+// the horizon and tags cleared.
+func (m *Model) startCreate(placeholder string) tea.Cmd {
+	m.isInputMode = true
+	m.textInput.Reset()
+	m.textInput.Placeholder = placeholder
+	m.textInput.Focus()
+
+	m.inputField = createFieldName
+	m.inputHorizon = ""
+	m.tagsInput = textinput.New()
+	m.tagsInput.Placeholder = "tags, comma separated"
+	if len(m.cfg.Defaults.Tags) > 0 {
+		m.tagsInput.Placeholder = strings.Join(m.cfg.Defaults.Tags, ", ") + " (default)"
+	}
+	m.tagsInput.CharLimit = 128
+	return textinput.Blink
+}
+
+// handleCreateInput handles keys in the add-goal prompt. Enter creates the
+// goal from any field; esc discards it.
+func (m Model) handleCreateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.isInputMode = false
+		return m, nil
+
+	case tea.KeyEnter:
+		m.isInputMode = false
+		name := strings.TrimSpace(m.textInput.Value())
+		if name == "" {
+			return m, nil
+		}
+		g, err := m.store.CreateGoal(m.inputParent, name)
+		if err != nil {
+			m.setStatus("Error: " + err.Error())
+			return m, nil
+		}
+		if err := m.applyCreateOptions(g.Path); err != nil {
+			m.setStatus("Created " + name + ", but: " + err.Error())
+		} else if root := m.activeQueueGoal(); root != nil && !isWithin(g.Path, root.Path) {
+			m.setStatus("Created: " + name + " (outside this tab; o to peek)")
+		} else {
+			m.setStatus("Created: " + name)
+		}
+		m.reload()
+		return m, nil
+
+	case tea.KeyTab, tea.KeyShiftTab:
+		step := 1
+		if msg.Type == tea.KeyShiftTab {
+			step = createFieldCount - 1
+		}
+		m.inputField = (m.inputField + step) % createFieldCount
+		m.textInput.Blur()
+		m.tagsInput.Blur()
+		switch m.inputField {
+		case createFieldName:
+			return m, m.textInput.Focus()
+		case createFieldTags:
+			return m, m.tagsInput.Focus()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.inputField {
+	case createFieldName:
+		m.textInput, cmd = m.textInput.Update(msg)
+	case createFieldTags:
+		m.tagsInput, cmd = m.tagsInput.Update(msg)
+	case createFieldHorizon:
+		m.stepCreateHorizon(msg.String())
+	}
+	return m, cmd
+}
+
+// stepCreateHorizon sets the prompt's horizon from a key: 1…9 pick one,
+// ←/→ cycle, backspace returns to the default.
+func (m *Model) stepCreateHorizon(k string) {
+	horizons := m.createHorizons()
+	i := 0
+	for j, h := range horizons {
+		if h == m.inputHorizon {
+			i = j
+		}
+	}
+	switch k {
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if n := int(k[0] - '0'); n < len(horizons) {
+			i = n
+		}
+	case "right", "l":
+		i = (i + 1) % len(horizons)
+	case "left", "h":
+		i = (i + len(horizons) - 1) % len(horizons)
+	case "backspace", "0":
+		i = 0
+	}
+	m.inputHorizon = horizons[i]
+}
+
+// applyCreateOptions sets the horizon and tags chosen in the prompt on the
+// goal just created.
+func (m *Model) applyCreateOptions(goalPath string) error {
+	tags := strings.TrimSpace(m.tagsInput.Value())
+	if tags != "" {
+		g, err := m.store.LoadGoal(goalPath)
+		if err != nil {
+			return err
+		}
+		if err := g.SetField("tags", tags); err != nil {
+			return err
+		}
+		if err := m.store.SaveGoal(g); err != nil {
+			return err
+		}
+	}
+	if m.inputHorizon != "" {
+		// SetHorizon commits, picking up the tags too
+		_, err := m.store.SetHorizon(goalPath, m.inputHorizon)
+		return err
+	}
+	if tags != "" {
+		m.store.Commit("tag: " + goalPath)
+	}
+	return nil
+}
+
+// renderCreatePrompt returns the add prompt's rows: the name input at the
+// new goal's depth and the options under it.
+func (m Model) renderCreatePrompt(width int) []string {
+	indent := strings.Repeat(DepthIndent, m.inputDepth)
+	prompt := InputPromptStyle.Render("> ")
+	fit := lipgloss.NewStyle().MaxWidth(width)
+	return []string{
+		fit.Render(indent + prompt + m.textInput.View()),
+		fit.Render(indent + "  " + m.renderCreateOptions()),
+	}
+}
+
+// renderCreateOptions is the line under the add prompt showing the horizon
+// and tags fields, highlighting the focused one.
+func (m Model) renderCreateOptions() string {
+	horizon := "horizon: " + string(m.inputHorizon)
+	if m.inputHorizon == "" {
+		horizon = "horizon: default (" + m.cfg.NewGoalHorizon() + ")"
+	}
+	if m.inputField == createFieldHorizon {
+		horizon = SelectedStyle.Render(horizon + " ←→")
+	} else {
+		horizon = FooterStyle.Render(horizon)
+	}
+	tags := FooterStyle.Render("#") + m.tagsInput.View()
+	return horizon + "  " + tags
+}
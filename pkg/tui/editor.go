@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// editorFallbacks are tried in order when $EDITOR is unset or can't be found.
+var editorFallbacks = []string{"nvim", "vim", "nano"}
+
+// lookPath finds an executable. Replaceable in tests.
+var lookPath = exec.LookPath
+
+// ResolveEditor picks the command line for editing files: $EDITOR (which
+// may carry arguments, e.g. "code --wait") if its program can be found,
+// otherwise the first of editorFallbacks that can. fallback is the
+// fallback used, "" when $EDITOR was.
+func ResolveEditor(env string) (argv []string, fallback string, err error) {
+	if fields := strings.Fields(env); len(fields) > 0 {
+		if _, err := lookPath(fields[0]); err == nil {
+			return fields, "", nil
+		}
+	}
+	for _, name := range editorFallbacks {
+		if _, err := lookPath(name); err == nil {
+			return []string{name}, name, nil
+		}
+	}
+	if env != "" {
+		return nil, "", fmt.Errorf("editor %q not found and none of %s are installed", env, strings.Join(editorFallbacks, ", "))
+	}
+	return nil, "", fmt.Errorf("$EDITOR is not set and none of %s are installed", strings.Join(editorFallbacks, ", "))
+}
+
+// editorStatus describes how an editor session ended for the status bar,
+// or returns "" when there's nothing to report.
+func editorStatus(msg EditorFinishedMsg) string {
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(msg.Err, &exitErr):
+		return fmt.Sprintf("Editor exited with status %d", exitErr.ExitCode())
+	case msg.Err != nil:
+		return "Editor failed: " + msg.Err.Error()
+	case msg.Fallback != "":
+		if msg.Env == "" {
+			return "$EDITOR not set, used " + msg.Fallback
+		}
+		return fmt.Sprintf("$EDITOR %q not found, used %s", msg.Env, msg.Fallback)
+	}
+	return ""
+}
+
+// editorArgs returns the arguments that open file at line in the editor
+// argv runs, for the editors whose syntax is known. Others just get file.
+func editorArgs(argv []string, file string, line int) []string {
+	args := append([]string(nil), argv[1:]...)
+	if line <= 1 {
+		return append(args, file)
+	}
+	at := fmt.Sprintf("%s:%d", file, line)
+	switch strings.TrimSuffix(filepath.Base(argv[0]), ".exe") {
+	case "vi", "vim", "nvim", "gvim", "nano", "emacs", "emacsclient", "kak", "micro", "mg", "joe", "ne":
+		return append(args, fmt.Sprintf("+%d", line), file)
+	case "hx", "helix", "subl", "zed":
+		return append(args, at)
+	case "code", "codium", "cursor":
+		return append(args, "--goto", at)
+	}
+	return append(args, file)
+}
+
+// bodyStartLine returns the 1-based line of a goal file's content where the
+// notes begin: the first non-blank line after the frontmatter, or the line
+// just after it when the body is empty.
+func bodyStartLine(content string) int {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 1
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "---" {
+			continue
+		}
+		start := i + 1
+		for j := start; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) != "" {
+				return j + 1
+			}
+		}
+		return start + 1
+	}
+	return 1
+}
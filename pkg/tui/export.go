@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportSnippetLen caps the note snippet after each exported goal, in runes.
+const exportSnippetLen = 80
+
+// exportMarkdown renders items as a markdown task list under title, with
+// section headers as headings and each goal's latest note as a one-line
+// snippet.
+func exportMarkdown(title string, items []TreeItem) string {
+	base := -1
+	for _, item := range items {
+		if !item.IsSectionHeader && (base < 0 || item.Depth < base) {
+			base = item.Depth
+		}
+	}
+
+	var md strings.Builder
+	md.WriteString("# " + title + "\n")
+	for _, item := range items {
+		if item.IsSectionHeader {
+			md.WriteString("\n## " + item.Name + "\n\n")
+			continue
+		}
+		g := item.Goal
+		check := " "
+		if g.IsComplete() {
+			check = "x"
+		}
+		md.WriteString(strings.Repeat("  ", item.Depth-base) + "- [" + check + "] " + item.Name)
+		if g.IsInProgress() {
+			md.WriteString(" *(in progress)*")
+		}
+		if notes := latestNoteBullets(g.Body, 1); len(notes) > 0 {
+			md.WriteString(" — " + truncateRunes(notes[0], exportSnippetLen))
+		}
+		md.WriteString("\n")
+	}
+	return md.String()
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// exportTitle names what the tree is showing: the active queue goal or
+// all goals, and the search narrowing it.
+func (m *Model) exportTitle() string {
+	title := "All goals"
+	if g := m.activeQueueGoal(); g != nil {
+		title = displayName(g)
+	}
+	if m.searchQuery != "" {
+		title += fmt.Sprintf(" matching %q", m.searchQuery)
+	}
+	return title
+}
+
+// exportView writes the rows the tree is showing, as filtered, expanded
+// and sorted, to path as markdown, or copies them when path is "".
+func (m *Model) exportView(path string) (tea.Cmd, error) {
+	goals := 0
+	for _, item := range m.visibleItems {
+		if !item.IsSectionHeader {
+			goals++
+		}
+	}
+	md := exportMarkdown(m.exportTitle(), m.visibleItems)
+	what := fmt.Sprintf("%d goals", goals)
+	if path == "" {
+		return copyText(what, md), nil
+	}
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		return nil, err
+	}
+	m.setStatus("Exported " + what + " to " + path)
+	return nil, nil
+}
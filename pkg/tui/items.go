@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/stefanpenner/cairn/pkg/store"
 )
 
@@ -13,7 +16,9 @@ type TreeItem struct {
 	Depth           int
 	HasChildren     bool
 	IsExpanded      bool
-	IsSectionHeader bool // true for "TODAY", "TOMORROW", "FUTURE" headers
+	IsSectionHeader bool   // true for "PINNED", the horizons' and "OTHER" headers
+	Hidden          int    // goals in a collapsed section, on its header
+	Breadcrumb      string // parents' titles, for a sub-goal rolled up into a section
 }
 
 // BuildTreeItems converts a slice of Goals into TreeItems for TUI rendering.
@@ -51,60 +56,185 @@ func displayName(g *store.Goal) string {
 // When true, items are grouped under TODAY / TOMORROW / FUTURE section headers.
 func FlattenVisibleItems(goals []*store.Goal, expandedState map[string]bool) []TreeItem {
 	var result []TreeItem
-	flattenGoals(goals, 0, "", expandedState, &result)
+	flattenGoals(goals, 0, "", expandedState, nil, &result)
 	return result
 }
 
-// FlattenWithHorizonGroups groups top-level goals by horizon with section headers.
-func FlattenWithHorizonGroups(goals []*store.Goal, expandedState map[string]bool) []TreeItem {
-	var today, tomorrow, future []*store.Goal
+// FlattenWithHorizonGroups groups top-level goals by horizon with section
+// headers, in the order of horizons; goals whose horizon isn't one of
+// those follow in an OTHER section rather than passing for the last one.
+// Pinned goals, at any depth, are listed once in a PINNED section above TODAY
+// instead of in their usual place. With rollup, so are sub-goals due sooner
+// than the section their parent is in: a today sub-goal of a future goal is
+// listed in TODAY, under its parents' titles, and not under its parent.
+func FlattenWithHorizonGroups(goals []*store.Goal, horizons []store.Horizon, expandedState map[string]bool, rollup bool) []TreeItem {
+	pinnedGoals := collectPinned(goals)
+	pinned := make(map[string]bool, len(pinnedGoals))
+	for _, g := range pinnedGoals {
+		pinned[g.Path] = true
+	}
+
+	sections := make([][]*store.Goal, len(horizons)+1) // by horizonRank; the last is OTHER
 	for _, g := range goals {
-		switch g.Horizon {
-		case store.HorizonToday:
-			today = append(today, g)
-		case store.HorizonTomorrow:
-			tomorrow = append(tomorrow, g)
-		default:
-			future = append(future, g)
+		if pinned[g.Path] {
+			continue
+		}
+		r := horizonRank(g.Horizon, horizons)
+		sections[r] = append(sections[r], g)
+	}
+
+	// Rolled-up sub-goals follow their section's top-level goals
+	skip := pinned
+	rolled := make([][]rolledUp, len(sections))
+	if rollup {
+		skip = make(map[string]bool, len(pinned))
+		for p := range pinned {
+			skip[p] = true
+		}
+		for _, g := range goals {
+			if !pinned[g.Path] {
+				rollUp(g.Children, horizonRank(g.Horizon, horizons), horizons, []string{displayName(g)}, skip, rolled)
+			}
 		}
 	}
 
 	var result []TreeItem
 
-	if len(today) > 0 {
+	if len(pinnedGoals) > 0 {
 		result = append(result, TreeItem{
-			ID:              "__header_today",
-			Name:            "TODAY",
+			ID:              "__header_pinned",
+			Name:            "PINNED",
 			IsSectionHeader: true,
+			IsExpanded:      true,
 			Goal:            &store.Goal{},
 		})
-		flattenGoals(today, 1, "__header_today", expandedState, &result)
+		flattenGoals(pinnedGoals, 1, "__header_pinned", expandedState, skip, &result)
 	}
 
-	if len(tomorrow) > 0 {
+	for r, section := range sections {
+		if len(section) == 0 && len(rolled[r]) == 0 {
+			continue
+		}
+		name := horizonOther
+		if r < len(horizons) {
+			name = string(horizons[r])
+		}
+		id := "__header_" + name
 		result = append(result, TreeItem{
-			ID:              "__header_tomorrow",
-			Name:            "TOMORROW",
+			ID:              id,
+			Name:            strings.ToUpper(name),
 			IsSectionHeader: true,
+			IsExpanded:      true,
 			Goal:            &store.Goal{},
 		})
-		flattenGoals(tomorrow, 1, "__header_tomorrow", expandedState, &result)
+		flattenGoals(section, 1, id, expandedState, skip, &result)
+		flattenRolledUp(rolled[r], id, expandedState, skip, &result)
 	}
 
-	if len(future) > 0 {
-		result = append(result, TreeItem{
-			ID:              "__header_future",
-			Name:            "FUTURE",
-			IsSectionHeader: true,
-			Goal:            &store.Goal{},
-		})
-		flattenGoals(future, 1, "__header_future", expandedState, &result)
+	return result
+}
+
+// horizonOther names the section after the configured horizons, for goals
+// whose horizon isn't one of them.
+const horizonOther = "other"
+
+// rolledUp is a sub-goal listed in a more urgent section than its parent.
+type rolledUp struct {
+	goal       *store.Goal
+	breadcrumb string
+}
+
+// horizonRank orders horizons by urgency: h's place in horizons, nearest
+// first. Goals without a horizon rank with the last one, and those with a
+// horizon not in the list after it, in OTHER.
+func horizonRank(h store.Horizon, horizons []store.Horizon) int {
+	if h == "" {
+		return len(horizons) - 1
+	}
+	if i := slices.Index(horizons, h); i >= 0 {
+		return i
+	}
+	return len(horizons)
+}
+
+// rollUp collects, in tree order, the goals under a parent listed in the
+// section of rank whose own horizon is more urgent into rolled by rank,
+// adding them to skip. Their sub-goals are compared with the section they
+// move to.
+func rollUp(goals []*store.Goal, rank int, horizons []store.Horizon, parents []string, skip map[string]bool, rolled [][]rolledUp) {
+	for _, g := range goals {
+		if skip[g.Path] {
+			continue // pinned
+		}
+		childRank := rank
+		if r := horizonRank(g.Horizon, horizons); r < rank {
+			skip[g.Path] = true
+			rolled[r] = append(rolled[r], rolledUp{goal: g, breadcrumb: strings.Join(parents, " › ")})
+			childRank = r
+		}
+		rollUp(g.Children, childRank, horizons, append(parents[:len(parents):len(parents)], displayName(g)), skip, rolled)
 	}
+}
 
+// flattenRolledUp appends rolled-up goals to a section like its top-level
+// goals, each with its breadcrumb.
+func flattenRolledUp(goals []rolledUp, parentID string, expandedState map[string]bool, skip map[string]bool, result *[]TreeItem) {
+	for _, r := range goals {
+		start := len(*result)
+		flattenGoals([]*store.Goal{r.goal}, 1, parentID, expandedState, skip, result)
+		(*result)[start].Breadcrumb = r.breadcrumb
+	}
+}
+
+// sectionKey names the section a header item starts, e.g. "future".
+func sectionKey(item TreeItem) string {
+	return strings.TrimPrefix(item.ID, "__header_")
+}
+
+// CollapseSections reduces the sections named in collapsed (by sectionKey) to
+// their header, which records how many top-level goals it hides.
+func CollapseSections(items []TreeItem, collapsed map[string]bool) []TreeItem {
+	if len(collapsed) == 0 {
+		return items
+	}
+	var result []TreeItem
+	hiding := -1 // index in result of the collapsed header being filled
+	for _, item := range items {
+		if item.IsSectionHeader {
+			hiding = -1
+			if collapsed[sectionKey(item)] {
+				item.IsExpanded = false
+				hiding = len(result)
+			}
+			result = append(result, item)
+			continue
+		}
+		if hiding >= 0 {
+			if item.Depth == 1 {
+				result[hiding].Hidden++
+			}
+			continue
+		}
+		result = append(result, item)
+	}
 	return result
 }
 
-func flattenGoals(goals []*store.Goal, depth int, parentID string, expandedState map[string]bool, result *[]TreeItem) {
+// collectPinned returns the pinned goals in tree order.
+func collectPinned(goals []*store.Goal) []*store.Goal {
+	var pinned []*store.Goal
+	for _, g := range goals {
+		if g.Pinned {
+			pinned = append(pinned, g)
+		}
+		pinned = append(pinned, collectPinned(g.Children)...)
+	}
+	return pinned
+}
+
+// flattenGoals appends goals and their expanded descendants to result.
+// Children in skip are left out; they're listed elsewhere (e.g. PINNED).
+func flattenGoals(goals []*store.Goal, depth int, parentID string, expandedState map[string]bool, skip map[string]bool, result *[]TreeItem) {
 	for _, g := range goals {
 		item := TreeItem{
 			ID:          g.Path,
@@ -118,7 +248,13 @@ func flattenGoals(goals []*store.Goal, depth int, parentID string, expandedState
 		*result = append(*result, item)
 
 		if item.HasChildren && item.IsExpanded {
-			flattenGoals(g.Children, depth+1, g.Path, expandedState, result)
+			var children []*store.Goal
+			for _, c := range g.Children {
+				if !skip[c.Path] {
+					children = append(children, c)
+				}
+			}
+			flattenGoals(children, depth+1, g.Path, expandedState, skip, result)
 		}
 	}
 }
@@ -10,9 +10,11 @@ type KeyMap struct {
 	Right        key.Binding
 	Enter        key.Binding
 	Space        key.Binding
+	DoneNext     key.Binding
 	Tab          key.Binding
 	NextQueue    key.Binding
 	PrevQueue    key.Binding
+	JumpQueue    key.Binding
 	InlineEdit   key.Binding
 	ExternalEdit key.Binding
 	Add          key.Binding
@@ -24,11 +26,30 @@ type KeyMap struct {
 	Sync         key.Binding
 	Help         key.Binding
 	Move         key.Binding
+	Undo         key.Binding
 	Search       key.Binding
+	NextMatch    key.Binding
+	PrevMatch    key.Binding
+	Palette      key.Binding
 	Quit         key.Binding
-	Today        key.Binding
-	Tomorrow     key.Binding
-	Future       key.Binding
+	SetHorizon   key.Binding
+	Pin          key.Binding
+	ToggleFuture key.Binding
+	PlainNotes   key.Binding
+	Pomodoro     key.Binding
+	CopySummary  key.Binding
+	PanelLayout  key.Binding
+	ToggleNotes  key.Binding
+	Peek         key.Binding
+	Grouping     key.Binding
+	OpenURL      key.Binding
+	SessionLog   key.Binding
+
+	// Notes pane
+	NextSection    key.Binding
+	PrevSection    key.Binding
+	ToggleSection  key.Binding
+	ToggleSections key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings.
@@ -44,7 +65,7 @@ func DefaultKeyMap() KeyMap {
 		),
 		Left: key.NewBinding(
 			key.WithKeys("left", "h"),
-			key.WithHelp("←/h", "collapse"),
+			key.WithHelp("←/h", "collapse / parent"),
 		),
 		Right: key.NewBinding(
 			key.WithKeys("right", "l"),
@@ -60,12 +81,16 @@ func DefaultKeyMap() KeyMap {
 		),
 		Tab: key.NewBinding(
 			key.WithKeys("tab"),
-			key.WithHelp("tab", "switch pane"),
+			key.WithHelp("tab", "switch pane / next link"),
 		),
 		NextQueue: key.NewBinding(
 			key.WithKeys("]"),
 			key.WithHelp("]", "next queue"),
 		),
+		JumpQueue: key.NewBinding(
+			key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+			key.WithHelp("alt+1…9", "jump to queue tab"),
+		),
 		PrevQueue: key.NewBinding(
 			key.WithKeys("["),
 			key.WithHelp("[", "prev queue"),
@@ -110,10 +135,26 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("m"),
 			key.WithHelp("m", "move mode"),
 		),
+		Undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo last move (move mode)"),
+		),
 		Search: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "search"),
 		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		Palette: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "command"),
+		),
 		Help: key.NewBinding(
 			key.WithKeys("?"),
 			key.WithHelp("?", "help"),
@@ -122,24 +163,80 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("q", "ctrl+c"),
 			key.WithHelp("q", "quit"),
 		),
-		Today: key.NewBinding(
-			key.WithKeys("1"),
-			key.WithHelp("1", "set today"),
+		SetHorizon: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1…9", "set horizon"),
+		),
+		Pin: key.NewBinding(
+			key.WithKeys("!"),
+			key.WithHelp("!", "pin / unpin"),
+		),
+		ToggleFuture: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "collapse / show the last horizon"),
+		),
+		PlainNotes: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "plain text / markdown notes"),
+		),
+		Pomodoro: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "pomodoro start / pause"),
+		),
+		CopySummary: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy summary"),
+		),
+		PanelLayout: key.NewBinding(
+			key.WithKeys("|"),
+			key.WithHelp("|", "side by side / stacked panes"),
+		),
+		ToggleNotes: key.NewBinding(
+			key.WithKeys("\\"),
+			key.WithHelp("\\", "hide / show notes pane"),
+		),
+		Peek: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "peek at all goals from a queue tab"),
+		),
+		Grouping: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "group by horizon / plain tree"),
+		),
+		OpenURL: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "open a link in the notes by number"),
+		),
+		DoneNext: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "complete and go to the next open goal"),
+		),
+		SessionLog: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "what you did this session"),
+		),
+		NextSection: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "next note section"),
+		),
+		PrevSection: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "previous note section"),
 		),
-		Tomorrow: key.NewBinding(
-			key.WithKeys("2"),
-			key.WithHelp("2", "set tomorrow"),
+		ToggleSection: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "fold note section"),
 		),
-		Future: key.NewBinding(
-			key.WithKeys("3"),
-			key.WithHelp("3", "set future"),
+		ToggleSections: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "fold older note sections"),
 		),
 	}
 }
 
 // ShortHelp returns the footer help text.
 func (k KeyMap) ShortHelp() string {
-	return "↑↓ nav  tab pane  e edit  E $EDITOR  space toggle  / search  r rename  a/A add  m move  ? help"
+	return "↑↓ nav  tab pane  e edit  E $EDITOR  space toggle  / search  : command  r rename  a/A add  m move  ? help"
 }
 
 // FullHelp returns all key bindings for the help modal.
@@ -149,21 +246,40 @@ func (k KeyMap) FullHelp() [][]string {
 		{"↓/j", "Move down"},
 		{"←/h", "Collapse / go to parent"},
 		{"→/l", "Expand"},
-		{"enter", "Toggle expand/collapse"},
+		{"enter", "Toggle expand/collapse (goal or section header)"},
 		{"space", "Toggle complete/incomplete"},
-		{"tab", "Switch pane (tree / notes)"},
+		{"D", "Complete and move to the next open goal below"},
+		{"tab", "Switch pane (tree / notes); in notes, next link (frontmatter, then the notes' markdown links)"},
 		{"]", "Next queue item"},
 		{"[", "Previous queue item"},
-		{"e", "Inline edit notes"},
+		{"alt+1…9", "Jump to queue tab by number"},
+		{"o", "Peek at all goals from a queue tab (o/esc returns)"},
+		{"e", "Inline edit notes (ctrl+d inserts a date header, ctrl+t an empty note)"},
 		{"E", "Edit in $EDITOR"},
-		{"/", "Search tree"},
-		{"a", "Add sub-goal under selection"},
-		{"A", "Add top-level goal"},
+		{"/", "Search tree (↑ recalls recent searches)"},
+		{"n/N", "Jump to next / previous search match"},
+		{":", "Command prompt (:add, :move, :horizon, :label, :sort, :sync, :goto, :pomodoro, :export)"},
+		{"a", "Add sub-goal under selection (tab sets horizon and tags)"},
+		{"A", "Add top-level goal, or in a queue tab one beside the tab's goal (tab sets horizon and tags)"},
 		{"r", "Rename goal"},
 		{"d", "Delete goal (with confirmation)"},
 		{"C", "Toggle expand/collapse all"},
-		{"m", "Enter move mode (reorder/reparent)"},
-		{"1/2/3", "Set horizon: today/tomorrow/future"},
+		{"m", "Move mode: j/k move a marker anywhere in the tree, h/l set its nesting, enter drops the goal there"},
+		{"u", "Move mode: undo the last drop"},
+		{"1…9", "Set horizon: the configured horizons in order (today/tomorrow/future)"},
+		{"!", "Pin / unpin (listed under PINNED)"},
+		{"f", "Collapse / show the last horizon's section (FUTURE)"},
+		{"G", "Group by horizon / plain tree (hierarchy only)"},
+		{"v", "Show notes as plain text / rendered markdown"},
+		{"P", "Pomodoro on the selected goal: start / pause / resume"},
+		{"y", "Copy a markdown summary of the goal to the clipboard"},
+		{"|", "Panes side by side / stacked (tree above notes)"},
+		{"\\", "Hide / show the notes pane"},
+		{"J/K", "Notes pane: next / previous dated section"},
+		{"z", "Notes pane: fold / unfold section"},
+		{"Z", "Notes pane: fold all but the newest / unfold all"},
+		{"L", "Notes pane: open link N (the superscript after a URL)"},
+		{"S", "Session log: what was completed, noted, created this session"},
 		{"R", "Reload from filesystem"},
 		{"s", "Git sync"},
 		{"?", "Toggle help"},
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stefanpenner/cairn/pkg/enrich"
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// linkStateTTL is how long a looked-up link state is shown before it's
+// fetched again.
+const linkStateTTL = 5 * time.Minute
+
+// linkStateTimeout bounds a single lookup.
+const linkStateTimeout = 10 * time.Second
+
+// LinkStateMsg is sent when a link's state has been looked up. Failures
+// aren't reported; the link is just shown without a state.
+type LinkStateMsg struct {
+	Link string
+}
+
+// WithLinkStates returns m set to look up the state of the selected goal's
+// links with f (config.EnrichGitHub) and show it next to them.
+func (m Model) WithLinkStates(f enrich.Fetcher) Model {
+	m.linkStates = enrich.NewCache(f, linkStateTTL)
+	m.linkFetching = make(map[string]bool)
+	return m
+}
+
+// fetchLinkStates batches cmd with lookups for the selected goal's links
+// that have no fresh cached state and aren't already being fetched.
+func (m Model) fetchLinkStates(cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.linkStates == nil {
+		return m, cmd
+	}
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return m, cmd
+	}
+	cmds := []tea.Cmd{cmd}
+	for _, k := range sortedLinkKeys(goal) {
+		link := goal.Links[k]
+		if k == store.LinkWaiting || m.linkFetching[link] || !m.linkStates.Supports(link) {
+			continue
+		}
+		if _, ok := m.linkStates.Get(link); ok {
+			continue
+		}
+		m.linkFetching[link] = true
+		cache := m.linkStates
+		cmds = append(cmds, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), linkStateTimeout)
+			defer cancel()
+			cache.Fetch(ctx, link)
+			return LinkStateMsg{Link: link}
+		})
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// linkStateSuffix returns what to show after link, e.g. " (open)", or ""
+// when its state isn't known.
+func (m Model) linkStateSuffix(link string) string {
+	if m.linkStates == nil {
+		return ""
+	}
+	if state, _ := m.linkStates.Get(link); state != "" {
+		return " " + state.Label()
+	}
+	return ""
+}
@@ -1,18 +1,26 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
+	"github.com/stefanpenner/cairn/pkg/config"
+	"github.com/stefanpenner/cairn/pkg/enrich"
+	"github.com/stefanpenner/cairn/pkg/notify"
 	"github.com/stefanpenner/cairn/pkg/store"
 	gsync "github.com/stefanpenner/cairn/pkg/sync"
 )
@@ -27,12 +35,27 @@ type SyncDoneMsg struct {
 
 // EditorFinishedMsg is sent when $EDITOR returns.
 type EditorFinishedMsg struct {
+	Err      error
+	Env      string // $EDITOR as set
+	Fallback string // editor used instead of $EDITOR, if any
+}
+
+// WarningMsg reports a problem that didn't stop a change, like a goal
+// body template that failed to render.
+type WarningMsg struct {
+	Err error
+}
+
+// HookFailedMsg is sent when a hook script fails. The change that triggered
+// it has already been saved.
+type HookFailedMsg struct {
 	Err error
 }
 
 // Model is the Bubble Tea model for the productivity TUI.
 type Model struct {
-	store         *store.Store
+	store         store.Backend
+	cfg           *config.Config
 	keys          KeyMap
 	width         int
 	height        int
@@ -45,30 +68,105 @@ type Model struct {
 	focusedPane   int // 0 = tree, 1 = notes
 	notesScroll   int
 
+	// Dated note sections: the selected one in the notes pane, and which
+	// are collapsed (goal path → date). View state only.
+	noteSection    int
+	collapsedNotes map[string]map[string]bool
+
+	// Pane arrangement, panelsSideBySide or panelsStacked; starts from
+	// config.PanelLayout and the last choice is saved in the runtime dir
+	panelLayout string
+	notesHidden bool
+
+	// Note bodies shown as preformatted text rather than markdown;
+	// starts from config.PlainNotes and toggles for the session
+	plainNotes bool
+
+	// Link focused in the notes pane (index into openableLinks), -1 for none
+	focusedLink int
+
+	// Waiting for the number of a notes URL to open, and the digits so far
+	linkPrompt bool
+	linkDigits string
+
+	// Looked-up link states (config.EnrichGitHub); nil when disabled.
+	// linkFetching holds links with a lookup in flight.
+	linkStates   *enrich.Cache
+	linkFetching map[string]bool
+
+	// The all-goals view as a plain tree, without horizon sections.
+	// Saved in the runtime dir with the collapsed sections.
+	ungrouped bool
+
+	// Horizon sections shown as just their header (sectionKey → true).
+	// Saved in the runtime dir so they stay collapsed between sessions.
+	collapsedSections map[string]bool
+
 	// Modal state
 	showHelpModal     bool
 	showDeleteConfirm bool
 	deleteTarget      string
+	deleteSummary     *store.SubtreeSummary // nil if it couldn't be computed
+
+	// Parent completion prompt (config.PropagateStatus)
+	showCompleteParent   bool
+	completeParentTarget *store.Goal
+
+	// Open sub-goals prompt after completing a parent (config.CollapseOnComplete "ask")
+	showCompleteChildren   bool
+	completeChildrenTarget *store.Goal
+	completeChildrenOpen   []string
+
+	// Confirmation before adding a sub-goal to a complete goal
+	showAddUnderComplete bool
+
+	// Confirmation before an expand-all showing this many more goals
+	// (config.ExpandAllConfirm)
+	showExpandAll    bool
+	expandAllReveals int
+
+	// Changes made this session, oldest first, and the modal listing them
+	session        []store.Event
+	showSessionLog bool
+
+	// Queue removal prompt after completing a queued goal (config.AutoQueue)
+	showDequeue   bool
+	dequeueTarget *store.Goal
 
 	// Move mode
-	isMoveMode bool
-	moveTarget string // path of the goal being moved
+	isMoveMode  bool
+	moveTarget  string   // path of the goal being moved
+	moveSlot    int      // the ghost marker sits after this visible row, -1 for above the first
+	moveDepth   int      // and at this depth
+	moveHistory []moveOp // drops made this move session, for undo
 
 	// Input mode (for adding goals)
 	isInputMode      bool
 	textInput        textinput.Model
-	inputParent      string // parent path for new goal, "" for top-level
-	inputDepth       int    // indentation depth for the input line in the tree
-	inputInsertAfter int    // visible items index to insert input after
+	inputParent      string        // parent path for new goal, "" for top-level
+	inputDepth       int           // indentation depth for the input line in the tree
+	inputInsertAfter int           // visible items index to insert input after
+	inputField       int           // focused field, one of the createField constants
+	inputHorizon     store.Horizon // horizon for the new goal, "" for the default
+	tagsInput        textinput.Model
 
 	// Rename mode
 	isRenameMode   bool
 	renameGoalPath string
 
+	// Remote URL prompt after syncing without a remote (uses textInput)
+	showSetRemote bool
+
 	// Inline edit mode
 	isEditing    bool
 	noteEditor   textarea.Model
-	editGoalPath string // path of the goal being edited
+	editGoalPath string      // path of the goal being edited
+	editBase     *store.Goal // the goal as last read or saved, to spot changes on disk
+
+	// Prompt when the edited goal changed or was deleted on disk meanwhile
+	showEditConflict bool
+	editConflictGone bool // deleted rather than changed
+	editConflictExit bool // leave edit mode once resolved (esc rather than ctrl+s)
 
 	// External edit tracking
 	externalEditPath string
@@ -77,8 +175,20 @@ type Model struct {
 	isSearching    bool
 	searchQuery    string
 	searchMatchIDs map[string]bool // IDs of items matching query
+	searchMatches  []string        // the same IDs in tree order, for n/N
 	searchAncIDs   map[string]bool // IDs of ancestor items (for context)
 
+	// Recent queries, oldest first, recalled with ↑ in the search input.
+	// Saved in the runtime dir like collapsedSections.
+	searchHistory    []string
+	searchHistoryIdx int // len(searchHistory) when not browsing
+
+	// Command prompt (":")
+	isPalette         bool
+	paletteInput      string
+	paletteHistory    []string
+	paletteHistoryIdx int // len(paletteHistory) when not browsing
+
 	// Status message
 	statusMsg     string
 	statusTimeout time.Time
@@ -89,20 +199,77 @@ type Model struct {
 
 	// Track whether all items are expanded for toggle
 	allExpanded bool
+
+	// Clock, replaceable in tests
+	now func() time.Time
+	// Day the tomorrow → today rollover last ran
+	rolloverDay string
+	// Consecutive days with a completion, recomputed on reload
+	streak   int
+	activity []store.DayActivity // per day, for the header's streak and counters
+	// Window title last set (config.TerminalTitle)
+	shownTitle string
+	// Goal to select once the tree first loads (cairn tui <goal>)
+	startGoal string
+	// Cursor and expansion of the views not currently shown, by viewKey
+	viewStates map[string]viewState
+	// Showing all goals from a queue tab (o), and the tab's state to return to
+	peeking   bool
+	peekState viewState
+
+	// Pomodoro timer (P), nil when none is running; pomodoroRuns numbers
+	// its runs for pomodoroTickMsg
+	pomodoro     *pomodoro
+	pomodoroRuns int
+
+	// Git sync running in the background, the step it's on and the
+	// header's spinner for it
+	syncing     bool
+	syncStep    gsync.Step
+	syncSpinner spinner.Model
+	// Desktop notifications for pomodoros, nil to only use the status bar
+	notifier notify.Notifier
 }
 
+// viewState is what switching queue tabs remembers about a view.
+type viewState struct {
+	selected string // ID of the selected item
+	expanded map[string]bool
+}
+
+// streakWindow is how many days back the header's streak can reach.
+const streakWindow = 365
+
 // NewModel creates a new TUI model.
-func NewModel(s *store.Store) Model {
+func NewModel(s store.Backend, cfg *config.Config) Model {
 	ti := textinput.New()
 	ti.Placeholder = "goal-name"
 	ti.CharLimit = 64
 
 	m := Model{
 		store:         s,
+		cfg:           cfg,
+		now:           time.Now,
 		keys:          DefaultKeyMap(),
 		expandedState: make(map[string]bool),
 		textInput:     ti,
+
+		collapsedNotes: make(map[string]map[string]bool),
+		plainNotes:     cfg.PlainNotes,
+		panelLayout:    cfg.PanelLayout,
+		focusedLink:    -1,
+		viewStates:     make(map[string]viewState),
+
+		collapsedSections: make(map[string]bool),
 	}
+	m.loadUIState()
+	return m
+}
+
+// WithStartGoal returns m set to open on goalPath: the goal is selected,
+// its ancestors expanded and its queue tab activated once the tree loads.
+func (m Model) WithStartGoal(goalPath string) Model {
+	m.startGoal = goalPath
 	return m
 }
 
@@ -118,34 +285,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Pre-create glamour renderer at the right width
-		rightWidth := msg.Width - (msg.Width / 4) - 1 - 2
-		if rightWidth < 20 {
-			rightWidth = 20
-		}
-		m.getGlamourRenderer(rightWidth)
-		// Resize editor if active
-		if m.isEditing {
-			editorWidth := msg.Width - (msg.Width / 4) - 1
-			if editorWidth < 20 {
-				editorWidth = 20
-			}
-			m.noteEditor.SetWidth(editorWidth)
-			contentHeight := msg.Height - 5
-			editorHeight := contentHeight - 4 - 1 // header estimate + file path
-			if editorHeight < 3 {
-				editorHeight = 3
-			}
-			m.noteEditor.SetHeight(editorHeight)
-		}
+		m.resizePanes()
 		m.reload()
-		return m, tea.ClearScreen
+		title := m.titleCmd()
+		return m.fetchLinkStates(tea.Batch(tea.ClearScreen, title))
 
 	case FileChangedMsg:
 		m.reload()
+		title := m.titleCmd()
+		return m.fetchLinkStates(title)
+
+	case LinkStateMsg:
+		delete(m.linkFetching, msg.Link)
 		return m, nil
 
+	case SyncProgressMsg:
+		m.syncStep = msg.Step
+		return m, waitSync(msg.updates)
+
+	case spinner.TickMsg:
+		if !m.syncing {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.syncSpinner, cmd = m.syncSpinner.Update(msg)
+		return m, cmd
+
 	case SyncDoneMsg:
+		m.syncing = false
+		if errors.Is(msg.Err, gsync.ErrNoRemote) {
+			m.promptRemote()
+			return m, textinput.Blink
+		}
 		if msg.Err != nil {
 			m.setStatus("Sync failed: " + msg.Err.Error())
 		} else {
@@ -154,22 +325,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case LinkOpenedMsg:
+		if msg.Err != nil {
+			m.setStatus("Open failed: " + msg.Err.Error())
+		} else {
+			m.setStatus("Opened " + msg.Target)
+		}
+		return m, nil
+
+	case CopiedMsg:
+		if msg.Err != nil {
+			m.setStatus("Copy failed: " + msg.Err.Error())
+		} else {
+			m.setStatus("Copied " + msg.What)
+		}
+		return m, nil
+
 	case EditorFinishedMsg:
 		if m.externalEditPath != "" {
 			m.store.Commit("edit: " + m.externalEditPath)
 			m.externalEditPath = ""
 		}
 		m.reload()
+		if status := editorStatus(msg); status != "" {
+			m.setStatus(status)
+		}
+		return m, nil
+
+	case pomodoroTickMsg:
+		return m, m.tickPomodoro(msg)
+
+	case HookFailedMsg:
+		m.setStatus("Warning: " + msg.Err.Error())
+		return m, nil
+
+	case WarningMsg:
+		m.setStatus("Warning: " + msg.Err.Error())
+		return m, nil
+
+	case EventMsg:
+		m.recordAction(msg.Event)
 		return m, nil
 
 	case tea.KeyMsg:
-		return m.handleKeyMsg(msg)
+		next, cmd := m.handleKeyMsg(msg)
+		if next, ok := next.(Model); ok {
+			title := next.titleCmd()
+			return next.fetchLinkStates(tea.Batch(cmd, title))
+		}
+		return next, cmd
 	}
 
 	// Update text input if in input mode
 	if m.isInputMode {
 		var cmd tea.Cmd
-		m.textInput, cmd = m.textInput.Update(msg)
+		if m.inputField == createFieldTags {
+			m.tagsInput, cmd = m.tagsInput.Update(msg)
+		} else {
+			m.textInput, cmd = m.textInput.Update(msg)
+		}
 		return m, cmd
 	}
 
@@ -186,28 +400,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Input mode handling
 	if m.isInputMode {
-		switch msg.Type {
-		case tea.KeyEsc:
-			m.isInputMode = false
-			return m, nil
-		case tea.KeyEnter:
-			name := strings.TrimSpace(m.textInput.Value())
-			if name != "" {
-				_, err := m.store.CreateGoal(m.inputParent, name)
-				if err != nil {
-					m.setStatus("Error: " + err.Error())
-				} else {
-					m.setStatus("Created: " + name)
-					m.reload()
-				}
-			}
-			m.isInputMode = false
-			return m, nil
-		default:
-			var cmd tea.Cmd
-			m.textInput, cmd = m.textInput.Update(msg)
-			return m, cmd
-		}
+		return m.handleCreateInput(msg)
 	}
 
 	// Rename mode handling
@@ -242,6 +435,16 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Remote URL prompt
+	if m.showSetRemote {
+		return m.handleSetRemote(msg)
+	}
+
+	// Edit conflict prompt
+	if m.showEditConflict {
+		return m.handleEditConflict(msg)
+	}
+
 	// Inline edit mode handling
 	if m.isEditing {
 		return m.handleEditMode(msg)
@@ -252,6 +455,16 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleSearchInput(msg)
 	}
 
+	// Command prompt handling
+	if m.isPalette {
+		return m.handlePaletteInput(msg)
+	}
+
+	// Link number after the open-link key
+	if m.linkPrompt {
+		return m.handleOpenURL(msg)
+	}
+
 	// Help modal
 	if m.showHelpModal {
 		switch msg.String() {
@@ -261,11 +474,32 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Session log modal
+	if m.showSessionLog {
+		switch msg.String() {
+		case "esc", "enter", "S", "q":
+			m.showSessionLog = false
+		}
+		return m, nil
+	}
+
 	// Move mode handling
 	if m.isMoveMode {
 		return m.handleMoveMode(msg)
 	}
 
+	// Expand-all confirmation on a big tree
+	if m.showExpandAll {
+		switch msg.String() {
+		case "y", "Y":
+			m.showExpandAll = false
+			m.expandAll()
+		case "n", "N", "esc":
+			m.showExpandAll = false
+		}
+		return m, nil
+	}
+
 	// Delete confirmation
 	if m.showDeleteConfirm {
 		switch msg.String() {
@@ -286,6 +520,75 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Parent completion prompt
+	if m.showCompleteParent {
+		switch msg.String() {
+		case "y", "Y":
+			parent := m.completeParentTarget
+			m.showCompleteParent = false
+			if _, err := m.store.SetStatus(parent.Path, store.StatusComplete); err != nil {
+				m.setStatus("Error: " + err.Error())
+			} else {
+				m.setStatus(displayName(parent) + " → complete")
+				m.propagateStatus(parent.Path)
+				m.closeOut(parent)
+				m.offerDequeue(parent)
+				m.reload()
+			}
+		case "n", "N", "esc":
+			m.showCompleteParent = false
+		}
+		return m, nil
+	}
+
+	// Adding under a complete goal
+	if m.showAddUnderComplete {
+		switch msg.String() {
+		case "y", "Y":
+			m.showAddUnderComplete = false
+			return m, m.startAdd()
+		case "n", "N", "esc":
+			m.showAddUnderComplete = false
+		}
+		return m, nil
+	}
+
+	// Queue removal prompt
+	if m.showDequeue {
+		switch msg.String() {
+		case "y", "Y":
+			target := m.dequeueTarget
+			m.showDequeue = false
+			if _, err := m.store.Dequeue(target.Path); err != nil {
+				m.setStatus("Error: " + err.Error())
+			} else {
+				m.setStatus("Removed " + displayName(target) + " from the queue")
+				m.reload()
+			}
+		case "n", "N", "esc":
+			m.showDequeue = false
+		}
+		return m, nil
+	}
+
+	// Open sub-goals prompt
+	if m.showCompleteChildren {
+		switch msg.String() {
+		case "y", "Y":
+			target := m.completeChildrenTarget
+			m.showCompleteChildren = false
+			if done, err := m.store.CompleteDescendants(target.Path); err != nil {
+				m.setStatus("Error: " + err.Error())
+			} else {
+				m.setStatus(fmt.Sprintf("Completed %d sub-goal(s) of %s", len(done), displayName(target)))
+				m.reload()
+			}
+		case "n", "N", "esc":
+			m.showCompleteChildren = false
+		}
+		return m, nil
+	}
+
 	// If search filter is active (not typing), Esc/Enter clears it
 	if m.searchQuery != "" && (msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter) {
 		var curID string
@@ -294,6 +597,7 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.searchQuery = ""
 		m.searchMatchIDs = nil
+		m.searchMatches = nil
 		m.searchAncIDs = nil
 		m.rebuildVisible()
 		if curID != "" {
@@ -319,18 +623,7 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.notesScroll--
 			}
 		} else {
-			if m.cursor > 0 {
-				m.cursor--
-				// Skip section headers
-				if m.cursor >= 0 && m.cursor < len(m.visibleItems) && m.visibleItems[m.cursor].IsSectionHeader {
-					if m.cursor > 0 {
-						m.cursor--
-					} else {
-						m.cursor++
-					}
-				}
-			}
-			m.notesScroll = 0
+			m.stepCursor(-1)
 		}
 
 	case key.Matches(msg, m.keys.Down):
@@ -338,24 +631,15 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Scroll notes panel down
 			m.notesScroll++
 		} else {
-			if m.cursor < len(m.visibleItems)-1 {
-				m.cursor++
-				// Skip section headers
-				if m.cursor < len(m.visibleItems) && m.visibleItems[m.cursor].IsSectionHeader {
-					if m.cursor < len(m.visibleItems)-1 {
-						m.cursor++
-					} else {
-						m.cursor--
-					}
-				}
-			}
-			m.notesScroll = 0
+			m.stepCursor(1)
 		}
 
 	case key.Matches(msg, m.keys.Right):
 		if m.cursor < len(m.visibleItems) {
 			item := m.visibleItems[m.cursor]
-			if item.HasChildren {
+			if item.IsSectionHeader {
+				m.setSectionCollapsed(item, false)
+			} else if item.HasChildren {
 				m.expandedState[item.ID] = true
 				m.rebuildVisible()
 			}
@@ -364,9 +648,25 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Left):
 		if m.cursor < len(m.visibleItems) {
 			item := m.visibleItems[m.cursor]
-			if item.IsExpanded {
+			if item.IsSectionHeader {
+				m.setSectionCollapsed(item, true)
+			} else if item.IsExpanded {
 				m.expandedState[item.ID] = false
 				m.rebuildVisible()
+			} else {
+				m.moveCursorToParent(item)
+			}
+		}
+
+	case m.focusedPane == 1 && m.focusedLink >= 0 && key.Matches(msg, m.keys.Enter):
+		if goal := m.selectedNoteGoal(); goal != nil {
+			if k := m.focusedLinkKey(goal); k != "" {
+				cmd := m.openTarget(goal, goal.Links[k])
+				return m, cmd
+			}
+			if link, ok := m.focusedBodyLink(goal); ok {
+				cmd := m.openTarget(goal, link.target)
+				return m, cmd
 			}
 		}
 
@@ -374,39 +674,113 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.cursor < len(m.visibleItems) {
 			item := m.visibleItems[m.cursor]
 			if item.IsSectionHeader {
-				// no-op on section headers
+				m.setSectionCollapsed(item, item.IsExpanded)
 			} else if item.HasChildren {
 				m.expandedState[item.ID] = !m.expandedState[item.ID]
 				m.rebuildVisible()
 			}
 		}
 
+	case key.Matches(msg, m.keys.ToggleFuture):
+		if m.ungrouped && m.activeQueueGoal() == nil {
+			m.setStatus("No horizon sections in the plain tree: G groups by horizon")
+		} else {
+			m.toggleFutureSection()
+		}
+
 	case key.Matches(msg, m.keys.Space):
-		if m.cursor < len(m.visibleItems) {
+		if m.onGoal() {
 			item := m.visibleItems[m.cursor]
-			_, err := m.store.ToggleStatus(item.Goal.Path)
+			g, err := m.store.ToggleStatus(item.Goal.Path)
 			if err != nil {
 				m.setStatus("Error: " + err.Error())
 			} else {
+				m.propagateStatus(item.Goal.Path)
+				if g.IsComplete() {
+					m.closeOut(item.Goal)
+					m.offerDequeue(item.Goal)
+				}
 				m.reload()
 			}
 		}
 
+	case key.Matches(msg, m.keys.DoneNext):
+		m.completeAndAdvance()
+
 	case key.Matches(msg, m.keys.Tab):
-		m.focusedPane = (m.focusedPane + 1) % 2
+		// In the notes pane, tab walks the goal's links before returning
+		// to the tree
+		if m.focusedPane == 1 {
+			m.cycleLinkFocus()
+		} else if !m.notesHidden {
+			m.focusedPane = 1
+		}
+
+	case m.focusedPane == 1 && key.Matches(msg, m.keys.NextSection):
+		m.stepNoteSection(1)
+
+	case m.focusedPane == 1 && key.Matches(msg, m.keys.PrevSection):
+		m.stepNoteSection(-1)
+
+	case m.focusedPane == 1 && key.Matches(msg, m.keys.ToggleSection):
+		m.toggleNoteSection()
+
+	case m.focusedPane == 1 && key.Matches(msg, m.keys.ToggleSections):
+		m.toggleOlderNoteSections()
+
+	case key.Matches(msg, m.keys.PanelLayout):
+		m.togglePanelLayout()
+
+	case key.Matches(msg, m.keys.ToggleNotes):
+		m.toggleNotesPane()
+
+	case key.Matches(msg, m.keys.Grouping):
+		m.toggleGrouping()
+
+	case key.Matches(msg, m.keys.OpenURL):
+		m.startOpenURL()
+
+	case key.Matches(msg, m.keys.SessionLog):
+		m.showSessionLog = true
+
+	case key.Matches(msg, m.keys.CopySummary):
+		if m.onGoal() {
+			g := m.visibleItems[m.cursor].Goal
+			return m, copyText("summary of "+g.Title, GoalSummary(g, m.now()))
+		}
+
+	case key.Matches(msg, m.keys.Pomodoro):
+		return m, m.togglePomodoro()
+
+	case key.Matches(msg, m.keys.PlainNotes):
+		m.plainNotes = !m.plainNotes
+		m.notesScroll = 0
+		if m.plainNotes {
+			m.setStatus("Notes: plain text")
+		} else {
+			m.setStatus("Notes: markdown")
+		}
 
 	case key.Matches(msg, m.keys.NextQueue):
 		if m.queue != nil && len(m.queue.Items) > 0 {
-			m.activeQueue = (m.activeQueue + 1) % len(m.queue.Items)
-			m.cursor = 0
-			m.rebuildVisible()
+			// Wraps to the first tab, also from the all-goals view past the last
+			next := m.activeQueue + 1
+			if next >= len(m.queue.Items) {
+				next = 0
+			}
+			m.switchQueue(next)
+		}
+
+	case key.Matches(msg, m.keys.JumpQueue):
+		// Bindings are alt+1…alt+9; the digit is the tab's 1-based index
+		s := msg.String()
+		if n := int(s[len(s)-1] - '0'); m.queue != nil && n <= len(m.queue.Items) {
+			m.switchQueue(n - 1)
 		}
 
 	case key.Matches(msg, m.keys.PrevQueue):
 		if m.queue != nil && len(m.queue.Items) > 0 {
-			m.activeQueue = (m.activeQueue - 1 + len(m.queue.Items)) % len(m.queue.Items)
-			m.cursor = 0
-			m.rebuildVisible()
+			m.switchQueue((m.activeQueue - 1 + len(m.queue.Items)) % len(m.queue.Items))
 		}
 
 	case key.Matches(msg, m.keys.InlineEdit):
@@ -415,6 +789,14 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if item.IsSectionHeader {
 				break
 			}
+			if item.Goal.BodyErr != nil {
+				m.setStatus("Error: " + item.Goal.BodyErr.Error())
+				break
+			}
+			if item.Goal.FrontmatterErr != nil {
+				m.setStatus("Fix the frontmatter first: press E to edit the file")
+				break
+			}
 			m.enterEditMode(item.Goal)
 			return m, textarea.Blink
 		}
@@ -425,52 +807,38 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if item.IsSectionHeader {
 				break
 			}
+			if item.Goal.Encrypted {
+				// $EDITOR would see the ciphertext, and anything typed over
+				// it would be saved as plaintext
+				m.setStatus("Notes are encrypted: edit them with e, not $EDITOR")
+				break
+			}
 			m.externalEditPath = item.Goal.Path
 			return m, m.openEditor(item.Goal)
 		}
 
 	case key.Matches(msg, m.keys.AddTop):
-		m.isInputMode = true
-		m.textInput.Reset()
-		m.textInput.Focus()
 		m.inputParent = ""
 		m.inputDepth = 0
 		m.inputInsertAfter = len(m.visibleItems) - 1
-		m.textInput.Placeholder = "top-level goal name"
-		return m, textinput.Blink
-
-	case key.Matches(msg, m.keys.Add):
-		m.isInputMode = true
-		m.textInput.Reset()
-		m.textInput.Focus()
-		if m.cursor < len(m.visibleItems) {
-			parent := m.visibleItems[m.cursor]
-			m.inputParent = parent.Goal.Path
-			m.inputDepth = parent.Depth + 1
-
-			// Expand parent so children are visible
-			if parent.HasChildren && !parent.IsExpanded {
-				m.expandedState[parent.ID] = true
-				m.rebuildVisible()
+		if root := m.activeQueueGoal(); root != nil {
+			// Beside the tab's goal, under the same parent
+			if parent := filepath.Dir(root.Path); parent != "." {
+				m.inputParent = parent
 			}
+			return m, m.startCreate("goal name beside " + displayName(root))
+		}
+		return m, m.startCreate("top-level goal name")
 
-			// Find last visible descendant of parent to place input after
-			m.inputInsertAfter = m.cursor
-			for j := m.cursor + 1; j < len(m.visibleItems); j++ {
-				if m.visibleItems[j].Depth <= parent.Depth {
-					break
-				}
-				m.inputInsertAfter = j
-			}
+	case key.Matches(msg, m.keys.Peek) || (m.peeking && msg.Type == tea.KeyEsc):
+		m.togglePeek()
 
-			m.textInput.Placeholder = "sub-goal name under " + parent.Name
-		} else {
-			m.inputParent = ""
-			m.inputDepth = 0
-			m.inputInsertAfter = len(m.visibleItems) - 1
-			m.textInput.Placeholder = "top-level goal name"
+	case key.Matches(msg, m.keys.Add):
+		if m.onGoal() && m.visibleItems[m.cursor].Goal.IsComplete() {
+			m.showAddUnderComplete = true
+			return m, nil
 		}
-		return m, textinput.Blink
+		return m, m.startAdd()
 
 	case key.Matches(msg, m.keys.Rename):
 		if m.cursor < len(m.visibleItems) {
@@ -488,77 +856,90 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case key.Matches(msg, m.keys.Delete):
-		if m.cursor < len(m.visibleItems) {
+		if m.onGoal() {
 			m.deleteTarget = m.visibleItems[m.cursor].Goal.Path
+			m.deleteSummary, _ = m.store.SubtreeSummary(m.deleteTarget)
 			m.showDeleteConfirm = true
 		}
 
 	case key.Matches(msg, m.keys.ToggleExpand):
 		if m.allExpanded {
-			m.expandedState = make(map[string]bool)
-			m.allExpanded = false
+			m.collapseAll()
+		} else if more := len(m.flattenView(expandedPaths(m.goals))) - len(m.visibleItems); m.cfg.ExpandAllConfirm > 0 && more > m.cfg.ExpandAllConfirm {
+			m.expandAllReveals = more
+			m.showExpandAll = true
 		} else {
 			m.expandAll()
-			m.allExpanded = true
 		}
-		m.rebuildVisible()
 
 	case key.Matches(msg, m.keys.Reload):
 		m.reload()
 		m.setStatus("Reloaded")
 
 	case key.Matches(msg, m.keys.Sync):
-		return m, m.doSync()
+		cmd := m.doSync()
+		return m, cmd
 
 	case key.Matches(msg, m.keys.Move):
-		if m.cursor < len(m.visibleItems) {
+		if m.onGoal() {
 			m.isMoveMode = true
 			m.moveTarget = m.visibleItems[m.cursor].Goal.Path
-			m.setStatus("Move mode: j/k reorder, h unparent, l reparent, enter/esc exit")
+			m.homeMoveMarker()
+			m.setStatus(moveModeHelp)
 		}
 
 	case key.Matches(msg, m.keys.Search):
 		m.isSearching = true
+		m.searchHistoryIdx = len(m.searchHistory)
 		m.searchQuery = ""
 		m.searchMatchIDs = nil
+		m.searchMatches = nil
 		m.searchAncIDs = nil
 
+	case m.searchQuery != "" && key.Matches(msg, m.keys.NextMatch):
+		m.jumpToMatch(1)
+
+	case m.searchQuery != "" && key.Matches(msg, m.keys.PrevMatch):
+		m.jumpToMatch(-1)
+
+	case key.Matches(msg, m.keys.Palette):
+		m.openPalette()
+
 	case key.Matches(msg, m.keys.Help):
 		m.showHelpModal = !m.showHelpModal
 
-	case key.Matches(msg, m.keys.Today):
-		if m.cursor < len(m.visibleItems) {
-			item := m.visibleItems[m.cursor]
-			_, err := m.store.SetHorizon(item.Goal.Path, store.HorizonToday)
-			if err != nil {
-				m.setStatus("Error: " + err.Error())
-			} else {
-				m.setStatus(item.Name + " → today")
-				m.reload()
-			}
-		}
-
-	case key.Matches(msg, m.keys.Tomorrow):
-		if m.cursor < len(m.visibleItems) {
+	case key.Matches(msg, m.keys.SetHorizon):
+		// The digit is the horizon's 1-based place in the configured list
+		horizons := m.store.Horizons()
+		if n := int(msg.String()[0] - '0'); n > len(horizons) {
+			names := strings.Join(store.HorizonNames(horizons), ", ")
+			m.setStatus(fmt.Sprintf("No horizon %d: there are %d (%s)", n, len(horizons), names))
+		} else if m.onGoal() {
 			item := m.visibleItems[m.cursor]
-			_, err := m.store.SetHorizon(item.Goal.Path, store.HorizonTomorrow)
+			h := horizons[n-1]
+			_, err := m.store.SetHorizon(item.Goal.Path, h)
 			if err != nil {
 				m.setStatus("Error: " + err.Error())
 			} else {
-				m.setStatus(item.Name + " → tomorrow")
+				m.setStatus(item.Name + " → " + string(h))
 				m.reload()
 			}
 		}
 
-	case key.Matches(msg, m.keys.Future):
-		if m.cursor < len(m.visibleItems) {
+	case key.Matches(msg, m.keys.Pin):
+		if m.onGoal() {
 			item := m.visibleItems[m.cursor]
-			_, err := m.store.SetHorizon(item.Goal.Path, store.HorizonFuture)
+			goal, err := m.store.TogglePin(item.Goal.Path)
 			if err != nil {
 				m.setStatus("Error: " + err.Error())
 			} else {
-				m.setStatus(item.Name + " → future")
+				if goal.Pinned {
+					m.setStatus("Pinned " + item.Name)
+				} else {
+					m.setStatus("Unpinned " + item.Name)
+				}
 				m.reload()
+				m.moveCursorToGoal(goal.Path)
 			}
 		}
 	}
@@ -571,18 +952,36 @@ func (m Model) handleEditMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case msg.Type == tea.KeyEsc:
 		// Save and exit
-		m.saveInlineEdit()
-		m.isEditing = false
-		m.noteEditor.Blur()
+		if !m.saveInlineEdit() {
+			m.editConflictExit = true
+			return m, nil
+		}
+		m.exitEditMode()
 		m.reload()
-		m.setStatus("Saved")
 		return m, nil
 
 	case msg.Type == tea.KeyCtrlS:
 		// Save but stay in edit mode
-		m.saveInlineEdit()
+		if !m.saveInlineEdit() {
+			m.editConflictExit = false
+			return m, nil
+		}
 		m.reload()
-		m.setStatus("Saved")
+		return m, nil
+
+	case msg.Type == tea.KeyCtrlD:
+		// Start today's section, as cairn note would head it
+		m.insertEditorBlock(store.DateHeader(m.now().Format("2006-01-02")) + "\n")
+		return m, nil
+
+	case msg.Type == tea.KeyCtrlT:
+		// An empty note in the goal's note template, to type into
+		goal := m.findGoalByPath(m.goals, m.editGoalPath)
+		if goal == nil {
+			goal = m.editBase
+		}
+		base := store.NoteTemplate{Format: m.cfg.NoteFormat, Section: m.cfg.NoteSection}
+		m.insertEditorBlock(store.NoteTemplateFor(goal, base).Snippet(m.now()))
 		return m, nil
 
 	case msg.Type == tea.KeyCtrlC:
@@ -599,6 +998,15 @@ func (m Model) handleEditMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// insertEditorBlock inserts text at the inline editor's cursor, on a line
+// of its own when the cursor is partway through one.
+func (m *Model) insertEditorBlock(text string) {
+	if li := m.noteEditor.LineInfo(); li.StartColumn+li.ColumnOffset > 0 {
+		text = "\n" + text
+	}
+	m.noteEditor.InsertString(text)
+}
+
 // handleSearchInput handles key messages while typing in the search bar.
 func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
@@ -607,13 +1015,35 @@ func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.isSearching = false
 		m.searchQuery = ""
 		m.searchMatchIDs = nil
+		m.searchMatches = nil
 		m.searchAncIDs = nil
 		m.rebuildVisible()
 		return m, nil
 
+	case tea.KeyUp:
+		if m.searchHistoryIdx > 0 {
+			m.searchHistoryIdx--
+			m.searchQuery = m.searchHistory[m.searchHistoryIdx]
+			m.applySearchFilter()
+			m.rebuildVisible()
+		}
+		return m, nil
+
 	case tea.KeyEnter, tea.KeyDown, tea.KeyTab:
+		// ↓ steps forward through recalled queries first
+		if msg.Type == tea.KeyDown && m.searchHistoryIdx < len(m.searchHistory) {
+			m.searchHistoryIdx++
+			m.searchQuery = ""
+			if m.searchHistoryIdx < len(m.searchHistory) {
+				m.searchQuery = m.searchHistory[m.searchHistoryIdx]
+			}
+			m.applySearchFilter()
+			m.rebuildVisible()
+			return m, nil
+		}
 		// Exit search input but keep filter active
 		m.isSearching = false
+		m.rememberSearch(m.searchQuery)
 		return m, nil
 
 	case tea.KeyBackspace:
@@ -635,17 +1065,133 @@ func (m Model) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
-// enterEditMode sets up the textarea for inline editing of a goal's notes.
-func (m *Model) enterEditMode(goal *store.Goal) {
-	ta := textarea.New()
-	ta.ShowLineNumbers = false
-	ta.SetValue(goal.Body)
+// propagateStatus pushes a status change up to the goal's ancestors when
+// enabled, prompting to complete a parent whose children are now all complete.
+func (m *Model) propagateStatus(goalPath string) {
+	if !m.cfg.PropagateStatus {
+		return
+	}
+	parent, err := m.store.PropagateStatus(goalPath)
+	if err != nil {
+		m.setStatus("Error: " + err.Error())
+		return
+	}
+	if parent != nil {
+		m.completeParentTarget = parent
+		m.showCompleteParent = true
+	}
+}
+
+// completeAndAdvance marks the selected goal complete, with the same
+// follow-ups as completing it with space, and moves the cursor to the next
+// open goal below it, skipping section headers, complete goals and the
+// goal's own sub-goals. An already complete goal is left as it is.
+func (m *Model) completeAndAdvance() {
+	if !m.onGoal() {
+		return
+	}
+	item := m.visibleItems[m.cursor]
+	next := ""
+	for _, it := range m.visibleItems[m.cursor+1:] {
+		if !it.IsSectionHeader && !it.Goal.IsComplete() && !isWithin(it.Goal.Path, item.Goal.Path) {
+			next = it.Goal.Path
+			break
+		}
+	}
+
+	if !item.Goal.IsComplete() {
+		if _, err := m.store.SetStatus(item.Goal.Path, store.StatusComplete); err != nil {
+			m.setStatus("Error: " + err.Error())
+			return
+		}
+		m.propagateStatus(item.Goal.Path)
+		m.closeOut(item.Goal)
+		m.offerDequeue(item.Goal)
+		m.reload()
+	}
+	if next == "" {
+		m.setStatus(item.Name + " done; no open goals below")
+		return
+	}
+	m.moveCursorToGoal(next)
+	m.setStatus(item.Name + " done")
+}
 
-	// Size the editor to the right panel, leaving room for header and file path
-	rightWidth := m.width - (m.width / 4) - 1
-	if rightWidth < 20 {
-		rightWidth = 20
+// closeOut collapses a parent that was just completed when
+// CollapseOnComplete is set and, with "ask", offers to complete the
+// sub-goals still open under it. g is the goal as loaded in the tree.
+func (m *Model) closeOut(g *store.Goal) {
+	if m.cfg.CollapseOnComplete == "off" || len(g.Children) == 0 {
+		return
+	}
+	m.expandedState[g.Path] = false
+	if m.cfg.CollapseOnComplete != "ask" || m.showCompleteParent {
+		return
+	}
+	if open := store.OpenDescendants(g); len(open) > 0 {
+		m.completeChildrenTarget = g
+		m.completeChildrenOpen = open
+		m.showCompleteChildren = true
+	}
+}
+
+// startAdd opens the add prompt for a sub-goal of the selected goal, or a
+// top-level goal when a section header is selected.
+func (m *Model) startAdd() tea.Cmd {
+	placeholder := "top-level goal name"
+	if m.onGoal() {
+		parent := m.visibleItems[m.cursor]
+		m.inputParent = parent.Goal.Path
+		m.inputDepth = parent.Depth + 1
+
+		// Expand parent so children are visible
+		if parent.HasChildren && !parent.IsExpanded {
+			m.expandedState[parent.ID] = true
+			m.rebuildVisible()
+		}
+
+		// Find last visible descendant of parent to place input after
+		m.inputInsertAfter = m.cursor
+		for j := m.cursor + 1; j < len(m.visibleItems); j++ {
+			if m.visibleItems[j].Depth <= parent.Depth {
+				break
+			}
+			m.inputInsertAfter = j
+		}
+
+		placeholder = "sub-goal name under " + parent.Name
+	} else {
+		m.inputParent = ""
+		m.inputDepth = 0
+		m.inputInsertAfter = len(m.visibleItems) - 1
 	}
+	return m.startCreate(placeholder)
+}
+
+// offerDequeue asks whether to remove g, just completed, from the queue
+// when AutoQueue is set, g is queued and no other prompt is showing.
+func (m *Model) offerDequeue(g *store.Goal) {
+	if !m.cfg.AutoQueue || m.showCompleteParent || m.showCompleteChildren {
+		return
+	}
+	if m.queue == nil || !slices.Contains(m.queue.Items, g.Path) {
+		return
+	}
+	m.dequeueTarget = g
+	m.showDequeue = true
+}
+
+// enterEditMode sets up the textarea for inline editing of a goal's notes.
+func (m *Model) enterEditMode(goal *store.Goal) {
+	if m.notesHidden {
+		m.toggleNotesPane()
+	}
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.SetValue(goal.Body)
+
+	// Size the editor to the notes pane, leaving room for header and file path
+	_, notes := m.paneSizes(m.width, m.height-5) // outer chrome (header/tabs/seps/footer)
 
 	// Estimate header height (title + metadata + links + glamour spacing)
 	headerLines := 3 // title line + blank + meta line (rough estimate)
@@ -653,74 +1199,252 @@ func (m *Model) enterEditMode(goal *store.Goal) {
 		headerLines += len(goal.Links) + 1
 	}
 
-	contentHeight := m.height - 5 // outer chrome (header/tabs/seps/footer)
-	editorHeight := contentHeight - headerLines - 1 // -1 for file path line
+	editorHeight := notes.height - headerLines - 1 // -1 for file path line
 	if editorHeight < 3 {
 		editorHeight = 3
 	}
-	ta.SetWidth(rightWidth)
+	ta.SetWidth(notes.width)
 	ta.SetHeight(editorHeight)
 	ta.Focus()
 
 	m.isEditing = true
 	m.noteEditor = ta
 	m.editGoalPath = goal.Path
+	m.editBase = goal
 	m.focusedPane = 1
 }
 
-// saveInlineEdit saves the textarea content back to the goal file.
-func (m *Model) saveInlineEdit() {
+// exitEditMode leaves inline editing.
+func (m *Model) exitEditMode() {
+	m.isEditing = false
+	m.showEditConflict = false
+	m.noteEditor.Blur()
+}
+
+// saveInlineEdit saves the textarea content back to the goal file and
+// reports whether it did. A goal whose frontmatter changed on disk since
+// editing began keeps those changes; one whose notes changed or that was
+// deleted isn't saved, and the edit conflict prompt asks what to do.
+func (m *Model) saveInlineEdit() bool {
 	goal, err := m.store.LoadGoal(m.editGoalPath)
-	if err != nil {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		m.showEditConflict = true
+		m.editConflictGone = true
+		return false
+	case err != nil:
 		m.setStatus("Save error: " + err.Error())
-		return
+		return false
+	case goal.Body != m.editBase.Body && goal.Body != m.noteEditor.Value():
+		m.showEditConflict = true
+		m.editConflictGone = false
+		return false
 	}
+	merged := !goal.Updated.Equal(m.editBase.Updated)
+	if !m.writeInlineEdit(goal) {
+		return false
+	}
+	if merged {
+		m.setStatus("Saved, keeping changes made on disk")
+	} else {
+		m.setStatus("Saved")
+	}
+	return true
+}
+
+// writeInlineEdit saves the textarea content as goal's notes and makes the
+// result the base for spotting later changes on disk.
+func (m *Model) writeInlineEdit(goal *store.Goal) bool {
 	goal.Body = m.noteEditor.Value()
 	if err := m.store.SaveGoal(goal); err != nil {
 		m.setStatus("Save error: " + err.Error())
-	} else {
-		m.store.Commit("edit: " + m.editGoalPath)
+		return false
+	}
+	m.store.Commit("edit: " + goal.Path)
+	if saved, err := m.store.LoadGoal(goal.Path); err == nil {
+		m.editBase = saved
 	}
+	return true
+}
+
+// handleEditConflict handles keys in the prompt shown when the edited goal
+// changed on disk: overwrite it, reload it (dropping the edit) or save the
+// edit as a copy. Esc goes back to editing.
+func (m Model) handleEditConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	saved := false
+	switch msg.String() {
+	case "o", "O":
+		goal := m.editBase
+		if !m.editConflictGone {
+			current, err := m.store.LoadGoal(m.editGoalPath)
+			if err != nil {
+				m.setStatus("Save error: " + err.Error())
+				return m, nil
+			}
+			goal = current
+		}
+		if saved = m.writeInlineEdit(goal); saved {
+			m.setStatus("Saved over the changes on disk")
+		}
+	case "r", "R":
+		m.showEditConflict = false
+		if m.editConflictGone {
+			m.exitEditMode()
+			m.setStatus("Edit discarded: the goal was deleted")
+			m.reload()
+			return m, nil
+		}
+		current, err := m.store.LoadGoal(m.editGoalPath)
+		if err != nil {
+			m.setStatus("Reload error: " + err.Error())
+			return m, nil
+		}
+		m.editBase = current
+		m.noteEditor.SetValue(current.Body)
+		m.setStatus("Reloaded from disk")
+		saved = true
+	case "c", "C":
+		path, err := m.saveEditCopy()
+		if err != nil {
+			m.setStatus("Save error: " + err.Error())
+			return m, nil
+		}
+		m.exitEditMode()
+		m.setStatus("Saved your edit as " + path)
+		m.reload()
+		return m, nil
+	case "esc":
+		m.showEditConflict = false
+		return m, nil
+	default:
+		return m, nil
+	}
+	m.showEditConflict = false
+	if saved && m.editConflictExit {
+		m.exitEditMode()
+	}
+	m.reload()
+	return m, nil
+}
+
+// saveEditCopy saves the textarea content as a new sibling of the edited
+// goal, titled after it, and returns the new goal's path.
+func (m *Model) saveEditCopy() (string, error) {
+	parent := filepath.Dir(m.editGoalPath)
+	if parent == "." {
+		parent = ""
+	}
+	name := filepath.Base(m.editGoalPath)
+	slug := name + "-copy"
+	for i := 2; ; i++ {
+		if _, err := m.store.LoadGoal(filepath.Join(parent, slug)); err != nil {
+			break
+		}
+		slug = fmt.Sprintf("%s-copy-%d", name, i)
+	}
+	g, err := m.store.CreateGoal(parent, slug)
+	if err != nil {
+		return "", err
+	}
+	g.Title = m.editBase.Title + " (copy)"
+	g.Body = m.noteEditor.Value()
+	if err := m.store.SaveGoal(g); err != nil {
+		return "", err
+	}
+	m.store.Commit("edit: save copy as " + g.Path)
+	return g.Path, nil
+}
+
+// searchHistoryMax is how many recent queries are remembered.
+const searchHistoryMax = 20
+
+// rememberSearch moves query to the end of the search history, dropping the
+// oldest entry once there are searchHistoryMax, and saves it.
+func (m *Model) rememberSearch(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	if n := len(m.searchHistory); n > 0 && m.searchHistory[n-1] == query {
+		return
+	}
+	m.searchHistory = slices.DeleteFunc(m.searchHistory, func(q string) bool { return q == query })
+	m.searchHistory = append(m.searchHistory, query)
+	if extra := len(m.searchHistory) - searchHistoryMax; extra > 0 {
+		m.searchHistory = slices.Delete(m.searchHistory, 0, extra)
+	}
+	m.saveUIState()
 }
 
 // applySearchFilter computes searchMatchIDs and searchAncIDs based on searchQuery.
 func (m *Model) applySearchFilter() {
 	if m.searchQuery == "" {
 		m.searchMatchIDs = nil
+		m.searchMatches = nil
 		m.searchAncIDs = nil
 		return
 	}
 
 	query := strings.ToLower(m.searchQuery)
 	m.searchMatchIDs = make(map[string]bool)
+	m.searchMatches = nil
 	m.searchAncIDs = make(map[string]bool)
 
-	// Walk all visible items looking for matches
-	// We need to walk the full flattened tree (before filtering)
-	var allItems []TreeItem
-	allItems = FlattenWithHorizonGroups(m.goals, m.expandedState)
-	// Also add items from non-grouped view if using queue
-	if m.queue != nil && len(m.queue.Items) > 0 && m.activeQueue < len(m.queue.Items) {
-		activeSlug := m.queue.Items[m.activeQueue]
-		for _, g := range m.goals {
-			if g.Slug == activeSlug {
-				allItems = FlattenVisibleItems([]*store.Goal{g}, m.expandedState)
-				break
-			}
-		}
-	}
+	// Match against everything the current view could show, including
+	// collapsed subtrees; ancestors of matches are expanded below.
+	allItems := m.flattenView(expandedPaths(m.goals))
 
 	for _, item := range allItems {
 		if item.IsSectionHeader {
 			continue
 		}
-		if strings.Contains(strings.ToLower(item.Name), query) {
+		if strings.Contains(strings.ToLower(item.Name), query) || store.MatchesName(item.Goal, query) {
 			m.searchMatchIDs[item.ID] = true
+			m.searchMatches = append(m.searchMatches, item.ID)
 			m.addSearchAncestors(item.ParentID, allItems)
 		}
 	}
 }
 
+// jumpToMatch moves the cursor to the next (1) or previous (-1) search
+// match, wrapping around. Ancestor rows shown for context are skipped.
+func (m *Model) jumpToMatch(delta int) {
+	var rows []int // visible rows of matches, in order
+	for _, id := range m.searchMatches {
+		for i, item := range m.visibleItems {
+			if item.ID == id {
+				rows = append(rows, i)
+				break
+			}
+		}
+	}
+	if len(rows) == 0 {
+		m.setStatus("No matches")
+		return
+	}
+
+	next := 0
+	if delta > 0 {
+		for next < len(rows) && rows[next] <= m.cursor {
+			next++
+		}
+		next %= len(rows)
+	} else {
+		next = len(rows) - 1
+		for next >= 0 && rows[next] >= m.cursor {
+			next--
+		}
+		if next < 0 {
+			next = len(rows) - 1
+		}
+	}
+	m.cursor = rows[next]
+	m.notesScroll = 0
+	m.noteSection = 0
+	m.focusedLink = -1
+	m.setStatus(fmt.Sprintf("Match %d/%d", next+1, len(rows)))
+}
+
 // addSearchAncestors walks up the tree adding ancestor IDs and auto-expanding them.
 func (m *Model) addSearchAncestors(parentID string, allItems []TreeItem) {
 	if parentID == "" {
@@ -743,176 +1467,140 @@ func (m *Model) addSearchAncestors(parentID string, allItems []TreeItem) {
 	}
 }
 
-func (m Model) handleMoveMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch {
-	case key.Matches(msg, m.keys.Quit):
-		m.isMoveMode = false
-		m.moveTarget = ""
-		m.setStatus("Move cancelled")
-
-	case msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter:
-		m.isMoveMode = false
-		m.moveTarget = ""
-		m.setStatus("Move complete")
-
-	case key.Matches(msg, m.keys.Down):
-		// Try reorder down among siblings first
-		moved := m.tryReorder(1)
-		if !moved {
-			// At bottom of siblings — shift to next horizon
-			m.shiftHorizon(1)
-		}
-
-	case key.Matches(msg, m.keys.Up):
-		// Try reorder up among siblings first
-		moved := m.tryReorder(-1)
-		if !moved {
-			// At top of siblings — shift to previous horizon
-			m.shiftHorizon(-1)
-		}
-
-	case key.Matches(msg, m.keys.Left):
-		// Unparent: move to parent's parent (one level up)
-		parentPath := filepath.Dir(m.moveTarget)
-		if parentPath == "." {
-			// Already top-level, nothing to do
-			m.setStatus("Already at top level")
-		} else {
-			grandparentPath := filepath.Dir(parentPath)
-			if grandparentPath == "." {
-				grandparentPath = ""
-			}
-			if err := m.store.MoveGoal(m.moveTarget, grandparentPath); err != nil {
-				m.setStatus("Move error: " + err.Error())
-			} else {
-				// Update moveTarget to reflect new path
-				slug := filepath.Base(m.moveTarget)
-				if grandparentPath == "" {
-					m.moveTarget = slug
-				} else {
-					m.moveTarget = filepath.Join(grandparentPath, slug)
-				}
-				// Expand the new parent so we can see the moved item
-				if grandparentPath != "" {
-					m.expandedState[grandparentPath] = true
-				}
-				m.reload()
-				m.moveCursorToGoal(m.moveTarget)
-			}
-		}
-
-	case key.Matches(msg, m.keys.Right):
-		// Reparent: move under the previous sibling
-		slug := filepath.Base(m.moveTarget)
+// stepCursor moves the tree cursor one row up (-1) or down (1). Section
+// headers are rows too, so they can be collapsed and expanded.
+func (m *Model) stepCursor(delta int) {
+	if delta < 0 && m.cursor > 0 {
+		m.cursor--
+	}
+	if delta > 0 && m.cursor < len(m.visibleItems)-1 {
+		m.cursor++
+	}
+	m.notesScroll = 0
+	m.noteSection = 0
+	m.focusedLink = -1
+}
 
-		// Find the previous sibling in the visible items
-		prevSibling := m.findPreviousSibling(m.moveTarget)
-		if prevSibling == "" {
-			m.setStatus("No previous sibling to move under")
-		} else {
-			if err := m.store.MoveGoal(m.moveTarget, prevSibling); err != nil {
-				m.setStatus("Move error: " + err.Error())
-			} else {
-				m.moveTarget = filepath.Join(prevSibling, slug)
-				// Expand the new parent so we can see the moved item
-				m.expandedState[prevSibling] = true
-				m.reload()
-				m.moveCursorToGoal(m.moveTarget)
-			}
+// moveCursorToParent moves the cursor to item's parent row, which for a
+// top-level goal is its section header.
+func (m *Model) moveCursorToParent(item TreeItem) {
+	for i := m.cursor - 1; i >= 0; i-- {
+		if m.visibleItems[i].ID == item.ParentID {
+			m.cursor = i
+			m.notesScroll = 0
+			m.noteSection = 0
+			m.focusedLink = -1
+			return
 		}
 	}
+}
 
-	return m, nil
+// onGoal reports whether the cursor is on a goal rather than a section header.
+func (m *Model) onGoal() bool {
+	return m.cursor < len(m.visibleItems) && !m.visibleItems[m.cursor].IsSectionHeader
 }
 
-// tryReorder attempts to reorder the move target among its siblings.
-// Returns true if the goal actually moved, false if it was already at the boundary.
-func (m *Model) tryReorder(delta int) bool {
-	// Check if the goal is at the boundary before calling ReorderGoal
-	goal := m.findGoalByPath(m.goals, m.moveTarget)
-	if goal == nil {
-		return false
+// skipHeader moves the cursor off a section header onto the first goal
+// below it, so a fresh view starts on a goal.
+func (m *Model) skipHeader() {
+	for i := m.cursor; i < len(m.visibleItems); i++ {
+		if !m.visibleItems[i].IsSectionHeader {
+			m.cursor = i
+			return
+		}
 	}
+}
 
-	// Find siblings
-	parentPath := filepath.Dir(m.moveTarget)
-	if parentPath == "." {
-		parentPath = ""
+// setSectionCollapsed collapses or expands the section header's goals,
+// leaving the cursor on the header, and remembers the choice.
+func (m *Model) setSectionCollapsed(header TreeItem, collapsed bool) {
+	key := sectionKey(header)
+	if m.collapsedSections[key] == collapsed {
+		return
 	}
-	var siblings []*store.Goal
-	if parentPath == "" {
-		siblings = m.goals
+	if collapsed {
+		m.collapsedSections[key] = true
 	} else {
-		parent := m.findGoalByPath(m.goals, parentPath)
-		if parent != nil {
-			siblings = parent.Children
-		}
+		delete(m.collapsedSections, key)
 	}
+	m.saveUIState()
+	m.rebuildVisible()
+	m.moveCursorToID(header.ID)
+}
 
-	// Find current index among siblings
-	idx := -1
-	for i, s := range siblings {
-		if s.Path == m.moveTarget {
-			idx = i
-			break
-		}
+// toggleFutureSection collapses or expands the last horizon's section,
+// FUTURE by default, from anywhere in the tree. The selection stays put
+// unless it was inside that section, in which case the header takes it.
+func (m *Model) toggleFutureSection() {
+	future := string(store.FarHorizon(m.store.Horizons()))
+	selected := ""
+	if m.cursor < len(m.visibleItems) {
+		selected = m.visibleItems[m.cursor].ID
 	}
-	if idx == -1 {
-		return false
-	}
-
-	newIdx := idx + delta
-	if newIdx < 0 || newIdx >= len(siblings) {
-		return false
+	if m.collapsedSections[future] {
+		delete(m.collapsedSections, future)
+		m.setStatus(strings.ToUpper(future) + " shown")
+	} else {
+		m.collapsedSections[future] = true
+		m.setStatus(strings.ToUpper(future) + " collapsed")
 	}
-
-	if err := m.store.ReorderGoal(m.moveTarget, delta); err != nil {
-		m.setStatus("Move error: " + err.Error())
-		return false
+	m.saveUIState()
+	m.rebuildVisible()
+	if !m.moveCursorToID(selected) {
+		m.moveCursorToID("__header_" + future)
 	}
-	m.reload()
-	m.moveCursorToGoal(m.moveTarget)
-	return true
 }
 
-var horizonOrder = []store.Horizon{store.HorizonToday, store.HorizonTomorrow, store.HorizonFuture}
-
-// shiftHorizon changes the move target's horizon to the next/previous one.
-func (m *Model) shiftHorizon(delta int) {
-	goal := m.findGoalByPath(m.goals, m.moveTarget)
-	if goal == nil {
-		return
+// moveCursorToID selects the visible row with id and reports whether there is one.
+func (m *Model) moveCursorToID(id string) bool {
+	for i, item := range m.visibleItems {
+		if item.ID == id {
+			m.cursor = i
+			return true
+		}
 	}
+	return false
+}
 
-	// Only shift horizon for top-level goals
-	parentPath := filepath.Dir(m.moveTarget)
-	if parentPath != "." {
-		return
+// invalidDestination returns why dest can't become the moved goal's parent,
+// or "" if it can.
+func (m *Model) invalidDestination(dest string) string {
+	target := m.moveTarget
+	switch {
+	case dest == "":
+		return "Pick a goal"
+	case dest == target || strings.HasPrefix(dest, target+string(filepath.Separator)):
+		return "Can't move a goal under itself or a descendant"
+	case dest == filepath.Dir(target):
+		return "Already under " + dest
 	}
-
-	currentIdx := 0
-	for i, h := range horizonOrder {
-		if h == goal.Horizon {
-			currentIdx = i
-			break
+	if parent := m.findGoalByPath(m.goals, dest); parent != nil {
+		slug := filepath.Base(target)
+		for _, child := range parent.Children {
+			if child.Slug == slug {
+				return dest + " already has a goal named " + slug
+			}
 		}
 	}
+	return ""
+}
 
-	newIdx := currentIdx + delta
-	if newIdx < 0 || newIdx >= len(horizonOrder) {
-		return
+// siblingIndex returns the goal's position among its siblings and how many
+// siblings there are, or -1 if it isn't in the tree.
+func (m *Model) siblingIndex(goalPath string) (idx, count int) {
+	siblings := m.goals
+	if parentPath := filepath.Dir(goalPath); parentPath != "." {
+		siblings = nil
+		if parent := m.findGoalByPath(m.goals, parentPath); parent != nil {
+			siblings = parent.Children
+		}
 	}
-
-	newHorizon := horizonOrder[newIdx]
-	_, err := m.store.SetHorizon(m.moveTarget, newHorizon)
-	if err != nil {
-		m.setStatus("Move error: " + err.Error())
-		return
+	for i, s := range siblings {
+		if s.Path == goalPath {
+			return i, len(siblings)
+		}
 	}
-
-	m.setStatus(filepath.Base(m.moveTarget) + " → " + string(newHorizon))
-	m.reload()
-	m.moveCursorToGoal(m.moveTarget)
+	return -1, len(siblings)
 }
 
 // moveCursorToGoal positions the cursor on the given goal path in the visible items.
@@ -925,35 +1613,6 @@ func (m *Model) moveCursorToGoal(goalPath string) {
 	}
 }
 
-// findPreviousSibling returns the path of the previous sibling of the goal.
-// It looks at the goal tree data (not just visible items) to find the actual sibling.
-func (m *Model) findPreviousSibling(goalPath string) string {
-	slug := filepath.Base(goalPath)
-	parentPath := filepath.Dir(goalPath)
-	if parentPath == "." {
-		parentPath = ""
-	}
-
-	// Find siblings from the goal tree
-	var siblings []*store.Goal
-	if parentPath == "" {
-		siblings = m.goals
-	} else {
-		parent := m.findGoalByPath(m.goals, parentPath)
-		if parent == nil {
-			return ""
-		}
-		siblings = parent.Children
-	}
-
-	for i, sib := range siblings {
-		if sib.Slug == slug && i > 0 {
-			return siblings[i-1].Path
-		}
-	}
-	return ""
-}
-
 // findGoalByPath recursively searches for a goal by its path.
 func (m *Model) findGoalByPath(goals []*store.Goal, path string) *store.Goal {
 	for _, g := range goals {
@@ -967,13 +1626,47 @@ func (m *Model) findGoalByPath(goals []*store.Goal, path string) *store.Goal {
 	return nil
 }
 
+// rolloverIfNewDay promotes tomorrow goals to today on the first reload of
+// each day, which covers both startup and a TUI left open past midnight.
+func (m *Model) rolloverIfNewDay() {
+	day := m.now().Format("2006-01-02")
+	if day == m.rolloverDay {
+		return
+	}
+	m.rolloverDay = day
+
+	n, err := m.store.RolloverHorizons(m.now())
+	if err != nil && !errors.Is(err, store.ErrReadOnly) {
+		m.setStatus("Rollover error: " + err.Error())
+		return
+	}
+	if n > 0 {
+		m.setStatus(fmt.Sprintf("Rolled over %d goal(s) from tomorrow to today", n))
+	}
+}
+
+// activeQueueGoal returns the goal the active queue entry points at, or nil
+// when no tab is active or it's being peeked past. Entries are goal paths,
+// so queued goals may be nested (e.g. after a move).
+func (m *Model) activeQueueGoal() *store.Goal {
+	if m.queue == nil || m.activeQueue >= len(m.queue.Items) || m.peeking {
+		return nil
+	}
+	return m.findGoalByPath(m.goals, m.queue.Items[m.activeQueue])
+}
+
 func (m *Model) reload() {
+	m.rolloverIfNewDay()
+
 	goals, err := m.store.LoadGoalTree()
 	if err != nil {
 		m.setStatus("Load error: " + err.Error())
 		return
 	}
+	firstLoad := m.goals == nil
 	m.goals = goals
+	m.activity = store.Activity(goals, m.now(), streakWindow)
+	m.streak = store.Streak(m.activity)
 
 	q, err := m.store.LoadQueue()
 	if err != nil {
@@ -981,38 +1674,177 @@ func (m *Model) reload() {
 	}
 	m.queue = q
 
+	m.applySearchFilter()
 	m.rebuildVisible()
+	if firstLoad {
+		m.skipHeader()
+	}
+
+	if m.startGoal != "" {
+		m.focusGoal(m.startGoal)
+		m.startGoal = ""
+	}
 }
 
-func (m *Model) rebuildVisible() {
-	// If we have a queue and an active queue item, show that goal's tree
-	var goalsToShow []*store.Goal
-	useHorizonGroups := false
-	if m.queue != nil && len(m.queue.Items) > 0 && m.activeQueue < len(m.queue.Items) {
-		activeSlug := m.queue.Items[m.activeQueue]
-		for _, g := range m.goals {
-			if g.Slug == activeSlug {
-				goalsToShow = []*store.Goal{g}
-				break
-			}
+// focusGoal selects goalPath, switching to the queue tab that contains it
+// (or the all-goals view when it isn't queued) and expanding its ancestors.
+func (m *Model) focusGoal(goalPath string) {
+	goalPath = filepath.Clean(goalPath)
+	if m.findGoalByPath(m.goals, goalPath) == nil {
+		m.setStatus("Goal not found: " + goalPath)
+		return
+	}
+
+	// No tab is active past the last entry, which shows every goal
+	queue := len(m.queue.Items)
+	for i, item := range m.queue.Items {
+		if isWithin(goalPath, item) && m.findGoalByPath(m.goals, item) != nil {
+			queue = i
+			break
 		}
 	}
+	m.switchQueue(queue)
 
-	// If no queue match, show all goals grouped by horizon
-	if len(goalsToShow) == 0 {
-		goalsToShow = m.goals
-		useHorizonGroups = true
+	for dir := filepath.Dir(goalPath); dir != "."; dir = filepath.Dir(dir) {
+		m.expandedState[dir] = true
 	}
+	m.applySearchFilter()
+	m.rebuildVisible()
+	m.moveCursorToGoal(goalPath)
+}
 
-	if useHorizonGroups {
-		m.visibleItems = FlattenWithHorizonGroups(goalsToShow, m.expandedState)
+// viewKey identifies the current view for viewStates: the active queue
+// entry, or "" for the all-goals view.
+func (m *Model) viewKey() string {
+	if m.queue == nil || m.activeQueue >= len(m.queue.Items) {
+		return ""
+	}
+	return m.queue.Items[m.activeQueue]
+}
+
+// switchQueue activates queue tab i, saving the cursor and expansion of the
+// view being left and restoring those of the one being entered. Views seen
+// for the first time start from the current expansion with the cursor on top.
+func (m *Model) switchQueue(i int) {
+	m.endPeek()
+	selected := ""
+	if m.cursor < len(m.visibleItems) {
+		selected = m.visibleItems[m.cursor].ID
+	}
+	m.viewStates[m.viewKey()] = viewState{selected: selected, expanded: m.expandedState}
+
+	m.activeQueue = i
+	saved, ok := m.viewStates[m.viewKey()]
+	if ok {
+		m.expandedState = saved.expanded
 	} else {
-		m.visibleItems = FlattenVisibleItems(goalsToShow, m.expandedState)
+		m.expandedState = maps.Clone(m.expandedState)
+	}
+
+	m.cursor = 0
+	m.notesScroll = 0
+	m.noteSection = 0
+	m.focusedLink = -1
+	m.applySearchFilter()
+	m.rebuildVisible()
+	if saved.selected == "" || !m.moveCursorToID(saved.selected) {
+		m.skipHeader()
 	}
+}
 
-	// Apply search filter if active
+// togglePeek shows every goal, grouped by horizon, from a queue tab, with
+// the cursor on the goal selected there so its siblings are in view.
+// Toggling again returns to the tab as it was.
+func (m *Model) togglePeek() {
+	if m.peeking {
+		saved := m.peekState
+		m.endPeek()
+		m.applySearchFilter()
+		m.rebuildVisible()
+		if !m.moveCursorToID(saved.selected) {
+			m.skipHeader()
+		}
+		return
+	}
+	root := m.activeQueueGoal()
+	if root == nil {
+		m.setStatus("Peek shows all goals from a queue tab")
+		return
+	}
+	goalPath, selected := root.Path, ""
+	if m.onGoal() {
+		goalPath = m.visibleItems[m.cursor].Goal.Path
+		selected = m.visibleItems[m.cursor].ID
+	}
+	m.peekState = viewState{selected: selected, expanded: m.expandedState}
+	m.peeking = true
+
+	m.expandedState = maps.Clone(m.expandedState)
+	if all, ok := m.viewStates[""]; ok {
+		m.expandedState = maps.Clone(all.expanded)
+	}
+	for dir := filepath.Dir(goalPath); dir != "."; dir = filepath.Dir(dir) {
+		m.expandedState[dir] = true
+	}
+	m.applySearchFilter()
+	m.rebuildVisible()
+	m.moveCursorToGoal(goalPath)
+	m.setStatus("Peeking at all goals: o or esc returns to the tab")
+}
+
+// endPeek leaves peeking, restoring the tab's expansion.
+func (m *Model) endPeek() {
+	if m.peeking {
+		m.peeking = false
+		m.expandedState = m.peekState.expanded
+	}
+}
+
+// isWithin reports whether goalPath is root or one of its descendants.
+func isWithin(goalPath, root string) bool {
+	root = filepath.Clean(root)
+	return goalPath == root || strings.HasPrefix(goalPath, root+string(filepath.Separator))
+}
+
+// flattenView flattens the goals the current view shows: the active queue
+// goal's subtree, or (with no queue match) all goals grouped by horizon.
+// rebuildVisible and the search filter share it so matches are always
+// computed against the rows the tree can display.
+func (m *Model) flattenView(expanded map[string]bool) []TreeItem {
+	if g := m.activeQueueGoal(); g != nil {
+		return FlattenVisibleItems([]*store.Goal{g}, expanded)
+	}
+	if m.ungrouped {
+		return FlattenVisibleItems(m.goals, expanded)
+	}
+	return FlattenWithHorizonGroups(m.goals, m.store.Horizons(), expanded, m.cfg.HorizonRollup)
+}
+
+// expandedPaths returns an expanded state with every goal that has children open.
+func expandedPaths(goals []*store.Goal) map[string]bool {
+	expanded := make(map[string]bool)
+	var walk func([]*store.Goal)
+	walk = func(goals []*store.Goal) {
+		for _, g := range goals {
+			if len(g.Children) > 0 {
+				expanded[g.Path] = true
+				walk(g.Children)
+			}
+		}
+	}
+	walk(goals)
+	return expanded
+}
+
+func (m *Model) rebuildVisible() {
+	m.visibleItems = m.flattenView(m.expandedState)
+
+	// Apply search filter if active. Searching reveals matches in
+	// collapsed sections.
 	if m.searchQuery != "" && (m.searchMatchIDs != nil || m.searchAncIDs != nil) {
 		m.visibleItems = FilterVisibleItems(m.visibleItems, m.searchMatchIDs, m.searchAncIDs)
+	} else {
+		m.visibleItems = CollapseSections(m.visibleItems, m.collapsedSections)
 	}
 
 	// Clamp cursor
@@ -1022,19 +1854,11 @@ func (m *Model) rebuildVisible() {
 	if m.cursor < 0 {
 		m.cursor = 0
 	}
-
-	// Skip section headers
-	if m.cursor < len(m.visibleItems) && m.visibleItems[m.cursor].IsSectionHeader {
-		for i := m.cursor; i < len(m.visibleItems); i++ {
-			if !m.visibleItems[i].IsSectionHeader {
-				m.cursor = i
-				return
-			}
-		}
-	}
 }
 
+// expandAll expands every goal, keeping the cursor on the selected goal.
 func (m *Model) expandAll() {
+	selected := m.selectedGoalPath()
 	var expand func(goals []*store.Goal)
 	expand = func(goals []*store.Goal) {
 		for _, g := range goals {
@@ -1045,7 +1869,108 @@ func (m *Model) expandAll() {
 		}
 	}
 	expand(m.goals)
+	m.allExpanded = true
+	m.rebuildVisible()
+	m.restoreCursor(selected)
+	_, shown := countItems(m.visibleItems)
+	m.setStatus(fmt.Sprintf("Expanded all: %d goals shown", shown))
+}
+
+// collapseAll collapses every goal. The cursor stays on the selected goal
+// or, now that it's hidden, the ancestor it's under.
+func (m *Model) collapseAll() {
+	selected := m.selectedGoalPath()
+	m.expandedState = make(map[string]bool)
+	m.allExpanded = false
+	m.rebuildVisible()
+	m.restoreCursor(selected)
+	m.setStatus("Collapsed all")
+}
+
+// selectedGoalPath returns the path of the goal under the cursor, or "".
+func (m *Model) selectedGoalPath() string {
+	if !m.onGoal() {
+		return ""
+	}
+	return m.visibleItems[m.cursor].Goal.Path
+}
+
+// restoreCursor moves the cursor back to goalPath after the visible items
+// changed, or to its nearest visible ancestor.
+func (m *Model) restoreCursor(goalPath string) {
+	for p := goalPath; p != "" && p != "."; p = filepath.Dir(p) {
+		for i, item := range m.visibleItems {
+			if !item.IsSectionHeader && item.Goal.Path == p {
+				m.cursor = i
+				return
+			}
+		}
+	}
+}
+
+// resizePanes fits the glamour renderer and, while editing, the editor to
+// the notes pane after the terminal or the pane arrangement changes.
+func (m *Model) resizePanes() {
+	if m.notesHidden {
+		return
+	}
+	_, notes := m.paneSizes(m.width, m.height-5) // outer chrome (header/tabs/seps/footer)
+	m.getGlamourRenderer(max(notes.width-2, 20))
+	if m.isEditing {
+		m.noteEditor.SetWidth(notes.width)
+		m.noteEditor.SetHeight(max(notes.height-4-1, 3)) // header estimate + file path
+	}
+}
+
+// togglePanelLayout switches between side-by-side and stacked panes and
+// remembers the choice.
+func (m *Model) togglePanelLayout() {
+	if m.panelLayout == panelsStacked {
+		m.panelLayout = panelsSideBySide
+		m.setStatus("Layout: side by side")
+	} else {
+		m.panelLayout = panelsStacked
+		m.setStatus("Layout: stacked")
+	}
+	m.notesScroll = 0
+	m.resizePanes()
+	m.saveUIState()
+}
+
+// toggleGrouping switches the all-goals view between horizon sections
+// and the plain goal hierarchy, keeping the selected goal, and remembers
+// the choice.
+func (m *Model) toggleGrouping() {
+	selected := ""
+	if m.onGoal() {
+		selected = m.visibleItems[m.cursor].Goal.Path
+	}
+	m.ungrouped = !m.ungrouped
+	if m.ungrouped {
+		m.setStatus("Plain tree: G groups by horizon again")
+	} else {
+		m.setStatus("Grouped by horizon")
+	}
+	m.saveUIState()
 	m.rebuildVisible()
+	if selected != "" {
+		m.moveCursorToGoal(selected)
+	}
+}
+
+// toggleNotesPane hides the notes pane, giving the tree the full width,
+// or brings it back, and remembers the choice.
+func (m *Model) toggleNotesPane() {
+	m.notesHidden = !m.notesHidden
+	if m.notesHidden {
+		m.focusedPane = 0
+		m.setStatus("Notes pane hidden")
+	} else {
+		m.setStatus("Notes pane shown")
+	}
+	m.notesScroll = 0
+	m.resizePanes()
+	m.saveUIState()
 }
 
 // getGlamourRenderer returns a cached glamour renderer, creating one if needed
@@ -1066,15 +1991,46 @@ func (m *Model) getGlamourRenderer(width int) *glamour.TermRenderer {
 	return r
 }
 
+// ResetWindowTitle clears the title config.TerminalTitle set, for printing
+// once the TUI exits.
+const ResetWindowTitle = "\x1b]2;\x07"
+
+// windowTitle is the terminal title for today's progress, e.g.
+// "cairn · 3/12 today".
+func (m Model) windowTitle() string {
+	done, total := store.TodayProgress(m.goals)
+	if total == 0 {
+		return "cairn"
+	}
+	return fmt.Sprintf("cairn · %d/%d today", done, total)
+}
+
+// titleCmd sets the terminal title when config.TerminalTitle is on and the
+// title has changed since it was last set.
+func (m *Model) titleCmd() tea.Cmd {
+	if !m.cfg.TerminalTitle {
+		return nil
+	}
+	title := m.windowTitle()
+	if title == m.shownTitle {
+		return nil
+	}
+	m.shownTitle = title
+	return tea.SetWindowTitle(title)
+}
+
 func (m *Model) setStatus(msg string) {
 	m.statusMsg = msg
 	m.statusTimeout = time.Now().Add(3 * time.Second)
 }
 
 func (m *Model) openEditor(g *store.Goal) tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vim"
+	env := os.Getenv("EDITOR")
+	argv, fallback, err := ResolveEditor(env)
+	if err != nil {
+		m.externalEditPath = ""
+		m.setStatus("Error: " + err.Error())
+		return nil
 	}
 
 	filePath := g.FilePath
@@ -1087,15 +2043,13 @@ func (m *Model) openEditor(g *store.Goal) tea.Cmd {
 		filePath = g.FilePath
 	}
 
-	c := exec.Command(editor, filePath)
+	// Jump past the frontmatter to the notes where the editor supports it
+	line := 1
+	if content, err := store.SerializeFrontmatter(g); err == nil {
+		line = bodyStartLine(content)
+	}
+	c := exec.Command(argv[0], editorArgs(argv, filePath, line)...)
 	return tea.ExecProcess(c, func(err error) tea.Msg {
-		return EditorFinishedMsg{Err: err}
+		return EditorFinishedMsg{Err: err, Env: env, Fallback: fallback}
 	})
 }
-
-func (m Model) doSync() tea.Cmd {
-	return func() tea.Msg {
-		err := gsync.SyncRepo(m.store.Root)
-		return SyncDoneMsg{Err: err}
-	}
-}
@@ -0,0 +1,2411 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stefanpenner/cairn/pkg/config"
+	"github.com/stefanpenner/cairn/pkg/enrich"
+	"github.com/stefanpenner/cairn/pkg/store"
+	gsync "github.com/stefanpenner/cairn/pkg/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestModel builds a Model over an in-memory store populated by setup,
+// sized like a typical terminal.
+func newTestModel(t *testing.T, setup func(s *store.MemStore)) (Model, *store.MemStore) {
+	t.Helper()
+	return newTestModelWithConfig(t, config.Default(), setup)
+}
+
+// newTestModelWithConfig is newTestModel with non-default settings.
+func newTestModelWithConfig(t *testing.T, cfg *config.Config, setup func(s *store.MemStore)) (Model, *store.MemStore) {
+	t.Helper()
+	s := store.NewMemStore()
+	if setup != nil {
+		setup(s)
+	}
+	m := NewModel(s, cfg)
+	m = update(m, tea.WindowSizeMsg{Width: 120, Height: 30})
+	return m, s
+}
+
+// update feeds msgs through the model in order and returns the result.
+func update(m Model, msgs ...tea.Msg) Model {
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	return m
+}
+
+// press converts key names (as written in KeyMap) into key messages.
+func press(keys ...string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, k := range keys {
+		switch k {
+		case "enter":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEsc})
+		case "tab":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab})
+		case "backspace":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyBackspace})
+		case "up":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyUp})
+		case "down":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyDown})
+		case "space":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		default:
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+	return msgs
+}
+
+// typeText converts a string into one key message per rune.
+func typeText(text string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range text {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m|\x1b\]8;;[^\x1b]*\x1b\\`)
+
+// plain strips ANSI styling and hyperlinks so views can be matched as text.
+func plain(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+func mustCreate(t *testing.T, s store.Backend, parent, slug string) {
+	t.Helper()
+	_, err := s.CreateGoal(parent, slug)
+	require.NoError(t, err)
+}
+
+func mustHorizon(t *testing.T, s store.Backend, goalPath string, h store.Horizon) {
+	t.Helper()
+	_, err := s.SetHorizon(goalPath, h)
+	require.NoError(t, err)
+}
+
+func selectedPath(m Model) string {
+	if m.cursor < 0 || m.cursor >= len(m.visibleItems) {
+		return ""
+	}
+	return m.visibleItems[m.cursor].Goal.Path
+}
+
+func visibleIDs(m Model) []string {
+	var ids []string
+	for _, item := range m.visibleItems {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestModelConfiguredHorizons(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		s.SetHorizons([]store.Horizon{"today", "this-week", "someday"})
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "", "beta")
+		mustHorizon(t, s, "beta", "this-week")
+		mustCreate(t, s, "", "legacy")
+		g, err := s.LoadGoal("legacy")
+		require.NoError(t, err)
+		g.Horizon = store.HorizonTomorrow // from before horizons were configured
+		require.NoError(t, s.SaveGoal(g))
+	})
+	assert.Equal(t, []string{"__header_this-week", "beta", "__header_someday", "alpha", "__header_other", "legacy"}, visibleIDs(m),
+		"unknown horizons trail in OTHER")
+	assert.Contains(t, plain(m.View()), "THIS-WEEK")
+
+	m.moveCursorToID("alpha")
+	m = update(m, press("2")...)
+	assert.Equal(t, []string{"__header_this-week", "alpha", "beta", "__header_other", "legacy"}, visibleIDs(m))
+	assert.Contains(t, m.statusMsg, "alpha → this-week")
+
+	m = update(m, press("4")...)
+	assert.Contains(t, m.statusMsg, "No horizon 4: there are 3 (today, this-week, someday)")
+
+	m.moveCursorToID("legacy")
+	m = update(m, press("1")...)
+	g, err := s.LoadGoal("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+}
+
+func TestModelNavigationStopsOnSectionHeaders(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustHorizon(t, s, "alpha", store.HorizonToday)
+		mustCreate(t, s, "", "beta")
+	})
+
+	assert.Equal(t, []string{"__header_today", "alpha", "__header_future", "beta"}, visibleIDs(m))
+	assert.Equal(t, "alpha", selectedPath(m), "cursor starts on the first goal, not a header")
+
+	m = update(m, press("j")...)
+	assert.Equal(t, "__header_future", m.visibleItems[m.cursor].ID, "headers are rows so they can be toggled")
+	m = update(m, press("j")...)
+	assert.Equal(t, "beta", selectedPath(m))
+
+	m = update(m, press("k", "k")...)
+	assert.Equal(t, "alpha", selectedPath(m))
+
+	// h on a top-level goal goes to its section header
+	m = update(m, press("h")...)
+	assert.Equal(t, "__header_today", m.visibleItems[m.cursor].ID)
+}
+
+func TestModelCollapseSections(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustHorizon(t, s, "alpha", store.HorizonToday)
+		mustCreate(t, s, "", "beta")
+		mustCreate(t, s, "beta", "child")
+		mustCreate(t, s, "", "gamma")
+	})
+
+	// f collapses FUTURE from anywhere, keeping the selection
+	m = update(m, press("f")...)
+	assert.Equal(t, []string{"__header_today", "alpha", "__header_future"}, visibleIDs(m))
+	assert.Equal(t, "alpha", selectedPath(m))
+	assert.Contains(t, plain(m.View()), "FUTURE (2 hidden)")
+
+	// enter on a header toggles it
+	m = update(m, press("j", "enter")...)
+	assert.Equal(t, []string{"__header_today", "alpha", "__header_future", "beta", "gamma"}, visibleIDs(m))
+	assert.Equal(t, "__header_future", m.visibleItems[m.cursor].ID)
+	m = update(m, press("k", "k", "enter")...)
+	assert.Equal(t, []string{"__header_today", "__header_future", "beta", "gamma"}, visibleIDs(m))
+
+	// Header actions that need a goal do nothing
+	m = update(m, press(" ", "d", "1")...)
+	assert.False(t, m.showDeleteConfirm)
+
+	// Selecting inside FUTURE when it collapses moves the cursor to its header
+	m = update(m, press("j", "j", "f")...)
+	assert.Equal(t, "__header_future", m.visibleItems[m.cursor].ID)
+
+	// Search reveals matches in collapsed sections until it's cleared
+	m = update(m, press("/")...)
+	m = update(m, typeText("child")...)
+	assert.Equal(t, []string{"__header_future", "beta", "beta/child"}, visibleIDs(m))
+	m = update(m, press("esc")...)
+	assert.Equal(t, []string{"__header_today", "__header_future"}, visibleIDs(m))
+}
+
+func TestModelCollapsedSectionsPersist(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	mustCreate(t, s, "", "alpha")
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = update(m, press("f")...)
+	assert.Equal(t, []string{"__header_future"}, visibleIDs(m))
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Equal(t, []string{"__header_future"}, visibleIDs(m), "collapsed sections are restored")
+	m = update(m, press("enter")...)
+	assert.Equal(t, []string{"__header_future", "alpha"}, visibleIDs(m))
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Equal(t, []string{"__header_future", "alpha"}, visibleIDs(m))
+}
+
+func TestModelSearchHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	mustCreate(t, s, "", "otr")
+	mustCreate(t, s, "", "infra")
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	for _, query := range []string{"otr", "infra", "otr"} {
+		m = update(m, press("/")...)
+		m = update(m, typeText(query)...)
+		m = update(m, press("enter", "esc")...)
+	}
+	assert.Equal(t, []string{"infra", "otr"}, m.searchHistory, "repeated queries move to the end")
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = update(m, press("/")...)
+	assert.Empty(t, m.searchQuery, "search starts blank")
+	m = update(m, press("up")...)
+	assert.Equal(t, "otr", m.searchQuery, "history survives restarts")
+	assert.Equal(t, []string{"__header_future", "otr"}, visibleIDs(m), "recalled queries filter")
+	m = update(m, press("up", "up")...)
+	assert.Equal(t, "infra", m.searchQuery)
+	m = update(m, press("down", "down")...)
+	assert.Empty(t, m.searchQuery)
+	assert.True(t, m.isSearching)
+
+	m = update(m, press("up", "esc")...)
+	assert.Empty(t, m.searchQuery, "esc still clears")
+	assert.False(t, m.isSearching)
+
+	for i := range searchHistoryMax + 5 {
+		m.rememberSearch(fmt.Sprintf("q%d", i))
+	}
+	assert.Len(t, m.searchHistory, searchHistoryMax)
+	assert.Equal(t, "q5", m.searchHistory[0])
+}
+
+func TestModelEncryptedGoal(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		g, err := s.CreateGoal("", "secret")
+		require.NoError(t, err)
+		g.Encrypted = true
+		g.BodyErr = store.ErrNoIdentity
+		require.NoError(t, s.SaveGoal(g))
+	})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "secret "+IconLocked)
+	assert.Contains(t, view, "set age_identity", "the notes pane says how to unlock them")
+
+	m = update(m, press("e")...)
+	assert.False(t, m.isEditing, "locked notes can't be edited")
+	assert.Contains(t, m.statusMsg, "encrypted")
+
+	m.statusMsg = ""
+	m = update(m, press("E")...)
+	assert.Empty(t, m.externalEditPath, "$EDITOR would see the ciphertext")
+	assert.Contains(t, m.statusMsg, "edit them with e")
+}
+
+func TestModelInlineEditInserts(t *testing.T) {
+	cfg := config.Default()
+	cfg.NoteFormat = "{time} {text}"
+	m, s := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	m.now = func() time.Time { return time.Date(2026, 3, 2, 9, 30, 0, 0, time.Local) }
+
+	m = update(m, press("e")...)
+	require.True(t, m.isEditing)
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlD})
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = update(m, typeText("shipped")...)
+	assert.Equal(t, "## 2026-03-02\n- 09:30 shipped", m.noteEditor.Value())
+
+	// Partway through a line, the header starts a new one
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlD})
+	assert.Equal(t, "## 2026-03-02\n- 09:30 shipped\n## 2026-03-02\n", m.noteEditor.Value())
+	assert.True(t, m.isEditing, "inserting stays in edit mode")
+
+	// A goal's note_section heads the note
+	m = update(m, press("esc")...)
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	g.NoteSection = "Log"
+	g.Body = ""
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+	m = update(m, press("e")...)
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlT})
+	assert.Equal(t, "### Log\n- 09:30 ", m.noteEditor.Value())
+}
+
+func TestModelInlineEditConflicts(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	saveKey := tea.KeyMsg{Type: tea.KeyCtrlS}
+	external := func(change func(g *store.Goal)) {
+		t.Helper()
+		g, err := s.LoadGoal("otr")
+		require.NoError(t, err)
+		change(g)
+		require.NoError(t, s.SaveGoal(g))
+		m = update(m, FileChangedMsg{})
+	}
+
+	// Frontmatter changed on disk: merged, and the header shows it
+	m = update(m, press("e")...)
+	require.True(t, m.isEditing)
+	m = update(m, typeText("mine")...)
+	external(func(g *store.Goal) { g.Horizon = store.HorizonToday })
+	m = update(m, saveKey)
+	assert.False(t, m.showEditConflict)
+	assert.Contains(t, m.statusMsg, "keeping changes made on disk")
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "mine", g.Body)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+
+	// Notes changed on disk: esc asks, and o overwrites them
+	external(func(g *store.Goal) { g.Body = "theirs" })
+	m = update(m, press("esc")...)
+	require.True(t, m.showEditConflict)
+	assert.Contains(t, plain(m.View()), "changed on disk")
+	m = update(m, press("o")...)
+	assert.False(t, m.isEditing, "esc leaves edit mode once resolved")
+	g, err = s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "mine", g.Body)
+
+	// ...or r takes the disk's version, or c saves the edit beside it
+	m = update(m, press("e")...)
+	external(func(g *store.Goal) { g.Body = "theirs" })
+	m = update(m, saveKey)
+	m = update(m, press("r")...)
+	assert.True(t, m.isEditing, "ctrl+s keeps editing")
+	assert.Equal(t, "theirs", m.noteEditor.Value())
+	m = update(m, typeText(" too")...)
+	external(func(g *store.Goal) { g.Body = "again" })
+	m = update(m, press("esc", "c")...)
+	assert.False(t, m.isEditing)
+	copied, err := s.LoadGoal("otr-copy")
+	require.NoError(t, err)
+	assert.Equal(t, "theirs too", copied.Body)
+	assert.Equal(t, "otr (copy)", copied.Title)
+
+	// Deleted on disk: asks instead of recreating it
+	m = update(m, press("e")...)
+	require.NoError(t, s.DeleteGoal("otr"))
+	m = update(m, FileChangedMsg{})
+	m = update(m, press("esc")...)
+	require.True(t, m.showEditConflict)
+	assert.Contains(t, plain(m.View()), "was deleted on disk")
+	m = update(m, press("r")...)
+	assert.False(t, m.isEditing)
+	_, err = s.LoadGoal("otr")
+	assert.Error(t, err, "not resurrected")
+}
+
+func TestModelBrokenFrontmatter(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		g, err := s.CreateGoal("", "broken")
+		require.NoError(t, err)
+		g.Title = ""
+		g.FrontmatterErr = errors.New("unclosed frontmatter delimiter")
+		require.NoError(t, s.SaveGoal(g))
+	})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "broken "+IconWarning+" frontmatter")
+	assert.Contains(t, view, "unclosed frontmatter delimiter", "the notes pane says what's wrong")
+
+	m = update(m, press("e")...)
+	assert.False(t, m.isEditing, "the file needs fixing first")
+	assert.Contains(t, m.statusMsg, "press E")
+}
+
+func TestModelStackedPanels(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	mustCreate(t, s, "", "otr")
+	rule := strings.Repeat("─", 120)
+	rules := func(view string) (n int) {
+		for _, line := range strings.Split(view, "\n") {
+			if line == rule {
+				n++
+			}
+		}
+		return n
+	}
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	view := plain(m.View())
+	assert.Equal(t, 2, rules(view))
+	assert.Contains(t, view, "│")
+
+	m = update(m, press("|")...)
+	view = plain(m.View())
+	assert.Equal(t, panelsStacked, m.panelLayout)
+	assert.Equal(t, 3, rules(view), "a rule divides the tree from the notes")
+	assert.NotContains(t, view, "│")
+	lines := strings.Split(view, "\n")
+	require.Len(t, lines, 30)
+	assert.Contains(t, lines[len(lines)-3], "goal.md", "the file path stays pinned above the footer")
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Equal(t, panelsStacked, m.panelLayout, "the choice is remembered")
+	m = update(m, press("|")...)
+	assert.Equal(t, panelsSideBySide, m.panelLayout)
+
+	cfg := config.Default()
+	cfg.PanelLayout = panelsStacked
+	m = update(NewModel(s, cfg), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Equal(t, panelsStacked, m.panelLayout, "the config sets the default")
+}
+
+func TestModelHideNotesPane(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	mustCreate(t, s, "", "otr")
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	require.Contains(t, plain(m.View()), "│")
+
+	m = update(m, press("tab")...)
+	m = update(m, press("\\")...)
+	view := plain(m.View())
+	assert.True(t, m.notesHidden)
+	assert.Equal(t, 0, m.focusedPane, "focus returns to the tree")
+	assert.NotContains(t, view, "│")
+	assert.NotContains(t, view, "goal.md")
+	m = update(m, press("tab")...)
+	assert.Equal(t, 0, m.focusedPane, "a hidden pane can't be focused")
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 100, Height: 30})
+	assert.True(t, m.notesHidden, "the choice is remembered")
+	assert.Zero(t, m.glamourWidth)
+
+	m = update(m, press("\\")...)
+	assert.False(t, m.notesHidden)
+	assert.Equal(t, 100-100/4-1-2, m.glamourWidth, "the renderer fits the pane again")
+	assert.Contains(t, plain(m.View()), "goal.md")
+}
+
+func TestModelToggleGrouping(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	mustCreate(t, s, "", "alpha")
+	mustCreate(t, s, "", "beta")
+	mustHorizon(t, s, "beta", store.HorizonToday)
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Equal(t, []string{"__header_today", "beta", "__header_future", "alpha"}, visibleIDs(m))
+	m = update(m, press("j")...)
+	m = update(m, press("j")...)
+	require.Equal(t, "alpha", selectedPath(m))
+
+	m = update(m, press("G")...)
+	assert.True(t, m.ungrouped)
+	assert.Equal(t, []string{"alpha", "beta"}, visibleIDs(m), "tree order, no headers")
+	assert.Equal(t, "alpha", selectedPath(m), "the selection is kept")
+	assert.NotContains(t, plain(m.View()), "TODAY")
+
+	m = update(m, press("f")...)
+	assert.False(t, m.collapsedSections["future"], "no sections to collapse")
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.True(t, m.ungrouped, "the choice is remembered")
+	assert.Equal(t, []string{"alpha", "beta"}, visibleIDs(m))
+
+	m = update(m, press("j")...)
+	m = update(m, press("G")...)
+	assert.False(t, m.ungrouped)
+	assert.Equal(t, []string{"__header_today", "beta", "__header_future", "alpha"}, visibleIDs(m))
+	assert.Equal(t, "beta", selectedPath(m))
+}
+
+func TestModelHorizonRollup(t *testing.T) {
+	setup := func(s *store.MemStore) {
+		mustCreate(t, s, "", "project")
+		mustCreate(t, s, "project", "phase")
+		mustCreate(t, s, "project/phase", "fix")
+		mustCreate(t, s, "project/phase/fix", "step")
+		mustCreate(t, s, "project", "prep")
+		mustHorizon(t, s, "project/phase/fix", store.HorizonToday)
+		mustHorizon(t, s, "project/prep", store.HorizonTomorrow)
+	}
+
+	m, _ := newTestModel(t, setup)
+	m = update(m, press("C")...)
+	assert.Equal(t, []string{"__header_future", "project", "project/phase", "project/phase/fix", "project/phase/fix/step", "project/prep"}, visibleIDs(m),
+		"only top-level goals are grouped by default")
+
+	cfg := config.Default()
+	cfg.HorizonRollup = true
+	m, _ = newTestModelWithConfig(t, cfg, setup)
+	m = update(m, press("C")...)
+	assert.Equal(t, []string{
+		"__header_today", "project/phase/fix", "project/phase/fix/step",
+		"__header_tomorrow", "project/prep",
+		"__header_future", "project", "project/phase",
+	}, visibleIDs(m), "each goal is listed once, in the most urgent section")
+
+	require.Equal(t, "project/phase/fix", m.visibleItems[1].ID)
+	assert.Equal(t, 1, m.visibleItems[1].Depth)
+	assert.Contains(t, plain(m.renderTreeItem(m.visibleItems[1], false, 80)), "project › phase › fix")
+	assert.Equal(t, 2, m.visibleItems[2].Depth, "its sub-goals stay under it")
+	assert.Empty(t, m.visibleItems[2].Breadcrumb)
+}
+
+func TestModelAddUnderCompleteGoal(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+		_, err := s.SetStatus("launch", store.StatusComplete)
+		require.NoError(t, err)
+	})
+	require.Equal(t, "launch", selectedPath(m))
+
+	m = update(m, press("a")...)
+	assert.True(t, m.showAddUnderComplete)
+	assert.False(t, m.isInputMode)
+	assert.Contains(t, plain(m.View()), "'launch' is complete")
+	m = update(m, press("n")...)
+	assert.False(t, m.showAddUnderComplete)
+	assert.False(t, m.isInputMode, "cancelled")
+
+	m = update(m, press("a")...)
+	m = update(m, press("y")...)
+	require.True(t, m.isInputMode)
+	m = update(m, typeText("followup")...)
+	m = update(m, press("enter")...)
+	_, err := s.LoadGoal("launch/followup")
+	assert.NoError(t, err)
+}
+
+func TestModelExpandCollapse(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+	})
+
+	assert.Len(t, m.visibleItems, 2) // header + otr
+	m = update(m, press("l")...)
+	assert.Equal(t, []string{"__header_future", "otr", filepath.Join("otr", "ios")}, visibleIDs(m))
+
+	m = update(m, press("h")...)
+	assert.Len(t, m.visibleItems, 2)
+}
+
+func TestModelLeftJumpsToParent(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, filepath.Join("otr", "ios"), "login")
+	})
+
+	m = update(m, press("l", "j", "l", "j")...)
+	require.Equal(t, filepath.Join("otr", "ios", "login"), selectedPath(m))
+
+	m = update(m, press("h")...)
+	assert.Equal(t, filepath.Join("otr", "ios"), selectedPath(m), "a leaf jumps to its parent")
+
+	m = update(m, press("h")...)
+	assert.Equal(t, filepath.Join("otr", "ios"), selectedPath(m), "an expanded node collapses first")
+	assert.NotContains(t, visibleIDs(m), filepath.Join("otr", "ios", "login"))
+
+	m = update(m, press("h", "h")...)
+	assert.Equal(t, "otr", selectedPath(m), "top-level goals don't jump onto section headers")
+}
+
+func TestModelToggleStatus(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "task")
+	})
+
+	m = update(m, press("space")...)
+	g, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusInProgress, g.Status)
+	assert.True(t, m.visibleItems[m.cursor].Goal.IsInProgress(), "tree reflects the new status")
+}
+
+func TestModelCompleteAndAdvance(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustHorizon(t, s, "alpha", store.HorizonToday)
+		mustCreate(t, s, "alpha", "step")
+		mustCreate(t, s, "", "beta")
+		_, err := s.SetStatus("beta", store.StatusComplete)
+		require.NoError(t, err)
+		mustCreate(t, s, "", "gamma")
+	})
+	m.moveCursorToGoal("alpha")
+	m = update(m, press("l")...)
+	require.Equal(t, []string{"__header_today", "alpha", filepath.Join("alpha", "step"), "__header_future", "beta", "gamma"}, visibleIDs(m))
+
+	m = update(m, press("D")...)
+	g, err := s.LoadGoal("alpha")
+	require.NoError(t, err)
+	assert.True(t, g.IsComplete())
+	assert.Equal(t, "gamma", selectedPath(m), "skips its sub-goals, the FUTURE header and complete beta")
+
+	m = update(m, press("D")...)
+	assert.Equal(t, "gamma", selectedPath(m))
+	assert.Contains(t, m.statusMsg, "no open goals below")
+	g, err = s.LoadGoal("gamma")
+	require.NoError(t, err)
+	assert.True(t, g.IsComplete())
+
+	m = update(m, press("k", "D")...)
+	g, err = s.LoadGoal("beta")
+	require.NoError(t, err)
+	assert.True(t, g.IsComplete(), "an already complete goal stays complete")
+}
+
+func TestModelPropagateStatusPromptsForParent(t *testing.T) {
+	cfg := config.Default()
+	cfg.PropagateStatus = true
+	m, s := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		_, err := s.SetStatus(filepath.Join("otr", "ios"), store.StatusInProgress)
+		require.NoError(t, err)
+	})
+
+	m = update(m, press("l", "j", "space")...)
+	require.Equal(t, filepath.Join("otr", "ios"), selectedPath(m))
+	assert.True(t, m.showCompleteParent)
+	assert.Contains(t, plain(m.View()), "All children of 'otr' are complete")
+
+	m = update(m, press("y")...)
+	assert.False(t, m.showCompleteParent)
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusComplete, g.Status)
+}
+
+func TestModelDequeueOnComplete(t *testing.T) {
+	cfg := config.Default()
+	cfg.AutoQueue = true
+	m, s := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "", "infra")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr", "infra"}}))
+	})
+
+	// In progress first: no prompt until the goal is complete
+	m = update(m, press("space")...)
+	assert.False(t, m.showDequeue)
+	m = update(m, press("space")...)
+	require.True(t, m.showDequeue)
+	assert.Contains(t, plain(m.View()), "'otr' is complete — remove it from the queue?")
+
+	m = update(m, press("y")...)
+	assert.False(t, m.showDequeue)
+	q, err := s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra"}, q.Items)
+
+	// Declining keeps it queued
+	m = update(m, press("space", "space")...)
+	require.True(t, m.showDequeue)
+	m = update(m, press("n")...)
+	assert.False(t, m.showDequeue)
+	q, err = s.LoadQueue()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra"}, q.Items)
+}
+
+func TestModelPropagateStatusIsOptIn(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+	})
+
+	m = update(m, press("l", "j", "space")...)
+	assert.False(t, m.showCompleteParent)
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusIncomplete, g.Status, "parents are untouched by default")
+}
+
+func TestModelCollapseOnComplete(t *testing.T) {
+	cfg := config.Default()
+	cfg.CollapseOnComplete = "ask"
+	m, s := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr/ios", "push")
+		mustCreate(t, s, "otr", "android")
+		_, err := s.SetStatus("otr", store.StatusInProgress)
+		require.NoError(t, err)
+		_, err = s.SetStatus("otr/android", store.StatusComplete)
+		require.NoError(t, err)
+	})
+
+	m = update(m, press("l")...)
+	require.Equal(t, "otr", selectedPath(m))
+	require.Contains(t, visibleIDs(m), "otr/ios")
+
+	m = update(m, press("space")...)
+	assert.NotContains(t, visibleIDs(m), "otr/ios", "completed parent collapses")
+	require.True(t, m.showCompleteChildren)
+	assert.Contains(t, plain(m.View()), "2 sub-goal(s) under it are still open")
+
+	m = update(m, press("y")...)
+	assert.False(t, m.showCompleteChildren)
+	for _, p := range []string{"otr/ios", "otr/ios/push"} {
+		g, err := s.LoadGoal(p)
+		require.NoError(t, err)
+		assert.Equal(t, store.StatusComplete, g.Status, p)
+	}
+
+	// "on" collapses without asking; the default leaves the tree alone
+	for mode, collapses := range map[string]bool{"on": true, "off": false} {
+		cfg := config.Default()
+		cfg.CollapseOnComplete = mode
+		m, _ := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+			mustCreate(t, s, "", "otr")
+			mustCreate(t, s, "otr", "ios")
+			_, err := s.SetStatus("otr", store.StatusInProgress)
+			require.NoError(t, err)
+		})
+		m = update(m, press("l", "space")...)
+		assert.False(t, m.showCompleteChildren, mode)
+		assert.Equal(t, !collapses, slices.Contains(visibleIDs(m), "otr/ios"), mode)
+	}
+}
+
+func TestModelAddTopLevelGoal(t *testing.T) {
+	m, s := newTestModel(t, nil)
+
+	m = update(m, press("A")...)
+	assert.True(t, m.isInputMode)
+	m = update(m, typeText("new goal")...)
+	m = update(m, press("enter")...)
+
+	assert.False(t, m.isInputMode)
+	_, err := s.LoadGoal("new-goal")
+	assert.NoError(t, err)
+	assert.Contains(t, visibleIDs(m), "new-goal")
+}
+
+func TestModelAddSubGoal(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+
+	m = update(m, press("a")...)
+	m = update(m, typeText("ios")...)
+	m = update(m, press("enter")...)
+
+	_, err := s.LoadGoal(filepath.Join("otr", "ios"))
+	assert.NoError(t, err)
+	m = update(m, press("l")...)
+	assert.Contains(t, visibleIDs(m), filepath.Join("otr", "ios"))
+}
+
+func TestModelDeleteRequiresConfirmation(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "doomed")
+	})
+
+	m = update(m, press("d")...)
+	assert.True(t, m.showDeleteConfirm)
+	assert.Contains(t, plain(m.View()), "Delete 'doomed'")
+	assert.Contains(t, plain(m.View()), "1 goal(s), 0 line(s) of notes")
+
+	m = update(m, press("n")...)
+	_, err := s.LoadGoal("doomed")
+	assert.NoError(t, err, "declining keeps the goal")
+
+	m = update(m, press("d", "y")...)
+	_, err = s.LoadGoal("doomed")
+	assert.Error(t, err)
+	assert.Empty(t, m.visibleItems)
+}
+
+func TestModelRename(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+
+	m = update(m, press("r")...)
+	for range "otr" {
+		m = update(m, press("backspace")...)
+	}
+	m = update(m, typeText("Over The Rainbow")...)
+	update(m, press("enter")...)
+
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "Over The Rainbow", g.Title)
+}
+
+func TestModelSearchFiltersTree(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "android")
+		mustCreate(t, s, "", "infra")
+	})
+
+	m = update(m, press("j", "l", "/")...)
+	m = update(m, typeText("ios")...)
+	assert.Equal(t, []string{"__header_future", "otr", filepath.Join("otr", "ios")}, visibleIDs(m), "match plus its ancestors")
+
+	m = update(m, press("enter")...)
+	assert.False(t, m.isSearching)
+	assert.Equal(t, "ios", m.searchQuery, "filter stays active after leaving the input")
+
+	m = update(m, press("esc")...)
+	assert.Empty(t, m.searchQuery)
+	assert.Contains(t, visibleIDs(m), "infra")
+}
+
+func TestModelSearchFindsCollapsedMatches(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "", "infra")
+	})
+
+	m = update(m, press("/")...)
+	m = update(m, typeText("ios")...)
+	assert.Equal(t, []string{"__header_future", "otr", filepath.Join("otr", "ios")}, visibleIDs(m),
+		"the collapsed parent is expanded to reveal the match")
+}
+
+func TestModelSearchMatchesPath(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		g, err := s.CreateGoal("", "infra-migration")
+		require.NoError(t, err)
+		g.Title = "Infrastructure Migration 2026"
+		require.NoError(t, s.SaveGoal(g))
+		mustCreate(t, s, "", "otr")
+	})
+
+	m = update(m, press("/")...)
+	m = update(m, typeText("infra-mig")...)
+	assert.Equal(t, []string{"__header_future", "infra-migration"}, visibleIDs(m))
+	row := func() string { return plain(m.renderTreeItem(m.visibleItems[1], false, 80)) }
+	assert.Contains(t, row(), "Infrastructure Migration 2026 (matched path)")
+
+	m = update(m, press("esc", "/")...)
+	m = update(m, typeText("structure")...)
+	assert.NotContains(t, row(), "(matched path)", "matched by title")
+}
+
+func TestModelSearchWithinQueueTab(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "infra", "ios-runners")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr", "infra"}}))
+	})
+
+	m = update(m, press("/")...)
+	m = update(m, typeText("ios")...)
+	assert.Equal(t, []string{"otr", filepath.Join("otr", "ios")}, visibleIDs(m), "only matches in the active tab")
+	assert.Len(t, m.searchMatchIDs, 1)
+
+	m = update(m, press("enter", "]")...)
+	assert.Equal(t, []string{"infra", filepath.Join("infra", "ios-runners")}, visibleIDs(m), "switching tabs re-runs the filter")
+}
+
+func TestModelSearchNextMatch(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios-app")
+		mustCreate(t, s, "otr", "android")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "infra", "ios-runners")
+	})
+
+	m = update(m, press("/")...)
+	m = update(m, typeText("ios")...)
+	m = update(m, press("enter")...)
+	assert.Equal(t, []string{filepath.Join("infra", "ios-runners"), filepath.Join("otr", "ios-app")}, m.searchMatches)
+
+	m = update(m, press("n")...)
+	assert.Equal(t, filepath.Join("infra", "ios-runners"), selectedPath(m), "ancestor rows are skipped")
+	assert.Equal(t, "Match 1/2", m.statusMsg)
+	m = update(m, press("n")...)
+	assert.Equal(t, filepath.Join("otr", "ios-app"), selectedPath(m))
+	m = update(m, press("n")...)
+	assert.Equal(t, filepath.Join("infra", "ios-runners"), selectedPath(m), "wraps around")
+	m = update(m, press("N")...)
+	assert.Equal(t, filepath.Join("otr", "ios-app"), selectedPath(m), "wraps backwards")
+	m = update(m, press("N")...)
+	assert.Equal(t, filepath.Join("infra", "ios-runners"), selectedPath(m))
+
+	m = update(m, press("esc")...)
+	assert.Nil(t, m.searchMatches)
+}
+
+func TestModelMoveModeReorder(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "", "beta")
+	})
+	m.moveCursorToGoal("alpha")
+
+	m = update(m, press("m", "j")...)
+	assert.Contains(t, m.statusMsg, "not moved", "just below itself is the same place")
+	m = update(m, press("j")...)
+	assert.Contains(t, m.statusMsg, "enter: move into FUTURE")
+	assert.Contains(t, plain(m.View()), IconGhost+" alpha")
+
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", goals[0].Slug, "nothing moves before the drop")
+
+	m = update(m, press("enter")...)
+	assert.True(t, m.isMoveMode, "still in move mode to keep going")
+	goals, err = s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "beta", goals[0].Slug)
+	assert.Equal(t, "alpha", goals[1].Slug)
+	assert.Equal(t, "alpha", selectedPath(m), "cursor follows the moved goal")
+
+	// The marker is back on the goal, so enter is done
+	m = update(m, press("enter")...)
+	assert.False(t, m.isMoveMode)
+	assert.Contains(t, m.statusMsg, "Move complete")
+}
+
+func TestModelMoveModeAcrossParents(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "alpha", "a1")
+		mustCreate(t, s, "alpha", "a2")
+		mustCreate(t, s, "", "beta")
+	})
+	children := func(parent string) []string {
+		g, err := s.LoadGoal(parent)
+		require.NoError(t, err)
+		return g.ChildrenOrder
+	}
+	m.moveCursorToGoal("alpha")
+	m = update(m, press("l")...)
+	m.moveCursorToGoal("beta")
+
+	// Up past a2 the marker can't be shallower than a2, so it nests
+	m = update(m, press("m", "k")...)
+	assert.Contains(t, m.statusMsg, "enter: move under alpha")
+	m = update(m, press("enter")...)
+	assert.Equal(t, filepath.Join("alpha", "beta"), m.moveTarget)
+	assert.Equal(t, []string{"a1", "beta", "a2"}, children("alpha"))
+
+	m = update(m, press("h")...)
+	assert.Contains(t, m.statusMsg, "Can't outdent here")
+
+	// Below a2: as deep as a2's child, as shallow as top level
+	m = update(m, press("j", "j", "l")...)
+	assert.Contains(t, m.statusMsg, "enter: move under "+filepath.Join("alpha", "a2"))
+	m = update(m, press("l")...)
+	assert.Contains(t, m.statusMsg, "Can't nest deeper here")
+	m = update(m, press("h", "h", "enter")...)
+	assert.Equal(t, "beta", m.moveTarget)
+	assert.Equal(t, []string{"a1", "a2"}, children("alpha"))
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "beta", goals[1].Slug)
+}
+
+func TestModelMoveIntoCollapsedParent(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "alpha", "child")
+		mustCreate(t, s, "", "beta")
+	})
+	m.moveCursorToGoal("beta")
+
+	m = update(m, press("m", "l")...)
+	assert.Contains(t, m.statusMsg, "enter: move under alpha")
+	m = update(m, press("enter")...)
+
+	g, err := s.LoadGoal("alpha")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beta", "child"}, g.ChildrenOrder, "dropped right below the parent: its first child")
+	assert.Contains(t, visibleIDs(m), filepath.Join("alpha", "beta"), "the parent opens to show it")
+	assert.Equal(t, filepath.Join("alpha", "beta"), selectedPath(m))
+}
+
+func TestModelMoveRejectsDescendant(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "alpha", "child")
+		mustCreate(t, s, "", "beta")
+	})
+	m.moveCursorToGoal("alpha")
+	m = update(m, press("l")...)
+
+	m = update(m, press("m", "j")...)
+	assert.Contains(t, m.statusMsg, "descendant")
+	m = update(m, press("enter")...)
+	assert.True(t, m.isMoveMode, "the drop is rejected")
+	_, err := s.LoadGoal("alpha")
+	require.NoError(t, err)
+
+	m = update(m, press("j", "l")...)
+	assert.Contains(t, m.statusMsg, "descendant", "under the goal's own child")
+
+	m = update(m, press("h", "h", "j", "enter")...)
+	goals, err := s.LoadGoalTree()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.Equal(t, "beta", goals[0].Slug)
+	_, err = s.LoadGoal(filepath.Join("alpha", "child"))
+	assert.NoError(t, err, "sub-goals move along")
+}
+
+func TestModelMoveAcrossHorizonSections(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustHorizon(t, s, "alpha", store.HorizonToday)
+		mustCreate(t, s, "", "beta")
+		mustCreate(t, s, "", "gamma")
+	})
+	m.moveCursorToGoal("gamma")
+
+	m = update(m, press("m", "k", "k")...)
+	assert.Contains(t, m.statusMsg, "enter: move into TODAY")
+	m = update(m, press("enter")...)
+	g, err := s.LoadGoal("gamma")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+	assert.Equal(t, []string{"__header_today", "alpha", "gamma", "__header_future", "beta"}, visibleIDs(m))
+
+	m = update(m, press("u")...)
+	assert.Contains(t, m.statusMsg, "Undid last move")
+	g, err = s.LoadGoal("gamma")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonFuture, g.Horizon)
+	assert.Equal(t, []string{"__header_today", "alpha", "__header_future", "beta", "gamma"}, visibleIDs(m))
+
+	// Dropping into a collapsed section opens it
+	m = update(m, press("esc")...)
+	m.collapsedSections["today"] = true
+	m.rebuildVisible()
+	m.moveCursorToGoal("gamma")
+	m = update(m, press("m", "u")...)
+	assert.Contains(t, m.statusMsg, "Nothing to undo", "history doesn't carry over to the next move session")
+	m = update(m, press("k", "k", "enter")...)
+	g, err = s.LoadGoal("gamma")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+	assert.False(t, m.collapsedSections["today"])
+	assert.Equal(t, "gamma", selectedPath(m))
+}
+
+func TestModelMoveIntoParentRebuildsHorizonGroups(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "", "beta")
+		mustHorizon(t, s, "beta", store.HorizonToday)
+	})
+
+	assert.Equal(t, []string{"__header_today", "beta", "__header_future", "alpha"}, visibleIDs(m))
+	m.moveCursorToGoal("beta")
+
+	// Below alpha, one level in
+	m = update(m, press("m", "j", "j", "j", "l", "enter", "esc")...)
+
+	g, err := s.LoadGoal(filepath.Join("alpha", "beta"))
+	require.NoError(t, err)
+	assert.Empty(t, g.Horizon)
+	assert.Equal(t, []string{"__header_future", "alpha", filepath.Join("alpha", "beta")}, visibleIDs(m),
+		"the TODAY section disappears once its only goal is nested")
+}
+
+func TestModelQueueFollowsMovedGoal(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "", "beta")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"beta"}}))
+	})
+
+	require.NoError(t, s.MoveGoal("beta", "alpha"))
+	m = update(m, FileChangedMsg{})
+
+	assert.Equal(t, []string{filepath.Join("alpha", "beta")}, visibleIDs(m))
+}
+
+func TestModelHorizonKeys(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "task")
+	})
+
+	m = update(m, press("1")...)
+	g, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+	assert.Equal(t, []string{"__header_today", "task"}, visibleIDs(m))
+}
+
+func TestModelPinnedSection(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "alpha")
+		mustCreate(t, s, "alpha", "child")
+		mustCreate(t, s, "", "beta")
+		mustHorizon(t, s, "beta", store.HorizonToday)
+	})
+	m.expandedState["alpha"] = true
+	m.rebuildVisible()
+
+	// beta is the first row; pin it and it moves out of TODAY
+	m = update(m, press("!")...)
+	g, err := s.LoadGoal("beta")
+	require.NoError(t, err)
+	assert.True(t, g.Pinned)
+	assert.Equal(t, "beta", selectedPath(m), "cursor follows the pinned goal")
+	assert.Equal(t, []string{"__header_pinned", "beta", "__header_future", "alpha", filepath.Join("alpha", "child")}, visibleIDs(m))
+	assert.Contains(t, plain(m.View()), "PINNED")
+
+	// Nested goals can be pinned too; they're listed once, in tree order
+	_, err = s.TogglePin(filepath.Join("alpha", "child"))
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+	assert.Equal(t, []string{"__header_pinned", filepath.Join("alpha", "child"), "beta", "__header_future", "alpha"}, visibleIDs(m))
+
+	m = update(m, press("j", "!")...)
+	assert.Equal(t, []string{"__header_pinned", filepath.Join("alpha", "child"), "__header_today", "beta", "__header_future", "alpha"}, visibleIDs(m))
+}
+
+func TestModelRolloverAfterMidnight(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "task")
+		mustHorizon(t, s, "task", store.HorizonTomorrow)
+	})
+	assert.Equal(t, []string{"__header_tomorrow", "task"}, visibleIDs(m), "set today, so no rollover at startup")
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	m.now = func() time.Time { return tomorrow }
+	m = update(m, FileChangedMsg{})
+
+	g, err := s.LoadGoal("task")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+	assert.Equal(t, []string{"__header_today", "task"}, visibleIDs(m))
+	assert.Contains(t, m.statusMsg, "Rolled over 1 goal")
+}
+
+func TestModelFlagsStaleTodayGoals(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "lingering")
+		mustHorizon(t, s, "lingering", store.HorizonToday)
+	})
+
+	m.now = func() time.Time { return time.Now().AddDate(0, 0, 6) }
+	assert.Contains(t, plain(m.View()), "in TODAY for 6 days")
+
+	_, err := s.SetStatus("lingering", store.StatusComplete)
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+	assert.NotContains(t, plain(m.View()), "in TODAY for", "completed goals aren't stale")
+}
+
+func TestModelShowsWaitingLink(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+	})
+	g, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	require.NoError(t, g.SetField("links."+store.LinkWaiting, "Alice"))
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	m.now = func() time.Time { return time.Now().AddDate(0, 0, 4) }
+	assert.Contains(t, plain(m.View()), "waiting on: Alice (4 days)")
+}
+
+func TestModelNotesLinkNavigation(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+	})
+	g, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	require.NoError(t, g.SetField("links.pr", "https://example.com/pr/1"))
+	require.NoError(t, g.SetField("links.doc", "https://example.com/doc"))
+	require.NoError(t, g.SetField("links."+store.LinkWaiting, "Alice"))
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	var opened []string
+	orig := openCommand
+	openCommand = func(target string) *exec.Cmd {
+		opened = append(opened, target)
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { openCommand = orig })
+
+	// First tab enters the notes pane, the next ones walk links in key order
+	m = update(m, press("tab")...)
+	assert.Equal(t, 1, m.focusedPane)
+	assert.NotContains(t, plain(m.View()), IconLinkFocus)
+
+	m = update(m, press("tab")...)
+	assert.Contains(t, plain(m.View()), IconLinkFocus+" doc:")
+
+	m = update(m, press("tab")...)
+	assert.Contains(t, plain(m.View()), IconLinkFocus+" pr:")
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	require.NotNil(t, cmd)
+	m = update(m, cmd())
+	assert.Equal(t, []string{"https://example.com/pr/1"}, opened)
+	assert.Contains(t, plain(m.View()), "Opened https://example.com/pr/1")
+
+	// The waiting link isn't openable, so the next tab returns to the tree
+	m = update(m, press("tab")...)
+	assert.Equal(t, 0, m.focusedPane)
+	assert.NotContains(t, plain(m.View()), IconLinkFocus)
+}
+
+func TestModelNotesBodyLinks(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+	})
+	g, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	require.NoError(t, g.SetField("links.doc", "https://example.com/doc"))
+	g.Body = "Read the [spec](file://spec.pdf) and ![chart](chart.png).\n\n" +
+		"```\n[not](a-link)\n```\n\n" +
+		"- [PR](https://example.com/pr/1), see [below](#later)\n"
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	var opened []string
+	orig := openCommand
+	openCommand = func(target string) *exec.Cmd {
+		opened = append(opened, target)
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { openCommand = orig })
+	open := func() {
+		t.Helper()
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = next.(Model)
+		require.NotNil(t, cmd)
+		m = update(m, cmd())
+	}
+
+	// Frontmatter links first, then the body's in order
+	m = update(m, press("tab")...)
+	m = update(m, press("tab")...)
+	assert.Contains(t, plain(m.View()), IconLinkFocus+" doc:")
+
+	m = update(m, press("tab")...)
+	assert.Contains(t, plain(m.View()), IconLinkFocus+" spec")
+	assert.Contains(t, plain(m.View()), "spec → file://spec.pdf")
+	open()
+
+	m = update(m, press("tab")...)
+	assert.Contains(t, plain(m.View()), IconLinkFocus+" PR")
+	open()
+	spec, err := filepath.Abs(filepath.Join("goals", "launch", "spec.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file://" + filepath.ToSlash(spec), "https://example.com/pr/1"}, opened)
+
+	// Images, code and anchors aren't links to open
+	m = update(m, press("tab")...)
+	assert.Equal(t, 0, m.focusedPane)
+}
+
+func TestLinkTarget(t *testing.T) {
+	data := t.TempDir()
+	goal := &store.Goal{FilePath: filepath.Join(data, "goals", "otr", "goal.md")}
+	for target, want := range map[string]string{
+		"https://example.com/a?b=c":  "https://example.com/a?b=c",
+		"http://example.com":         "http://example.com",
+		"mailto:a@example.com":       "mailto:a@example.com",
+		"spec.pdf":                   "file://" + filepath.ToSlash(filepath.Join(data, "goals", "otr", "spec.pdf")),
+		"file://../notes.md":         "file://" + filepath.ToSlash(filepath.Join(data, "goals", "notes.md")),
+		"-rf":                        "file://" + filepath.ToSlash(filepath.Join(data, "goals", "otr", "-rf")),
+		"file://" + data + "/x.txt":  "file://" + filepath.ToSlash(filepath.Join(data, "x.txt")),
+		"../../../outside.sh":        "",
+		"file:///etc/passwd":         "",
+		"file://..%2F..%2F..%2Fx.sh": "",
+		"javascript:alert(1)":        "",
+		"smb://host/share":           "",
+		"//evil.example.com/x":       "",
+	} {
+		got, err := linkTarget(data, goal, target)
+		if want == "" {
+			assert.ErrorContains(t, err, "won't open", target)
+			continue
+		}
+		require.NoError(t, err, target)
+		assert.Equal(t, want, got, target)
+	}
+}
+
+func TestModelRefusesUnsafeLinks(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+	})
+	g, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	g.Body = "Run [this](../../../../tmp/x.sh).\n"
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	var opened []string
+	orig := openCommand
+	openCommand = func(target string) *exec.Cmd {
+		opened = append(opened, target)
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { openCommand = orig })
+
+	m = update(m, press("tab", "tab")...)
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	assert.Nil(t, cmd)
+	assert.Empty(t, opened)
+	assert.Contains(t, plain(m.View()), "Error: won't open ../../../../tmp/x.sh")
+}
+
+func TestModelOpenNoteURLs(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "launch")
+	})
+	g, err := s.LoadGoal("launch")
+	require.NoError(t, err)
+	g.Body = "See https://example.com/plan, then http:// and https://example.com/plan again.\n\n" +
+		"```\ncurl https://api.example.com/v1/status\n```\n"
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	var opened []string
+	orig := openCommand
+	openCommand = func(target string) *exec.Cmd {
+		opened = append(opened, target)
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { openCommand = orig })
+
+	// Numbered in order, a repeat keeping its number, code blocks included
+	assert.Equal(t, []string{"https://example.com/plan", "https://api.example.com/v1/status"}, m.noteURLs(g))
+	view := m.View()
+	assert.Contains(t, view, "\x1b]8;;https://example.com/plan\x1b\\")
+	assert.Contains(t, plain(view), "https://example.com/plan¹,")
+	assert.Contains(t, plain(view), "https://example.com/plan¹ again")
+	assert.Contains(t, plain(view), "https://api.example.com/v1/status²")
+	assert.NotContains(t, plain(view), "http://³")
+
+	m = update(m, press("L")...)
+	assert.Contains(t, plain(m.View()), "Open link: 1-2")
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = next.(Model)
+	require.NotNil(t, cmd)
+	m = update(m, cmd())
+	assert.Equal(t, []string{"https://api.example.com/v1/status"}, opened)
+	assert.False(t, m.linkPrompt)
+
+	// Anything but a number cancels
+	m = update(m, press("L")...)
+	m = update(m, press("esc")...)
+	assert.False(t, m.linkPrompt)
+	assert.Len(t, opened, 1)
+}
+
+func TestModelSessionLog(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	assert.Empty(t, m.SessionSummary())
+
+	// Events can arrive out of order; the log keeps them in time order
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)
+	m = update(m,
+		EventMsg{store.Event{Type: store.EventNote, Path: "otr", Time: at.Add(2 * time.Minute)}},
+		EventMsg{store.Event{Type: store.EventCreate, Path: "otr/ios", Time: at}},
+		EventMsg{store.Event{Type: store.EventNote, Path: "otr/ios", Time: at.Add(time.Minute)}},
+		EventMsg{store.Event{Type: store.EventComplete, Path: "otr/ios", Time: at.Add(3 * time.Minute)}},
+	)
+	assert.Equal(t, "Session: 1 completed, 2 notes, 1 created", m.SessionSummary())
+
+	m = update(m, press("S")...)
+	view := plain(m.View())
+	assert.Contains(t, view, "This Session")
+	created := strings.Index(view, "09:00  created otr/ios")
+	noted := strings.Index(view, "09:01  added note to otr/ios")
+	completed := strings.Index(view, "09:03  completed otr/ios")
+	require.True(t, created >= 0 && noted >= 0 && completed >= 0, view)
+	assert.Less(t, created, noted)
+	assert.Less(t, noted, completed)
+
+	m = update(m, press("esc")...)
+	assert.False(t, m.showSessionLog)
+}
+
+func TestModelWindowTitle(t *testing.T) {
+	setup := func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "otr", "ios")
+		mustHorizon(t, s, "otr/ios", store.HorizonToday)
+		mustHorizon(t, s, "infra", store.HorizonToday)
+	}
+	m, _ := newTestModel(t, setup)
+	assert.Empty(t, m.shownTitle, "off by default")
+
+	cfg := config.Default()
+	cfg.TerminalTitle = true
+	m, s := newTestModelWithConfig(t, cfg, setup)
+	assert.Equal(t, "cairn · 0/2 today", m.shownTitle)
+
+	_, err := s.SetStatus("infra", store.StatusComplete)
+	require.NoError(t, err)
+	next, cmd := m.Update(FileChangedMsg{})
+	m = next.(Model)
+	assert.Equal(t, "cairn · 1/2 today", m.shownTitle)
+	assert.NotNil(t, cmd)
+
+	// Nothing to send when the title hasn't changed
+	assert.Nil(t, m.titleCmd())
+}
+
+func TestModelExpandAllConfirm(t *testing.T) {
+	cfg := config.Default()
+	cfg.ExpandAllConfirm = 2
+	m, _ := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr/ios", "beta")
+		mustCreate(t, s, "otr", "android")
+	})
+	m.moveCursorToGoal("otr")
+	require.Equal(t, "otr", selectedPath(m))
+
+	// Three more goals would show: ask first
+	m = update(m, press("C")...)
+	assert.True(t, m.showExpandAll)
+	assert.Contains(t, plain(m.View()), "show 3 more goals")
+	m = update(m, press("n")...)
+	assert.False(t, m.showExpandAll)
+	assert.NotContains(t, visibleIDs(m), "otr/ios/beta")
+
+	m = update(m, press("C")...)
+	m = update(m, press("y")...)
+	assert.Contains(t, visibleIDs(m), "otr/ios/beta")
+	assert.Equal(t, "otr", selectedPath(m), "the cursor stays on its goal")
+	assert.Contains(t, plain(m.View()), "Expanded all: 4 goals shown")
+
+	// Collapsing hides the selected goal, so the cursor goes to its ancestor
+	m.moveCursorToGoal("otr/ios/beta")
+	m = update(m, press("C")...)
+	assert.NotContains(t, visibleIDs(m), "otr/ios")
+	assert.Equal(t, "otr", selectedPath(m))
+}
+
+func TestModelRendersIconAndColor(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "rocket")
+		mustCreate(t, s, "", "a-goal-with-a-title-much-wider-than-the-tree-panel")
+	})
+	g, err := s.LoadGoal("rocket")
+	require.NoError(t, err)
+	g.Icon = "🚀"
+	g.Color = "#E05252"
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	for _, item := range m.visibleItems {
+		if item.IsSectionHeader {
+			continue
+		}
+		line := m.renderTreeItem(item, false, 30)
+		assert.Equal(t, 30, lipgloss.Width(line), "row %s is padded or truncated to the panel", item.ID)
+	}
+	assert.Contains(t, plain(m.View()), "🚀 rocket")
+
+	_, ok := AccentStyle("#E05252")
+	assert.True(t, ok)
+	_, ok = AccentStyle("212")
+	assert.True(t, ok)
+	_, ok = AccentStyle("reddish")
+	assert.False(t, ok, "invalid colors fall back to the default style")
+}
+
+func TestModelRendersSummary(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	require.NoError(t, g.SetField("summary", "Off-the-record messaging for the mobile apps"))
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+	var item TreeItem
+	for _, it := range m.visibleItems {
+		if !it.IsSectionHeader && it.Goal.Path == "otr" {
+			item = it
+		}
+	}
+	require.NotNil(t, item.Goal)
+
+	assert.Contains(t, plain(m.renderTreeItem(item, false, 80)), "otr — Off-the-record messaging for the mobile apps")
+
+	line := m.renderTreeItem(item, false, 30)
+	assert.Equal(t, 30, lipgloss.Width(line))
+	assert.Contains(t, plain(line), "otr — Off-the-record me…", "truncated to fit")
+
+	assert.NotContains(t, plain(m.renderTreeItem(item, false, 16)), "—", "left out when there's no room")
+}
+
+func TestModelThemes(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, ApplyTheme("default")) })
+	for _, name := range config.Themes {
+		require.NoError(t, ApplyTheme(name), name)
+		theme := Themes[name]
+
+		m, _ := newTestModel(t, func(s *store.MemStore) {
+			mustCreate(t, s, "", "done")
+			mustCreate(t, s, "", "doing")
+			mustCreate(t, s, "", "todo")
+			_, err := s.SetStatus("done", store.StatusComplete)
+			require.NoError(t, err)
+			_, err = s.SetStatus("doing", store.StatusInProgress)
+			require.NoError(t, err)
+		})
+		view := plain(m.View())
+		for _, row := range []string{
+			theme.IconComplete + " done",
+			theme.IconInProgress + " doing",
+			theme.IconIncomplete + " todo",
+		} {
+			assert.Contains(t, view, row, "%s theme", name)
+		}
+
+		icons := map[string]bool{theme.IconComplete: true, theme.IconInProgress: true, theme.IconIncomplete: true}
+		assert.Len(t, icons, 3, "%s: every status has its own glyph", name)
+		assert.NotEqual(t, styleSummary(theme.Selected), styleSummary(theme.SearchCharSelected),
+			"%s: matches stand out on the selected row", name)
+		assert.NotEqual(t, styleSummary(theme.SearchRow), styleSummary(theme.SearchChar),
+			"%s: matched characters stand out on a matching row", name)
+	}
+	assert.Error(t, ApplyTheme("neon"))
+}
+
+// styleSummary describes the visible attributes of a style, which (unlike
+// rendered output) don't depend on the terminal's color support.
+func styleSummary(s lipgloss.Style) string {
+	return fmt.Sprint(s.GetForeground(), s.GetBackground(), s.GetBold(), s.GetUnderline(), s.GetReverse())
+}
+
+func TestModelShowsEstimates(t *testing.T) {
+	cfg := config.Default()
+	cfg.ShowEstimates = true
+	m, _ := newTestModelWithConfig(t, cfg, func(s *store.MemStore) {
+		mustCreate(t, s, "", "ship")
+		mustCreate(t, s, "ship", "api")
+		mustCreate(t, s, "ship", "docs")
+		_, err := s.SetEstimate(filepath.Join("ship", "api"), "2d")
+		require.NoError(t, err)
+		_, err = s.SetEstimate(filepath.Join("ship", "docs"), "4h")
+		require.NoError(t, err)
+	})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "Remaining: 2d 4h")
+	assert.Contains(t, view, "ship ~2d 4h")
+}
+
+func TestModelFoldsNoteSections(t *testing.T) {
+	body := "Intro\n\n## 2025-03-01\n- first day\n\n## 2025-03-02\n- second day\n- more\n"
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "log")
+	})
+	g, err := s.LoadGoal("log")
+	require.NoError(t, err)
+	g.Body = body
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	preamble, sections := store.SplitNoteSections(body)
+	assert.Equal(t, []string{"Intro", ""}, preamble)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "2025-03-02", sections[1].Date)
+
+	// Z folds everything but the newest section
+	m = update(m, press("tab", "Z")...)
+	view := plain(m.View())
+	assert.NotContains(t, view, "first day")
+	assert.Contains(t, view, "2025-03-01")
+	assert.Contains(t, view, "second day")
+
+	// z on the second section folds it too; the file is untouched
+	m = update(m, press("J", "z")...)
+	assert.NotContains(t, plain(m.View()), "second day")
+	g, err = s.LoadGoal("log")
+	require.NoError(t, err)
+	assert.Equal(t, body, g.Body)
+
+	// Z again unfolds all
+	m = update(m, press("Z")...)
+	view = plain(m.View())
+	assert.Contains(t, view, "first day")
+	assert.Contains(t, view, "second day")
+}
+
+func TestModelNoteSectionFoldsPersistAndSkipCodeBlocks(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.NewStore(dir)
+	require.NoError(t, err)
+	g, err := s.CreateGoal("", "log")
+	require.NoError(t, err)
+	g.Body = "## 2025-03-01\n- first day\n```\n## 2025-03-09\n```\n\n## 2025-03-02\n- second day\n"
+	require.NoError(t, s.SaveGoal(g))
+
+	m := update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = update(m, press("tab", "Z")...)
+	view := plain(m.View())
+	assert.NotContains(t, view, "first day")
+	assert.NotContains(t, view, "2025-03-09", "a header in a code block folds with its section")
+	assert.Contains(t, view, "second day")
+
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	view = plain(m.View())
+	assert.NotContains(t, view, "first day", "the folds are remembered")
+	assert.Contains(t, view, "second day")
+
+	m = update(m, press("tab", "Z")...)
+	m = update(NewModel(s, config.Default()), tea.WindowSizeMsg{Width: 120, Height: 30})
+	assert.Contains(t, plain(m.View()), "first day")
+}
+
+func TestModelQueueTabShowsSingleGoal(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "", "infra")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr", "infra"}}))
+	})
+
+	assert.Equal(t, []string{"otr"}, visibleIDs(m))
+	m = update(m, press("]")...)
+	assert.Equal(t, []string{"infra"}, visibleIDs(m))
+	m = update(m, press("[")...)
+	assert.Equal(t, []string{"otr"}, visibleIDs(m))
+}
+
+func TestModelQueueTabsKeepCursorAndExpansion(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "web")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "infra", "ci")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr", "infra"}}))
+	})
+
+	m = update(m, press("l", "j", "j")...)
+	require.Equal(t, filepath.Join("otr", "web"), selectedPath(m))
+
+	m = update(m, press("]")...)
+	assert.Equal(t, "infra", selectedPath(m), "a new view starts at the top")
+	m = update(m, press("l")...)
+	assert.Equal(t, []string{"infra", filepath.Join("infra", "ci")}, visibleIDs(m))
+
+	m = update(m, press("[")...)
+	assert.Equal(t, filepath.Join("otr", "web"), selectedPath(m))
+	m = update(m, press("h", "h")...)
+	require.Equal(t, []string{"otr"}, visibleIDs(m))
+
+	m = update(m, press("]")...)
+	assert.Equal(t, []string{"infra", filepath.Join("infra", "ci")}, visibleIDs(m), "expansion is per view")
+}
+
+func TestModelQueueTabRootAndPeek(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "web")
+		mustCreate(t, s, "", "infra")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{filepath.Join("otr", "ios")}}))
+	})
+	ios := filepath.Join("otr", "ios")
+	require.Equal(t, []string{ios}, visibleIDs(m))
+
+	// The tab's own goal can be completed...
+	m = update(m, press("space", "space")...)
+	g, err := s.LoadGoal(ios)
+	require.NoError(t, err)
+	assert.True(t, g.IsComplete())
+
+	// ...and A adds a goal beside it, not at the top level
+	m = update(m, press("A")...)
+	m = update(m, typeText("android")...)
+	m = update(m, press("enter")...)
+	_, err = s.LoadGoal(filepath.Join("otr", "android"))
+	require.NoError(t, err)
+	assert.Contains(t, m.statusMsg, "outside this tab")
+	assert.Equal(t, []string{ios}, visibleIDs(m))
+
+	// o peeks at everything with the tab's goal selected, and returns
+	m = update(m, press("o")...)
+	assert.Contains(t, visibleIDs(m), filepath.Join("otr", "web"))
+	assert.Contains(t, visibleIDs(m), "infra")
+	assert.Equal(t, ios, selectedPath(m))
+	assert.Contains(t, plain(m.View()), "queue tab")
+	m = update(m, press("o")...)
+	assert.Equal(t, []string{ios}, visibleIDs(m))
+	assert.Equal(t, ios, selectedPath(m))
+
+	m = update(m, press("o", "esc")...)
+	assert.Equal(t, []string{ios}, visibleIDs(m), "esc returns too")
+}
+
+func TestModelJumpToQueueTab(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "", "docs")
+		require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr", "infra", "docs"}}))
+	})
+	alt := func(r rune) tea.Msg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}, Alt: true} }
+
+	assert.Contains(t, plain(m.View()), "1 otr")
+	assert.Contains(t, plain(m.View()), "3 docs")
+
+	m = update(m, press("l", "j")...)
+	m = update(m, alt('3'))
+	assert.Equal(t, []string{"docs"}, visibleIDs(m))
+	m = update(m, alt('9'))
+	assert.Equal(t, []string{"docs"}, visibleIDs(m), "tabs past the end are ignored")
+	m = update(m, alt('1'))
+	assert.Equal(t, filepath.Join("otr", "ios"), selectedPath(m), "the tab's cursor is restored")
+}
+
+func TestModelStartGoal(t *testing.T) {
+	s := store.NewMemStore()
+	mustCreate(t, s, "", "otr")
+	mustCreate(t, s, "otr", "ios")
+	mustCreate(t, s, filepath.Join("otr", "ios"), "login")
+	mustCreate(t, s, "", "infra")
+	mustCreate(t, s, "", "misc")
+	require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"infra", "otr"}}))
+
+	start := func(goalPath string) Model {
+		m := NewModel(s, config.Default()).WithStartGoal(goalPath)
+		return update(m, tea.WindowSizeMsg{Width: 120, Height: 30})
+	}
+
+	login := filepath.Join("otr", "ios", "login")
+	m := start(login)
+	assert.Equal(t, 1, m.activeQueue, "the queue tab holding the goal is active")
+	assert.Equal(t, login, selectedPath(m), "ancestors are expanded down to the goal")
+
+	m = start("misc")
+	assert.Equal(t, "misc", selectedPath(m), "unqueued goals open in the all-goals view")
+	m = update(m, press("]")...)
+	assert.Equal(t, []string{"infra"}, visibleIDs(m))
+
+	m = start("nope")
+	assert.Equal(t, []string{"infra"}, visibleIDs(m))
+	assert.Contains(t, plain(m.View()), "Goal not found: nope")
+}
+
+func TestModelViewRendersTreeAndNotes(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	_, err := s.AddNote("otr", "shipped the beta")
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "Productivity")
+	assert.Contains(t, view, "0/1 goals complete")
+	assert.Contains(t, view, "FUTURE")
+	assert.Contains(t, view, "shipped the beta")
+}
+
+func TestModelNotesRenderWikiLinks(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	_, err := s.AddNote("otr", "blocked on [[goals/infra/ci/ci|the CI move]] and [[infra/dns/goal.md]]")
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "blocked on the CI move and dns")
+	assert.NotContains(t, view, "[[")
+}
+
+func TestModelPlainNotes(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		g, err := s.LoadGoal("otr")
+		require.NoError(t, err)
+		g.Body = "name\tcount\n# not a heading\n**kept**   as   typed"
+		require.NoError(t, s.SaveGoal(g))
+	})
+	require.False(t, m.plainNotes)
+	assert.NotContains(t, plain(m.View()), "**kept**", "markdown by default")
+
+	m = update(m, press("v")...)
+	require.True(t, m.plainNotes)
+	view := plain(m.View())
+	assert.Contains(t, view, "name    count")
+	assert.Contains(t, view, "# not a heading")
+	assert.Contains(t, view, "**kept**   as   typed")
+
+	m = update(m, press("v")...)
+	assert.False(t, m.plainNotes)
+
+	cfg := config.Default()
+	cfg.PlainNotes = true
+	m, _ = newTestModelWithConfig(t, cfg, nil)
+	assert.True(t, m.plainNotes)
+}
+
+type stubLinkFetcher struct {
+	states  map[string]enrich.State
+	fetched []string
+}
+
+func (f *stubLinkFetcher) Supports(link string) bool {
+	_, ok := enrich.ParseGitHubURL(link)
+	return ok
+}
+
+func (f *stubLinkFetcher) State(_ context.Context, link string) (enrich.State, error) {
+	f.fetched = append(f.fetched, link)
+	if state, ok := f.states[link]; ok {
+		return state, nil
+	}
+	return "", errors.New("offline")
+}
+
+// runCmd runs cmd and any commands it batches, returning their messages.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestModelSyncProgress(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+
+	// Feed the sync's messages through by hand, like the program would
+	updates := make(chan tea.Msg, 2)
+	m.syncing = true
+	m = update(m, SyncProgressMsg{Step: gsync.StepPulling, updates: updates})
+	assert.Contains(t, plain(m.View()), "Pulling…")
+
+	next, cmd := m.Update(press("s")[0])
+	m = next.(Model)
+	assert.Nil(t, cmd, "one sync at a time")
+	assert.Contains(t, plain(m.View()), "Sync already running")
+
+	updates <- SyncDoneMsg{Err: errors.New("push failed")}
+	m = update(m, runCmd(waitSync(updates))...)
+	assert.False(t, m.syncing)
+	view := plain(m.View())
+	assert.NotContains(t, view, "Pulling…")
+	assert.Contains(t, view, "Sync failed: push failed")
+}
+
+func TestModelSyncWithoutRemote(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	m.syncing = true
+	m = update(m, SyncDoneMsg{Err: gsync.ErrNoRemote})
+	assert.False(t, m.syncing)
+	require.True(t, m.showSetRemote)
+	assert.Contains(t, plain(m.View()), "Enter a git remote URL")
+
+	// Nothing typed yet: enter does nothing
+	m = update(m, press("enter")...)
+	assert.True(t, m.showSetRemote)
+
+	m = update(m, press("esc")...)
+	assert.False(t, m.showSetRemote)
+	assert.Contains(t, plain(m.View()), "Committed locally; no remote set")
+}
+
+func TestModelLinkStates(t *testing.T) {
+	const (
+		merged  = "https://github.com/acme/app/pull/1"
+		broken  = "https://github.com/acme/app/issues/2"
+		website = "https://example.com/spec"
+	)
+	s := store.NewMemStore()
+	mustCreate(t, s, "", "otr")
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	g.Links = map[string]string{"pr": merged, "issue": broken, "spec": website}
+	require.NoError(t, s.SaveGoal(g))
+
+	f := &stubLinkFetcher{states: map[string]enrich.State{merged: enrich.StateMerged}}
+	m := NewModel(s, config.Default()).WithLinkStates(f)
+	next, cmd := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = next.(Model)
+	assert.NotContains(t, plain(m.View()), "(merged ✓)", "nothing shown until the lookup finishes")
+
+	var msgs []tea.Msg
+	for _, msg := range runCmd(cmd) {
+		if _, ok := msg.(LinkStateMsg); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	assert.ElementsMatch(t, []string{merged, broken}, f.fetched, "only GitHub links are looked up")
+	m = update(m, msgs...)
+	view := plain(m.View())
+	assert.Contains(t, view, "pr: "+merged+" (merged ✓)")
+	assert.Contains(t, view, "issue: "+broken)
+	assert.NotContains(t, view, broken+" (", "failed lookups show the plain link")
+
+	// Cached results aren't fetched again
+	next, cmd = m.Update(FileChangedMsg{})
+	m = next.(Model)
+	runCmd(cmd)
+	assert.Len(t, f.fetched, 2)
+}
+
+type fakeNotifier struct {
+	bodies []string
+}
+
+func (f *fakeNotifier) Notify(_, body string) error {
+	f.bodies = append(f.bodies, body)
+	return nil
+}
+
+func TestModelPomodoro(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	n := &fakeNotifier{}
+	m = m.WithNotifier(n)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	m.now = func() time.Time { return now }
+	tick := func() tea.Cmd {
+		next, cmd := m.Update(pomodoroTickMsg{id: m.pomodoro.id})
+		m = next.(Model)
+		return cmd
+	}
+
+	m = update(m, press("P")...)
+	require.NotNil(t, m.pomodoro)
+	assert.Contains(t, plain(m.View()), "🍅 25:00 otr")
+
+	now = now.Add(10 * time.Minute)
+	assert.NotNil(t, tick(), "keeps ticking until the time is up")
+	m = update(m, press("P")...)
+	assert.True(t, m.pomodoro.paused)
+	stale := m.pomodoro.id
+	now = now.Add(time.Hour)
+	assert.Contains(t, plain(m.View()), "🍅 15:00 otr (paused)", "paused time doesn't count")
+
+	m = update(m, press("P")...)
+	assert.False(t, m.pomodoro.paused)
+	m = update(m, FileChangedMsg{})
+	require.NotNil(t, m.pomodoro, "survives reloads")
+	next, cmd := m.Update(pomodoroTickMsg{id: stale})
+	m = next.(Model)
+	assert.Nil(t, cmd, "ticks from before the pause are dropped")
+
+	now = now.Add(15 * time.Minute)
+	batch, ok := tick()().(tea.BatchMsg)
+	require.True(t, ok, "notifies and starts the break")
+	batch[0]()
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Contains(t, g.Body, "- 🍅 25m")
+	assert.Equal(t, []string{"Pomodoro done: otr"}, n.bodies)
+	assert.Contains(t, plain(m.View()), "☕ 5:00 break")
+
+	now = now.Add(5 * time.Minute)
+	runCmd(tick())
+	assert.Nil(t, m.pomodoro)
+	assert.Equal(t, "Break over", m.statusMsg)
+	assert.Len(t, n.bodies, 2)
+
+	m = update(m, press("P", ":")...)
+	m = update(m, typeText("pomodoro cancel")...)
+	m = update(m, press("enter")...)
+	assert.Nil(t, m.pomodoro)
+	assert.Equal(t, "Pomodoro cancelled", m.statusMsg)
+}
+
+func TestModelCopySummary(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "android")
+		_, err := s.SetStatus("otr/ios", store.StatusComplete)
+		require.NoError(t, err)
+		for _, note := range []string{"kickoff", "beta out", "blocked on review", "review done"} {
+			_, err := s.AddNote("otr", note)
+			require.NoError(t, err)
+		}
+	})
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	require.NoError(t, g.SetField("links.pr", "https://example.com/pr/1"))
+	require.NoError(t, s.SaveGoal(g))
+	m = update(m, FileChangedMsg{})
+
+	var copied []string
+	orig := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+	t.Cleanup(func() { copyToClipboard = orig })
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = next.(Model)
+	require.NotNil(t, cmd)
+	m = update(m, cmd())
+	assert.Equal(t, "Copied summary of otr", m.statusMsg)
+
+	require.Len(t, copied, 1)
+	assert.Equal(t, "**otr**\n"+
+		"**Horizon:** future | **Status:** incomplete | **Progress:** 1/2\n"+
+		"- **pr:** https://example.com/pr/1\n"+
+		"\nRecent notes:\n"+
+		"- review done\n"+
+		"- blocked on review\n"+
+		"- beta out\n", copied[0], "newest notes first")
+	assert.Contains(t, plain(m.View()), "Progress: 1/2", "the notes pane shows the same metadata")
+}
+
+func TestModelExportView(t *testing.T) {
+	m, _ := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "ios-widgets")
+		mustCreate(t, s, "otr", "android")
+		mustCreate(t, s, "", "infra")
+		mustHorizon(t, s, "otr", store.HorizonToday)
+		_, err := s.SetStatus("otr/ios", store.StatusComplete)
+		require.NoError(t, err)
+		_, err = s.SetStatus("otr/ios-widgets", store.StatusInProgress)
+		require.NoError(t, err)
+		_, err = s.AddNote("otr/ios", "shipped to the store")
+		require.NoError(t, err)
+	})
+
+	m = update(m, press("/")...)
+	m = update(m, typeText("ios")...)
+	m = update(m, press("enter", ":")...)
+	file := filepath.Join(t.TempDir(), "plan.md")
+	m = update(m, typeText("export "+file)...)
+	m = update(m, press("enter")...)
+	assert.Equal(t, "Exported 3 goals to "+file, m.statusMsg)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "# All goals matching \"ios\"\n"+
+		"\n## TODAY\n\n"+
+		"- [ ] otr\n"+
+		"  - [x] ios — shipped to the store\n"+
+		"  - [ ] ios-widgets *(in progress)*\n", string(data), "just the filtered rows")
+
+	var copied []string
+	orig := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+	t.Cleanup(func() { copyToClipboard = orig })
+
+	m = update(m, press(":")...)
+	m = update(m, typeText("export")...)
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	require.NotNil(t, cmd)
+	m = update(m, cmd())
+	assert.Equal(t, "Copied 3 goals", m.statusMsg)
+	assert.Equal(t, []string{string(data)}, copied)
+}
+
+func TestModelHeaderStatsFollowScope(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "", "infra")
+		mustCreate(t, s, "infra", "ci")
+		_, err := s.SetStatus(filepath.Join("otr", "ios"), store.StatusComplete)
+		require.NoError(t, err)
+	})
+
+	view := plain(m.View())
+	assert.Contains(t, view, "1/4 goals complete")
+	assert.NotContains(t, view, "(all:", "no secondary total when everything is in scope")
+
+	require.NoError(t, s.SaveQueue(&store.Queue{Items: []string{"otr"}}))
+	m = update(m, FileChangedMsg{})
+	assert.Contains(t, plain(m.View()), "1/2 goals complete (all: 1/4)")
+}
+
+func TestModelHeaderShowsStreak(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "task")
+	})
+	assert.NotContains(t, plain(m.View()), "streak")
+
+	_, err := s.SetStatus("task", store.StatusComplete)
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+	assert.Contains(t, plain(m.View()), "1-day streak")
+}
+
+func TestModelHeaderCounters(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "a")
+		mustCreate(t, s, "", "b")
+	})
+	assert.Contains(t, plain(m.View()), "0 done +2 new today, 0 done +2 new this week")
+
+	_, err := s.SetStatus("a", store.StatusComplete)
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+	assert.Contains(t, plain(m.View()), "1 done +2 new today")
+
+	m.now = func() time.Time { return time.Now().AddDate(0, 0, 2) }
+	m = update(m, FileChangedMsg{})
+	view := plain(m.View())
+	assert.Contains(t, view, "0 done today, 1 done +2 new this week", "counts move with the clock")
+
+	cfg := config.Default()
+	cfg.HeaderCounters = nil
+	m, _ = newTestModelWithConfig(t, cfg, nil)
+	assert.NotContains(t, plain(m.View()), "done")
+}
+
+func TestModelCommandPalette(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "ios")
+		mustCreate(t, s, "otr", "web")
+		mustCreate(t, s, "", "infra")
+	})
+	run := func(m Model, line string) Model {
+		m = update(m, press(":")...)
+		m = update(m, typeText(line)...)
+		return update(m, press("enter")...)
+	}
+
+	m = run(m, "add otr/android")
+	_, err := s.LoadGoal(filepath.Join("otr", "android"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("otr", "android"), selectedPath(m))
+
+	m = run(m, "goto wb")
+	assert.Equal(t, filepath.Join("otr", "web"), selectedPath(m), "fuzzy match on the path")
+
+	_, err = s.SetStatus(filepath.Join("otr", "web"), store.StatusInProgress)
+	require.NoError(t, err)
+	m = update(m, FileChangedMsg{})
+	m = run(m, "sort status")
+	otr, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "android", "ios"}, otr.ChildrenOrder)
+
+	m = run(m, "goto infra")
+	m = run(m, "horizon today")
+	g, err := s.LoadGoal("infra")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+
+	m = run(m, "horizon someday")
+	assert.Contains(t, plain(m.View()), "Error:")
+	m = run(m, "frobnicate")
+	assert.Contains(t, plain(m.View()), "Unknown command: frobnicate")
+	m = run(m, "move")
+	assert.Contains(t, plain(m.View()), "Usage: :move")
+
+	// Tab completes command names and goal paths
+	m = update(m, press(":")...)
+	m = update(m, typeText("ho")...)
+	m = update(m, press("tab")...)
+	assert.Equal(t, "horizon ", m.paletteInput)
+	m = update(m, press("esc", ":")...)
+	m = update(m, typeText("goto otr/i")...)
+	m = update(m, press("tab")...)
+	assert.Equal(t, "goto "+filepath.Join("otr", "ios"), m.paletteInput)
+
+	// Up/down walk the history
+	m = update(m, press("esc", ":", "up")...)
+	assert.Equal(t, "move", m.paletteInput)
+	m = update(m, press("up", "up", "down")...)
+	assert.Equal(t, "frobnicate", m.paletteInput)
+	m = update(m, press("down", "down")...)
+	assert.Equal(t, "", m.paletteInput)
+}
+
+func TestModelStatusLabel(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+	})
+	run := func(m Model, line string) Model {
+		m = update(m, press(":")...)
+		m = update(m, typeText(line)...)
+		return update(m, press("enter")...)
+	}
+
+	m = run(m, "label in review")
+	g, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, "in review", g.StatusLabel)
+	assert.Equal(t, store.StatusIncomplete, g.Status, "the label leaves the status alone")
+	view := plain(m.View())
+	assert.Contains(t, view, "otr [in review]")
+	assert.Contains(t, view, "Status: incomplete (in review)")
+
+	m = update(m, press(":")...)
+	m = update(m, typeText("label i")...)
+	m = update(m, press("tab")...)
+	assert.Equal(t, "label in review", m.paletteInput, "labels in use complete")
+	m = update(m, press("esc")...)
+
+	m = run(m, "label")
+	g, err = s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Empty(t, g.StatusLabel)
+	assert.NotContains(t, plain(m.View()), "[in review]")
+}
+
+func TestModelHelpModal(t *testing.T) {
+	m, _ := newTestModel(t, nil)
+
+	m = update(m, press("?")...)
+	assert.Contains(t, plain(m.View()), "Keyboard Shortcuts")
+	m = update(m, press("esc")...)
+	assert.NotContains(t, plain(m.View()), "Keyboard Shortcuts")
+}
+
+func TestModelEditorFallbackAndErrors(t *testing.T) {
+	installed := map[string]bool{"nano": true, "code": true}
+	lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	t.Cleanup(func() { lookPath = exec.LookPath })
+
+	argv, fallback, err := ResolveEditor("code --wait")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "--wait"}, argv)
+	assert.Empty(t, fallback)
+
+	argv, fallback, err = ResolveEditor("hx")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nano"}, argv)
+	assert.Equal(t, "nano", fallback)
+
+	m, _ := newTestModel(t, func(s *store.MemStore) { mustCreate(t, s, "", "alpha") })
+	m = update(m, EditorFinishedMsg{Env: "hx", Fallback: "nano"})
+	assert.Equal(t, `$EDITOR "hx" not found, used nano`, m.statusMsg)
+
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	m = update(m, EditorFinishedMsg{Err: exitErr})
+	assert.Equal(t, "Editor exited with status 3", m.statusMsg)
+
+	m = update(m, EditorFinishedMsg{Err: fmt.Errorf("fork failed")})
+	assert.Equal(t, "Editor failed: fork failed", m.statusMsg)
+
+	// Nothing to fall back to: E reports it instead of doing nothing
+	installed = nil
+	t.Setenv("EDITOR", "hx")
+	next, cmd := m.Update(press("E")[0])
+	m = next.(Model)
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.statusMsg, `editor "hx" not found`)
+	assert.Empty(t, m.externalEditPath)
+}
+
+func TestModelNoteCountAndAge(t *testing.T) {
+	tenDaysAgo := time.Now().AddDate(0, 0, -10).Format("2006-01-02")
+	m, s := newTestModel(t, func(s *store.MemStore) {
+		mustCreate(t, s, "", "otr")
+		mustCreate(t, s, "otr", "stale")
+		mustCreate(t, s, "otr", "quiet")
+		mustCreate(t, s, "otr", "fresh")
+
+		g, err := s.LoadGoal("otr/stale")
+		require.NoError(t, err)
+		g.Body = "## " + tenDaysAgo + "\n- old\n- older\n"
+		require.NoError(t, s.SaveGoal(g))
+		_, err = s.AddNote("otr/fresh", "just now")
+		require.NoError(t, err)
+	})
+	m = update(m, press("l")...)
+
+	view := plain(m.View())
+	assert.Contains(t, view, "stale · 2 notes · 10d")
+	assert.Contains(t, view, "fresh · 1 note · today")
+	assert.NotContains(t, view, "quiet ·", "goals without notes get no suffix")
+
+	m = update(m, press("j")...)
+	m = update(m, press(":")...)
+	m = update(m, typeText("sort recent")...)
+	m = update(m, press("enter")...)
+	otr, err := s.LoadGoal("otr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh", "stale", "quiet"}, otr.ChildrenOrder)
+}
+
+func TestEditorJumpsToBody(t *testing.T) {
+	g := &store.Goal{Title: "Ship", Status: store.StatusIncomplete, Body: "## 2025-03-01\n- note\n"}
+	content, err := store.SerializeFrontmatter(g)
+	require.NoError(t, err)
+	line := bodyStartLine(content)
+	assert.Equal(t, "## 2025-03-01", strings.Split(content, "\n")[line-1])
+	assert.Equal(t, 1, bodyStartLine("no frontmatter"))
+
+	assert.Equal(t, []string{"+7", "goal.md"}, editorArgs([]string{"nvim"}, "goal.md", 7))
+	assert.Equal(t, []string{"--wait", "--goto", "goal.md:7"}, editorArgs([]string{"/usr/local/bin/code", "--wait"}, "goal.md", 7))
+	assert.Equal(t, []string{"goal.md:7"}, editorArgs([]string{"hx"}, "goal.md", 7))
+	assert.Equal(t, []string{"goal.md"}, editorArgs([]string{"ed"}, "goal.md", 7), "unknown editors just open the file")
+	assert.Equal(t, []string{"goal.md"}, editorArgs([]string{"vim"}, "goal.md", 1))
+}
+
+func TestModelCreateWithHorizonAndTags(t *testing.T) {
+	m, s := newTestModel(t, func(s *store.MemStore) { mustCreate(t, s, "", "otr") })
+
+	m = update(m, press("A")...)
+	m = update(m, typeText("ship-it")...)
+	assert.Contains(t, plain(m.View()), "horizon: default (future)")
+	m = update(m, press("tab", "1")...)
+	assert.Contains(t, plain(m.View()), "horizon: today")
+	m = update(m, press("tab")...)
+	m = update(m, typeText("q3, launch")...)
+	m = update(m, press("enter")...)
+
+	g, err := s.LoadGoal("ship-it")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonToday, g.Horizon)
+	assert.Equal(t, []string{"q3", "launch"}, g.Tags)
+	assert.False(t, m.isInputMode)
+
+	// Options reset between goals; digits in the name field are just text
+	m = update(m, press("A")...)
+	m = update(m, typeText("v2")...)
+	m = update(m, press("enter")...)
+	g, err = s.LoadGoal("v2")
+	require.NoError(t, err)
+	assert.Equal(t, store.HorizonFuture, g.Horizon)
+	assert.Empty(t, g.Tags)
+
+	// Esc discards everything
+	m = update(m, press("A")...)
+	m = update(m, typeText("nope")...)
+	m = update(m, press("tab", "2", "esc")...)
+	_, err = s.LoadGoal("nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,455 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// moveModeHelp is the status line on entering move mode.
+const moveModeHelp = "Move mode: j/k move the marker, h/l nesting, enter drop, u undo, esc done"
+
+// handleMoveMode handles keys in move mode, which works like dragging in an
+// outline editor: a ghost marker stands in for the moved goal and travels
+// the gaps between visible rows, crossing parents and sections, and h/l
+// pick how deeply it nests there. Nothing changes until enter drops the
+// goal at the marker.
+func (m Model) handleMoveMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		m.endMove()
+		m.setStatus("Move cancelled")
+
+	case msg.Type == tea.KeyEsc:
+		m.endMove()
+		m.setStatus("Move complete")
+
+	case key.Matches(msg, m.keys.Undo):
+		m.undoMove()
+
+	case key.Matches(msg, m.keys.Down):
+		m.stepMoveMarker(1)
+
+	case key.Matches(msg, m.keys.Up):
+		m.stepMoveMarker(-1)
+
+	case key.Matches(msg, m.keys.Left):
+		m.shiftMoveDepth(-1)
+
+	case key.Matches(msg, m.keys.Right):
+		m.shiftMoveDepth(1)
+
+	case msg.Type == tea.KeyEnter:
+		if m.atMoveHome() {
+			m.endMove()
+			m.setStatus("Move complete")
+			break
+		}
+		drop, reason := m.resolveDrop()
+		if reason != "" {
+			m.setStatus(reason)
+			break
+		}
+		if m.dropIsNoop(drop) {
+			m.endMove()
+			m.setStatus("Move complete")
+			break
+		}
+		if err := m.dropMoveTarget(drop); err != nil {
+			m.setStatus("Move error: " + err.Error())
+			m.reload()
+			m.moveCursorToGoal(m.moveTarget)
+			m.homeMoveMarker()
+			break
+		}
+		m.setStatus("Moved " + describeDrop(drop) + " (enter done, u undo)")
+	}
+
+	return m, nil
+}
+
+// endMove leaves move mode; drops already made stay.
+func (m *Model) endMove() {
+	m.isMoveMode = false
+	m.moveTarget = ""
+	m.moveHistory = nil
+}
+
+// atMoveHome reports whether the marker is still where homeMoveMarker put
+// it, so enter there leaves the goal be.
+func (m *Model) atMoveHome() bool {
+	for i, item := range m.visibleItems {
+		if !item.IsSectionHeader && item.Goal.Path == m.moveTarget {
+			return m.moveSlot == i-1 && m.moveDepth == item.Depth
+		}
+	}
+	return false
+}
+
+// homeMoveMarker puts the ghost marker where the moved goal is now: in the
+// gap above its row, at its depth.
+func (m *Model) homeMoveMarker() {
+	for i, item := range m.visibleItems {
+		if !item.IsSectionHeader && item.Goal.Path == m.moveTarget {
+			m.moveSlot, m.moveDepth = i-1, item.Depth
+			return
+		}
+	}
+	m.moveSlot, m.moveDepth = m.firstMoveSlot(), 0
+	m.clampMoveMarker()
+}
+
+// firstMoveSlot is the topmost gap the marker can take: above the first
+// row, unless that row is a section header, which goals can't precede.
+func (m *Model) firstMoveSlot() int {
+	if len(m.visibleItems) > 0 && m.visibleItems[0].IsSectionHeader {
+		return 0
+	}
+	return -1
+}
+
+// moveDepthRange returns the depths the marker can take in the gap after
+// row slot: no shallower than the row below, which keeps its parent, and
+// no deeper than a child of the row above.
+func (m *Model) moveDepthRange(slot int) (lo, hi int) {
+	base := 0
+	if len(m.visibleItems) > 0 && m.visibleItems[0].IsSectionHeader {
+		base = 1 // top-level goals sit under their section's header
+	}
+	lo, hi = base, base
+	if slot >= 0 && slot < len(m.visibleItems) && !m.visibleItems[slot].IsSectionHeader {
+		hi = m.visibleItems[slot].Depth + 1
+	}
+	if slot+1 < len(m.visibleItems) && !m.visibleItems[slot+1].IsSectionHeader {
+		lo = m.visibleItems[slot+1].Depth
+	}
+	return lo, hi
+}
+
+// clampMoveMarker keeps the marker in a gap that exists and at a depth the
+// gap allows, e.g. after the tree reloads under it.
+func (m *Model) clampMoveMarker() {
+	m.moveSlot = max(m.firstMoveSlot(), min(m.moveSlot, len(m.visibleItems)-1))
+	lo, hi := m.moveDepthRange(m.moveSlot)
+	m.moveDepth = max(lo, min(m.moveDepth, hi))
+}
+
+// stepMoveMarker moves the marker delta gaps down (or up), keeping its
+// depth as far as the new gap allows.
+func (m *Model) stepMoveMarker(delta int) {
+	m.moveSlot += delta
+	m.clampMoveMarker()
+	m.reportDrop()
+}
+
+// shiftMoveDepth nests the marker one level deeper (1) or shallower (-1).
+func (m *Model) shiftMoveDepth(delta int) {
+	lo, hi := m.moveDepthRange(m.moveSlot)
+	switch depth := m.moveDepth + delta; {
+	case depth > hi:
+		m.setStatus("Can't nest deeper here")
+	case depth < lo:
+		m.setStatus("Can't outdent here")
+	default:
+		m.moveDepth = depth
+		m.reportDrop()
+	}
+}
+
+// moveGhost returns where to draw the ghost marker: after visible row
+// after (-1 for above the first) and at depth. ok is false outside move mode.
+func (m *Model) moveGhost() (after, depth int, ok bool) {
+	if !m.isMoveMode {
+		return 0, 0, false
+	}
+	m.clampMoveMarker()
+	return m.moveSlot, m.moveDepth, true
+}
+
+// moveDrop is where dropping the moved goal at the marker puts it.
+type moveDrop struct {
+	parent  string        // the new parent's path, "" for top level
+	index   int           // position among the new siblings, not counting the goal
+	horizon store.Horizon // top level: the section's horizon to take, "" to keep its own
+	section string        // top level: the section it lands in, by sectionKey
+	expand  string        // a collapsed parent to open so the goal shows there
+}
+
+// resolveDrop works out where the marker would drop the moved goal, or why
+// the goal can't go there.
+func (m *Model) resolveDrop() (moveDrop, string) {
+	var drop moveDrop
+	goal := m.findGoalByPath(m.goals, m.moveTarget)
+	if goal == nil {
+		return drop, "Moved goal not found"
+	}
+	m.clampMoveMarker()
+	items := m.visibleItems
+	slot, depth := m.moveSlot, m.moveDepth
+
+	// The new parent is the nearest row above the marker that's shallower:
+	// a goal, or a section header for top level
+	anchor := -1
+	for i := slot; i >= 0; i-- {
+		if items[i].IsSectionHeader || items[i].Depth < depth {
+			anchor = i
+			break
+		}
+	}
+	if anchor >= 0 && !items[anchor].IsSectionHeader {
+		parent := items[anchor]
+		drop.parent = parent.Goal.Path
+		if drop.parent == m.moveTarget || strings.HasPrefix(drop.parent, m.moveTarget+string(filepath.Separator)) {
+			return drop, "Can't move a goal under itself or a descendant"
+		}
+		if !parent.IsExpanded {
+			drop.expand = drop.parent
+		}
+	} else if anchor >= 0 {
+		header := items[anchor]
+		drop.section = sectionKey(header)
+		if reason := m.dropIntoSection(goal, drop.section); reason != "" {
+			return drop, reason
+		}
+		horizons := m.store.Horizons()
+		if i := slices.Index(horizons, store.Horizon(drop.section)); i >= 0 &&
+			horizonRank(goal.Horizon, horizons) != i {
+			drop.horizon = store.Horizon(drop.section)
+		}
+	}
+
+	siblings := m.childrenOf(drop.parent)
+	if parentPath(m.moveTarget) != drop.parent {
+		for _, s := range siblings {
+			if s.Slug == goal.Slug {
+				return drop, describeParent(drop.parent) + " already has a goal named " + goal.Slug
+			}
+		}
+	}
+
+	// Land after the nearest sibling above the marker, else before the
+	// nearest one below it in the same parent or section
+	order := make([]string, 0, len(siblings))
+	for _, s := range siblings {
+		if s.Path != m.moveTarget {
+			order = append(order, s.Path)
+		}
+	}
+	isSibling := func(item TreeItem) bool {
+		return !item.IsSectionHeader && item.Goal.Path != m.moveTarget && parentPath(item.Goal.Path) == drop.parent
+	}
+	drop.index = -1
+	for i := slot; i > anchor; i-- {
+		if isSibling(items[i]) {
+			drop.index = slices.Index(order, items[i].Goal.Path) + 1
+			break
+		}
+	}
+	for i := slot + 1; drop.index == -1 && i < len(items) && !items[i].IsSectionHeader && items[i].Depth >= depth; i++ {
+		if isSibling(items[i]) {
+			drop.index = slices.Index(order, items[i].Goal.Path)
+		}
+	}
+	if drop.index == -1 {
+		drop.index = 0 // a parent's first child, e.g. under a collapsed one
+		if drop.parent == "" {
+			drop.index = len(order) // a section's goals are all elsewhere in the order
+		}
+	}
+	return drop, ""
+}
+
+// dropIntoSection returns why goal can't be dropped at the top level of
+// the section named key, or "" if it can. Horizon sections set the goal's
+// horizon; PINNED and OTHER only take goals already listed there.
+func (m *Model) dropIntoSection(goal *store.Goal, key string) string {
+	topLevel := parentPath(goal.Path) == ""
+	switch key {
+	case "pinned":
+		if !topLevel || !goal.Pinned {
+			return "Only pinned goals go in PINNED (pin with !)"
+		}
+	case horizonOther:
+		horizons := m.store.Horizons()
+		if !topLevel || horizonRank(goal.Horizon, horizons) != len(horizons) {
+			return "Only goals with an unconfigured horizon go in OTHER"
+		}
+	default:
+		if goal.Pinned && topLevel {
+			return "Pinned goals stay in PINNED (unpin with !)"
+		}
+	}
+	return ""
+}
+
+// dropIsNoop reports whether drop leaves the moved goal where it is.
+func (m *Model) dropIsNoop(drop moveDrop) bool {
+	if drop.parent != parentPath(m.moveTarget) || drop.horizon != "" {
+		return false
+	}
+	idx, _ := m.siblingIndex(m.moveTarget)
+	return idx == drop.index
+}
+
+// reportDrop says where enter would drop the moved goal, or why it can't.
+func (m *Model) reportDrop() {
+	drop, reason := m.resolveDrop()
+	switch {
+	case m.atMoveHome():
+		m.setStatus("enter: done (not moved)")
+	case reason != "":
+		m.setStatus(reason)
+	case m.dropIsNoop(drop):
+		m.setStatus("enter: done (not moved)")
+	default:
+		m.setStatus("enter: move " + describeDrop(drop))
+	}
+}
+
+// describeDrop says where drop puts the goal, e.g. "under otr" or "into TODAY".
+func describeDrop(drop moveDrop) string {
+	switch {
+	case drop.parent != "":
+		return "under " + drop.parent
+	case drop.section != "":
+		return "into " + strings.ToUpper(drop.section)
+	}
+	return "to top level"
+}
+
+// describeParent names a parent path for messages, "top level" for "".
+func describeParent(parent string) string {
+	if parent == "" {
+		return "Top level"
+	}
+	return parent
+}
+
+// dropMoveTarget moves the goal to drop: under its new parent, at its
+// position there, with the horizon of the section it lands in. It records
+// how to undo that and leaves the marker on the goal.
+func (m *Model) dropMoveTarget(drop moveDrop) error {
+	goal := m.findGoalByPath(m.goals, m.moveTarget)
+	if goal == nil {
+		return fmt.Errorf("goal %s not found", m.moveTarget)
+	}
+	op := moveOp{parent: parentPath(m.moveTarget)}
+	op.index, _ = m.siblingIndex(m.moveTarget)
+	if drop.parent != op.parent || drop.horizon != "" {
+		op.horizon = goal.Horizon
+		if op.horizon == "" {
+			op.horizon = store.FarHorizon(m.store.Horizons()) // listed in the same section
+		}
+	}
+
+	path := m.moveTarget
+	if drop.parent != op.parent {
+		if err := m.store.MoveGoal(path, drop.parent); err != nil {
+			return err
+		}
+		path = filepath.Join(drop.parent, goal.Slug)
+		m.moveTarget = path
+	}
+	// MoveGoal appends; put the goal at the marker
+	if err := m.store.MoveToIndex(path, drop.index); err != nil {
+		return err
+	}
+	if drop.horizon != "" {
+		if _, err := m.store.SetHorizon(path, drop.horizon); err != nil {
+			return err
+		}
+	}
+	op.path = path
+	m.moveHistory = append(m.moveHistory, op)
+
+	if drop.expand != "" {
+		m.expandedState[drop.expand] = true
+	}
+	if drop.section != "" && m.collapsedSections[drop.section] {
+		delete(m.collapsedSections, drop.section)
+		m.saveUIState()
+	}
+	m.reload()
+	m.moveCursorToGoal(path)
+	m.homeMoveMarker()
+	return nil
+}
+
+// moveOp records a drop with enough of the prior state to invert it.
+type moveOp struct {
+	path    string        // the goal's path after the drop
+	parent  string        // the parent path before, "" for top-level
+	index   int           // the sibling index before
+	horizon store.Horizon // the horizon before, if the drop could change it
+}
+
+// undoMove reverts the most recent drop of the current move session.
+func (m *Model) undoMove() {
+	if len(m.moveHistory) == 0 {
+		m.setStatus("Nothing to undo")
+		return
+	}
+	op := m.moveHistory[len(m.moveHistory)-1]
+	m.moveHistory = m.moveHistory[:len(m.moveHistory)-1]
+
+	restored, err := m.undoDrop(op)
+	if err != nil {
+		m.setStatus("Undo error: " + err.Error())
+		m.reload()
+		m.homeMoveMarker()
+		return
+	}
+
+	m.moveTarget = restored
+	m.reload()
+	m.moveCursorToGoal(m.moveTarget)
+	m.homeMoveMarker()
+	m.setStatus(fmt.Sprintf("Undid last move (%d more to undo)", len(m.moveHistory)))
+}
+
+// undoDrop moves a goal back under its old parent, at its old sibling
+// position, with its old horizon, and returns its path there.
+func (m *Model) undoDrop(op moveOp) (string, error) {
+	restored := op.path
+	if parentPath(op.path) != op.parent {
+		if err := m.store.MoveGoal(op.path, op.parent); err != nil {
+			return op.path, err
+		}
+		restored = filepath.Join(op.parent, filepath.Base(op.path))
+	}
+	if err := m.store.MoveToIndex(restored, op.index); err != nil {
+		return restored, err
+	}
+	if op.parent == "" && op.horizon != "" {
+		if _, err := m.store.SetHorizon(restored, op.horizon); err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
+
+// childrenOf returns the goals directly under parent, the top-level goals
+// for "", in the store's order.
+func (m *Model) childrenOf(parent string) []*store.Goal {
+	if parent == "" {
+		return m.goals
+	}
+	if g := m.findGoalByPath(m.goals, parent); g != nil {
+		return g.Children
+	}
+	return nil
+}
+
+// parentPath returns the path of goalPath's parent, "" for a top-level goal.
+func parentPath(goalPath string) string {
+	if dir := filepath.Dir(goalPath); dir != "." {
+		return dir
+	}
+	return ""
+}
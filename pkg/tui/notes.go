@@ -0,0 +1,553 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// notesMarkdown builds the markdown shown in the notes pane: the goal header
+// followed by the body, with collapsed date sections reduced to their header.
+func (m Model) notesMarkdown(goal *store.Goal) string {
+	return m.renderGoalHeader(goal) + m.markFocusedBodyLink(goal, m.notesBody(goal, false))
+}
+
+// notesBody is the body part of the notes pane, with collapsed date
+// sections reduced to their header. plain writes section headers as text
+// rather than markdown, for showing the body preformatted.
+func (m Model) notesBody(goal *store.Goal, plain bool) string {
+	if goal.BodyErr != nil {
+		return "\n" + IconLocked + " " + goal.BodyErr.Error() + "\n"
+	}
+	if goal.Body == "" {
+		return ""
+	}
+	var md strings.Builder
+
+	preamble, sections := store.SplitNoteSections(strings.TrimRight(renderWikiLinks(goal.Body), "\n"))
+	if len(sections) == 0 {
+		md.WriteString(renderWikiLinks(goal.Body))
+		md.WriteString("\n")
+		return md.String()
+	}
+
+	if len(preamble) > 0 {
+		md.WriteString(strings.Join(preamble, "\n"))
+		md.WriteString("\n")
+	}
+	heading, hidden := "## ", " _(%d hidden)_"
+	if plain {
+		heading, hidden = "", " (%d hidden)"
+	}
+	collapsed := m.collapsedNotes[goal.Path]
+	for i, sec := range sections {
+		marker := ""
+		if m.focusedPane == 1 && i == m.noteSection {
+			marker = " " + IconSectionCursor
+		}
+		if collapsed[sec.Date] {
+			md.WriteString(fmt.Sprintf("%s%s %s%s"+hidden+"\n\n", heading, IconCollapsed, sec.Date, marker, countNoteLines(sec.Lines)))
+			continue
+		}
+		md.WriteString(fmt.Sprintf("%s%s %s%s\n", heading, IconExpanded, sec.Date, marker))
+		md.WriteString(strings.Join(sec.Lines, "\n"))
+		md.WriteString("\n")
+	}
+	return md.String()
+}
+
+// wikiLinkPattern matches Obsidian wikilinks: [[target]] or [[target|title]].
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+
+// renderWikiLinks replaces wikilinks with their title, emphasized, so notes
+// written in Obsidian read cleanly here. A link without a title shows the
+// goal's name: [[goals/otr/ios/ios]] and [[otr/ios/goal.md]] both show "ios".
+func renderWikiLinks(body string) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		match := wikiLinkPattern.FindStringSubmatch(link)
+		title := strings.TrimSpace(match[2])
+		if title == "" {
+			target := strings.TrimSuffix(strings.TrimSpace(match[1]), ".md")
+			if path.Base(target) == "goal" {
+				target = path.Dir(target)
+			}
+			title = path.Base(target)
+		}
+		return "*" + title + "*"
+	})
+}
+
+// countNoteLines counts the non-blank lines in a section.
+func countNoteLines(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// notesLines renders the notes pane content for goal into display lines,
+// with the URLs in the body numbered and made clickable.
+func (m Model) notesLines(goal *store.Goal) []string {
+	lines, _ := m.linkedNotesLines(goal)
+	return lines
+}
+
+// noteURLs returns the URLs in goal's notes, in the order notesLines
+// numbers them.
+func (m Model) noteURLs(goal *store.Goal) []string {
+	_, urls := m.linkedNotesLines(goal)
+	return urls
+}
+
+// linkedNotesLines renders goal's notes and links the URLs below the
+// header. The header's own links are opened with tab instead.
+func (m Model) linkedNotesLines(goal *store.Goal) ([]string, []string) {
+	lines := m.renderNotesLines(goal)
+	skip := min(len(m.headerLines(goal)), len(lines))
+	body, urls := linkNotes(lines[skip:], m.glamourWidth)
+	return append(lines[:skip:skip], body...), urls
+}
+
+// renderNotesLines renders the notes pane content for goal, as markdown or
+// as plain text.
+func (m Model) renderNotesLines(goal *store.Goal) []string {
+	if m.plainNotes {
+		return m.plainNotesLines(goal)
+	}
+	md := m.notesMarkdown(goal)
+	rendered := md
+	if m.glamourRenderer != nil {
+		if r, err := m.glamourRenderer.Render(md); err == nil {
+			rendered = r
+		}
+	}
+	rendered = strings.TrimRight(rendered, "\n ")
+	return strings.Split(rendered, "\n")
+}
+
+// plainNotesLines renders the goal header as markdown but leaves the body
+// as written: no reflow, whitespace kept, tabs expanded so columns line up.
+// Lines wider than the pane are cut off rather than wrapped.
+func (m Model) plainNotesLines(goal *store.Goal) []string {
+	lines := m.headerLines(goal)
+	body := strings.TrimRight(m.markFocusedBodyLink(goal, m.notesBody(goal, true)), "\n")
+	if body == "" {
+		return lines
+	}
+	lines = append(lines, "")
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\t", "    "), "\n") {
+		lines = append(lines, " "+line)
+	}
+	return lines
+}
+
+// urlPattern matches bare http(s) URLs in rendered notes. Escape codes end
+// a match, so styling glamour puts around a URL is left out of it.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>()\[\]{}"'` + "`" + `\x1b]+`)
+
+// noteURL trims sentence punctuation off a urlPattern match and reports
+// whether what's left is a URL worth opening.
+func noteURL(match string) (string, bool) {
+	u := strings.TrimRight(match, ".,;:!?")
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	return u, true
+}
+
+// superscriptDigits are ⁰ through ⁹, for numbering links.
+var superscriptDigits = []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+
+// superscript writes n in superscript digits.
+func superscript(n int) string {
+	var b strings.Builder
+	for _, d := range fmt.Sprint(n) {
+		b.WriteRune(superscriptDigits[d-'0'])
+	}
+	return b.String()
+}
+
+// linkNotes wraps each URL in lines in an OSC 8 hyperlink, so terminals
+// that support it can open it on click, and marks it with its number for
+// the open-link key. A URL seen before keeps its first number. Lines
+// the markers push past width, glamour's padding, are cut back to it.
+// It returns the new lines and the URLs by number, starting at 1.
+func linkNotes(lines []string, width int) ([]string, []string) {
+	var urls []string
+	number := make(map[string]int)
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		before := lipgloss.Width(line)
+		linked := false
+		out[i] = urlPattern.ReplaceAllStringFunc(line, func(match string) string {
+			u, ok := noteURL(match)
+			if !ok {
+				return match
+			}
+			n, seen := number[u]
+			if !seen {
+				urls = append(urls, u)
+				n = len(urls)
+				number[u] = n
+			}
+			linked = true
+			return "\x1b]8;;" + u + "\x1b\\" + u + "\x1b]8;;\x1b\\" + superscript(n) + match[len(u):]
+		})
+		if linked && width > 0 && lipgloss.Width(out[i]) > max(before, width) {
+			out[i] = lipgloss.NewStyle().MaxWidth(max(before, width)).Render(out[i])
+		}
+	}
+	return out, urls
+}
+
+// openNoteURL opens the notes pane's URL number n.
+func (m *Model) openNoteURL(n int) tea.Cmd {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return nil
+	}
+	urls := m.noteURLs(goal)
+	if n < 1 || n > len(urls) {
+		m.setStatus(fmt.Sprintf("No link %d in these notes", n))
+		return nil
+	}
+	return m.openTarget(goal, urls[n-1])
+}
+
+// startOpenURL asks for the number of the notes link to open.
+func (m *Model) startOpenURL() {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return
+	}
+	count := len(m.noteURLs(goal))
+	if count == 0 {
+		m.setStatus("No links in these notes")
+		return
+	}
+	m.linkPrompt = true
+	m.linkDigits = ""
+	m.setStatus(fmt.Sprintf("Open link: 1-%d (esc cancels)", count))
+}
+
+// handleOpenURL reads the link number typed after the open-link key. It
+// opens as soon as no more digits could follow, or on enter.
+func (m Model) handleOpenURL(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	goal := m.selectedNoteGoal()
+	var count int
+	if goal != nil {
+		count = len(m.noteURLs(goal))
+	}
+	s := msg.String()
+	switch {
+	case len(s) == 1 && s[0] >= '0' && s[0] <= '9':
+		m.linkDigits += s
+	case msg.Type == tea.KeyEnter && m.linkDigits != "":
+	default:
+		m.linkPrompt = false
+		m.setStatus("")
+		return m, nil
+	}
+	var n int
+	fmt.Sscan(m.linkDigits, &n)
+	if msg.Type != tea.KeyEnter && n > 0 && n*10 <= count {
+		m.setStatus(fmt.Sprintf("Open link: %s (enter opens)", m.linkDigits))
+		return m, nil
+	}
+	m.linkPrompt = false
+	return m, m.openNoteURL(n)
+}
+
+// headerLines renders the goal header alone: the lines notesLines starts
+// with before the body.
+func (m Model) headerLines(goal *store.Goal) []string {
+	header := m.renderGoalHeader(goal)
+	if m.glamourRenderer != nil {
+		if r, err := m.glamourRenderer.Render(header); err == nil {
+			header = r
+		}
+	}
+	return strings.Split(strings.TrimRight(header, "\n "), "\n")
+}
+
+// selectedNoteGoal returns the goal whose notes are showing, or nil.
+func (m *Model) selectedNoteGoal() *store.Goal {
+	if m.cursor >= len(m.visibleItems) || m.visibleItems[m.cursor].IsSectionHeader {
+		return nil
+	}
+	return m.visibleItems[m.cursor].Goal
+}
+
+// stepNoteSection selects the next (1) or previous (-1) dated section of the
+// current goal's notes and scrolls it into view.
+func (m *Model) stepNoteSection(delta int) {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return
+	}
+	_, sections := store.SplitNoteSections(goal.Body)
+	if len(sections) == 0 {
+		m.setStatus("No dated note sections")
+		return
+	}
+	m.noteSection += delta
+	if m.noteSection < 0 {
+		m.noteSection = 0
+	}
+	if m.noteSection >= len(sections) {
+		m.noteSection = len(sections) - 1
+	}
+	m.scrollToNoteSection(goal, sections[m.noteSection].Date)
+}
+
+// scrollToNoteSection scrolls the notes pane so the header for date is at the top.
+func (m *Model) scrollToNoteSection(goal *store.Goal, date string) {
+	for i, line := range m.notesLines(goal) {
+		if strings.Contains(line, IconExpanded+" "+date) || strings.Contains(line, IconCollapsed+" "+date) {
+			m.notesScroll = i
+			return
+		}
+	}
+}
+
+// toggleNoteSection collapses or expands the selected dated section.
+func (m *Model) toggleNoteSection() {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return
+	}
+	_, sections := store.SplitNoteSections(goal.Body)
+	if m.noteSection >= len(sections) {
+		return
+	}
+	date := sections[m.noteSection].Date
+	if m.collapsedNotes[goal.Path] == nil {
+		m.collapsedNotes[goal.Path] = make(map[string]bool)
+	}
+	m.collapsedNotes[goal.Path][date] = !m.collapsedNotes[goal.Path][date]
+	m.scrollToNoteSection(goal, date)
+	m.saveUIState()
+}
+
+// toggleOlderNoteSections collapses every dated section but the newest, or
+// expands them all if any are collapsed.
+func (m *Model) toggleOlderNoteSections() {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return
+	}
+	_, sections := store.SplitNoteSections(goal.Body)
+	if len(sections) == 0 {
+		return
+	}
+	anyCollapsed := false
+	for _, c := range m.collapsedNotes[goal.Path] {
+		anyCollapsed = anyCollapsed || c
+	}
+	if anyCollapsed {
+		delete(m.collapsedNotes, goal.Path)
+		m.saveUIState()
+		m.setStatus("Expanded all note sections")
+		return
+	}
+
+	newest := sections[0].Date
+	for _, sec := range sections {
+		if sec.Date > newest {
+			newest = sec.Date
+		}
+	}
+	collapsed := make(map[string]bool)
+	for _, sec := range sections {
+		if sec.Date != newest {
+			collapsed[sec.Date] = true
+		}
+	}
+	m.collapsedNotes[goal.Path] = collapsed
+	m.notesScroll = 0
+	m.saveUIState()
+	m.setStatus("Collapsed older note sections")
+}
+
+// sortedLinkKeys returns g's link keys in a stable order for display.
+func sortedLinkKeys(g *store.Goal) []string {
+	keys := make([]string, 0, len(g.Links))
+	for k := range g.Links {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// openableLinks returns the keys of g's links that can be focused and
+// opened from the notes pane. "waiting" holds a name, not a URL.
+func openableLinks(g *store.Goal) []string {
+	var keys []string
+	for _, k := range sortedLinkKeys(g) {
+		if k != store.LinkWaiting {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// focusedLinkKey returns the key of the link focused in the notes pane, or "".
+func (m Model) focusedLinkKey(g *store.Goal) string {
+	if m.focusedPane != 1 || m.focusedLink < 0 {
+		return ""
+	}
+	keys := openableLinks(g)
+	if m.focusedLink >= len(keys) {
+		return ""
+	}
+	return keys[m.focusedLink]
+}
+
+// cycleLinkFocus moves link focus to the next link of the current goal:
+// its frontmatter links, then the markdown links in its notes. Past the
+// last link (or with none) focus returns to the tree.
+func (m *Model) cycleLinkFocus() {
+	goal := m.selectedNoteGoal()
+	if goal != nil && m.focusedLink+1 < len(openableLinks(goal))+len(m.bodyLinks(goal)) {
+		m.focusedLink++
+		if link, ok := m.focusedBodyLink(goal); ok {
+			m.setStatus(link.text + " → " + link.target + " (enter opens)")
+		}
+		return
+	}
+	m.focusedLink = -1
+	m.focusedPane = 0
+}
+
+// bodyLink is a markdown [text](target) link in a goal's notes.
+type bodyLink struct {
+	text, target string
+	at           int // offset of the "[" in the body it was found in
+}
+
+// markdownLinkPattern matches inline markdown links. Images, "![alt](src)",
+// are told apart by the character before the match.
+var markdownLinkPattern = regexp.MustCompile(`\[([^\[\]\n]+)\]\(([^()\s]+)\)`)
+
+// markdownLinks returns the links in body, skipping images, links in fenced
+// code blocks and in-page anchors.
+func markdownLinks(body string) []bodyLink {
+	var links []bodyLink
+	fence, offset := "", 0
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		case fence == "":
+			for _, loc := range markdownLinkPattern.FindAllStringSubmatchIndex(line, -1) {
+				target := line[loc[4]:loc[5]]
+				if (loc[0] > 0 && line[loc[0]-1] == '!') || strings.HasPrefix(target, "#") {
+					continue
+				}
+				links = append(links, bodyLink{text: line[loc[2]:loc[3]], target: target, at: offset + loc[0]})
+			}
+		}
+		offset += len(line)
+	}
+	return links
+}
+
+// bodyLinks returns the markdown links in the part of goal's notes that's
+// showing: collapsed sections' links are left out.
+func (m Model) bodyLinks(goal *store.Goal) []bodyLink {
+	return markdownLinks(m.notesBody(goal, false))
+}
+
+// focusedBodyLink returns the markdown link focused in the notes pane.
+func (m Model) focusedBodyLink(goal *store.Goal) (bodyLink, bool) {
+	if m.focusedPane != 1 || m.focusedLink < 0 {
+		return bodyLink{}, false
+	}
+	i := m.focusedLink - len(openableLinks(goal))
+	links := m.bodyLinks(goal)
+	if i < 0 || i >= len(links) {
+		return bodyLink{}, false
+	}
+	return links[i], true
+}
+
+// markFocusedBodyLink puts the focus marker before the focused markdown
+// link in body, one of notesBody's renderings of goal.
+func (m Model) markFocusedBodyLink(goal *store.Goal, body string) string {
+	if m.focusedPane != 1 || m.focusedLink < 0 {
+		return body
+	}
+	i := m.focusedLink - len(openableLinks(goal))
+	links := markdownLinks(body)
+	if i < 0 || i >= len(links) {
+		return body
+	}
+	return body[:links[i].at] + IconLinkFocus + " " + body[links[i].at:]
+}
+
+// linkTarget resolves a link from goal's notes or links for the opener.
+// Notes can arrive through a sync, so only http, https and mailto URLs and
+// files inside dataDir are allowed; anything else is an error. Relative
+// paths and relative file:// URLs are taken from the goal's directory, and
+// files are handed over as file:// URLs.
+func linkTarget(dataDir string, goal *store.Goal, target string) (string, error) {
+	refuse := fmt.Errorf("won't open %s: only http, https, mailto and files in the data directory", target)
+	rest, isFile := strings.CutPrefix(target, "file://")
+	if !isFile {
+		u, err := url.Parse(target)
+		switch {
+		case err == nil && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "mailto"):
+			return target, nil
+		case err != nil || u.Scheme != "" || u.Host != "":
+			return "", refuse
+		}
+		rest = target
+	}
+	// Openers decode file URLs, so check the path they'll see
+	rest, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", refuse
+	}
+	root, err := filepath.Abs(dataDir)
+	if err != nil {
+		return "", refuse
+	}
+	path := rest
+	if !filepath.IsAbs(path) {
+		dir, err := filepath.Abs(filepath.Dir(goal.FilePath))
+		if err != nil {
+			return "", refuse
+		}
+		path = filepath.Join(dir, path)
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return "", refuse
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
+
+// openTarget opens a link from goal's notes or links, or says in the status
+// bar why it won't.
+func (m *Model) openTarget(goal *store.Goal, target string) tea.Cmd {
+	resolved, err := linkTarget(m.store.DataDir(), goal, target)
+	if err != nil {
+		m.setStatus("Error: " + err.Error())
+		return nil
+	}
+	return openLink(resolved)
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stefanpenner/cairn/pkg/clipboard"
+)
+
+// LinkOpenedMsg is sent once the system opener has been started for a link.
+type LinkOpenedMsg struct {
+	Target string
+	Err    error
+}
+
+// openCommand builds the platform's "open this URL or file" command.
+// Replaceable in tests.
+var openCommand = func(target string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return exec.Command("xdg-open", target)
+	}
+}
+
+// openLink opens target with the system opener without waiting for it.
+func openLink(target string) tea.Cmd {
+	return func() tea.Msg {
+		cmd := openCommand(target)
+		if err := cmd.Start(); err != nil {
+			return LinkOpenedMsg{Target: target, Err: err}
+		}
+		go cmd.Wait() // reap the opener; it usually exits right away
+		return LinkOpenedMsg{Target: target}
+	}
+}
+
+// CopiedMsg is sent once text has been put on the clipboard.
+type CopiedMsg struct {
+	What string // e.g. "summary of Ship iOS"
+	Err  error
+}
+
+// copyToClipboard puts text on the clipboard through the terminal.
+// Replaceable in tests.
+var copyToClipboard = func(text string) error {
+	return clipboard.Copy(os.Stdout, text)
+}
+
+// copyText copies text, described by what in the status bar.
+func copyText(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return CopiedMsg{What: what, Err: copyToClipboard(text)}
+	}
+}
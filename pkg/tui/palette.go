@@ -0,0 +1,473 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// paletteCommand is a command run from the ":" prompt. Commands that act
+// on a goal use the selected one.
+type paletteCommand struct {
+	name  string
+	usage string
+	// complete lists candidates for the argument, if it has any
+	complete func(m *Model) []string
+	run      func(m *Model, arg string) (tea.Cmd, error)
+}
+
+var paletteCommands = []paletteCommand{
+	{name: "add", usage: ":add [parent/]slug", complete: goalPaths, run: paletteAdd},
+	{name: "move", usage: ":move <parent> (/ for top-level)", complete: goalPaths, run: paletteMove},
+	{name: "horizon", usage: ":horizon <today|tomorrow|future>", complete: horizonNames, run: paletteHorizon},
+	{name: "label", usage: ":label [text] (clears without text)", complete: statusLabels, run: paletteLabel},
+	{name: "sort", usage: ":sort [status|title|recent]", complete: sortKeys, run: paletteSort},
+	{name: "sync", usage: ":sync", run: paletteSync},
+	{name: "goto", usage: ":goto <path>", complete: goalPaths, run: paletteGoto},
+	{name: "pomodoro", usage: ":pomodoro cancel", complete: pomodoroActions, run: palettePomodoro},
+	{name: "export", usage: ":export [file.md] (copies without a file)", run: paletteExport},
+}
+
+func findPaletteCommand(name string) *paletteCommand {
+	for i := range paletteCommands {
+		if paletteCommands[i].name == name {
+			return &paletteCommands[i]
+		}
+	}
+	return nil
+}
+
+// handlePaletteInput handles key messages while the ":" prompt is open.
+func (m Model) handlePaletteInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.isPalette = false
+		return m, nil
+
+	case tea.KeyEnter:
+		m.isPalette = false
+		line := strings.TrimSpace(m.paletteInput)
+		if line == "" {
+			return m, nil
+		}
+		if n := len(m.paletteHistory); n == 0 || m.paletteHistory[n-1] != line {
+			m.paletteHistory = append(m.paletteHistory, line)
+		}
+		return m, m.runPalette(line)
+
+	case tea.KeyTab:
+		m.completePalette()
+		return m, nil
+
+	case tea.KeyUp:
+		if m.paletteHistoryIdx > 0 {
+			m.paletteHistoryIdx--
+			m.paletteInput = m.paletteHistory[m.paletteHistoryIdx]
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		if m.paletteHistoryIdx < len(m.paletteHistory) {
+			m.paletteHistoryIdx++
+		}
+		m.paletteInput = ""
+		if m.paletteHistoryIdx < len(m.paletteHistory) {
+			m.paletteInput = m.paletteHistory[m.paletteHistoryIdx]
+		}
+		return m, nil
+
+	case tea.KeyBackspace:
+		if len(m.paletteInput) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.paletteInput)
+			m.paletteInput = m.paletteInput[:len(m.paletteInput)-size]
+		}
+		return m, nil
+
+	case tea.KeySpace:
+		m.paletteInput += " "
+		return m, nil
+
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.paletteInput += string(msg.Runes)
+		}
+		return m, nil
+	}
+}
+
+// openPalette shows an empty ":" prompt.
+func (m *Model) openPalette() {
+	m.isPalette = true
+	m.paletteInput = ""
+	m.paletteHistoryIdx = len(m.paletteHistory)
+}
+
+// runPalette parses and runs a command line, reporting errors in the status bar.
+func (m *Model) runPalette(line string) tea.Cmd {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	cmd := findPaletteCommand(name)
+	if cmd == nil {
+		m.setStatus("Unknown command: " + name)
+		return nil
+	}
+	teaCmd, err := cmd.run(m, arg)
+	if errors.Is(err, errPaletteUsage) {
+		m.setStatus("Usage: " + cmd.usage)
+		return nil
+	}
+	if err != nil {
+		m.setStatus("Error: " + err.Error())
+		return nil
+	}
+	return teaCmd
+}
+
+// completePalette completes the command name or argument being typed. A
+// single candidate is filled in; several are narrowed to their common
+// prefix and listed in the status bar.
+func (m *Model) completePalette() {
+	name, arg, hasArg := strings.Cut(m.paletteInput, " ")
+
+	var prefix string
+	var candidates []string
+	if !hasArg {
+		for _, c := range paletteCommands {
+			candidates = append(candidates, c.name)
+		}
+		prefix = name
+	} else {
+		cmd := findPaletteCommand(name)
+		if cmd == nil || cmd.complete == nil {
+			return
+		}
+		candidates = cmd.complete(m)
+		prefix = strings.TrimLeft(arg, " ")
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 && hasArg && name == "goto" {
+		if best := fuzzyGoal(candidates, prefix); best != "" {
+			matches = []string{best}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		m.setStatus("No completions")
+		return
+	case 1:
+		if !hasArg {
+			m.paletteInput = matches[0] + " "
+		} else {
+			m.paletteInput = name + " " + matches[0]
+		}
+		return
+	}
+
+	common := commonPrefix(matches)
+	if !hasArg {
+		m.paletteInput = common
+	} else {
+		m.paletteInput = name + " " + common
+	}
+	const shown = 8
+	if len(matches) > shown {
+		matches = append(matches[:shown], "…")
+	}
+	m.setStatus(strings.Join(matches, "  "))
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// fuzzyGoal returns the path in paths that best matches query: an exact
+// path, then the shortest path containing query, then the shortest one
+// containing its characters in order. It returns "" if nothing matches.
+func fuzzyGoal(paths []string, query string) string {
+	query = strings.ToLower(query)
+	best, bestRank := "", 0
+	for _, p := range paths {
+		lower := strings.ToLower(p)
+		rank := 0
+		switch {
+		case lower == query:
+			return p
+		case strings.Contains(lower, query):
+			rank = 2
+		case isSubsequence(query, lower):
+			rank = 1
+		default:
+			continue
+		}
+		if rank > bestRank || (rank == bestRank && len(p) < len(best)) {
+			best, bestRank = p, rank
+		}
+	}
+	return best
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	for _, r := range s {
+		if sub == "" {
+			break
+		}
+		first, size := utf8.DecodeRuneInString(sub)
+		if r == first {
+			sub = sub[size:]
+		}
+	}
+	return sub == ""
+}
+
+// goalPaths lists every goal path in tree order.
+func goalPaths(m *Model) []string {
+	var paths []string
+	var walk func([]*store.Goal)
+	walk = func(goals []*store.Goal) {
+		for _, g := range goals {
+			paths = append(paths, g.Path)
+			walk(g.Children)
+		}
+	}
+	walk(m.goals)
+	return paths
+}
+
+func horizonNames(m *Model) []string {
+	return store.HorizonNames(m.store.Horizons())
+}
+
+// statusLabels lists the status labels already in use, for reuse.
+func statusLabels(m *Model) []string {
+	var labels []string
+	var walk func([]*store.Goal)
+	walk = func(goals []*store.Goal) {
+		for _, g := range goals {
+			if g.StatusLabel != "" && !slices.Contains(labels, g.StatusLabel) {
+				labels = append(labels, g.StatusLabel)
+			}
+			walk(g.Children)
+		}
+	}
+	walk(m.goals)
+	sort.Strings(labels)
+	return labels
+}
+
+func sortKeys(*Model) []string {
+	return []string{"status", "title", "recent"}
+}
+
+var (
+	errNoSelection  = errors.New("no goal selected")
+	errPaletteUsage = errors.New("usage") // reported with the command's usage line
+)
+
+func paletteAdd(m *Model, arg string) (tea.Cmd, error) {
+	if arg == "" {
+		return nil, errPaletteUsage
+	}
+	parent, slug := filepath.Split(filepath.Clean(arg))
+	parent = strings.TrimSuffix(parent, string(filepath.Separator))
+	g, err := m.store.CreateGoal(parent, slug)
+	if err != nil {
+		return nil, err
+	}
+	m.setStatus("Created: " + g.Path)
+	m.reload()
+	m.focusGoal(g.Path)
+	return nil, nil
+}
+
+func paletteMove(m *Model, arg string) (tea.Cmd, error) {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return nil, errNoSelection
+	}
+	if arg == "" {
+		return nil, errPaletteUsage
+	}
+	dest := filepath.Clean(arg)
+	if dest == "/" || dest == "." {
+		dest = ""
+	}
+	if dest == "" {
+		if filepath.Dir(goal.Path) == "." {
+			return nil, errors.New("already top-level")
+		}
+	} else {
+		m.moveTarget = goal.Path
+		reason := m.invalidDestination(dest)
+		m.moveTarget = ""
+		if reason != "" {
+			return nil, errors.New(reason)
+		}
+		if m.findGoalByPath(m.goals, dest) == nil {
+			return nil, fmt.Errorf("goal %s not found", dest)
+		}
+	}
+	if err := m.store.MoveGoal(goal.Path, dest); err != nil {
+		return nil, err
+	}
+	newPath := filepath.Join(dest, goal.Slug)
+	m.setStatus("Moved to " + newPath)
+	m.reload()
+	m.focusGoal(newPath)
+	return nil, nil
+}
+
+func paletteHorizon(m *Model, arg string) (tea.Cmd, error) {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return nil, errNoSelection
+	}
+	h, err := store.ParseHorizon(arg, m.store.Horizons())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.store.SetHorizon(goal.Path, h); err != nil {
+		return nil, err
+	}
+	m.setStatus(goal.Title + " → " + string(h))
+	m.reload()
+	m.moveCursorToGoal(goal.Path)
+	return nil, nil
+}
+
+// paletteLabel sets the selected goal's status label, shown next to its
+// title, or clears it.
+func paletteLabel(m *Model, arg string) (tea.Cmd, error) {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return nil, errNoSelection
+	}
+	g, err := m.store.LoadGoal(goal.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.SetField("status_label", arg); err != nil {
+		return nil, err
+	}
+	if err := m.store.SaveGoal(g); err != nil {
+		return nil, err
+	}
+	m.store.Commit("set " + goal.Path + " status_label")
+	if g.StatusLabel == "" {
+		m.setStatus(goal.Title + ": label cleared")
+	} else {
+		m.setStatus(goal.Title + " → " + g.StatusLabel)
+	}
+	m.reload()
+	m.moveCursorToGoal(goal.Path)
+	return nil, nil
+}
+
+// statusRank orders goals for :sort status: in progress, then open, then done.
+func statusRank(g *store.Goal) int {
+	switch {
+	case g.IsInProgress():
+		return 0
+	case g.IsComplete():
+		return 2
+	default:
+		return 1
+	}
+}
+
+// paletteSort reorders the selected goal and its siblings.
+func paletteSort(m *Model, arg string) (tea.Cmd, error) {
+	goal := m.selectedNoteGoal()
+	if goal == nil {
+		return nil, errNoSelection
+	}
+	if arg == "" {
+		arg = "status"
+	}
+
+	parentPath := filepath.Dir(goal.Path)
+	siblings := m.goals
+	if parentPath == "." {
+		parentPath = ""
+	} else if parent := m.findGoalByPath(m.goals, parentPath); parent != nil {
+		siblings = parent.Children
+	}
+	sorted := slices.Clone(siblings)
+	switch arg {
+	case "status":
+		sort.SliceStable(sorted, func(i, j int) bool { return statusRank(sorted[i]) < statusRank(sorted[j]) })
+	case "title":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(displayName(sorted[i])) < strings.ToLower(displayName(sorted[j]))
+		})
+	case "recent":
+		// Latest note first; goals without notes keep their order at the end
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LastNote.After(sorted[j].LastNote) })
+	default:
+		return nil, errPaletteUsage
+	}
+
+	order := make([]string, len(sorted))
+	for i, g := range sorted {
+		order[i] = g.Slug
+	}
+	if err := m.store.SetChildrenOrder(parentPath, order); err != nil {
+		return nil, err
+	}
+	m.setStatus("Sorted by " + arg)
+	m.reload()
+	m.moveCursorToGoal(goal.Path)
+	return nil, nil
+}
+
+func paletteSync(m *Model, arg string) (tea.Cmd, error) {
+	return m.doSync(), nil
+}
+
+func paletteGoto(m *Model, arg string) (tea.Cmd, error) {
+	if arg == "" {
+		return nil, errPaletteUsage
+	}
+	path := fuzzyGoal(goalPaths(m), arg)
+	if path == "" {
+		return nil, fmt.Errorf("no goal matches %q", arg)
+	}
+	m.focusGoal(path)
+	return nil, nil
+}
+
+func pomodoroActions(*Model) []string {
+	return []string{"cancel"}
+}
+
+func palettePomodoro(m *Model, arg string) (tea.Cmd, error) {
+	if arg != "cancel" {
+		return nil, errPaletteUsage
+	}
+	m.cancelPomodoro()
+	return nil, nil
+}
+
+func paletteExport(m *Model, arg string) (tea.Cmd, error) {
+	return m.exportView(arg)
+}
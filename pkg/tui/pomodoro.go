@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stefanpenner/cairn/pkg/notify"
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// pomodoroTickMsg drives a running pomodoro's countdown. Each run (start,
+// resume, break) ticks with its own id so ticks left over from before a
+// pause or cancel are ignored.
+type pomodoroTickMsg struct {
+	id int
+}
+
+// pomodoro is a work or break timer started with P. It's Model state, so
+// it carries on across reloads.
+type pomodoro struct {
+	goal    string // path of the goal being worked on
+	title   string
+	onBreak bool
+	paused  bool
+	ends    time.Time     // while running
+	left    time.Duration // while paused
+	id      int
+}
+
+// WithNotifier returns m set to show a desktop notification through n when
+// a pomodoro or its break ends.
+func (m Model) WithNotifier(n notify.Notifier) Model {
+	m.notifier = n
+	return m
+}
+
+// togglePomodoro starts a pomodoro on the selected goal, or pauses or
+// resumes the current one.
+func (m *Model) togglePomodoro() tea.Cmd {
+	p := m.pomodoro
+	switch {
+	case p == nil:
+		if !m.onGoal() {
+			return nil
+		}
+		g := m.visibleItems[m.cursor].Goal
+		m.pomodoro = &pomodoro{goal: g.Path, title: g.Title}
+		m.setStatus(fmt.Sprintf("Pomodoro started: %s (%s)", g.Title, store.FormatEstimate(m.cfg.PomodoroWork)))
+		return m.runPomodoro(m.cfg.PomodoroWork)
+	case p.paused:
+		m.setStatus("Pomodoro resumed")
+		return m.runPomodoro(p.left)
+	default:
+		p.paused = true
+		p.left = p.ends.Sub(m.now())
+		m.setStatus("Pomodoro paused: P resumes, :pomodoro cancel stops it")
+		return nil
+	}
+}
+
+// runPomodoro (re)starts the current timer with d left.
+func (m *Model) runPomodoro(d time.Duration) tea.Cmd {
+	m.pomodoroRuns++
+	p := m.pomodoro
+	p.paused = false
+	p.ends = m.now().Add(d)
+	p.id = m.pomodoroRuns
+	return pomodoroTick(p.id)
+}
+
+func pomodoroTick(id int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return pomodoroTickMsg{id: id} })
+}
+
+// cancelPomodoro stops the current timer without recording anything.
+func (m *Model) cancelPomodoro() {
+	if m.pomodoro == nil {
+		m.setStatus("No pomodoro running")
+		return
+	}
+	m.pomodoro = nil
+	m.setStatus("Pomodoro cancelled")
+}
+
+// tickPomodoro handles a countdown tick. A finished pomodoro is recorded
+// as a note on its goal and followed by a break; a finished break clears
+// the timer.
+func (m *Model) tickPomodoro(msg pomodoroTickMsg) tea.Cmd {
+	p := m.pomodoro
+	if p == nil || p.paused || msg.id != p.id {
+		return nil
+	}
+	if m.now().Before(p.ends) {
+		return pomodoroTick(p.id)
+	}
+
+	if p.onBreak {
+		m.pomodoro = nil
+		m.setStatus("Break over")
+		return m.notify("Break over")
+	}
+
+	work := store.FormatEstimate(m.cfg.PomodoroWork)
+	if _, err := m.store.AddNote(p.goal, "🍅 "+work); err != nil {
+		m.setStatus("Error: " + err.Error())
+	} else {
+		m.reload()
+		m.setStatus(fmt.Sprintf("🍅 Pomodoro done: %s", p.title))
+	}
+	done := m.notify(fmt.Sprintf("Pomodoro done: %s", p.title))
+	if m.cfg.PomodoroBreak <= 0 {
+		m.pomodoro = nil
+		return done
+	}
+	p.onBreak = true
+	return tea.Batch(done, m.runPomodoro(m.cfg.PomodoroBreak))
+}
+
+// notify shows body as a desktop notification when a notifier is set.
+// Failures are ignored: the status bar already says the same thing.
+func (m *Model) notify(body string) tea.Cmd {
+	n := m.notifier
+	if n == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		n.Notify(notify.Title, body)
+		return nil
+	}
+}
+
+// pomodoroLabel is the header's countdown, e.g. "🍅 24:59 Ship iOS", or ""
+// when no timer is running.
+func (m Model) pomodoroLabel() string {
+	p := m.pomodoro
+	if p == nil {
+		return ""
+	}
+	left := p.left
+	if !p.paused {
+		left = p.ends.Sub(m.now())
+	}
+	secs := int((left + time.Second - 1) / time.Second)
+	if secs < 0 {
+		secs = 0
+	}
+	label := fmt.Sprintf("🍅 %d:%02d %s", secs/60, secs%60, p.title)
+	if p.onBreak {
+		label = fmt.Sprintf("☕ %d:%02d break", secs/60, secs%60)
+	}
+	if p.paused {
+		label += " (paused)"
+	}
+	return label
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// EventMsg carries a change the store made on the TUI's behalf, for the
+// session log.
+type EventMsg struct {
+	Event store.Event
+}
+
+// sessionLogShown is how many of the latest actions the session modal lists.
+const sessionLogShown = 20
+
+// sessionLogPath returns the file config.SessionLog appends actions to,
+// or "" if there's no data directory to keep it in.
+func sessionLogPath(dataDir string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, store.RuntimeDir, "sessions.log")
+}
+
+// recordAction adds e to the session log, in time order: events arrive
+// through the program's message queue, not necessarily as they happened.
+func (m *Model) recordAction(e store.Event) {
+	i := sort.Search(len(m.session), func(i int) bool { return m.session[i].Time.After(e.Time) })
+	m.session = append(m.session, store.Event{})
+	copy(m.session[i+1:], m.session[i:])
+	m.session[i] = e
+	if m.cfg.SessionLog && !m.cfg.ReadOnly {
+		appendSessionLog(sessionLogPath(m.store.DataDir()), e)
+	}
+}
+
+// appendSessionLog writes e to path as a line of text, best-effort like
+// the event log it mirrors.
+func appendSessionLog(path string, e store.Event) {
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s\n", e.Time.Local().Format("2006-01-02 15:04"), describeAction(e))
+}
+
+// describeAction says what e did, e.g. "completed otr/ios".
+func describeAction(e store.Event) string {
+	switch e.Type {
+	case store.EventComplete:
+		return "completed " + e.Path
+	case store.EventCreate:
+		return "created " + e.Path
+	case store.EventNote:
+		return "added note to " + e.Path
+	case store.EventMove:
+		return "moved " + e.Path + " to " + e.To
+	case store.EventDelete:
+		return "deleted " + e.Path
+	}
+	return e.Type + " " + e.Path
+}
+
+// SessionSummary counts what was done this session, e.g. "Session: 3
+// completed, 5 notes, 1 created". It's "" if nothing was.
+func (m Model) SessionSummary() string {
+	counts := make(map[string]int)
+	for _, e := range m.session {
+		counts[e.Type]++
+	}
+	var parts []string
+	for _, kind := range []struct{ event, one, many string }{
+		{store.EventComplete, "completed", "completed"},
+		{store.EventNote, "note", "notes"},
+		{store.EventCreate, "created", "created"},
+		{store.EventMove, "moved", "moved"},
+		{store.EventDelete, "deleted", "deleted"},
+	} {
+		switch n := counts[kind.event]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+kind.one)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind.many))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Session: " + strings.Join(parts, ", ")
+}
@@ -1,6 +1,11 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Color palette — adapted from gha-analyzer
 var (
@@ -31,6 +36,15 @@ var (
 
 	FooterStyle = lipgloss.NewStyle().
 			Foreground(ColorGray)
+
+	// StreakStyle highlights the run of days with a completion
+	StreakStyle = lipgloss.NewStyle().
+			Foreground(ColorOrange)
+
+	// PomodoroStyle shows the running pomodoro's countdown
+	PomodoroStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(ColorCyan)
 )
 
 // Tab styles
@@ -64,16 +78,62 @@ var (
 	IncompleteStyle = lipgloss.NewStyle().
 			Foreground(ColorOffWhite)
 
+	// StaleStyle marks goals that have lingered in TODAY
+	StaleStyle = lipgloss.NewStyle().
+			Foreground(ColorOrange)
+
+	// InvalidDestStyle marks goals that can't take the moved goal
+	InvalidDestStyle = lipgloss.NewStyle().
+				Foreground(ColorGrayDim).
+				Strikethrough(true)
+
 	MoveStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(ColorOrange).
 			Background(ColorMoveBg)
 
+	// MoveDimStyle fades the rest of the tree while moving a goal
+	MoveDimStyle = lipgloss.NewStyle().
+			Foreground(ColorGray)
+
+	// NoteInfoStyle is the note count and age after a goal's title
+	NoteInfoStyle = lipgloss.NewStyle().
+			Foreground(ColorGrayDim)
+
+	// SummaryStyle is a goal's summary after its title
+	SummaryStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(ColorGrayDim)
+
+	// MoveGhostStyle is the placeholder row at the moved goal's drop position
+	MoveGhostStyle = lipgloss.NewStyle().
+			Italic(true).
+			Foreground(ColorOrange)
+
 	DepthIndent = "  "
 )
 
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// AccentStyle returns the style for a goal's color accent: a hex color like
+// "#E05252" or an ANSI color number 0-255. ok is false for anything else, so
+// a typo falls back to the default style.
+func AccentStyle(color string) (style lipgloss.Style, ok bool) {
+	if !hexColor.MatchString(color) {
+		n, err := strconv.Atoi(color)
+		if err != nil || n < 0 || n > 255 {
+			return lipgloss.Style{}, false
+		}
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)), true
+}
+
 // Horizon styles
 var (
+	PinnedStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(ColorMagenta)
+
 	HorizonTodayStyle = lipgloss.NewStyle().
 				Bold(true).
 				Foreground(ColorRed)
@@ -147,14 +207,33 @@ var (
 
 	SearchCountStyle = lipgloss.NewStyle().
 				Foreground(ColorGray)
+
+	// SearchHintStyle is the note on a match found by its path, not its title
+	SearchHintStyle = lipgloss.NewStyle().
+			Foreground(ColorGrayDim)
+
+	StatusLabelStyle = lipgloss.NewStyle().
+				Foreground(ColorCyan)
 )
 
-// Status icons
-const (
+// Status icons vary by theme (see ApplyTheme)
+var (
 	IconComplete   = "✓"
 	IconInProgress = "◐"
 	IconIncomplete = "○"
-	IconExpanded   = "▼"
-	IconCollapsed  = "▶"
-	IconMove       = "↕"
+)
+
+// Icons
+const (
+	IconExpanded  = "▼"
+	IconCollapsed = "▶"
+	IconMove      = "↕"
+	IconGhost     = "↳"
+	IconPin       = "⚑"
+	IconWaiting   = "🕒"
+	IconLocked    = "🔒"
+	IconWarning   = "⚠"
+
+	IconSectionCursor = "◂"
+	IconLinkFocus     = "▸"
 )
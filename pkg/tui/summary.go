@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// summaryNotes is how many of the latest note bullets a summary includes.
+const summaryNotes = 3
+
+// goalMeta is the metadata line of a goal's header in the notes pane and
+// in summaries: horizon, status, progress, tags and estimates. Goals in
+// TODAY for more than staleDays days are flagged; zero disables that.
+func goalMeta(goal *store.Goal, now time.Time, staleDays int) []string {
+	var meta []string
+	if goal.Horizon != "" {
+		meta = append(meta, "**Horizon:** "+string(goal.Horizon))
+	}
+	if goal.Status != "" {
+		status := string(goal.Status)
+		if goal.StatusLabel != "" {
+			status += " (" + goal.StatusLabel + ")"
+		}
+		meta = append(meta, "**Status:** "+status)
+	}
+	if done, total := subGoalProgress(goal); total > 0 {
+		meta = append(meta, fmt.Sprintf("**Progress:** %d/%d", done, total))
+	}
+	if len(goal.Tags) > 0 {
+		meta = append(meta, "**Tags:** "+strings.Join(goal.Tags, ", "))
+	}
+	if goal.Estimate != "" {
+		meta = append(meta, "**Estimate:** "+goal.Estimate)
+	}
+	if len(goal.Children) > 0 {
+		if remaining := store.FormatRemaining(goal); remaining != "" {
+			meta = append(meta, "**Remaining:** "+remaining)
+		}
+	}
+	if store.IsStaleToday(goal, now, staleDays) {
+		meta = append(meta, fmt.Sprintf("**in TODAY for %d days**", store.DaysInToday(goal, now)))
+	}
+	return meta
+}
+
+// linkLine is the markdown list item for goal's link k.
+func linkLine(goal *store.Goal, k string, now time.Time) string {
+	v := goal.Links[k]
+	if k == store.LinkWaiting {
+		return fmt.Sprintf("- %s **waiting on:** %s (%d days)", IconWaiting, v, store.DaysWaiting(goal, now))
+	}
+	return "- **" + k + ":** " + v
+}
+
+// subGoalProgress counts goal's complete sub-goals at every depth.
+func subGoalProgress(goal *store.Goal) (done, total int) {
+	for _, c := range goal.Children {
+		total++
+		if c.IsComplete() {
+			done++
+		}
+		d, t := subGoalProgress(c)
+		done += d
+		total += t
+	}
+	return done, total
+}
+
+// latestNoteBullets returns the text of the newest n note bullets in body,
+// newest first. AddNote puts a day's latest note first under its date
+// header, so sections are read newest date first and top down. A body
+// without dated sections gives its last n bullets.
+func latestNoteBullets(body string, n int) []string {
+	preamble, sections := store.SplitNoteSections(body)
+	if len(sections) == 0 {
+		bullets := listItems(preamble)
+		if len(bullets) > n {
+			bullets = bullets[len(bullets)-n:]
+		}
+		return bullets
+	}
+	slices.SortStableFunc(sections, func(a, b store.NoteSection) int { return strings.Compare(b.Date, a.Date) })
+	var bullets []string
+	for _, sec := range sections {
+		bullets = append(bullets, listItems(sec.Lines)...)
+		if len(bullets) >= n {
+			return bullets[:n]
+		}
+	}
+	return bullets
+}
+
+// listItems returns the text of the "- " and "* " list items in lines.
+func listItems(lines []string) []string {
+	var items []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if text, ok := strings.CutPrefix(line, "- "); ok {
+			items = append(items, text)
+		} else if text, ok := strings.CutPrefix(line, "* "); ok {
+			items = append(items, text)
+		}
+	}
+	return items
+}
+
+// GoalSummary is a compact markdown summary of goal for pasting into chat:
+// its title, the notes pane's metadata, links and latest note bullets.
+// goal should come from the goal tree so its sub-goals are counted.
+func GoalSummary(goal *store.Goal, now time.Time) string {
+	var md strings.Builder
+	md.WriteString("**" + goal.Title + "**\n")
+	if meta := goalMeta(goal, now, 0); len(meta) > 0 {
+		md.WriteString(strings.Join(meta, " | ") + "\n")
+	}
+	for _, k := range sortedLinkKeys(goal) {
+		md.WriteString(linkLine(goal, k, now) + "\n")
+	}
+	if notes := latestNoteBullets(goal.Body, summaryNotes); len(notes) > 0 {
+		md.WriteString("\nRecent notes:\n")
+		for _, n := range notes {
+			md.WriteString("- " + n + "\n")
+		}
+	}
+	return md.String()
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	gsync "github.com/stefanpenner/cairn/pkg/sync"
+)
+
+// SyncProgressMsg is sent as git sync starts each step. The header shows
+// the step next to a spinner until SyncDoneMsg.
+type SyncProgressMsg struct {
+	Step gsync.Step
+
+	updates <-chan tea.Msg
+}
+
+// doSync starts a git sync in the background. Its progress and result
+// arrive one at a time through waitSync.
+func (m *Model) doSync() tea.Cmd {
+	if m.syncing {
+		m.setStatus("Sync already running")
+		return nil
+	}
+	m.syncing = true
+	m.syncStep = ""
+	m.syncSpinner = spinner.New(spinner.WithSpinner(spinner.MiniDot))
+
+	updates := make(chan tea.Msg)
+	opts := gsync.Options{
+		DraftTags:    m.cfg.DraftTags,
+		FieldAliases: m.cfg.FieldAliases,
+		Branch:       m.cfg.SyncBranch,
+		Pull:         m.cfg.SyncPull,
+		Progress: func(step gsync.Step) {
+			updates <- SyncProgressMsg{Step: step, updates: updates}
+		},
+		Output: io.Discard, // git would draw over the TUI
+	}
+	dir := m.store.DataDir()
+	go func() {
+		updates <- SyncDoneMsg{Err: gsync.SyncRepo(dir, opts)}
+	}()
+	return tea.Batch(waitSync(updates), m.syncSpinner.Tick)
+}
+
+// waitSync waits for the running sync's next message.
+func waitSync(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-updates }
+}
+
+// syncLabel is the header's spinner and current step while syncing.
+func (m Model) syncLabel() string {
+	if !m.syncing {
+		return ""
+	}
+	step := "Syncing"
+	if m.syncStep != "" {
+		step = string(m.syncStep)
+	}
+	return m.syncSpinner.View() + " " + step + "…"
+}
+
+// promptRemote asks for a remote URL after a sync found none.
+func (m *Model) promptRemote() {
+	m.showSetRemote = true
+	m.textInput.Reset()
+	m.textInput.Placeholder = "git@github.com:you/goals.git"
+	m.textInput.Focus()
+}
+
+// handleSetRemote handles keys in the remote URL prompt: enter sets origin
+// and syncs again, esc leaves the changes committed locally.
+func (m Model) handleSetRemote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.showSetRemote = false
+		m.textInput.Blur()
+		m.setStatus("Committed locally; no remote set")
+		return m, nil
+	case tea.KeyEnter:
+		url := strings.TrimSpace(m.textInput.Value())
+		if url == "" {
+			return m, nil
+		}
+		m.showSetRemote = false
+		m.textInput.Blur()
+		if err := gsync.SetRemote(m.store.DataDir(), url); err != nil {
+			m.setStatus("Error: " + err.Error())
+			return m, nil
+		}
+		m.setStatus("Remote set to " + url)
+		cmd := m.doSync()
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the set of styles and glyphs that convey status, horizon,
+// selection and search matches. The rest of the palette is shared.
+type Theme struct {
+	Complete   lipgloss.Style
+	InProgress lipgloss.Style
+	Incomplete lipgloss.Style
+	Stale      lipgloss.Style
+
+	IconComplete   string
+	IconInProgress string
+	IconIncomplete string
+
+	HorizonToday    lipgloss.Style
+	HorizonTomorrow lipgloss.Style
+	HorizonFuture   lipgloss.Style
+
+	Selected           lipgloss.Style
+	SearchRow          lipgloss.Style
+	SearchChar         lipgloss.Style
+	SearchCharSelected lipgloss.Style
+}
+
+// defaultTheme is the styles.go palette as declared.
+var defaultTheme = currentTheme()
+
+// Themes holds the presets selectable with the theme config key.
+var Themes = map[string]Theme{
+	"default": defaultTheme,
+
+	// Okabe-Ito colors, which stay distinct under the common kinds of
+	// color blindness, plus weight and glyph fill so status reads without hue.
+	"colorblind": {
+		Complete:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#0072B2")),
+		InProgress: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#E69F00")),
+		Incomplete: lipgloss.NewStyle().Foreground(ColorOffWhite),
+		Stale:      lipgloss.NewStyle().Underline(true).Foreground(lipgloss.Color("#E69F00")),
+
+		IconComplete:   "●",
+		IconInProgress: "◑",
+		IconIncomplete: "○",
+
+		HorizonToday:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#D55E00")),
+		HorizonTomorrow: lipgloss.NewStyle().Foreground(lipgloss.Color("#56B4E9")),
+		HorizonFuture:   lipgloss.NewStyle().Foreground(ColorGray),
+
+		Selected:           lipgloss.NewStyle().Bold(true).Foreground(ColorWhite).Background(ColorSelectionBg),
+		SearchRow:          lipgloss.NewStyle().Background(ColorSearchRowBg),
+		SearchChar:         lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("#F0E442")),
+		SearchCharSelected: lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("#F0E442")).Background(ColorSelectionBg),
+	},
+
+	// Bright ANSI colors on the terminal background, reverse video for the
+	// selection and underlines for matches.
+	"high-contrast": {
+		Complete:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")),
+		InProgress: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")),
+		Incomplete: lipgloss.NewStyle().Foreground(lipgloss.Color("15")),
+		Stale:      lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("11")),
+
+		IconComplete:   "■",
+		IconInProgress: "◧",
+		IconIncomplete: "□",
+
+		HorizonToday:    lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("9")),
+		HorizonTomorrow: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")),
+		HorizonFuture:   lipgloss.NewStyle().Foreground(lipgloss.Color("15")),
+
+		Selected:           lipgloss.NewStyle().Bold(true).Reverse(true),
+		SearchRow:          lipgloss.NewStyle().Underline(true),
+		SearchChar:         lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("14")),
+		SearchCharSelected: lipgloss.NewStyle().Bold(true).Underline(true).Reverse(true).Foreground(lipgloss.Color("14")),
+	},
+}
+
+// currentTheme captures the styles in effect.
+func currentTheme() Theme {
+	return Theme{
+		Complete:   CompleteStyle,
+		InProgress: InProgressStyle,
+		Incomplete: IncompleteStyle,
+		Stale:      StaleStyle,
+
+		IconComplete:   IconComplete,
+		IconInProgress: IconInProgress,
+		IconIncomplete: IconIncomplete,
+
+		HorizonToday:    HorizonTodayStyle,
+		HorizonTomorrow: HorizonTomorrowStyle,
+		HorizonFuture:   HorizonFutureStyle,
+
+		Selected:           SelectedStyle,
+		SearchRow:          SearchRowStyle,
+		SearchChar:         SearchCharStyle,
+		SearchCharSelected: SearchCharSelectedStyle,
+	}
+}
+
+// ApplyTheme switches the package styles to the named preset. An empty name
+// means "default".
+func ApplyTheme(name string) error {
+	if name == "" {
+		name = "default"
+	}
+	t, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+
+	CompleteStyle = t.Complete
+	InProgressStyle = t.InProgress
+	IncompleteStyle = t.Incomplete
+	StaleStyle = t.Stale
+
+	IconComplete = t.IconComplete
+	IconInProgress = t.IconInProgress
+	IconIncomplete = t.IconIncomplete
+
+	HorizonTodayStyle = t.HorizonToday
+	HorizonTomorrowStyle = t.HorizonTomorrow
+	HorizonFutureStyle = t.HorizonFuture
+
+	SelectedStyle = t.Selected
+	SearchRowStyle = t.SearchRow
+	SearchCharStyle = t.SearchChar
+	SearchCharSelectedStyle = t.SearchCharSelected
+	return nil
+}
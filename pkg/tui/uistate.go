@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/stefanpenner/cairn/pkg/store"
+)
+
+// uiState is the view state kept between sessions, in the data
+// directory's runtime dir.
+type uiState struct {
+	CollapsedSections []string `json:"collapsed_sections,omitempty"`
+	// CollapsedNotes are the collapsed dated note sections by goal path
+	CollapsedNotes map[string][]string `json:"collapsed_notes,omitempty"`
+	SearchHistory  []string            `json:"search_history,omitempty"`
+	PanelLayout    string              `json:"panel_layout,omitempty"`
+	NotesHidden    bool                `json:"notes_hidden,omitempty"`
+	Ungrouped      bool                `json:"ungrouped,omitempty"`
+}
+
+// uiStatePath returns where dataDir's UI state lives, or "" if there's no
+// data directory to keep it in.
+func uiStatePath(dataDir string) string {
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, store.RuntimeDir, "ui.json")
+}
+
+// loadUIState restores the saved view state. A missing or unreadable file
+// leaves the defaults.
+func (m *Model) loadUIState() {
+	path := uiStatePath(m.store.DataDir())
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var state uiState
+	if json.Unmarshal(data, &state) != nil {
+		return
+	}
+	for _, key := range state.CollapsedSections {
+		m.collapsedSections[key] = true
+	}
+	for goalPath, dates := range state.CollapsedNotes {
+		collapsed := make(map[string]bool, len(dates))
+		for _, date := range dates {
+			collapsed[date] = true
+		}
+		m.collapsedNotes[goalPath] = collapsed
+	}
+	m.searchHistory = state.SearchHistory
+	if state.PanelLayout == panelsSideBySide || state.PanelLayout == panelsStacked {
+		m.panelLayout = state.PanelLayout
+	}
+	m.notesHidden = state.NotesHidden
+	m.ungrouped = state.Ungrouped
+}
+
+// saveUIState writes the view state, best-effort: losing it only costs
+// the user a keypress next session. Read-only mode never writes.
+func (m *Model) saveUIState() {
+	path := uiStatePath(m.store.DataDir())
+	if path == "" || m.cfg.ReadOnly {
+		return
+	}
+	state := uiState{SearchHistory: m.searchHistory, NotesHidden: m.notesHidden, Ungrouped: m.ungrouped}
+	if m.panelLayout != m.cfg.PanelLayout {
+		state.PanelLayout = m.panelLayout
+	}
+	for key, collapsed := range m.collapsedSections {
+		if collapsed {
+			state.CollapsedSections = append(state.CollapsedSections, key)
+		}
+	}
+	slices.Sort(state.CollapsedSections)
+	for goalPath, collapsed := range m.collapsedNotes {
+		var dates []string
+		for date, c := range collapsed {
+			if c {
+				dates = append(dates, date)
+			}
+		}
+		if len(dates) == 0 {
+			continue
+		}
+		slices.Sort(dates)
+		if state.CollapsedNotes == nil {
+			state.CollapsedNotes = make(map[string][]string)
+		}
+		state.CollapsedNotes[goalPath] = dates
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	os.WriteFile(path, append(data, '\n'), 0644)
+}
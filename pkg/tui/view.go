@@ -2,16 +2,47 @@ package tui
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/stefanpenner/cairn/pkg/store"
 )
 
 const minWidth = 40
 const minHeight = 10
 
+// Pane arrangements, as in config.PanelLayout.
+const (
+	panelsSideBySide = "side-by-side"
+	panelsStacked    = "stacked"
+)
+
+// paneSize is the area a pane is drawn in.
+type paneSize struct {
+	width, height int
+}
+
+// paneSizes splits the w×contentHeight area between the header and footer
+// into the tree and notes panes: side by side with a │ divider, or stacked
+// with the tree on top and a ─ divider. A hidden notes pane gets nothing.
+func (m Model) paneSizes(w, contentHeight int) (tree, notes paneSize) {
+	if m.notesHidden {
+		return paneSize{w, contentHeight}, paneSize{}
+	}
+	if m.panelLayout == panelsStacked {
+		treeHeight := max(contentHeight*2/5, 3)
+		notesHeight := max(contentHeight-treeHeight-1, 3)
+		return paneSize{w, treeHeight}, paneSize{w, notesHeight}
+	}
+	leftWidth := w / 4
+	rightWidth := w - leftWidth - 1 // 1 char for divider
+	return paneSize{max(leftWidth, 20), contentHeight}, paneSize{max(rightWidth, 20), contentHeight}
+}
+
 // View implements tea.Model.
 func (m Model) View() string {
 	w := m.width
@@ -28,11 +59,51 @@ func (m Model) View() string {
 		return placeOverlay(modal, w, h)
 	}
 
+	if m.showSessionLog {
+		modal := m.renderSessionLogModal()
+		return placeOverlay(modal, w, h)
+	}
+
 	if m.showDeleteConfirm {
 		modal := m.renderDeleteModal()
 		return placeOverlay(modal, w, h)
 	}
 
+	if m.showCompleteParent {
+		modal := m.renderCompleteParentModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showCompleteChildren {
+		modal := m.renderCompleteChildrenModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showAddUnderComplete {
+		modal := m.renderAddUnderCompleteModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showExpandAll {
+		modal := m.renderExpandAllModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showDequeue {
+		modal := m.renderDequeueModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showSetRemote {
+		modal := m.renderSetRemoteModal()
+		return placeOverlay(modal, w, h)
+	}
+
+	if m.showEditConflict {
+		modal := m.renderEditConflictModal()
+		return placeOverlay(modal, w, h)
+	}
+
 	var b strings.Builder
 
 	// Header
@@ -52,11 +123,14 @@ func (m Model) View() string {
 	headerLines := 3
 	footerLines := 2
 
-	// Search bar takes a line if active
+	// Search bar and command prompt take a line each if active
 	searchActive := m.isSearching || m.searchQuery != ""
 	if searchActive {
 		headerLines++
 	}
+	if m.isPalette {
+		headerLines++
+	}
 
 	contentHeight := h - headerLines - footerLines
 
@@ -66,32 +140,51 @@ func (m Model) View() string {
 		b.WriteString("\n")
 	}
 
-	// Two-panel layout — thin divider (just │, no padding spaces)
-	leftWidth := w / 4
-	rightWidth := w - leftWidth - 1 // 1 char for divider
-	if leftWidth < 20 {
-		leftWidth = 20
-	}
-	if rightWidth < 20 {
-		rightWidth = 20
+	// Command prompt
+	if m.isPalette {
+		b.WriteString(m.renderPaletteBar(w))
+		b.WriteString("\n")
 	}
 
-	leftPanel := m.renderTreePanel(leftWidth, contentHeight)
-	rightPanel := m.renderNotesPanel(rightWidth, contentHeight)
+	// Two-panel layout — thin divider (just │ or ─, no padding)
+	tree, notes := m.paneSizes(w, contentHeight)
+	leftPanel := m.renderTreePanel(tree.width, tree.height)
+	rightPanel := ""
+	if !m.notesHidden {
+		rightPanel = m.renderNotesPanel(notes.width, notes.height)
+	}
 
-	// Join panels side by side with thin divider
 	sepColor := ColorGrayDim
 	if m.focusedPane == 1 || m.isEditing {
 		sepColor = ColorPurple
 	}
-	sep := lipgloss.NewStyle().Foreground(sepColor).Render("│")
-	for i := 0; i < contentHeight; i++ {
-		leftLine := getLine(leftPanel, i, leftWidth)
-		rightLine := getLine(rightPanel, i, rightWidth)
-		b.WriteString(leftLine)
-		b.WriteString(sep)
-		b.WriteString(rightLine)
+	sepStyle := lipgloss.NewStyle().Foreground(sepColor)
+	if m.notesHidden {
+		for i := 0; i < tree.height; i++ {
+			b.WriteString(getLine(leftPanel, i, tree.width))
+			b.WriteString("\n")
+		}
+	} else if m.panelLayout == panelsStacked {
+		// Tree above notes, divided by a full-width rule
+		for i := 0; i < tree.height; i++ {
+			b.WriteString(getLine(leftPanel, i, tree.width))
+			b.WriteString("\n")
+		}
+		b.WriteString(sepStyle.Render(strings.Repeat("─", w)))
 		b.WriteString("\n")
+		for i := 0; i < notes.height; i++ {
+			b.WriteString(getLine(rightPanel, i, notes.width))
+			b.WriteString("\n")
+		}
+	} else {
+		// Join panels side by side with thin divider
+		sep := sepStyle.Render("│")
+		for i := 0; i < contentHeight; i++ {
+			b.WriteString(getLine(leftPanel, i, tree.width))
+			b.WriteString(sep)
+			b.WriteString(getLine(rightPanel, i, notes.width))
+			b.WriteString("\n")
+		}
 	}
 
 	// Separator
@@ -108,10 +201,27 @@ func (m Model) View() string {
 func (m Model) renderHeader(width int) string {
 	title := HeaderStyle.Render("Productivity")
 
-	// Stats
-	totalGoals := countGoals(m.goals)
-	completeGoals := countComplete(m.goals)
-	stats := HeaderCountStyle.Render(fmt.Sprintf("%d/%d goals complete", completeGoals, totalGoals))
+	// Stats for the current scope (active queue goal or everything), plus
+	// an overall total when the scope is narrower
+	all := expandedPaths(m.goals)
+	complete, total := countItems(m.flattenView(all))
+	stats := HeaderCountStyle.Render(fmt.Sprintf("%d/%d goals complete", complete, total))
+	if m.activeQueueGoal() != nil {
+		allComplete, allTotal := countItems(FlattenWithHorizonGroups(m.goals, m.store.Horizons(), all, m.cfg.HorizonRollup))
+		stats += lipgloss.NewStyle().Foreground(ColorGrayDim).Render(fmt.Sprintf(" (all: %d/%d)", allComplete, allTotal))
+	}
+	if counters := m.headerCounters(); counters != "" {
+		stats = HeaderCountStyle.Render(counters+" · ") + stats
+	}
+	if m.streak > 0 {
+		stats = StreakStyle.Render(fmt.Sprintf("%d-day streak", m.streak)) + HeaderCountStyle.Render(" · ") + stats
+	}
+	if label := m.pomodoroLabel(); label != "" {
+		stats = PomodoroStyle.Render(label) + HeaderCountStyle.Render(" · ") + stats
+	}
+	if label := m.syncLabel(); label != "" {
+		stats = HeaderCountStyle.Render(label+" · ") + stats
+	}
 
 	// Status message
 	status := ""
@@ -127,6 +237,35 @@ func (m Model) renderHeader(width int) string {
 	return title + strings.Repeat(" ", gap) + status + stats
 }
 
+// counterWindows are the days and labels of config.HeaderCounterWindows.
+var counterWindows = map[string]struct {
+	days  int
+	label string
+}{
+	"today": {1, "today"},
+	"week":  {7, "this week"},
+	"month": {30, "this month"},
+}
+
+// headerCounters counts goals completed and created in each of the
+// configured windows, e.g. "5 done today, 12 done +3 new this week".
+func (m Model) headerCounters() string {
+	var parts []string
+	for _, name := range m.cfg.HeaderCounters {
+		w, ok := counterWindows[name]
+		if !ok {
+			continue
+		}
+		total := store.LastDays(m.activity, w.days)
+		part := fmt.Sprintf("%d done", total.Completions)
+		if total.Created > 0 {
+			part += fmt.Sprintf(" +%d new", total.Created)
+		}
+		parts = append(parts, part+" "+w.label)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (m Model) renderQueueTabs(width int) string {
 	if m.queue == nil || len(m.queue.Items) == 0 {
 		return FooterStyle.Render("Queue: (empty — add goals to queue.md)")
@@ -135,12 +274,20 @@ func (m Model) renderQueueTabs(width int) string {
 	var tabs []string
 	tabs = append(tabs, FooterStyle.Render("Queue: "))
 	for i, item := range m.queue.Items {
-		if i == m.activeQueue {
-			tabs = append(tabs, ActiveTabStyle.Render(item))
+		// Tabs 1-9 can be jumped to with alt+N
+		label := item
+		if i < 9 {
+			label = fmt.Sprintf("%d %s", i+1, item)
+		}
+		if i == m.activeQueue && !m.peeking {
+			tabs = append(tabs, ActiveTabStyle.Render(label))
 		} else {
-			tabs = append(tabs, InactiveTabStyle.Render(item))
+			tabs = append(tabs, InactiveTabStyle.Render(label))
 		}
 	}
+	if m.peeking {
+		tabs = append(tabs, FooterStyle.Render("  peeking at all goals, o returns"))
+	}
 	return strings.Join(tabs, "")
 }
 
@@ -169,6 +316,14 @@ func (m Model) renderSearchBar(width int) string {
 	return left + strings.Repeat(" ", padWidth) + countStr
 }
 
+func (m Model) renderPaletteBar(width int) string {
+	bar := SearchBarStyle.Render(" : "+m.paletteInput) + SearchBarStyle.Render("█")
+	if pad := width - lipgloss.Width(bar); pad > 0 {
+		bar += strings.Repeat(" ", pad)
+	}
+	return bar
+}
+
 func (m Model) renderTreePanel(width, height int) string {
 	var lines []string
 
@@ -182,12 +337,17 @@ func (m Model) renderTreePanel(width, height int) string {
 		lines = append(lines, FooterStyle.Render("No goals yet. Press 'a' to add one."))
 	}
 
-	// Scrolling window
+	// Scrolling window, following the ghost marker in move mode
+	ghostAfter, ghostDepth, ghostOK := m.moveGhost()
+	focus := m.cursor
+	if ghostOK {
+		focus = max(ghostAfter, 0)
+	}
 	startIdx := 0
 	endIdx := len(m.visibleItems)
 	if len(m.visibleItems) > treeHeight {
 		half := treeHeight / 2
-		startIdx = m.cursor - half
+		startIdx = focus - half
 		if startIdx < 0 {
 			startIdx = 0
 		}
@@ -201,11 +361,15 @@ func (m Model) renderTreePanel(width, height int) string {
 		}
 	}
 
+	if ghostOK && ghostAfter == -1 {
+		lines = append(lines, m.renderMoveGhost(ghostDepth, width))
+	}
+
 	for i := startIdx; i < endIdx; i++ {
 		item := m.visibleItems[i]
 
 		if item.IsSectionHeader {
-			lines = append(lines, m.renderSectionHeader(item, width))
+			lines = append(lines, m.renderSectionHeader(item, i == m.cursor, width))
 			continue
 		}
 
@@ -222,19 +386,20 @@ func (m Model) renderTreePanel(width, height int) string {
 		line := m.renderTreeItem(item, isSelected, width)
 		lines = append(lines, line)
 
+		// Ghost row previewing where the moved goal will land
+		if ghostOK && i == ghostAfter {
+			lines = append(lines, m.renderMoveGhost(ghostDepth, width))
+		}
+
 		// Insert input line at the correct position
 		if m.isInputMode && i == m.inputInsertAfter {
-			indent := strings.Repeat(DepthIndent, m.inputDepth)
-			prompt := InputPromptStyle.Render("> ")
-			lines = append(lines, indent+prompt+m.textInput.View())
+			lines = append(lines, m.renderCreatePrompt(width)...)
 		}
 	}
 
 	// Fallback for input when insert point is out of range or no items
 	if m.isInputMode && (len(m.visibleItems) == 0 || m.inputInsertAfter < startIdx || m.inputInsertAfter >= endIdx) {
-		indent := strings.Repeat(DepthIndent, m.inputDepth)
-		prompt := InputPromptStyle.Render("> ")
-		lines = append(lines, indent+prompt+m.textInput.View())
+		lines = append(lines, m.renderCreatePrompt(width)...)
 	}
 
 	// Pad to treeHeight so the path line lands at the bottom
@@ -250,9 +415,11 @@ func (m Model) renderTreePanel(width, height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m Model) renderSectionHeader(item TreeItem, width int) string {
+func (m Model) renderSectionHeader(item TreeItem, isSelected bool, width int) string {
 	var style lipgloss.Style
 	switch item.Name {
+	case "PINNED":
+		style = PinnedStyle
 	case "TODAY":
 		style = HorizonTodayStyle
 	case "TOMORROW":
@@ -261,7 +428,14 @@ func (m Model) renderSectionHeader(item TreeItem, width int) string {
 		style = HorizonFutureStyle
 	}
 
-	label := style.Bold(true).Render("── " + item.Name + " ")
+	name := item.Name
+	if !item.IsExpanded {
+		name += fmt.Sprintf(" (%d hidden)", item.Hidden)
+	}
+	label := style.Bold(true).Render("── " + name + " ")
+	if isSelected {
+		label = SelectedStyle.Render("── " + name + " ")
+	}
 	labelWidth := lipgloss.Width(label)
 	remaining := width - labelWidth
 	if remaining > 0 {
@@ -270,6 +444,13 @@ func (m Model) renderSectionHeader(item TreeItem, width int) string {
 	return label
 }
 
+// summarySep separates a goal's title from its summary in the tree, which
+// shows the summary only with at least summaryMinWidth cells to spare.
+const (
+	summarySep      = " — "
+	summaryMinWidth = 12
+)
+
 func (m Model) renderTreeItem(item TreeItem, isSelected bool, width int) string {
 	indent := strings.Repeat(DepthIndent, item.Depth)
 
@@ -285,9 +466,16 @@ func (m Model) renderTreeItem(item TreeItem, isSelected bool, width int) string
 		expandIcon = "  "
 	}
 
+	// Outside the moved goal and the cursor, move mode dims the tree, so
+	// those rows are drawn without their own colors
+	isMoveTarget := m.isMoveMode && item.Goal.Path == m.moveTarget
+	dimmed := m.isMoveMode && !isMoveTarget && !isSelected
+
 	// Status icon
 	var statusIcon string
-	if item.Goal.IsComplete() {
+	if dimmed {
+		statusIcon = goalIcon(item.Goal)
+	} else if item.Goal.IsComplete() {
 		statusIcon = CompleteStyle.Render(IconComplete)
 	} else if item.Goal.IsInProgress() {
 		statusIcon = InProgressStyle.Render(IconInProgress)
@@ -297,7 +485,6 @@ func (m Model) renderTreeItem(item TreeItem, isSelected bool, width int) string
 
 	// Move mode indicator
 	movePrefix := ""
-	isMoveTarget := m.isMoveMode && item.Goal.Path == m.moveTarget
 	if isMoveTarget {
 		movePrefix = IconMove + " "
 	}
@@ -306,24 +493,127 @@ func (m Model) renderTreeItem(item TreeItem, isSelected bool, width int) string
 	isSearchMatch := m.searchMatchIDs[item.ID]
 	name := item.Name
 	if isSearchMatch && m.searchQuery != "" {
+		charStyle, rowStyle := SearchCharStyle, SearchRowStyle
 		if isSelected {
-			name = highlightMatch(name, m.searchQuery, SearchCharSelectedStyle, SelectedStyle)
+			charStyle, rowStyle = SearchCharSelectedStyle, SelectedStyle
+		}
+		if strings.Contains(strings.ToLower(name), strings.ToLower(m.searchQuery)) {
+			name = highlightMatch(name, m.searchQuery, charStyle, rowStyle)
 		} else {
-			name = highlightMatch(name, m.searchQuery, SearchCharStyle, SearchRowStyle)
+			// Matched by slug or path: highlight the whole title and say so
+			name = charStyle.Render(name) + SearchHintStyle.Render(" (matched path)")
+		}
+	}
+
+	if !isSearchMatch && !isSelected && !dimmed && !isMoveTarget {
+		if store.IsStaleToday(item.Goal, m.now(), m.cfg.StaleTodayDays) {
+			name = StaleStyle.Render(name)
+		} else if accent, ok := AccentStyle(item.Goal.Color); ok {
+			name = accent.Render(name)
+		}
+	}
+	if item.Goal.Icon != "" {
+		name = item.Goal.Icon + " " + name
+	}
+	if item.Breadcrumb != "" {
+		crumb := item.Breadcrumb + " › "
+		if !dimmed {
+			crumb = NoteInfoStyle.Render(crumb)
+		}
+		name = crumb + name
+	}
+	if m.peeking && item.Goal.Path == m.viewKey() {
+		tab := " " + IconSectionCursor + " queue tab"
+		if !dimmed {
+			tab = StatusLabelStyle.Render(tab)
+		}
+		name += tab
+	}
+	if label := item.Goal.StatusLabel; label != "" {
+		label = " [" + label + "]"
+		if !dimmed {
+			label = StatusLabelStyle.Render(label)
+		}
+		name += label
+	}
+
+	pin := ""
+	if item.Goal.Pinned {
+		pin = " " + IconPin
+		if !dimmed {
+			pin = PinnedStyle.UnsetBold().Render(pin)
+		}
+	}
+
+	if item.Goal.Encrypted {
+		lock := " " + IconLocked
+		if !dimmed {
+			lock = NoteInfoStyle.Render(lock)
+		}
+		pin += lock
+	}
+
+	if item.Goal.FrontmatterErr != nil {
+		warning := " " + IconWarning + " frontmatter error"
+		if !dimmed {
+			warning = StaleStyle.Render(warning)
+		}
+		pin += warning
+	}
+
+	estimate := ""
+	if m.cfg.ShowEstimates {
+		if remaining := store.FormatRemaining(item.Goal); remaining != "" {
+			estimate = " ~" + remaining
+			if !dimmed {
+				estimate = HeaderCountStyle.Render(estimate)
+			}
+		}
+	}
+
+	notes := ""
+	if n := item.Goal.NoteCount; n > 0 {
+		noun := "notes"
+		if n == 1 {
+			noun = "note"
+		}
+		notes = fmt.Sprintf(" · %d %s · %s", n, noun, noteAge(item.Goal.LastNote, m.now()))
+		if !dimmed {
+			notes = NoteInfoStyle.Render(notes)
 		}
 	}
 
 	line := indent + movePrefix + expandIcon + statusIcon + " " + name
+	rest := pin + estimate + notes
+	if summary := item.Goal.Summary; summary != "" {
+		// Only in the room the rest of the row leaves, cut to fit
+		room := width - lipgloss.Width(line) - lipgloss.Width(rest) - lipgloss.Width(summarySep)
+		if room >= summaryMinWidth {
+			summary = summarySep + ansi.Truncate(summary, room, "…")
+			if !dimmed && !isSelected && !isMoveTarget {
+				summary = SummaryStyle.Render(summary)
+			}
+			line += summary
+		}
+	}
+	line += rest
 
-	// Pad to width
+	// Pad or truncate to width. lipgloss measures display cells, so
+	// double-width emoji icons stay aligned.
 	lineWidth := lipgloss.Width(line)
 	if lineWidth < width {
 		line += strings.Repeat(" ", width-lineWidth)
+	} else if lineWidth > width {
+		line = lipgloss.NewStyle().MaxWidth(width).Render(line)
 	}
 
 	if isMoveTarget {
 		line = MoveStyle.Render(line)
-	} else if isSearchMatch && !isSelected {
+	} else if isSelected {
+		line = SelectedStyle.Render(line)
+	} else if dimmed {
+		line = MoveDimStyle.Render(line)
+	} else if isSearchMatch {
 		line = SearchRowStyle.Render(line)
 	} else if isSelected {
 		line = SelectedStyle.Render(line)
@@ -333,15 +623,22 @@ func (m Model) renderTreeItem(item TreeItem, isSelected bool, width int) string
 }
 
 func (m Model) renderNotesPanel(width, height int) string {
-	if m.cursor >= len(m.visibleItems) || len(m.visibleItems) == 0 {
-		return FooterStyle.Render(" Select a goal to view notes")
-	}
-
-	item := m.visibleItems[m.cursor]
-	if item.IsSectionHeader {
-		return FooterStyle.Render(" Select a goal to view notes")
+	var goal *store.Goal
+	if m.isEditing {
+		// The edited goal as it is on disk now, wherever the cursor is
+		if goal = m.findGoalByPath(m.goals, m.editGoalPath); goal == nil {
+			goal = m.editBase
+		}
+	} else {
+		if m.cursor >= len(m.visibleItems) || len(m.visibleItems) == 0 {
+			return FooterStyle.Render(" Select a goal to view notes")
+		}
+		item := m.visibleItems[m.cursor]
+		if item.IsSectionHeader {
+			return FooterStyle.Render(" Select a goal to view notes")
+		}
+		goal = item.Goal
 	}
-	goal := item.Goal
 
 	// Reserve last line for file path
 	bodyHeight := height - 1
@@ -391,32 +688,8 @@ func (m Model) renderNotesPanel(width, height int) string {
 		return strings.Join(lines, "\n")
 	}
 
-	// Normal view mode — full markdown
-	var md strings.Builder
-	md.WriteString(header)
-
-	if goal.Body != "" {
-		md.WriteString(goal.Body)
-		if !strings.HasSuffix(goal.Body, "\n") {
-			md.WriteString("\n")
-		}
-	}
-
-	// Render with glamour (cached renderer)
-	var rendered string
-	if m.glamourRenderer != nil {
-		var err error
-		rendered, err = m.glamourRenderer.Render(md.String())
-		if err != nil {
-			rendered = md.String()
-		}
-	} else {
-		rendered = md.String()
-	}
-
-	// Trim trailing whitespace and split to lines
-	rendered = strings.TrimRight(rendered, "\n ")
-	lines := strings.Split(rendered, "\n")
+	// Normal view mode — full markdown, with collapsed date sections folded
+	lines := m.notesLines(goal)
 
 	// Apply scroll offset
 	scroll := m.notesScroll
@@ -446,25 +719,25 @@ func (m Model) renderNotesPanel(width, height int) string {
 func (m Model) renderGoalHeader(goal *store.Goal) string {
 	var md strings.Builder
 
-	md.WriteString("# " + goal.Title + "\n\n")
+	md.WriteString("# " + displayName(goal) + "\n\n")
 
-	var meta []string
-	if goal.Horizon != "" {
-		meta = append(meta, "**Horizon:** "+string(goal.Horizon))
-	}
-	if goal.Status != "" {
-		meta = append(meta, "**Status:** "+string(goal.Status))
+	if goal.FrontmatterErr != nil {
+		md.WriteString(IconWarning + " **Frontmatter error:** " + goal.FrontmatterErr.Error() + ". Press E to fix the file.\n\n")
 	}
-	if len(goal.Tags) > 0 {
-		meta = append(meta, "**Tags:** "+strings.Join(goal.Tags, ", "))
-	}
-	if len(meta) > 0 {
+
+	if meta := goalMeta(goal, m.now(), m.cfg.StaleTodayDays); len(meta) > 0 {
 		md.WriteString(strings.Join(meta, " | ") + "\n\n")
 	}
 
 	if len(goal.Links) > 0 {
-		for k, v := range goal.Links {
-			md.WriteString("- **" + k + ":** " + v + "\n")
+		focused := m.focusedLinkKey(goal)
+		for _, k := range sortedLinkKeys(goal) {
+			v := goal.Links[k]
+			if k == focused {
+				md.WriteString("- " + IconLinkFocus + " **" + k + ":** `" + v + "`" + m.linkStateSuffix(v) + "\n")
+			} else {
+				md.WriteString(linkLine(goal, k, m.now()) + m.linkStateSuffix(v) + "\n")
+			}
 		}
 		md.WriteString("\n")
 	}
@@ -474,18 +747,22 @@ func (m Model) renderGoalHeader(goal *store.Goal) string {
 
 func (m Model) renderFooter(width int) string {
 	help := m.keys.ShortHelp()
-	if m.isInputMode || m.isRenameMode {
+	if m.isInputMode {
+		help = "enter create  tab name/horizon/tags  1…9 horizon  esc cancel"
+	} else if m.isRenameMode {
 		help = "enter confirm  esc cancel"
 	} else if m.isEditing {
-		help = "esc save & exit  ctrl+s save  ctrl+c cancel"
+		help = "esc save & exit  ctrl+s save  ctrl+d date  ctrl+t note  ctrl+c cancel"
+	} else if m.isPalette {
+		help = "enter run  tab complete  ↑↓ history  esc cancel"
 	} else if m.isSearching {
-		help = "type to search  enter/↓ keep filter  esc clear"
+		help = "type to search  ↑ recent  enter/↓ keep filter  esc clear"
 	} else if m.searchQuery != "" {
-		help = "esc/enter clear filter  ↑↓ nav"
+		help = "n/N next/prev match  esc/enter clear filter  ↑↓ nav"
 	} else if m.isMoveMode {
-		help = "↑↓ reorder  ← unparent  → reparent  enter/esc exit move"
+		help = "↑↓ move marker  ←→ nesting  enter drop  u undo  esc done"
 	} else if m.focusedPane == 1 {
-		help = "↑↓ scroll notes  tab tree  e edit  E $EDITOR  ? help"
+		help = "↑↓ scroll notes  J/K section  z/Z fold  tab links/tree  enter open  e edit  E $EDITOR  ? help"
 	}
 	return FooterStyle.Render(help)
 }
@@ -511,18 +788,194 @@ func (m Model) renderHelpModal() string {
 	return ModalStyle.Render(b.String())
 }
 
+func (m Model) renderSessionLogModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("This Session"))
+	b.WriteString("\n\n")
+
+	timeStyle := lipgloss.NewStyle().Foreground(ColorBlue).Width(7)
+	descStyle := lipgloss.NewStyle().Foreground(ColorWhite)
+
+	actions := m.session
+	if len(actions) == 0 {
+		b.WriteString(descStyle.Render("Nothing done yet."))
+		b.WriteString("\n")
+	}
+	if len(actions) > sessionLogShown {
+		b.WriteString(FooterStyle.Render(fmt.Sprintf("… %d earlier", len(actions)-sessionLogShown)))
+		b.WriteString("\n")
+		actions = actions[len(actions)-sessionLogShown:]
+	}
+	for _, e := range actions {
+		b.WriteString(timeStyle.Render(e.Time.Local().Format("15:04")))
+		b.WriteString(descStyle.Render(describeAction(e)))
+		b.WriteString("\n")
+	}
+	if summary := m.SessionSummary(); summary != "" {
+		b.WriteString("\n")
+		b.WriteString(summary)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(FooterStyle.Render("Press Esc or S to close"))
+
+	return ModalStyle.Render(b.String())
+}
+
 func (m Model) renderDeleteModal() string {
 	var b strings.Builder
 
 	b.WriteString(ModalTitleStyle.Render("Delete Goal"))
 	b.WriteString("\n\n")
-	b.WriteString(fmt.Sprintf("Delete '%s' and all sub-goals?\n\n", m.deleteTarget))
+	b.WriteString(fmt.Sprintf("Delete '%s' and all sub-goals?\n", m.deleteTarget))
+	if s := m.deleteSummary; s != nil {
+		b.WriteString(FooterStyle.Render(fmt.Sprintf("%d goal(s), %d line(s) of notes", s.Goals, s.NoteLines)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Yes  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " No")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderCompleteParentModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Complete Parent"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("All children of '%s' are complete — mark '%s' complete?\n\n",
+		displayName(m.completeParentTarget), displayName(m.completeParentTarget)))
 	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Yes  ")
 	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " No")
 
 	return ModalStyle.Render(b.String())
 }
 
+func (m Model) renderCompleteChildrenModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Open Sub-goals"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("'%s' is complete but %d sub-goal(s) under it are still open — complete them too?\n\n",
+		displayName(m.completeChildrenTarget), len(m.completeChildrenOpen)))
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Yes  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " Leave them open")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderDequeueModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Dequeue"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("'%s' is complete — remove it from the queue?\n\n", displayName(m.dequeueTarget)))
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Yes  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " Keep it queued")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderAddUnderCompleteModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Goal Complete"))
+	b.WriteString("\n\n")
+	if m.cursor < len(m.visibleItems) {
+		b.WriteString(fmt.Sprintf("'%s' is complete — add a sub-goal under it anyway?\n\n", m.visibleItems[m.cursor].Name))
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Add anyway  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " Cancel")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderExpandAllModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Expand All"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Expanding everything will show %d more goals.\n\n", m.expandAllReveals))
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[y]") + " Expand all  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[n]") + " Cancel")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderEditConflictModal() string {
+	var b strings.Builder
+
+	name := filepath.Base(m.editGoalPath)
+	if m.editBase != nil {
+		name = displayName(m.editBase)
+	}
+	if m.editConflictGone {
+		b.WriteString(ModalTitleStyle.Render("Goal Deleted"))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("'%s' was deleted on disk while you were editing it.\n\n", name))
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[o]") + " Recreate it  ")
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[c]") + " Save as copy  ")
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[r]") + " Discard edit  ")
+	} else {
+		b.WriteString(ModalTitleStyle.Render("File Changed"))
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf("The notes of '%s' changed on disk while you were editing them.\n\n", name))
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[o]") + " Overwrite  ")
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[c]") + " Save as copy  ")
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[r]") + " Reload  ")
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGray).Render("[esc]") + " Keep editing")
+
+	return ModalStyle.Render(b.String())
+}
+
+func (m Model) renderSetRemoteModal() string {
+	var b strings.Builder
+
+	b.WriteString(ModalTitleStyle.Render("Set Remote"))
+	b.WriteString("\n\n")
+	b.WriteString("Changes were committed locally, but there's no remote to sync with.\n")
+	b.WriteString("Enter a git remote URL to push to:\n\n")
+	b.WriteString(m.textInput.View())
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorGreen).Render("[enter]") + " Set and sync  ")
+	b.WriteString(lipgloss.NewStyle().Foreground(ColorRed).Render("[esc]") + " Keep it local")
+
+	return ModalStyle.Render(b.String())
+}
+
+// goalIcon returns the unstyled status icon for g.
+func goalIcon(g *store.Goal) string {
+	switch {
+	case g.IsComplete():
+		return IconComplete
+	case g.IsInProgress():
+		return IconInProgress
+	default:
+		return IconIncomplete
+	}
+}
+
+// renderMoveGhost renders the placeholder row for the moved goal's drop
+// position, struck through where it can't be dropped.
+func (m Model) renderMoveGhost(depth, width int) string {
+	name := filepath.Base(m.moveTarget)
+	if g := m.findGoalByPath(m.goals, m.moveTarget); g != nil {
+		name = displayName(g)
+	}
+	line := strings.Repeat(DepthIndent, depth) + IconGhost + " " + name
+	if w := lipgloss.Width(line); w < width {
+		line += strings.Repeat(" ", width-w)
+	}
+	if _, reason := m.resolveDrop(); reason != "" && !m.atMoveHome() {
+		return InvalidDestStyle.Render(line)
+	}
+	return MoveGhostStyle.Render(line)
+}
+
 // highlightMatch splits name into before/match/after and styles the match portion
 // with charStyle, and the rest with rowStyle. The match is case-insensitive.
 func highlightMatch(name, query string, charStyle, rowStyle lipgloss.Style) string {
@@ -594,22 +1047,34 @@ func placeOverlay(modal string, width, height int) string {
 	return result.String()
 }
 
-func countGoals(goals []*store.Goal) int {
-	count := 0
-	for _, g := range goals {
-		count++
-		count += countGoals(g.Children)
+// countItems counts goal rows, ignoring section headers. Each goal appears
+// once in a flattened view, so nothing is counted twice.
+func countItems(items []TreeItem) (complete, total int) {
+	for _, item := range items {
+		if item.IsSectionHeader {
+			continue
+		}
+		total++
+		if item.Goal.IsComplete() {
+			complete++
+		}
 	}
-	return count
+	return complete, total
 }
 
-func countComplete(goals []*store.Goal) int {
-	count := 0
-	for _, g := range goals {
-		if g.IsComplete() {
-			count++
-		}
-		count += countComplete(g.Children)
+// noteAge describes how long ago the last note day was: "today", "3d",
+// "5w" or "4mo".
+func noteAge(last, now time.Time) string {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(math.Round(today.Sub(last).Hours() / 24))
+	switch {
+	case days <= 0:
+		return "today"
+	case days < 14:
+		return fmt.Sprintf("%dd", days)
+	case days < 60:
+		return fmt.Sprintf("%dw", days/7)
+	default:
+		return fmt.Sprintf("%dmo", days/30)
 	}
-	return count
 }
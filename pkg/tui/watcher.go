@@ -10,27 +10,31 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// StartWatcher watches the data directory for changes and sends FileChangedMsg.
-func StartWatcher(root string, program *tea.Program) (func(), error) {
+// WatchOptions controls how StartWatcher notices changes.
+type WatchOptions struct {
+	// Poll scans the directory instead of using filesystem events, for
+	// filesystems where those are unreliable (NFS, some containers).
+	Poll bool
+	// Debounce is how long to wait after the last event before reloading.
+	Debounce time.Duration
+	// PollInterval is how often to scan when polling.
+	PollInterval time.Duration
+}
+
+// StartWatcher watches the data directory for changes and sends
+// FileChangedMsg. It falls back to polling if filesystem events are
+// unavailable. The returned func stops watching.
+func StartWatcher(root string, opts WatchOptions, send func(tea.Msg)) (func(), error) {
+	if opts.Poll {
+		return startPoller(root, opts.PollInterval, send), nil
+	}
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		return nil, err
+		return startPoller(root, opts.PollInterval, send), nil
 	}
 
 	// Walk and add all directories
-	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			// Skip hidden dirs (like .git)
-			if strings.HasPrefix(info.Name(), ".") && path != root {
-				return filepath.SkipDir
-			}
-			return watcher.Add(path)
-		}
-		return nil
-	})
+	err = walkDirs(root, watcher.Add)
 	if err != nil {
 		watcher.Close()
 		return nil, err
@@ -47,27 +51,27 @@ func StartWatcher(root string, program *tea.Program) (func(), error) {
 				if !ok {
 					return
 				}
+				// If a new directory was created, watch it too
+				if event.Op&fsnotify.Create != 0 {
+					info, err := os.Stat(event.Name)
+					if err == nil && info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+						watcher.Add(event.Name)
+					}
+				}
+
 				// Only care about .md file changes
 				if !strings.HasSuffix(event.Name, ".md") {
 					continue
 				}
 
-				// Debounce: wait 200ms after last change
+				// Debounce: wait for a quiet period after the last change
 				if debounceTimer != nil {
 					debounceTimer.Stop()
 				}
-				debounceTimer = time.AfterFunc(200*time.Millisecond, func() {
-					program.Send(FileChangedMsg{})
+				debounceTimer = time.AfterFunc(opts.Debounce, func() {
+					send(FileChangedMsg{})
 				})
 
-				// If a new directory was created, watch it too
-				if event.Op&fsnotify.Create != 0 {
-					info, err := os.Stat(event.Name)
-					if err == nil && info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
-						watcher.Add(event.Name)
-					}
-				}
-
 			case <-watcher.Errors:
 				// Ignore watcher errors silently
 
@@ -84,3 +88,87 @@ func StartWatcher(root string, program *tea.Program) (func(), error) {
 
 	return cleanup, nil
 }
+
+// walkDirs calls fn for root and every directory under it, skipping hidden
+// ones like .git.
+func walkDirs(root string, fn func(string) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") && path != root {
+				return filepath.SkipDir
+			}
+			return fn(path)
+		}
+		return nil
+	})
+}
+
+// fileStamp is what polling compares to decide a file changed.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotMarkdown stamps every .md file under root outside hidden dirs.
+func snapshotMarkdown(root string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	walkDirs(root, func(dir string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				files[filepath.Join(dir, e.Name())] = fileStamp{info.ModTime(), info.Size()}
+			}
+		}
+		return nil
+	})
+	return files
+}
+
+// startPoller rescans root every interval and sends FileChangedMsg when a
+// markdown file was added, removed or modified since the last scan.
+func startPoller(root string, interval time.Duration, send func(tea.Msg)) func() {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := snapshotMarkdown(root)
+		for {
+			select {
+			case <-ticker.C:
+				current := snapshotMarkdown(root)
+				if !sameSnapshot(last, current) {
+					send(FileChangedMsg{})
+				}
+				last = current
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func sameSnapshot(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		other, ok := b[path]
+		if !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
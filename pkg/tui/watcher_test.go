@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherPolling(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "ship", ".hidden"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ship", "goal.md"), []byte("# Ship\n"), 0o644))
+
+	msgs := make(chan tea.Msg, 16)
+	stop, err := StartWatcher(root, WatchOptions{Poll: true, PollInterval: 10 * time.Millisecond}, func(msg tea.Msg) { msgs <- msg })
+	require.NoError(t, err)
+	defer stop()
+
+	expectChange := func(t *testing.T) {
+		t.Helper()
+		select {
+		case msg := <-msgs:
+			require.IsType(t, FileChangedMsg{}, msg)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no FileChangedMsg")
+		}
+	}
+	expectQuiet := func(t *testing.T) {
+		t.Helper()
+		select {
+		case msg := <-msgs:
+			t.Fatalf("unexpected %T", msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	expectQuiet(t)
+
+	// New file
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.md"), []byte("x"), 0o644))
+	expectChange(t)
+	expectQuiet(t)
+
+	// Modified file (size changes even if the mtime granularity is coarse)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ship", "goal.md"), []byte("# Ship it\n"), 0o644))
+	expectChange(t)
+
+	// Removed file
+	require.NoError(t, os.Remove(filepath.Join(root, "notes.md")))
+	expectChange(t)
+	expectQuiet(t)
+
+	// Non-markdown files and hidden dirs are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(root, "scratch.txt"), []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ship", ".hidden", "x.md"), []byte("x"), 0o644))
+	expectQuiet(t)
+}